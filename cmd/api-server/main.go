@@ -7,17 +7,33 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"mangahub/internal/auth"
+	"mangahub/internal/goals"
+	"mangahub/internal/mail"
+	"mangahub/internal/oidc"
 	"mangahub/internal/manga"
+	"mangahub/internal/ratelimit"
+	"mangahub/internal/session"
+	"mangahub/internal/stats"
+	"mangahub/internal/tlsconfig"
 	"mangahub/internal/user"
 	ws "mangahub/internal/websocket"
 	"mangahub/pkg/database"
 	"mangahub/pkg/models"
+	"mangahub/pkg/protocol"
 	"net/http"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 )
 
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
+	// Clients pick the protocol version and encoding, e.g. mangahub.v1.protobuf
+	Subprotocols: protocol.Subprotocols(),
 	CheckOrigin: func(r *http.Request) bool {
 		return true // Allow all origins for development
 	},
@@ -26,9 +42,25 @@ var upgrader = websocket.Upgrader{
 func main() {
 	// Configuration
 	dbPath := getEnv("DB_PATH", "./data/mangahub.db")
-	jwtSecret := getEnv("JWT_SECRET", "your-secret-key-change-this")
 	port := getEnv("PORT", ":8080")
 
+	// TLS for HTTP and WebSocket, configured like cmd/server
+	tlsConfig, err := tlsconfig.New(tlsconfig.Config{
+		CertFile: getEnv("TLS_CERT", ""),
+		KeyFile:  getEnv("TLS_KEY", ""),
+		ClientCA: getEnv("TLS_CLIENT_CA", ""),
+		Dev:      getEnv("TLS_DEV", "") == "true",
+		DevDir:   getEnv("TLS_DEV_DIR", filepath.Join(filepath.Dir(dbPath), "tls")),
+		Hosts:    splitList(getEnv("TLS_HOSTS", "")),
+	})
+	if err != nil {
+		log.Fatalf("Invalid TLS configuration: %v", err)
+	}
+	httpScheme, wsScheme := "http", "ws"
+	if tlsConfig != nil {
+		httpScheme, wsScheme = "https", "wss"
+	}
+
 	// Initialize database
 	db, err := database.InitDB(dbPath)
 	if err != nil {
@@ -43,29 +75,125 @@ func main() {
 
 	// Initialize repositories
 	userRepo := user.NewRepository(db)
+	if err := userRepo.SetAdmins(splitList(getEnv("ADMIN_USERS", ""))); err != nil {
+		log.Fatalf("Failed to set admins: %v", err)
+	}
 	mangaRepo := manga.NewRepository(db)
+	sessionRepo := session.NewRepository(db)
+	statsRepo := stats.NewRepository(db)
+	goalRepo := goals.NewRepository(db)
+
+	// Rate limits per IP, user and route class
+	rateLimits, err := ratelimit.ParseConfig(getEnv("RATE_LIMITS", ""))
+	if err != nil {
+		log.Fatalf("Invalid RATE_LIMITS: %v", err)
+	}
+	limits := ratelimit.New(rateLimits)
 
 	// Initialize services
-	userService := user.NewService(userRepo, jwtSecret)
+	// Access tokens are signed with keys kept in the database and rotated
+	tokenStore := auth.NewTokenStore(db)
+	keyRotation, err := time.ParseDuration(getEnv("JWT_KEY_ROTATION", auth.DefaultKeyRotation.String()))
+	if err != nil {
+		log.Fatalf("❌ Invalid JWT_KEY_ROTATION: %v", err)
+	}
+	signingKeys, err := auth.NewKeySet(tokenStore, getEnv("JWT_ALGORITHM", auth.AlgEdDSA), keyRotation)
+	if err != nil {
+		log.Fatalf("❌ Failed to load signing keys: %v", err)
+	}
+	tokenManager := auth.NewTokenManager(signingKeys, tokenStore)
+	go tokenManager.RunCleanup(time.Hour)
+	go signingKeys.RunRotation(time.Hour)
+	smtpPort, _ := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+	mailer := mail.New(mail.Config{
+		Host:     getEnv("SMTP_HOST", ""),
+		Port:     smtpPort,
+		Username: getEnv("SMTP_USERNAME", ""),
+		Password: getEnv("SMTP_PASSWORD", ""),
+		From:     getEnv("MAIL_FROM", "noreply@mangahub.local"),
+		Dir:      getEnv("MAIL_DIR", filepath.Join(filepath.Dir(dbPath), "mail")), // used when SMTP_HOST is unset
+	})
+	publicURL := getEnv("PUBLIC_URL", httpScheme+"://localhost"+port) // base of links in emails
+	userService := user.NewService(userRepo, tokenManager, mailer, publicURL)
+
+	// Password hashing cost, optionally raised until a hash takes PASSWORD_HASH_TARGET
+	passwordParams, err := auth.ParsePasswordParams(getEnv("PASSWORD_HASH_PARAMS", ""))
+	if err != nil {
+		log.Fatalf("❌ Invalid PASSWORD_HASH_PARAMS: %v", err)
+	}
+	if target := getEnv("PASSWORD_HASH_TARGET", ""); target != "" {
+		duration, err := time.ParseDuration(target)
+		if err != nil {
+			log.Fatalf("❌ Invalid PASSWORD_HASH_TARGET: %v", err)
+		}
+		passwordParams = auth.CalibratePasswordParams(passwordParams, duration)
+		log.Printf("🔑 Password hashing calibrated to argon2id %s", passwordParams)
+	}
+	userService.SetPasswordHasher(auth.NewPasswordHasher(passwordParams))
+
+	// Single sign-on through an OpenID Connect provider, if one is configured
+	if issuer := getEnv("OIDC_ISSUER", ""); issuer != "" {
+		userService.UseSSO(oidc.NewProvider(oidc.Config{
+			Issuer:       issuer,
+			ClientID:     getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+			Scopes:       strings.Fields(getEnv("OIDC_SCOPES", "")),
+		}))
+		log.Printf("🔐 Single sign-on enabled with %s", issuer)
+	}
 
 	// Create progress broadcast channel (for TCP server)
 	progressBroadcast := make(chan models.ProgressUpdate, 100)
 
+	// Coalesce page-level reading position writes
+	positionTracker := manga.NewPositionTracker(mangaRepo, 5*time.Second)
+	go positionTracker.Run()
+
+	// Infer reading sessions from progress events, then check goals (no UDP notifications)
+	goalService := goals.NewService(goalRepo, nil)
+	go goalService.Run()
+	sessionTracker := session.NewTracker(sessionRepo)
+	sessionTracker.OnProcessed(goalService.Observe)
+	go sessionTracker.Run()
+	go func() {
+		for update := range progressBroadcast {
+			sessionTracker.Observe(update)
+		}
+	}()
+
 	// Initialize handlers (without UDP for standalone API server)
 	userHandler := user.NewHandler(userService)
-	mangaHandler := manga.NewHandler(mangaRepo, progressBroadcast, nil)
+	mangaHandler := manga.NewHandler(mangaRepo, progressBroadcast, nil, positionTracker)
+	sessionHandler := session.NewHandler(sessionRepo)
+	statsHandler := stats.NewHandler(statsRepo)
+	goalHandler := goals.NewHandler(goalService)
 
 	// Initialize WebSocket hub
 	chatHub := ws.NewHub()
+	chatHub.LimitMessages(limits.Get(ratelimit.ClassChat))
 	go chatHub.Run()
+	tokenManager.OnSessionRevoked(chatHub.DisconnectSession)
+
+	// Deleted accounts are disconnected and their chat messages dropped
+	userService.OnAccountDeleted(func(u *models.User) {
+		chatHub.DisconnectUser(u.Username)
+		chatHub.ForgetUser(u.Username)
+	})
+	userService.ExportChatFrom(chatHub.MessagesBy)
+	go userService.RunAccountPurge(time.Hour)
 
 	// Setup Gin router
 	router := gin.Default()
+	// Without this gin believes any X-Forwarded-For header, letting clients
+	// pick the IP their rate limits are counted against
+	if err := router.SetTrustedProxies(splitList(getEnv("TRUSTED_PROXIES", ""))); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// CORS middleware
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 		
 		if c.Request.Method == "OPTIONS" {
@@ -76,6 +204,14 @@ func main() {
 		c.Next()
 	})
 
+	// Every request counts against its client IP
+	router.Use(ratelimit.Middleware(limits.Get(ratelimit.ClassIP), ratelimit.ByIP))
+
+	// Public keys for verifying access tokens
+	router.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.JSON(200, signingKeys.JWKS())
+	})
+
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -87,35 +223,88 @@ func main() {
 	// Public routes
 	public := router.Group("/api")
 	{
+		authLimit := ratelimit.Middleware(limits.Get(ratelimit.ClassAuth), ratelimit.ByIP)
+		searchLimit := ratelimit.Middleware(limits.Get(ratelimit.ClassSearch), ratelimit.ByIP)
+
 		// Auth routes
-		public.POST("/auth/register", userHandler.Register)
-		public.POST("/auth/login", userHandler.Login)
+		public.POST("/auth/register", authLimit, userHandler.Register)
+		public.POST("/auth/login", authLimit, userHandler.Login)
+		public.POST("/auth/login/2fa", authLimit, userHandler.LoginTwoFactor)
+		public.POST("/auth/refresh", authLimit, userHandler.Refresh)
+		public.POST("/auth/forgot", authLimit, userHandler.ForgotPassword)
+		public.POST("/auth/reset", authLimit, userHandler.ResetPassword)
+		public.GET("/auth/verify", authLimit, userHandler.VerifyEmail)
+		public.POST("/auth/sso/start", authLimit, userHandler.StartSSO)
+		public.POST("/auth/sso/callback", authLimit, userHandler.CompleteSSO)
 		
 		// Public manga routes
-		public.GET("/manga", mangaHandler.SearchManga)
-		public.GET("/manga/:id", mangaHandler.GetManga)
+		public.GET("/manga", searchLimit, mangaHandler.SearchManga)
+		public.GET("/manga/:id", searchLimit, mangaHandler.GetManga)
 	}
 
-	// Protected routes
+	// Routes personal access tokens can use, each checked for its scope
+	scoped := router.Group("/api")
+	scoped.Use(auth.JWTMiddleware(tokenManager), ratelimit.Middleware(limits.Get(ratelimit.ClassAPI), ratelimit.ByUser))
+	{
+		library := auth.RequireScope(auth.ScopeWriteLibrary)
+
+		// Library routes
+		scoped.GET("/library", library, mangaHandler.GetLibrary)
+		scoped.POST("/library", library, mangaHandler.AddToLibrary)
+		scoped.DELETE("/library/:id", library, mangaHandler.RemoveFromLibrary)
+
+		// Progress routes
+		scoped.PUT("/progress", library, mangaHandler.UpdateProgress)
+		scoped.GET("/progress/:manga_id/chapters", library, mangaHandler.GetReadChapters)
+		scoped.POST("/progress/:manga_id/chapters", library, mangaHandler.MarkChaptersRead)
+		scoped.DELETE("/progress/:manga_id/chapters", library, mangaHandler.MarkChaptersUnread)
+		scoped.GET("/progress/:manga_id/position", library, mangaHandler.GetPosition)
+
+		// Reading session routes
+		scoped.POST("/sessions", library, sessionHandler.StartSession)
+		scoped.GET("/sessions", library, sessionHandler.ListSessions)
+		scoped.GET("/sessions/summary", library, sessionHandler.GetSummary)
+		scoped.GET("/sessions/:id", library, sessionHandler.GetSession)
+		scoped.PATCH("/sessions/:id", library, sessionHandler.UpdateSession)
+	}
+
+	// Protected routes (login tokens only)
 	protected := router.Group("/api")
-	protected.Use(auth.JWTMiddleware(jwtSecret))
+	protected.Use(auth.JWTMiddleware(tokenManager), auth.RequireSession(), ratelimit.Middleware(limits.Get(ratelimit.ClassAPI), ratelimit.ByUser))
 	{
+		// Auth routes
+		protected.POST("/auth/logout", userHandler.Logout)
+		protected.POST("/auth/verify/resend", userHandler.ResendVerification)
+
 		// User routes
 		protected.GET("/users/profile", userHandler.GetProfile)
-		
-		// Library routes
-		protected.GET("/library", mangaHandler.GetLibrary)
-		protected.POST("/library", mangaHandler.AddToLibrary)
-		protected.DELETE("/library/:id", mangaHandler.RemoveFromLibrary)
-		
-		// Progress routes
-		protected.PUT("/progress", mangaHandler.UpdateProgress)
+		protected.GET("/users/me", userHandler.GetProfile)
+		protected.PATCH("/users/me", userHandler.UpdateProfile)
+		protected.GET("/users/me/stats", statsHandler.GetMyStats)
+		protected.PUT("/users/me/timezone", userHandler.UpdateTimezone)
+		protected.POST("/users/me/password", userHandler.ChangePassword)
+		protected.POST("/users/me/2fa/enroll", userHandler.EnrollTwoFactor)
+		protected.POST("/users/me/2fa/confirm", userHandler.ConfirmTwoFactor)
+		protected.DELETE("/users/me/2fa", userHandler.DisableTwoFactor)
+		protected.GET("/users/me/tokens", userHandler.ListTokens)
+		protected.POST("/users/me/tokens", userHandler.CreateToken)
+		protected.DELETE("/users/me/tokens/:id", userHandler.RevokeToken)
+		protected.GET("/users/me/sessions", userHandler.ListSessions)
+		protected.DELETE("/users/me/sessions/:id", userHandler.RevokeSession)
+		protected.GET("/users/me/data-export", userHandler.ExportData)
+		protected.DELETE("/users/me", userHandler.DeleteAccount)
+
+		// Goal and achievement routes
+		protected.GET("/goals", goalHandler.ListGoals)
+		protected.POST("/goals", goalHandler.CreateGoal)
+		protected.DELETE("/goals/:id", goalHandler.DeleteGoal)
+		protected.GET("/achievements", goalHandler.ListAchievements)
 	}
 
 	// WebSocket route (with auth)
 	router.GET("/ws/chat", func(c *gin.Context) {
-		// Get auth token from query params for WebSocket
-		token := c.Query("token")
+		// Get auth token from the header or query params for WebSocket
+		token := auth.TokenFromRequest(c.Request)
 		if token == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "token required",
@@ -124,13 +313,19 @@ func main() {
 		}
 
 		// Validate token
-		claims, err := auth.ValidateToken(token, jwtSecret)
-		if err != nil {
+		claims, err := tokenManager.Validate(token)
+		if err != nil || claims.IsPersonalToken() {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "invalid token",
 			})
 			return
 		}
+		if verified, _ := userService.IsEmailVerified(claims.UserID); !verified {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "verify your email address to use chat",
+			})
+			return
+		}
 
 		// Upgrade connection
 		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
@@ -139,17 +334,39 @@ func main() {
 			return
 		}
 
+		room := c.Query("room")
+		if room == "" {
+			room = "general"
+		}
+
 		// Serve WebSocket
-		ws.ServeWs(chatHub, conn, claims.UserID, claims.Username)
+		ws.ServeWs(chatHub, conn, claims.Username, room, claims.SessionID)
 	})
 
 	// Start server
 	log.Printf("🚀 MangaHub API Server starting on %s", port)
 	log.Printf("📚 Database: %s", dbPath)
 	log.Printf("🔐 JWT Authentication enabled")
-	log.Printf("💬 WebSocket Chat enabled at ws://localhost%s/ws/chat", port)
+	log.Printf("💬 WebSocket Chat enabled at %s://localhost%s/ws/chat", wsScheme, port)
 	
-	if err := router.Run(port); err != nil {
+	// Persist reading positions that haven't been flushed yet on shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		positionTracker.Flush()
+		log.Println("✅ API server shut down")
+		os.Exit(0)
+	}()
+
+	httpServer := &http.Server{Addr: port, Handler: router, TLSConfig: tlsConfig}
+	if tlsConfig != nil {
+		log.Printf("🔒 TLS enabled")
+		err = httpServer.ListenAndServeTLS("", "")
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
@@ -159,4 +376,15 @@ func getEnv(key, defaultValue string) string {
 		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+// splitList splits a comma separated environment variable, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -4,24 +4,34 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
 
+	"mangahub/internal/tlsconfig"
+	"mangahub/pkg/protocol"
 	pb "mangahub/proto/proto"
 
 	"github.com/gorilla/websocket"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"gopkg.in/yaml.v3"
 )
 
@@ -35,18 +45,32 @@ type Config struct {
 		UDPPort       int    `yaml:"udp_port"`
 		GRPCPort      int    `yaml:"grpc_port"`
 		WebSocketPort int    `yaml:"websocket_port"`
+		Encoding      string `yaml:"encoding"` // wire encoding for sync, notifications and chat: json or protobuf
 	} `yaml:"server"`
+	TLS struct {
+		Enabled            bool   `yaml:"enabled"`              // https, wss and TLS for gRPC and TCP sync
+		CAFile             string `yaml:"ca_file"`              // extra CA bundle, e.g. the server's development cert.pem
+		CertFile           string `yaml:"cert_file"`            // client certificate for servers that require one
+		KeyFile            string `yaml:"key_file"`             // its private key
+		InsecureSkipVerify bool   `yaml:"insecure_skip_verify"` // don't verify the server's certificate (development only)
+	} `yaml:"tls"`
 	Database struct {
 		Path string `yaml:"path"`
 	} `yaml:"database"`
 	User struct {
-		Username string `yaml:"username"`
-		Token    string `yaml:"token"`
-		UserID   string `yaml:"user_id"`
+		Username     string `yaml:"username"`
+		Token        string `yaml:"token"`
+		RefreshToken string `yaml:"refresh_token"`
+		UserID       string `yaml:"user_id"`
 	} `yaml:"user"`
+	Session struct {
+		ActiveID string `yaml:"active_id"` // reading session progress updates are attributed to
+	} `yaml:"session"`
 	Sync struct {
 		AutoSync           bool   `yaml:"auto_sync"`
 		ConflictResolution string `yaml:"conflict_resolution"`
+		LastSeq            int64  `yaml:"last_seq"`      // last sync event seen by sync monitor
+		LastSeqUser        string `yaml:"last_seq_user"` // user LastSeq belongs to
 	} `yaml:"sync"`
 	Notifications struct {
 		Enabled bool `yaml:"enabled"`
@@ -85,6 +109,10 @@ func main() {
 		handleLibrary()
 	case "progress":
 		handleProgress()
+	case "session":
+		handleSession()
+	case "goals":
+		handleGoals()
 	case "sync":
 		handleSync()
 	case "notify":
@@ -101,6 +129,10 @@ func main() {
 		handleStats()
 	case "export":
 		handleExport()
+	case "profile":
+		handleProfile()
+	case "account":
+		handleAccount()
 	case "help", "--help", "-h":
 		printUsage()
 	default:
@@ -116,17 +148,22 @@ Commands:
   init                     Initialize configuration
   version                  Show version
   auth <login|register>    Authentication (HTTP)
+  auth token <create|list> Personal access tokens (HTTP)
   manga <search|info>      Search and view manga (HTTP/gRPC)
   library <list|add>       Manage your library (HTTP)
-  progress update          Update reading progress (HTTP)
+  progress <update|mark>   Update reading progress (HTTP)
+  session <start|stop>     Track reading sessions (HTTP)
+  goals [add|remove]       Reading goals, streaks and badges (HTTP)
   sync <connect|monitor>   TCP synchronization
   notify <subscribe|send>  UDP notifications
   chat join                WebSocket chat
   grpc <get|search>        gRPC operations
   server <status|ping>     Server management
-  config show              View configuration
+  config <show|tls>        View configuration, set TLS options
   stats overview           Reading statistics
   export library           Export data
+  profile <show|edit>      View or edit your profile and preferences (HTTP)
+  account <export|delete>  Download or delete all your data (HTTP)
   `)
 }
 
@@ -144,6 +181,7 @@ func cmdInit() { // Initialize configuration
 	config.Server.UDPPort = 9091
 	config.Server.GRPCPort = 9092
 	config.Server.WebSocketPort = 8080
+	config.Server.Encoding = string(protocol.JSON)
 	config.Database.Path = filepath.Join(mangahubDir, "data.db")
 	config.Sync.AutoSync = true
 	config.Sync.ConflictResolution = "last_write_wins"
@@ -168,7 +206,7 @@ Preconditions: None
 Postconditions: User account is created
 Main Success Scenario: 1. User provides username, email, and password
 2. System validates input format and uniqueness
-3. System hashes password using bcrypt
+3. System hashes password using argon2id
 4. System creates user record in SQLite database
 5. System returns success confirmation
 Alternative Flows: - A1: Username already exists - System returns error message
@@ -347,7 +385,7 @@ Main Success Scenario: 1. Client calls UpdateProgress with user and manga data
 
 func handleAuth() {
 	if len(os.Args) < 3 {
-		fmt.Println("Usage: mangahub auth <register|login|logout|status>")
+		fmt.Println("Usage: mangahub auth <register|login|logout|status|verify|passwd|reset|2fa|token|sessions>")
 		os.Exit(1)
 	}
 
@@ -356,12 +394,14 @@ func handleAuth() {
 		cmdAuthRegister() // UC-001: User Registration
 	case "login":
 		cmdAuthLogin() // UC-002: User Authentication
-	case "logout": // Simple logout by clearing token
-		config.User = struct {
-			Username string `yaml:"username"`
-			Token    string `yaml:"token"`
-			UserID   string `yaml:"user_id"`
-		}{}
+	case "logout": // Revoke the login on the server, then clear tokens
+		if config.User.Token != "" {
+			if _, err := makeRequest("POST", "/auth/logout", nil, config.User.Token); err != nil {
+				fmt.Printf("⚠️  Could not revoke session on server: %v\n", err)
+			}
+		}
+		config.User = Config{}.User
+		config.Session.ActiveID = ""
 		saveConfig()
 		fmt.Println("✓ Logged out")
 	case "status":
@@ -370,7 +410,337 @@ func handleAuth() {
 		} else {
 			fmt.Printf("Status: Logged in as %s (UserID: %s)\n", config.User.Username, config.User.UserID)
 		}
+	case "token":
+		handleAuthToken()
+	case "sessions":
+		handleAuthSessions()
+	case "passwd":
+		cmdAuthPasswd()
+	case "reset":
+		cmdAuthReset()
+	case "verify":
+		cmdAuthVerify()
+	case "2fa":
+		handleAuth2FA()
+	}
+}
+
+func handleAuth2FA() {
+	if len(os.Args) < 4 {
+		fmt.Println("Usage: mangahub auth 2fa <enable|disable>")
+		os.Exit(1)
+	}
+
+	switch os.Args[3] {
+	case "enable":
+		cmdAuth2FAEnable()
+	case "disable":
+		cmdAuth2FADisable()
+	default:
+		fmt.Println("Usage: mangahub auth 2fa <enable|disable>")
+		os.Exit(1)
+	}
+}
+
+// Workflow: cmdAuth2FAEnable -> /users/me/2fa/enroll -> Scan secret -> Input code -> /users/me/2fa/confirm -> Show recovery codes
+func cmdAuth2FAEnable() {
+	requireAuth()
+
+	resp, err := makeRequest("POST", "/users/me/2fa/enroll", nil, config.User.Token)
+	if err != nil {
+		fmt.Printf("✗ Failed to set up two-factor authentication: %v\n", err)
+		os.Exit(1)
+	}
+	data, _ := resp["data"].(map[string]interface{})
+
+	fmt.Println("🔐 Add this account to your authenticator app")
+	fmt.Printf("  Secret: %s\n", data["secret"])
+	fmt.Printf("  URI:    %s\n", data["otpauth_uri"])
+	fmt.Println("\n💡 Most apps can also scan the URI as a QR code (e.g. qrencode -t ansiutf8 '<uri>')")
+
+	fmt.Print("\nCode from the app: ")
+	var code string
+	fmt.Scanln(&code)
+
+	resp, err = makeRequest("POST", "/users/me/2fa/confirm", map[string]string{"code": code}, config.User.Token)
+	if err != nil {
+		fmt.Printf("✗ Failed to enable two-factor authentication: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("✓ Two-factor authentication enabled")
+	fmt.Println("\nRecovery codes (each works once, store them somewhere safe):")
+	if data, ok := resp["data"].(map[string]interface{}); ok {
+		if codes, ok := data["recovery_codes"].([]interface{}); ok {
+			for _, c := range codes {
+				fmt.Printf("  %s\n", c)
+			}
+		}
+	}
+	fmt.Println("\n💡 Your other devices have been logged out")
+}
+
+// Workflow: cmdAuth2FADisable -> Input code -> DELETE /users/me/2fa
+func cmdAuth2FADisable() {
+	requireAuth()
+
+	fmt.Print("Two-factor code (or recovery code): ")
+	var code string
+	fmt.Scanln(&code)
+
+	if _, err := makeRequest("DELETE", "/users/me/2fa", map[string]string{"code": code}, config.User.Token); err != nil {
+		fmt.Printf("✗ Failed to disable two-factor authentication: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("✓ Two-factor authentication disabled")
+}
+
+// Workflow: cmdAuthVerify --token -> HTTP request to /auth/verify; --resend -> /auth/verify/resend
+func cmdAuthVerify() {
+	token := getFlag("--token")
+
+	if hasFlag("--resend") {
+		requireAuth()
+		if _, err := makeRequest("POST", "/auth/verify/resend", nil, config.User.Token); err != nil {
+			fmt.Printf("✗ Failed to resend verification email: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("✓ Verification email sent")
+		return
+	}
+
+	if token == "" {
+		fmt.Println("Usage: mangahub auth verify --token <token>")
+		fmt.Println("       mangahub auth verify --resend")
+		os.Exit(1)
+	}
+
+	resp, err := makeRequest("GET", "/auth/verify?token="+url.QueryEscape(token), nil, "")
+	if err != nil {
+		fmt.Printf("✗ Verification failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ %s\n", resp["message"])
+}
+
+// Workflow: cmdAuthPasswd -> Input current and new password -> HTTP request to /users/me/password
+func cmdAuthPasswd() {
+	requireAuth()
+
+	fmt.Print("Current password: ")
+	current := readPassword()
+	fmt.Print("New password: ")
+	newPassword := readPassword()
+	fmt.Print("Confirm new password: ")
+	if readPassword() != newPassword {
+		fmt.Println("✗ Passwords do not match")
+		os.Exit(1)
+	}
+
+	data := map[string]string{
+		"current_password": current,
+		"new_password":     newPassword,
+	}
+	if _, err := makeRequest("POST", "/users/me/password", data, config.User.Token); err != nil {
+		fmt.Printf("✗ Failed to change password: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("✓ Password changed")
+	fmt.Println("💡 Your other devices have been logged out")
+}
+
+// Workflow: cmdAuthReset --email -> /auth/forgot emails a token; cmdAuthReset --token -> Input new password -> /auth/reset
+func cmdAuthReset() {
+	email := getFlag("--email")
+	token := getFlag("--token")
+
+	if email == "" && token == "" {
+		fmt.Println("Usage: mangahub auth reset --email <email>   (send a reset token)")
+		fmt.Println("       mangahub auth reset --token <token>   (set a new password)")
+		os.Exit(1)
+	}
+
+	if token == "" {
+		resp, err := makeRequest("POST", "/auth/forgot", map[string]string{"email": email}, "")
+		if err != nil {
+			fmt.Printf("✗ Failed to request reset: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ %s\n", resp["message"])
+		fmt.Println("\nNext: mangahub auth reset --token <token from the email>")
+		return
+	}
+
+	fmt.Print("New password: ")
+	newPassword := readPassword()
+	fmt.Print("Confirm new password: ")
+	if readPassword() != newPassword {
+		fmt.Println("✗ Passwords do not match")
+		os.Exit(1)
+	}
+
+	data := map[string]string{
+		"token":        token,
+		"new_password": newPassword,
+	}
+	if _, err := makeRequest("POST", "/auth/reset", data, ""); err != nil {
+		fmt.Printf("✗ Failed to reset password: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Every login was revoked by the reset
+	config.User = Config{}.User
+	config.Session.ActiveID = ""
+	saveConfig()
+
+	fmt.Println("✓ Password reset. All devices have been logged out")
+	fmt.Println("\nNext: mangahub auth login --username <username>")
+}
+
+// Workflow: handleAuthSessions -> list devices from /users/me/sessions or revoke one with DELETE
+func handleAuthSessions() {
+	requireAuth()
+
+	if len(os.Args) >= 5 && os.Args[3] == "revoke" {
+		if _, err := makeRequest("DELETE", "/users/me/sessions/"+os.Args[4], nil, config.User.Token); err != nil {
+			fmt.Printf("✗ Failed to revoke session: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("✓ Session revoked and its connections closed")
+		return
+	}
+
+	resp, err := makeRequest("GET", "/users/me/sessions", nil, config.User.Token)
+	if err != nil {
+		fmt.Printf("✗ Failed to get sessions: %v\n", err)
+		os.Exit(1)
+	}
+
+	respData, _ := resp["data"].(map[string]interface{})
+	sessions, _ := respData["sessions"].([]interface{})
+
+	fmt.Println("💻 Active Sessions:")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	for _, s := range sessions {
+		session := s.(map[string]interface{})
+		name, _ := session["device_name"].(string)
+		if name == "" {
+			name = "Unknown device"
+		}
+		current := ""
+		if isCurrent, _ := session["current"].(bool); isCurrent {
+			current = " (this device)"
+		}
+		lastSeen, _ := session["last_seen_at"].(string)
+		if len(lastSeen) > 16 {
+			lastSeen = lastSeen[:16]
+		}
+		fmt.Printf("  %s  %s%s\n", session["id"], name, current)
+		fmt.Printf("    %s | IP: %s | Last seen: %s\n", session["user_agent"], session["ip"], lastSeen)
+	}
+	fmt.Println("\n💡 Log a device out with: mangahub auth sessions revoke <session-id>")
+}
+
+// Workflow: handleAuthToken -> cmdAuthTokenCreate, cmdAuthTokenList or cmdAuthTokenRevoke -> makeRequest to /users/me/tokens
+func handleAuthToken() {
+	requireAuth()
+
+	if len(os.Args) < 4 {
+		fmt.Println("Usage: mangahub auth token <create|list|revoke>")
+		os.Exit(1)
+	}
+
+	switch os.Args[3] {
+	case "create":
+		cmdAuthTokenCreate()
+	case "list":
+		cmdAuthTokenList()
+	case "revoke":
+		cmdAuthTokenRevoke()
+	default:
+		fmt.Println("Usage: mangahub auth token <create|list|revoke>")
+		os.Exit(1)
+	}
+}
+
+func cmdAuthTokenCreate() {
+	name := getFlag("--name")
+	scopes := getFlag("--scopes")
+	if name == "" || scopes == "" {
+		fmt.Println("Usage: mangahub auth token create --name <name> --scopes <read:catalog,write:library,admin:notify> [--expires-days <n>]")
+		os.Exit(1)
+	}
+
+	data := map[string]interface{}{
+		"name":   name,
+		"scopes": strings.Split(scopes, ","),
+	}
+	if days := getFlag("--expires-days"); days != "" {
+		var n int
+		fmt.Sscanf(days, "%d", &n)
+		data["expires_in_days"] = n
+	}
+
+	resp, err := makeRequest("POST", "/users/me/tokens", data, config.User.Token)
+	if err != nil {
+		fmt.Printf("✗ Failed to create token: %v\n", err)
+		os.Exit(1)
+	}
+
+	respData, _ := resp["data"].(map[string]interface{})
+	fmt.Printf("✓ Token '%s' created\n", name)
+	fmt.Printf("\n  %v\n\n", respData["token"])
+	fmt.Println("⚠️  Copy it now, it won't be shown again")
+	fmt.Println("💡 Send it as 'Authorization: Bearer <token>' from scripts and bots")
+}
+
+func cmdAuthTokenList() {
+	resp, err := makeRequest("GET", "/users/me/tokens", nil, config.User.Token)
+	if err != nil {
+		fmt.Printf("✗ Failed to get tokens: %v\n", err)
+		os.Exit(1)
+	}
+
+	respData, _ := resp["data"].(map[string]interface{})
+	tokens, _ := respData["tokens"].([]interface{})
+	if len(tokens) == 0 {
+		fmt.Println("No personal access tokens")
+		return
+	}
+
+	fmt.Println("🔑 Personal Access Tokens:")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	for _, t := range tokens {
+		token := t.(map[string]interface{})
+		var scopes []string
+		for _, scope := range token["scopes"].([]interface{}) {
+			scopes = append(scopes, scope.(string))
+		}
+		expires := "never"
+		if expiresAt, ok := token["expires_at"].(string); ok {
+			expires = expiresAt[:10]
+		}
+		lastUsed := "never"
+		if lastUsedAt, ok := token["last_used_at"].(string); ok {
+			lastUsed = lastUsedAt[:16]
+		}
+		fmt.Printf("  %s  %s\n", token["id"], token["name"])
+		fmt.Printf("    Scopes: %s | Expires: %s | Last used: %s\n", strings.Join(scopes, ", "), expires, lastUsed)
+	}
+}
+
+func cmdAuthTokenRevoke() {
+	if len(os.Args) < 5 {
+		fmt.Println("Usage: mangahub auth token revoke <token-id>")
+		os.Exit(1)
+	}
+
+	if _, err := makeRequest("DELETE", "/users/me/tokens/"+os.Args[4], nil, config.User.Token); err != nil {
+		fmt.Printf("✗ Failed to revoke token: %v\n", err)
+		os.Exit(1)
 	}
+	fmt.Println("✓ Token revoked")
 }
 
 // Workflow of UC-001: cmdAuthRegister -> Input username, email, password -> Send HTTP request to /auth/register -> Handle response
@@ -405,12 +775,18 @@ func cmdAuthRegister() {
 		fmt.Printf("  User ID: %s\n", respData["user_id"])
 		fmt.Printf("  Username: %s\n", respData["username"])
 	}
+	fmt.Println("\n📧 Check your email to verify your address (needed for chat and notifications)")
 	fmt.Printf("\nNext: mangahub auth login --username %s\n", username)
 }
 
 // Workflow of UC-002: cmdAuthLogin -> Input username, password -> Send HTTP request to /auth/login -> Handle response
 // Send HTTP request to /auth/login (see internal/user/handler.go)
 func cmdAuthLogin() {
+	if hasFlag("--sso") {
+		cmdAuthLoginSSO()
+		return
+	}
+
 	username := getFlag("--username")
 	if username == "" {
 		fmt.Print("Username: ")
@@ -420,9 +796,11 @@ func cmdAuthLogin() {
 	fmt.Print("Password: ")
 	password := readPassword()
 
+	hostname, _ := os.Hostname()
 	data := map[string]string{ // Request payload JSON
-		"username": username,
-		"password": password,
+		"username":    username,
+		"password":    password,
+		"device_name": hostname,
 	}
 
 	fmt.Println("\n🔄 Authenticating via HTTP...")
@@ -432,19 +810,144 @@ func cmdAuthLogin() {
 		os.Exit(1)
 	}
 
-	if respData, ok := resp["data"].(map[string]interface{}); ok { // Receive response data JSON
-		if token, ok := respData["token"].(string); ok {
-			config.User.Token = token
-			config.User.Username = username
-			if userID, ok := respData["user_id"].(string); ok {
-				config.User.UserID = userID
-			}
-			saveConfig()
+	respData, _ := resp["data"].(map[string]interface{}) // Receive response data JSON
+	finishLogin(respData, hostname)
+}
+
+// finishLogin answers a two-factor challenge if the login needs one, then
+// saves the tokens
+func finishLogin(respData map[string]interface{}, hostname string) {
+	// Accounts with two-factor authentication answer a challenge with a code
+	if required, _ := respData["two_factor_required"].(bool); required {
+		fmt.Print("Two-factor code (or recovery code): ")
+		var code string
+		fmt.Scanln(&code)
+
+		data := map[string]string{
+			"challenge_token": fmt.Sprint(respData["challenge_token"]),
+			"code":            code,
+			"device_name":     hostname,
+		}
+		resp, err := makeRequest("POST", "/auth/login/2fa", data, "")
+		if err != nil {
+			fmt.Printf("✗ Login failed: %v\n", err)
+			os.Exit(1)
+		}
+		respData, _ = resp["data"].(map[string]interface{})
+	}
+
+	username, _ := respData["username"].(string)
+	if token, ok := respData["token"].(string); ok {
+		config.User.Token = token
+		config.User.RefreshToken, _ = respData["refresh_token"].(string)
+		config.User.Username = username
+		if userID, ok := respData["user_id"].(string); ok {
+			config.User.UserID = userID
 		}
+		saveConfig()
 	}
 
 	fmt.Printf("✓ Welcome back, %s! (JWT token saved)\n", username)
 	fmt.Println("\n💡 Your session is now authenticated for HTTP, TCP, gRPC, and WebSocket")
+
+	if verified, ok := respData["email_verified"].(bool); ok && !verified {
+		fmt.Println("⚠️  Your email isn't verified yet: chat and notifications are disabled")
+		fmt.Println("   Use 'mangahub auth verify --token <token>' or 'mangahub auth verify --resend'")
+	}
+	if setup, _ := respData["two_factor_setup_required"].(bool); setup {
+		fmt.Println("⚠️  Admin accounts need two-factor authentication before sending notifications")
+		fmt.Println("   Use 'mangahub auth 2fa enable'")
+	}
+	if restored, _ := respData["account_restored"].(bool); restored {
+		fmt.Println("✓ Your account was scheduled for deletion; logging in has cancelled it")
+	}
+}
+
+// Workflow: cmdAuthLoginSSO -> listen on a loopback port -> HTTP POST /auth/sso/start -> open the
+// provider in a browser -> receive the redirect -> HTTP POST /auth/sso/callback -> finishLogin
+func cmdAuthLoginSSO() {
+	// The provider sends the browser back to this port (RFC 8252 loopback redirect)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fmt.Printf("✗ Failed to listen for the login redirect: %v\n", err)
+		os.Exit(1)
+	}
+	defer listener.Close()
+	redirectURI := fmt.Sprintf("http://127.0.0.1:%d/callback", listener.Addr().(*net.TCPAddr).Port)
+
+	resp, err := makeRequest("POST", "/auth/sso/start", map[string]string{"redirect_uri": redirectURI}, "")
+	if err != nil {
+		fmt.Printf("✗ Single sign-on failed: %v\n", err)
+		os.Exit(1)
+	}
+	respData, _ := resp["data"].(map[string]interface{})
+	authURL, _ := respData["authorization_url"].(string)
+	state, _ := respData["state"].(string)
+
+	callbacks := make(chan url.Values, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/callback" || query.Get("state") != state {
+			http.Error(w, "Unknown login", http.StatusBadRequest)
+			return
+		}
+		if query.Get("error") != "" {
+			fmt.Fprintln(w, "MangaHub login was cancelled. You can close this window.")
+		} else {
+			fmt.Fprintln(w, "MangaHub login complete. You can close this window and return to the terminal.")
+		}
+		select {
+		case callbacks <- query:
+		default:
+		}
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	fmt.Println("🌐 Opening your browser to sign in. If it doesn't open, visit:")
+	fmt.Printf("   %s\n\n", authURL)
+	openBrowser(authURL)
+	fmt.Println("⏳ Waiting for the identity provider...")
+
+	var query url.Values
+	select {
+	case query = <-callbacks:
+	case <-time.After(5 * time.Minute):
+		fmt.Println("✗ Timed out waiting for the login to finish")
+		os.Exit(1)
+	}
+	if reason := query.Get("error"); reason != "" {
+		fmt.Printf("✗ Login failed at the identity provider: %s %s\n", reason, query.Get("error_description"))
+		os.Exit(1)
+	}
+
+	hostname, _ := os.Hostname()
+	data := map[string]string{
+		"state":       state,
+		"code":        query.Get("code"),
+		"device_name": hostname,
+	}
+	resp, err = makeRequest("POST", "/auth/sso/callback", data, "")
+	if err != nil {
+		fmt.Printf("✗ Login failed: %v\n", err)
+		os.Exit(1)
+	}
+	respData, _ = resp["data"].(map[string]interface{})
+	finishLogin(respData, hostname)
+}
+
+// openBrowser opens a URL in the default browser, if there is one
+func openBrowser(target string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		cmd = exec.Command("xdg-open", target)
+	}
+	cmd.Start()
 }
 
 // ===== MANGA (UC-003, UC-004) - HTTP =====
@@ -686,7 +1189,7 @@ func cmdLibraryRemove() {
 // ===== PROGRESS (UC-006) - HTTP with TCP broadcast =====
 func handleProgress() {
 	if len(os.Args) < 3 {
-		fmt.Println("Usage: mangahub progress <update|history>")
+		fmt.Println("Usage: mangahub progress <update|mark|unmark|chapters|position|history>")
 		os.Exit(1)
 	}
 
@@ -695,6 +1198,14 @@ func handleProgress() {
 	switch os.Args[2] {
 	case "update":
 		cmdProgressUpdate()
+	case "mark":
+		cmdProgressMark(true)
+	case "unmark":
+		cmdProgressMark(false)
+	case "chapters":
+		cmdProgressChapters()
+	case "position":
+		cmdProgressPosition()
 	case "history":
 		cmdLibraryList()
 	}
@@ -705,7 +1216,7 @@ func cmdProgressUpdate() {
 	chapter := getFlag("--chapter")
 
 	if mangaID == "" || chapter == "" {
-		fmt.Println("Usage: mangahub progress update --manga-id <id> --chapter <number>")
+		fmt.Println("Usage: mangahub progress update --manga-id <id> --chapter <number> [--page <n>] [--scroll <percent>]")
 		os.Exit(1)
 	}
 
@@ -717,6 +1228,23 @@ func cmdProgressUpdate() {
 		"chapter":  chapterNum,
 	}
 
+	// Optional position inside the chapter for cross-device resume
+	if page := getFlag("--page"); page != "" {
+		var pageNum int
+		fmt.Sscanf(page, "%d", &pageNum)
+		data["page"] = pageNum
+	}
+	if scroll := getFlag("--scroll"); scroll != "" {
+		var scrollPercent float64
+		fmt.Sscanf(scroll, "%g", &scrollPercent)
+		data["scroll_percent"] = scrollPercent
+	}
+
+	// Attribute the chapter to the running session, if any
+	if config.Session.ActiveID != "" {
+		data["session_id"] = config.Session.ActiveID
+	}
+
 	fmt.Printf("📖 Updating progress via HTTP...\n")
 	resp, err := makeRequest("PUT", "/progress", data, config.User.Token)
 	if err != nil {
@@ -728,92 +1256,649 @@ func cmdProgressUpdate() {
 	if data, ok := resp["data"].(map[string]interface{}); ok {
 		fmt.Printf("  Manga: %s\n", data["manga_title"])
 		fmt.Printf("  Chapter: %.0f\n", data["chapter"])
+		if page, ok := data["page"].(float64); ok && page > 0 {
+			fmt.Printf("  Page: %.0f\n", page)
+		}
+		if scroll, ok := data["scroll_percent"].(float64); ok && scroll > 0 {
+			fmt.Printf("  Scroll: %.1f%%\n", scroll)
+		}
 	}
 
 	fmt.Println("\n💡 This update will be broadcasted to all your connected TCP clients")
 	fmt.Println("💡 Use 'mangahub sync monitor' to see real-time updates")
 }
 
-// ===== SYNC (UC-007, UC-008) - TCP =====
-func handleSync() {
-	if len(os.Args) < 3 {
-		fmt.Println("Usage: mangahub sync <connect|monitor|status>")
+// Workflow: cmdProgressMark -> Input manga ID, chapter ranges -> HTTP request to /progress/{id}/chapters -> Handle response
+// Send HTTP request to /progress/{id}/chapters (see internal/manga/handler.go)
+func cmdProgressMark(read bool) {
+	mangaID := getFlag("--manga-id")
+	chapters := getFlag("--chapters")
+
+	if mangaID == "" || chapters == "" {
+		fmt.Printf("Usage: mangahub progress %s --manga-id <id> --chapters <list>\n", os.Args[2])
+		fmt.Println("Chapters: single chapters and ranges, e.g. 1-20,25")
 		os.Exit(1)
 	}
 
-	requireAuth()
-
-	switch os.Args[2] {
-	case "connect":
-		cmdSyncConnect()
-	case "monitor":
-		cmdSyncMonitor()
-	case "status":
-		cmdSyncStatus()
+	data := map[string]interface{}{
+		"chapters": chapters,
 	}
-}
 
-func cmdSyncConnect() {
-	fmt.Printf("🔄 Connecting to TCP sync server at %s:%d...\n", config.Server.Host, config.Server.TCPPort)
+	method := "POST"
+	if !read {
+		method = "DELETE"
+	}
 
-	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", config.Server.Host, config.Server.TCPPort))
+	fmt.Printf("📖 Updating read chapters via HTTP...\n")
+	resp, err := makeRequest(method, "/progress/"+mangaID+"/chapters", data, config.User.Token)
 	if err != nil {
-		fmt.Printf("✗ TCP connection failed: %v\n", err)
-		fmt.Println("\n💡 Make sure the server is running: go run cmd/server/main.go")
+		fmt.Printf("✗ Failed: %v\n", err)
 		os.Exit(1)
 	}
-	defer conn.Close()
 
-	// Send authentication
-	authMsg := map[string]string{"user_id": config.User.UserID}
-	authData, _ := json.Marshal(authMsg)
-	conn.Write(append(authData, '\n'))
+	if read {
+		fmt.Println("✓ Chapters marked as read!")
+	} else {
+		fmt.Println("✓ Chapters marked as unread!")
+	}
+	if data, ok := resp["data"].(map[string]interface{}); ok {
+		printReadChapters(data)
+	}
+}
 
-	// Read confirmation
-	reader := bufio.NewReader(conn)
-	response, err := reader.ReadBytes('\n')
-	if err != nil {
-		fmt.Printf("✗ Failed to read response: %v\n", err)
+// Workflow: cmdProgressChapters -> Input manga ID -> HTTP request to /progress/{id}/chapters -> Handle response
+func cmdProgressChapters() {
+	mangaID := getFlag("--manga-id")
+	if mangaID == "" {
+		fmt.Println("Usage: mangahub progress chapters --manga-id <id>")
 		os.Exit(1)
 	}
 
-	var resp map[string]interface{}
-	json.Unmarshal(response, &resp)
-
-	fmt.Println("✓ Connected to TCP sync server successfully!")
-	fmt.Printf("  Status: %s\n", resp["status"])
-	fmt.Printf("  Message: %s\n", resp["message"])
-	fmt.Printf("  Client ID: %s\n", resp["client_id"])
+	resp, err := makeRequest("GET", "/progress/"+mangaID+"/chapters", nil, config.User.Token)
+	if err != nil {
+		fmt.Printf("✗ Failed: %v\n", err)
+		os.Exit(1)
+	}
 
-	fmt.Println("\n💡 Connection established. You will now receive real-time progress updates")
-	fmt.Println("💡 Use 'mangahub sync monitor' to keep the connection alive and monitor updates")
+	if data, ok := resp["data"].(map[string]interface{}); ok {
+		printReadChapters(data)
+	}
 }
 
-func cmdSyncMonitor() {
-	fmt.Printf("🔄 Connecting to TCP sync server for monitoring...\n")
+// Workflow: cmdProgressPosition -> Input manga ID -> HTTP request to /progress/{id}/position -> Handle response
+func cmdProgressPosition() {
+	mangaID := getFlag("--manga-id")
+	if mangaID == "" {
+		fmt.Println("Usage: mangahub progress position --manga-id <id>")
+		os.Exit(1)
+	}
 
-	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", config.Server.Host, config.Server.TCPPort))
+	resp, err := makeRequest("GET", "/progress/"+mangaID+"/position", nil, config.User.Token)
 	if err != nil {
-		fmt.Printf("✗ TCP connection failed: %v\n", err)
+		fmt.Printf("✗ Failed: %v\n", err)
 		os.Exit(1)
 	}
-	defer conn.Close()
-
-	// Authenticate
-	authMsg := map[string]string{"user_id": config.User.UserID}
-	authData, _ := json.Marshal(authMsg)
-	conn.Write(append(authData, '\n'))
 
-	// Read confirmation
-	reader := bufio.NewReader(conn)
-	confirmData, _ := reader.ReadBytes('\n')
-	var confirm map[string]interface{}
-	json.Unmarshal(confirmData, &confirm)
+	if data, ok := resp["data"].(map[string]interface{}); ok {
+		fmt.Println("📍 Last Reading Position")
+		fmt.Printf("  Manga ID: %s\n", data["manga_id"])
+		fmt.Printf("  Chapter: %.0f\n", data["chapter"])
+		if page, ok := data["page"].(float64); ok && page > 0 {
+			fmt.Printf("  Page: %.0f\n", page)
+		}
+		if scroll, ok := data["scroll_percent"].(float64); ok && scroll > 0 {
+			fmt.Printf("  Scroll: %.1f%%\n", scroll)
+		}
+		fmt.Printf("  Updated: %s\n", data["updated_at"])
+	}
+}
+
+func printReadChapters(data map[string]interface{}) {
+	if title, ok := data["manga_title"].(string); ok {
+		fmt.Printf("  Manga: %s\n", title)
+	} else {
+		fmt.Printf("  Manga ID: %s\n", data["manga_id"])
+	}
+	fmt.Printf("  Current Chapter: %.0f\n", data["current_chapter"])
+	readChapters, _ := data["read_chapters"].(string)
+	if readChapters == "" {
+		readChapters = "none"
+	}
+	fmt.Printf("  Read Chapters: %s (%.0f total)\n", readChapters, data["read_count"])
+}
+
+// ===== SESSION - HTTP =====
+func handleSession() {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: mangahub session <start|stop|status|list|summary>")
+		os.Exit(1)
+	}
+
+	requireAuth()
+
+	switch os.Args[2] {
+	case "start":
+		cmdSessionStart()
+	case "stop":
+		cmdSessionStop()
+	case "status":
+		cmdSessionStatus()
+	case "list":
+		cmdSessionList()
+	case "summary":
+		cmdSessionSummary()
+	}
+}
+
+// Workflow: cmdSessionStart -> Optional manga ID -> HTTP request to /sessions -> Remember session ID in config
+func cmdSessionStart() {
+	data := map[string]interface{}{}
+	if mangaID := getFlag("--manga-id"); mangaID != "" {
+		data["manga_id"] = mangaID
+	}
+
+	resp, err := makeRequest("POST", "/sessions", data, config.User.Token)
+	if err != nil {
+		fmt.Printf("✗ Failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	session, _ := resp["data"].(map[string]interface{})
+	sessionID, _ := session["id"].(string)
+	config.Session.ActiveID = sessionID
+	saveConfig()
+
+	fmt.Println("⏱️  Reading session started!")
+	fmt.Printf("  Session ID: %s\n", sessionID)
+	if mangaID, ok := session["manga_id"].(string); ok && mangaID != "" {
+		fmt.Printf("  Manga ID: %s\n", mangaID)
+	}
+	fmt.Println("\n💡 'mangahub progress update' will record chapters in this session")
+	fmt.Println("💡 Use 'mangahub session stop' when you're done reading")
+}
+
+// Workflow: cmdSessionStop -> HTTP PATCH /sessions/{id} with ended=true -> Clear session ID in config
+func cmdSessionStop() {
+	sessionID := getFlag("--id")
+	if sessionID == "" {
+		sessionID = config.Session.ActiveID
+	}
+	if sessionID == "" {
+		fmt.Println("✗ No active session. Start one with 'mangahub session start'")
+		os.Exit(1)
+	}
+
+	resp, err := makeRequest("PATCH", "/sessions/"+sessionID, map[string]interface{}{"ended": true}, config.User.Token)
+	if sessionID == config.Session.ActiveID {
+		// Forget the session even if the server already ended it
+		config.Session.ActiveID = ""
+		saveConfig()
+	}
+	if err != nil {
+		fmt.Printf("✗ Failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("✓ Reading session ended")
+	if session, ok := resp["data"].(map[string]interface{}); ok {
+		printSession(session)
+	}
+}
+
+func cmdSessionStatus() {
+	if config.Session.ActiveID == "" {
+		fmt.Println("Status: No active session")
+		return
+	}
+
+	resp, err := makeRequest("GET", "/sessions/"+config.Session.ActiveID, nil, config.User.Token)
+	if err != nil {
+		fmt.Printf("✗ Failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if session, ok := resp["data"].(map[string]interface{}); ok {
+		if _, ended := session["ended_at"]; ended {
+			fmt.Println("Status: Session ended (idle timeout)")
+			config.Session.ActiveID = ""
+			saveConfig()
+		} else {
+			fmt.Println("Status: Reading")
+		}
+		printSession(session)
+	}
+}
+
+func cmdSessionList() {
+	resp, err := makeRequest("GET", "/sessions", nil, config.User.Token)
+	if err != nil {
+		fmt.Printf("✗ Failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	data, _ := resp["data"].(map[string]interface{})
+	sessions, _ := data["sessions"].([]interface{})
+	if len(sessions) == 0 {
+		fmt.Println("No reading sessions yet")
+		return
+	}
+
+	fmt.Printf("⏱️  Recent Sessions (%d):\n\n", len(sessions))
+	fmt.Printf("%-38s %-10s %-20s %-10s\n", "ID", "SOURCE", "STARTED", "DURATION")
+	fmt.Println(strings.Repeat("-", 80))
+	for _, s := range sessions {
+		session := s.(map[string]interface{})
+		started, _ := time.Parse(time.RFC3339Nano, session["started_at"].(string))
+		duration := "running"
+		if _, ended := session["ended_at"]; ended {
+			duration = formatSeconds(session["duration_seconds"].(float64))
+		}
+		fmt.Printf("%-38s %-10s %-20s %-10s\n",
+			session["id"], session["source"], started.Local().Format("2006-01-02 15:04"), duration)
+	}
+}
+
+// Workflow: cmdSessionSummary -> Optional from/to dates -> HTTP request to /sessions/summary -> Print totals
+func cmdSessionSummary() {
+	// Days are bucketed in the given time zone, defaulting to $TZ and then UTC
+	tz := getFlag("--tz")
+	if tz == "" {
+		tz = os.Getenv("TZ")
+	}
+	if tz == "" {
+		tz = "UTC"
+	}
+
+	endpoint := "/sessions/summary?tz=" + url.QueryEscape(tz)
+	if from := getFlag("--from"); from != "" {
+		endpoint += "&from=" + url.QueryEscape(from)
+	}
+	if to := getFlag("--to"); to != "" {
+		endpoint += "&to=" + url.QueryEscape(to)
+	}
+
+	resp, err := makeRequest("GET", endpoint, nil, config.User.Token)
+	if err != nil {
+		fmt.Printf("✗ Failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	data, ok := resp["data"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	fmt.Println("⏱️  Reading Time")
+	fmt.Println("===============")
+	fmt.Printf("Total: %s in %.0f sessions\n", formatSeconds(data["total_seconds"].(float64)), data["sessions"])
+
+	for _, group := range []struct{ key, title string }{
+		{"per_day", "Per Day"},
+		{"per_manga", "Per Manga"},
+		{"per_genre", "Per Genre"},
+	} {
+		totals, _ := data[group.key].([]interface{})
+		if len(totals) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s:\n", group.title)
+		for _, t := range totals {
+			total := t.(map[string]interface{})
+			name := total["key"].(string)
+			if label, ok := total["label"].(string); ok && label != "" {
+				name = label
+			}
+			fmt.Printf("  %-30s %10s  %3.0f chapters\n", name, formatSeconds(total["seconds"].(float64)), total["chapters"])
+		}
+	}
+}
+
+func printSession(session map[string]interface{}) {
+	fmt.Printf("  Session ID: %s\n", session["id"])
+	fmt.Printf("  Source: %s\n", session["source"])
+	if mangaID, ok := session["manga_id"].(string); ok && mangaID != "" {
+		fmt.Printf("  Manga ID: %s\n", mangaID)
+	}
+	fmt.Printf("  Started: %s\n", session["started_at"])
+	if _, ended := session["ended_at"]; ended {
+		fmt.Printf("  Duration: %s\n", formatSeconds(session["duration_seconds"].(float64)))
+	}
+	if chapters, ok := session["chapters"].([]interface{}); ok {
+		fmt.Printf("  Chapters Read: %d\n", len(chapters))
+		for _, c := range chapters {
+			chapter := c.(map[string]interface{})
+			fmt.Printf("    - %s ch.%.0f\n", chapter["manga_id"], chapter["chapter"])
+		}
+	}
+}
+
+func formatSeconds(seconds float64) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+// ===== GOALS - HTTP =====
+func handleGoals() {
+	requireAuth()
+
+	subcommand := "list"
+	if len(os.Args) >= 3 {
+		subcommand = os.Args[2]
+	}
+
+	switch subcommand {
+	case "list":
+		cmdGoalsList()
+	case "add":
+		cmdGoalsAdd()
+	case "remove":
+		cmdGoalsRemove()
+	case "achievements":
+		cmdGoalsAchievements()
+	case "timezone":
+		cmdGoalsTimezone()
+	default:
+		fmt.Println("Usage: mangahub goals <list|add|remove|achievements|timezone>")
+		os.Exit(1)
+	}
+}
+
+// Workflow: cmdGoalsList -> HTTP request to /goals -> Print progress bars and streaks
+func cmdGoalsList() {
+	resp, err := makeRequest("GET", "/goals", nil, config.User.Token)
+	if err != nil {
+		fmt.Printf("✗ Failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	data, _ := resp["data"].(map[string]interface{})
+	fmt.Printf("🔥 Current Streak: %.0f days (longest %.0f)\n\n", data["current_streak"], data["longest_streak"])
+
+	goals, _ := data["goals"].([]interface{})
+	if len(goals) == 0 {
+		fmt.Println("No goals yet. Add one with 'mangahub goals add --kind chapters --target 50 --period month'")
+		return
+	}
+
+	fmt.Println("🎯 Goals:")
+	for _, g := range goals {
+		goal := g.(map[string]interface{})
+		progress := goal["progress"].(float64)
+		target := goal["target"].(float64)
+
+		const width = 20
+		filled := int(progress / target * width)
+		if filled > width {
+			filled = width
+		}
+		bar := strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+
+		mark := " "
+		if goal["completed"] == true {
+			mark = "✓"
+		}
+		fmt.Printf("  %s %-24s [%s] %.0f/%.0f\n", mark,
+			fmt.Sprintf("%.0f %s per %s", target, goal["kind"], goal["period"]), bar, progress, target)
+		fmt.Printf("    ID: %s\n", goal["id"])
+	}
+}
+
+// Workflow: cmdGoalsAdd -> Input kind, target, period -> HTTP request to /goals
+func cmdGoalsAdd() {
+	kind := getFlag("--kind")
+	target := getFlag("--target")
+	period := getFlag("--period")
+
+	if kind == "" || target == "" || period == "" {
+		fmt.Println("Usage: mangahub goals add --kind <chapters|series> --target <number> --period <day|week|month|year>")
+		os.Exit(1)
+	}
+
+	var targetNum int
+	fmt.Sscanf(target, "%d", &targetNum)
+
+	data := map[string]interface{}{
+		"kind":   kind,
+		"target": targetNum,
+		"period": period,
+	}
+
+	resp, err := makeRequest("POST", "/goals", data, config.User.Token)
+	if err != nil {
+		fmt.Printf("✗ Failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("✓ Goal created!")
+	if goal, ok := resp["data"].(map[string]interface{}); ok {
+		fmt.Printf("  ID: %s\n", goal["id"])
+		fmt.Printf("  Goal: %.0f %s per %s\n", goal["target"], goal["kind"], goal["period"])
+	}
+	fmt.Println("\n💡 You'll get a UDP notification at 50% and when you reach it")
+}
+
+func cmdGoalsRemove() {
+	id := getFlag("--id")
+	if id == "" {
+		fmt.Println("Usage: mangahub goals remove --id <goal-id>")
+		os.Exit(1)
+	}
+
+	if _, err := makeRequest("DELETE", "/goals/"+id, nil, config.User.Token); err != nil {
+		fmt.Printf("✗ Failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("✓ Goal removed")
+}
+
+// Workflow: cmdGoalsAchievements -> HTTP request to /achievements -> Print badges
+func cmdGoalsAchievements() {
+	resp, err := makeRequest("GET", "/achievements", nil, config.User.Token)
+	if err != nil {
+		fmt.Printf("✗ Failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	data, _ := resp["data"].(map[string]interface{})
+	fmt.Printf("🏆 Achievements (%.0f/%.0f unlocked):\n\n", data["unlocked"], data["total"])
+
+	achievements, _ := data["achievements"].([]interface{})
+	for _, a := range achievements {
+		achievement := a.(map[string]interface{})
+		if unlockedAt, ok := achievement["unlocked_at"].(string); ok {
+			unlocked, _ := time.Parse(time.RFC3339Nano, unlockedAt)
+			fmt.Printf("  🏅 %-15s %s (%s)\n", achievement["name"], achievement["description"], unlocked.Local().Format("2006-01-02"))
+		} else {
+			fmt.Printf("  🔒 %-15s %s\n", achievement["name"], achievement["description"])
+		}
+	}
+}
+
+// Workflow: cmdGoalsTimezone -> Input IANA zone -> HTTP request to /users/me/timezone
+func cmdGoalsTimezone() {
+	if len(os.Args) < 4 {
+		fmt.Println("Usage: mangahub goals timezone <zone>   e.g. Europe/Berlin")
+		os.Exit(1)
+	}
+
+	data := map[string]interface{}{
+		"timezone": os.Args[3],
+	}
+	if _, err := makeRequest("PUT", "/users/me/timezone", data, config.User.Token); err != nil {
+		fmt.Printf("✗ Failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Streaks and goal periods now follow %s\n", os.Args[3])
+}
+
+// ===== SYNC (UC-007, UC-008) - TCP =====
+func handleSync() {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: mangahub sync <connect|monitor|push|status|devices>")
+		os.Exit(1)
+	}
+
+	requireAuth()
+
+	switch os.Args[2] {
+	case "connect":
+		cmdSyncConnect()
+	case "monitor":
+		cmdSyncMonitor()
+	case "push":
+		cmdSyncPush()
+	case "status":
+		cmdSyncStatus()
+	case "devices":
+		cmdSyncDevices()
+	}
+}
+
+// wireEncoding is the encoding sync, notify and chat ask the servers for:
+// --encoding, or the one in the config (json or protobuf)
+func wireEncoding() protocol.Encoding {
+	name := getFlag("--encoding")
+	if name == "" {
+		name = config.Server.Encoding
+	}
+	enc, err := protocol.ParseEncoding(name)
+	if err != nil {
+		fmt.Printf("✗ %v\n", err)
+		os.Exit(1)
+	}
+	return enc
+}
+
+// Workflow: dialSync -> TCP connect -> Send hello with token, versions and encoding -> Read welcome (refresh once if expired)
+func dialSync(hello *protocol.Hello) (net.Conn, *protocol.Reader, *protocol.Welcome) {
+	if hello == nil {
+		hello = &protocol.Hello{}
+	}
+	hello.Versions = []int{protocol.Version}
+	hello.Encodings = []string{string(wireEncoding())}
+
+	for attempt := 0; ; attempt++ {
+		conn, err := dialTCP(10 * time.Second)
+		if err != nil {
+			fmt.Printf("✗ TCP connection failed: %v\n", err)
+			fmt.Println("\n💡 Make sure the server is running: go run cmd/server/main.go")
+			os.Exit(1)
+		}
+
+		// Send authentication; the handshake is always JSON
+		hello.Token = currentToken()
+		protocol.WriteFrame(conn, hello, protocol.JSON)
+
+		// Read confirmation
+		reader := protocol.NewReader(conn)
+		resp, err := reader.ReadMessage()
+		if err != nil {
+			conn.Close()
+			fmt.Printf("✗ Failed to read response: %v\n", err)
+			os.Exit(1)
+		}
+
+		switch msg := resp.(type) {
+		case *protocol.Welcome:
+			// Frames after the welcome use the encoding the server picked
+			reader.SetEncoding(msg.Encoding)
+			return conn, reader, msg
+		case *protocol.AuthError:
+			conn.Close()
+			if msg.Code == "token_expired" && attempt == 0 && refreshTokens() {
+				continue
+			}
+			fmt.Printf("✗ Authentication failed: %s\n", msg.Message)
+			if msg.Code != "unsupported_version" {
+				fmt.Println("💡 Log in again with: mangahub auth login")
+			}
+			os.Exit(1)
+		default:
+			conn.Close()
+			fmt.Printf("✗ Unexpected %s from the server\n", resp.MessageType())
+			os.Exit(1)
+		}
+	}
+}
+
+func cmdSyncConnect() {
+	fmt.Printf("🔄 Connecting to TCP sync server at %s:%d...\n", config.Server.Host, config.Server.TCPPort)
+
+	conn, _, welcome := dialSync(nil)
+	defer conn.Close()
+
+	fmt.Println("✓ Connected to TCP sync server successfully!")
+	fmt.Printf("  Status: %s\n", welcome.Status)
+	fmt.Printf("  Message: %s\n", welcome.Message)
+	fmt.Printf("  Client ID: %s\n", welcome.ClientID)
+	fmt.Printf("  Protocol: v%d (%s)\n", welcome.Version, welcome.Encoding)
+
+	fmt.Println("\n💡 Connection established. You will now receive real-time progress updates")
+	fmt.Println("💡 Use 'mangahub sync monitor' to keep the connection alive and monitor updates")
+}
+
+func cmdSyncMonitor() {
+	fmt.Printf("🔄 Connecting to TCP sync server for monitoring...\n")
+
+	// Start from the whole library, or resume after the last update seen so
+	// nothing sent while offline is lost
+	hello := &protocol.Hello{Snapshot: hasFlag("--snapshot")}
+	// Declaring a device shows it to the user's other devices and has the
+	// server send their presence here
+	hello.DeviceName = getFlag("--device")
+	if hello.DeviceName == "" {
+		hello.DeviceName, _ = os.Hostname()
+	}
+	hello.DeviceType = "cli"
+
+	var nowReading *protocol.NowReading
+	if mangaID := getFlag("--reading"); mangaID != "" {
+		nowReading = &protocol.NowReading{ID: "reading", MangaID: mangaID}
+		if chapterStr := getFlag("--chapter"); chapterStr != "" {
+			if _, err := fmt.Sscanf(chapterStr, "%d", &nowReading.Chapter); err != nil {
+				fmt.Println("✗ Chapter must be a number")
+				os.Exit(1)
+			}
+		}
+	}
+	resuming := !hello.Snapshot && config.Sync.LastSeqUser == config.User.UserID && config.Sync.LastSeq > 0
+	if resuming {
+		lastSeq := config.Sync.LastSeq
+		hello.LastSeq = &lastSeq
+	}
+
+	conn, reader, welcome := dialSync(hello)
+	defer conn.Close()
+	enc := welcome.Encoding
+
+	if welcome.Seq > 0 && !resuming {
+		saveSyncCursor(welcome.Seq)
+	}
+
+	// A gap in seq means updates were lost; the full library replaces them
+	resyncing := false
+	resync := func(reason string) {
+		if !resyncing {
+			resyncing = true
+			fmt.Printf("⚠️  %s, loading the full library...\n", reason)
+			protocol.WriteFrame(conn, &protocol.RequestSnapshot{ID: "resync"}, enc)
+		}
+	}
+	advance := func(seq int64) {
+		if !resyncing && !advanceSyncCursor(seq) {
+			resync("Some updates were dropped")
+		}
+	}
 
 	fmt.Println("✓ Connected to TCP sync server")
-	fmt.Printf("  Client ID: %s\n", confirm["client_id"])
+	fmt.Printf("  Client ID: %s\n", welcome.ClientID)
 	fmt.Println("\n📡 Monitoring real-time progress updates... (Press Ctrl+C to exit)\n")
 
+	if nowReading != nil {
+		protocol.WriteFrame(conn, nowReading, enc)
+	}
+
 	// Setup graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -829,45 +1914,280 @@ func cmdSyncMonitor() {
 		ticker := time.NewTicker(30 * time.Second)
 		defer ticker.Stop()
 		for range ticker.C {
-			heartbeat := map[string]string{"type": "heartbeat"}
-			hbData, _ := json.Marshal(heartbeat)
-			conn.Write(append(hbData, '\n'))
+			protocol.WriteFrame(conn, &protocol.Heartbeat{}, enc)
 		}
 	}()
 
 	// Listen for updates
 	for {
-		data, err := reader.ReadBytes('\n')
+		data, err := reader.ReadFrame()
 		if err != nil {
 			fmt.Printf("\n✗ Connection lost: %v\n", err)
 			break
 		}
+		msg, err := protocol.Unmarshal(data, enc)
+		if err != nil {
+			// Possibly a newer message this CLI doesn't know
+			continue
+		}
 
-		var msg map[string]interface{}
-		json.Unmarshal(data, &msg)
-
-		if msgType, ok := msg["type"].(string); ok {
-			if msgType == "progress_update" {
-				timestamp := time.Now().Format("15:04:05")
-				fmt.Printf("🔔 [%s] Progress Update\n", timestamp)
-				fmt.Printf("   Manga ID: %v\n", msg["manga_id"])
-				fmt.Printf("   Chapter: %.0f\n", msg["chapter"])
-				fmt.Printf("   Timestamp: %v\n\n", msg["timestamp"])
-			} else if msgType == "heartbeat_ack" {
-				// Silent heartbeat acknowledgment
+		switch msg := msg.(type) {
+		case *protocol.ReplayComplete:
+			if msg.Count > 0 {
+				fmt.Printf("⏪ Caught up on %d missed updates\n\n", msg.Count)
+			}
+			advance(msg.Seq)
+		case *protocol.ResyncRequired:
+			// Too much was missed to replay; start over from the current library
+			resync("Missed too many updates")
+		case *protocol.Snapshot:
+			if msg.ID == "resync" {
+				resyncing = false
+				fmt.Printf("✓ Resynced %d library entries\n\n", len(msg.Library))
+			} else {
+				// Asked for with --snapshot; updates after it are live
+				fmt.Printf("📚 Library as of update #%d (%d entries)\n\n", msg.Seq, len(msg.Library))
+				printSnapshot(msg)
+			}
+			// A snapshot older than events already seen mustn't move the
+			// cursor back
+			if msg.Seq > syncCursor() {
+				saveSyncCursor(msg.Seq)
 			}
+		case *protocol.Ack:
+			if msg.Status == "error" {
+				fmt.Printf("⚠️  %s\n\n", msg.Message)
+			}
+		case *protocol.ProgressUpdate:
+			timestamp := time.Now().Format("15:04:05")
+			fmt.Printf("🔔 [%s] Progress Update\n", timestamp)
+			fmt.Printf("   Manga ID: %s\n", msg.MangaID)
+			fmt.Printf("   Chapter: %d\n", msg.Chapter)
+			if msg.Page != nil && *msg.Page > 0 {
+				fmt.Printf("   Page: %d\n", *msg.Page)
+			}
+			if msg.ScrollPercent != nil && *msg.ScrollPercent > 0 {
+				fmt.Printf("   Scroll: %.1f%%\n", *msg.ScrollPercent)
+			}
+			fmt.Printf("   Timestamp: %d\n\n", msg.Timestamp)
+			advance(msg.Seq)
+		case *protocol.LibraryUpdate:
+			timestamp := time.Now().Format("15:04:05")
+			fmt.Printf("📚 [%s] Library Update\n", timestamp)
+			fmt.Printf("   Manga ID: %s\n", msg.MangaID)
+			if msg.Removed {
+				fmt.Print("   Removed from library\n\n")
+			} else {
+				chapter, rating := 0, 0
+				if msg.CurrentChapter != nil {
+					chapter = *msg.CurrentChapter
+				}
+				if msg.Rating != nil {
+					rating = *msg.Rating
+				}
+				fmt.Printf("   Status: %s, Chapter: %d, Rating: %d\n\n", msg.Status, chapter, rating)
+			}
+			advance(msg.Seq)
+		case *protocol.Presence:
+			timestamp := time.Now().Format("15:04:05")
+			fmt.Printf("💻 [%s] %s\n\n", timestamp, describeDevice(*msg))
+		case *protocol.HeartbeatAck:
+			// Silent heartbeat acknowledgment
+		case *protocol.ReauthRequired:
+			// Keep the connection open with a fresh token
+			if refreshTokens() {
+				protocol.WriteFrame(conn, &protocol.Auth{Token: config.User.Token}, enc)
+			} else {
+				fmt.Println("⚠️  Token expires soon and couldn't be refreshed")
+			}
+		case *protocol.AuthOK:
+			// Silent reauthentication acknowledgment
+		case *protocol.AuthError:
+			fmt.Printf("\n✗ Authentication failed: %s\n", msg.Message)
+			return
+		case *protocol.SessionRevoked:
+			fmt.Println("\n✗ This session was logged out from another device")
+			return
+		case *protocol.AccountDeleted:
+			fmt.Println("\n✗ This account was scheduled for deletion")
+			return
+		}
+	}
+}
+
+// Workflow: cmdSyncPush -> dialSync -> Send progress_update frame -> Wait for its ack
+func cmdSyncPush() {
+	mangaID := getFlag("--manga-id")
+	chapterStr := getFlag("--chapter")
+	if mangaID == "" || chapterStr == "" {
+		fmt.Println("Usage: mangahub sync push --manga-id <id> --chapter <number> [--page <number>]")
+		os.Exit(1)
+	}
+	var chapter int
+	if _, err := fmt.Sscanf(chapterStr, "%d", &chapter); err != nil {
+		fmt.Println("✗ Chapter must be a number")
+		os.Exit(1)
+	}
+
+	frame := &protocol.ProgressUpdate{
+		ID:      fmt.Sprintf("push-%d", time.Now().UnixNano()),
+		MangaID: mangaID,
+		Chapter: chapter,
+	}
+	if pageStr := getFlag("--page"); pageStr != "" {
+		var page int
+		if _, err := fmt.Sscanf(pageStr, "%d", &page); err != nil {
+			fmt.Println("✗ Page must be a number")
+			os.Exit(1)
+		}
+		frame.Page = &page
+	}
+
+	conn, reader, welcome := dialSync(nil)
+	defer conn.Close()
+
+	protocol.WriteFrame(conn, frame, welcome.Encoding)
+
+	// Other frames may arrive before the acknowledgement
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	for {
+		data, err := reader.ReadFrame()
+		if err != nil {
+			fmt.Printf("✗ No acknowledgement from the server: %v\n", err)
+			os.Exit(1)
+		}
+		msg, _ := protocol.Unmarshal(data, welcome.Encoding)
+		ack, ok := msg.(*protocol.Ack)
+		if !ok || ack.ID != frame.ID {
+			continue
+		}
+		if ack.Status != "ok" {
+			fmt.Printf("✗ Failed: %s\n", ack.Message)
+			os.Exit(1)
 		}
+		fmt.Printf("✓ Progress synced: %s chapter %d\n", mangaID, chapter)
+		return
+	}
+}
+
+// Workflow: cmdSyncDevices -> makeRequest GET /users/me/devices -> List connected devices and what they are reading
+func cmdSyncDevices() {
+	resp, err := makeRequest("GET", "/users/me/devices", nil, config.User.Token)
+	if err != nil {
+		fmt.Printf("✗ Failed to get devices: %v\n", err)
+		os.Exit(1)
+	}
+
+	respData, _ := resp["data"].(map[string]interface{})
+	var devices []protocol.Presence
+	data, _ := json.Marshal(respData["devices"])
+	json.Unmarshal(data, &devices)
+
+	if len(devices) == 0 {
+		fmt.Println("No devices are connected to sync")
+		fmt.Println("\n💡 Connect this one with: mangahub sync monitor")
+		return
+	}
+
+	fmt.Printf("💻 Connected Devices (%d):\n", len(devices))
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	for _, device := range devices {
+		fmt.Printf("  %s\n", describeDevice(device))
+		fmt.Printf("    Connected: %s | Last seen: %s\n",
+			time.Unix(device.ConnectedAt, 0).Format("2006-01-02 15:04"),
+			time.Unix(device.LastSeen, 0).Format("15:04:05"))
 	}
 }
 
+// describeDevice is one line about a device: its name, whether it is online
+// and what it is reading
+func describeDevice(device protocol.Presence) string {
+	name := device.DeviceName
+	if name == "" {
+		name = "Unknown device"
+	}
+	if device.DeviceType != "" {
+		name += " (" + device.DeviceType + ")"
+	}
+	if device.Status != "online" {
+		return name + " went offline"
+	}
+
+	if device.MangaID == "" {
+		return name + " is online"
+	}
+	title := device.MangaTitle
+	if title == "" {
+		title = device.MangaID
+	}
+	reading := name + " is reading " + title
+	if device.Chapter > 0 {
+		reading += fmt.Sprintf(", chapter %d", device.Chapter)
+	}
+	if device.Page > 0 {
+		reading += fmt.Sprintf(" page %d", device.Page)
+	}
+	return reading
+}
+
+// printSnapshot lists the library entries in a snapshot
+func printSnapshot(snapshot *protocol.Snapshot) {
+	if len(snapshot.Library) == 0 {
+		fmt.Print("   Your library is empty\n\n")
+		return
+	}
+	for i, entry := range snapshot.Library {
+		fmt.Printf("%d. %s\n", i+1, entry.MangaID)
+		fmt.Printf("   Status: %s | Chapter: %d", entry.Status, entry.CurrentChapter)
+		if entry.Rating > 0 {
+			fmt.Printf(" | Rating: %d/10", entry.Rating)
+		}
+		fmt.Println()
+	}
+	fmt.Println()
+}
+
+// advanceSyncCursor remembers an event if it is the next one after the last
+// seen. It returns false when events in between are missing, such as ones a
+// drop-oldest server dropped, leaving the cursor before the gap.
+func advanceSyncCursor(seq int64) bool {
+	last := syncCursor()
+	if seq > last+1 {
+		return false
+	}
+	if seq > last {
+		saveSyncCursor(seq)
+	}
+	return true
+}
+
+// syncCursor returns the last sync event the logged in user has seen
+func syncCursor() int64 {
+	if config.Sync.LastSeqUser != config.User.UserID {
+		return 0
+	}
+	return config.Sync.LastSeq
+}
+
+// saveSyncCursor stores the last sync event seen so sync monitor can resume
+func saveSyncCursor(seq int64) {
+	config.Sync.LastSeq = seq
+	config.Sync.LastSeqUser = config.User.UserID
+	saveConfig()
+}
+
 func cmdSyncStatus() {
 	fmt.Println("TCP Sync Status:")
 	fmt.Println("================")
 	fmt.Printf("Server: %s:%d\n", config.Server.Host, config.Server.TCPPort)
 	fmt.Printf("User ID: %s\n", config.User.UserID)
 	fmt.Printf("Auto-sync: %v\n", config.Sync.AutoSync)
+	if config.Sync.LastSeqUser == config.User.UserID && config.Sync.LastSeq > 0 {
+		fmt.Printf("Last update seen: #%d\n", config.Sync.LastSeq)
+	}
 	fmt.Println("\n💡 Use 'mangahub sync connect' to test connection")
 	fmt.Println("💡 Use 'mangahub sync monitor' to watch real-time updates")
+	fmt.Println("💡 Use 'mangahub sync devices' to see your other connected devices")
 }
 
 // ===== NOTIFY (UC-009, UC-010) - UDP =====
@@ -905,16 +2225,21 @@ func cmdNotifySubscribe() {
 	}
 	defer conn.Close()
 
-	// Register
-	regMsg := map[string]interface{}{
-		"type":    "register",
-		"user_id": config.User.UserID,
-		"preferences": map[string]bool{
-			"chapter_releases": config.Notifications.Enabled,
+	// Register. Without preferences the server uses the ones saved in the
+	// profile (mangahub profile edit --notify)
+	enc := wireEncoding()
+	regMsg := &protocol.Register{
+		UserID:   config.User.UserID,
+		Token:    currentToken(),
+		Versions: []int{protocol.Version},
+	}
+	if !config.Notifications.Enabled {
+		regMsg.Preferences = map[string]bool{
+			"chapter_releases": false,
 			"system_updates":   true,
-		},
+		}
 	}
-	data, _ := json.Marshal(regMsg)
+	data, _ := protocol.Marshal(regMsg, enc)
 	n, err := conn.Write(data)
 	if err != nil {
 		fmt.Printf("✗ Failed to register: %v\n", err)
@@ -931,12 +2256,19 @@ func cmdNotifySubscribe() {
 		os.Exit(1)
 	}
 
-	var confirmMsg map[string]interface{}
-	json.Unmarshal(buffer[:n], &confirmMsg)
+	confirmMsg, err := protocol.Unmarshal(buffer[:n], protocol.Detect(buffer[:n]))
+	if err != nil {
+		fmt.Printf("✗ Unreadable confirmation: %v\n", err)
+		os.Exit(1)
+	}
+	if rejected, ok := confirmMsg.(*protocol.Error); ok {
+		fmt.Printf("✗ Subscription rejected: %s\n", rejected.Message)
+		os.Exit(1)
+	}
 
 	fmt.Println("✓ Subscribed to UDP notifications successfully!")
-	if msg, ok := confirmMsg["message"].(string); ok {
-		fmt.Printf("  %s\n", msg)
+	if registered, ok := confirmMsg.(*protocol.Registered); ok && registered.Message != "" {
+		fmt.Printf("  %s\n", registered.Message)
 	}
 
 	fmt.Println("\n🔔 Listening for notifications... (Press Ctrl+C to exit)\n")
@@ -946,8 +2278,7 @@ func cmdNotifySubscribe() {
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-sigChan
-		unreg := map[string]interface{}{"type": "unregister", "user_id": config.User.UserID}
-		b, _ := json.Marshal(unreg)
+		b, _ := protocol.Marshal(&protocol.Unregister{UserID: config.User.UserID}, enc)
 		conn.Write(b)
 		time.Sleep(100 * time.Millisecond)
 		fmt.Println("\n✓ Unsubscribed from notifications")
@@ -959,8 +2290,7 @@ func cmdNotifySubscribe() {
 		ticker := time.NewTicker(30 * time.Second)
 		defer ticker.Stop()
 		for range ticker.C {
-			ping := map[string]interface{}{"type": "ping"}
-			pingData, _ := json.Marshal(ping)
+			pingData, _ := protocol.Marshal(&protocol.Ping{}, enc)
 			conn.Write(pingData)
 		}
 	}()
@@ -974,27 +2304,33 @@ func cmdNotifySubscribe() {
 			return
 		}
 
-		var msg map[string]interface{}
-		if err := json.Unmarshal(buffer[:n], &msg); err != nil {
-			continue
-		}
-
-		if msgType, ok := msg["type"].(string); ok && msgType == "pong" {
+		msg, err := protocol.Unmarshal(buffer[:n], protocol.Detect(buffer[:n]))
+		if err != nil {
 			continue
 		}
 
-		if title, ok := msg["title"].(string); ok {
+		switch msg := msg.(type) {
+		case *protocol.SessionRevoked:
+			fmt.Println("\n✗ This session was logged out from another device")
+			return
+		case *protocol.AccountDeleted:
+			fmt.Println("\n✗ This account was scheduled for deletion")
+			return
+		case *protocol.Notification:
+			if msg.Title == "" {
+				continue
+			}
 			timestamp := time.Now().Format("15:04:05")
 			fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━")
-			fmt.Printf("🔔 [%s] %s\n", timestamp, title)
-			if message, ok := msg["message"].(string); ok {
-				fmt.Printf("   %s\n", message)
+			fmt.Printf("🔔 [%s] %s\n", timestamp, msg.Title)
+			if msg.Message != "" {
+				fmt.Printf("   %s\n", msg.Message)
 			}
-			if mangaTitle, ok := msg["manga_title"].(string); ok {
-				fmt.Printf("   📖 %s\n", mangaTitle)
+			if msg.MangaTitle != "" {
+				fmt.Printf("   📖 %s\n", msg.MangaTitle)
 			}
-			if chapter, ok := msg["chapter"].(float64); ok {
-				fmt.Printf("   📑 Chapter %.0f\n", chapter)
+			if msg.Chapter > 0 {
+				fmt.Printf("   📑 Chapter %d\n", msg.Chapter)
 			}
 			fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 		}
@@ -1017,8 +2353,7 @@ func cmdNotifyTest() {
 	}
 	defer conn.Close()
 
-	msg := map[string]interface{}{"type": "ping"}
-	data, _ := json.Marshal(msg)
+	data, _ := protocol.Marshal(&protocol.Ping{}, wireEncoding())
 
 	start := time.Now()
 	conn.Write(data)
@@ -1031,10 +2366,8 @@ func cmdNotifyTest() {
 		return
 	}
 
-	var resp map[string]interface{}
-	json.Unmarshal(buffer[:n], &resp)
-
-	if resp["type"] == "pong" {
+	resp, _ := protocol.Unmarshal(buffer[:n], protocol.Detect(buffer[:n]))
+	if _, ok := resp.(*protocol.Pong); ok {
 		fmt.Printf("✓ UDP communication successful! (%d ms)\n", time.Since(start).Milliseconds())
 	}
 }
@@ -1094,11 +2427,10 @@ func handleChat() {
 func cmdChatRooms() {
 	requireAuth()
 
-	url := fmt.Sprintf("http://%s:%d/stats", config.Server.Host, config.Server.HTTPPort)
-	req, _ := http.NewRequest("GET", url, nil)
+	req, _ := http.NewRequest("GET", httpURL("/stats"), nil)
 	req.Header.Set("Authorization", "Bearer "+config.User.Token)
 
-	client := &http.Client{}
+	client := httpClient(10 * time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
 		fmt.Printf("✗ Failed to get room list: %v\n", err)
@@ -1133,40 +2465,48 @@ func cmdChatRooms() {
 }
 
 func cmdChatJoin() {
+	requireAuth()
+
 	// Get room from command line or use default
 	room := "general"
-	if len(os.Args) >= 4 {
+	if len(os.Args) >= 4 && !strings.HasPrefix(os.Args[3], "--") {
 		room = os.Args[3]
 	}
-
-	// Get username from config or prompt
 	username := config.User.Username
-	if username == "" {
-		fmt.Print("Enter your username: ")
-		scanner := bufio.NewScanner(os.Stdin)
-		if scanner.Scan() {
-			username = strings.TrimSpace(scanner.Text())
-		}
-		if username == "" {
-			fmt.Println("✗ Username is required")
-			os.Exit(1)
-		}
-	}
 
-	// Build WebSocket URL with query parameters
-	wsURL := fmt.Sprintf("ws://%s:%d/ws?username=%s&room=%s",
-		config.Server.Host, config.Server.HTTPPort, username, room) // Tạo room nếu chưa tồn tại
+	// Build WebSocket URL; the server takes the username from the token
+	wsScheme := "ws"
+	if config.TLS.Enabled {
+		wsScheme = "wss"
+	}
+	wsURL := fmt.Sprintf("%s://%s:%d/ws?room=%s", wsScheme,
+		config.Server.Host, config.Server.HTTPPort, url.QueryEscape(room)) // Tạo room nếu chưa tồn tại
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+currentToken())
 
 	// Connect to WebSocket server
 	fmt.Printf("💬 Connecting to room '%s' as '%s'...\n", room, username)
-	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	dialer := *websocket.DefaultDialer
+	dialer.Subprotocols = []string{protocol.Subprotocol(protocol.Version, wireEncoding())}
+	dialer.TLSClientConfig = clientTLS()
+	conn, _, err := dialer.Dial(wsURL, header)
 	if err != nil {
 		fmt.Printf("✗ WebSocket connection failed: %v\n", err)
-		fmt.Println("\n💡 Make sure the server is running")
+		fmt.Println("\n💡 Make sure the server is running, you are logged in and your email is verified")
 		os.Exit(1)
 	}
 	defer conn.Close()
 
+	// Servers that don't offer the subprotocol speak JSON
+	_, enc, err := protocol.ParseSubprotocol(conn.Subprotocol())
+	if err != nil {
+		enc = protocol.JSON
+	}
+	messageType := websocket.TextMessage
+	if enc == protocol.Protobuf {
+		messageType = websocket.BinaryMessage
+	}
+
 	fmt.Printf("✓ Connected to room '%s' successfully!\n", room)
 	fmt.Println("\n💬 Chat Room - Type your message and press Enter")
 	fmt.Println("   Commands: /quit to exit, /help for help")
@@ -1189,28 +2529,24 @@ func cmdChatJoin() {
 				return
 			}
 
-			// Parse JSON message
-			var msg map[string]interface{}
-			if err := json.Unmarshal(data, &msg); err != nil {
+			msg, err := protocol.Unmarshal(data, enc)
+			if err != nil {
 				log.Printf("Failed to parse message: %v", err)
 				continue
 			}
 
-			// Handle history message
-			if msgType, ok := msg["type"].(string); ok && msgType == "history" {
-				if messages, ok := msg["messages"].([]interface{}); ok && len(messages) > 0 {
+			switch msg := msg.(type) {
+			case *protocol.History:
+				if len(msg.Messages) > 0 {
 					fmt.Println("📜 Recent chat history:")
-					for _, m := range messages {
-						msgData := m.(map[string]interface{})
-						displayMessage(msgData)
+					for _, m := range msg.Messages {
+						displayMessage(m)
 					}
 					fmt.Println()
 				}
-				continue
+			case *protocol.ChatMessage:
+				displayMessage(*msg)
 			}
-
-			// Display regular message
-			displayMessage(msg)
 		}
 	}()
 
@@ -1241,20 +2577,15 @@ func cmdChatJoin() {
 				continue
 			}
 
-			// Create message
-			msg := map[string]interface{}{
-				"text": text,
-			}
-
-			// Marshal to JSON
-			data, err := json.Marshal(msg)
+			// Create message; the server fills in the rest
+			data, err := protocol.Marshal(&protocol.ChatMessage{Text: text}, enc)
 			if err != nil {
 				log.Printf("Failed to marshal message: %v", err)
 				continue
 			}
 
 			// Send message to server
-			err = conn.WriteMessage(websocket.TextMessage, data)
+			err = conn.WriteMessage(messageType, data)
 			if err != nil {
 				log.Println("Write error:", err)
 				return
@@ -1278,27 +2609,19 @@ func cmdChatJoin() {
 	}
 }
 
-func displayMessage(msg map[string]interface{}) {
-	msgType, _ := msg["type"].(string)
-	username, _ := msg["username"].(string)
-	text, _ := msg["text"].(string)
-	timeStr, _ := msg["time"].(string)
-
-	switch msgType {
-	case "chat":
-		fmt.Printf("[%s] %s: %s\n", timeStr, username, text)
+func displayMessage(msg protocol.ChatMessage) {
+	switch msg.Kind {
 	case "system":
-		fmt.Printf("[%s] * %s\n", timeStr, text)
+		fmt.Printf("[%s] * %s\n", msg.Time, msg.Text)
 	default:
-		// Fallback for any message format
-		fmt.Printf("[%s] %s: %s\n", timeStr, username, text)
+		fmt.Printf("[%s] %s: %s\n", msg.Time, msg.Username, msg.Text)
 	}
 }
 
 // ===== GRPC (UC-014, UC-015, UC-016) =====
 func handleGRPC() {
 	if len(os.Args) < 3 {
-		fmt.Println("Usage: mangahub grpc <get|search|update>")
+		fmt.Println("Usage: mangahub grpc <get|search|update|mark|stats>")
 		os.Exit(1)
 	}
 
@@ -1309,6 +2632,10 @@ func handleGRPC() {
 		cmdGRPCSearch()
 	case "update":
 		cmdGRPCUpdate()
+	case "mark":
+		cmdGRPCMark()
+	case "stats":
+		cmdGRPCStats()
 	}
 }
 
@@ -1323,7 +2650,7 @@ func cmdGRPCGet() {
 
 	conn, err := grpc.NewClient(
 		fmt.Sprintf("%s:%d", config.Server.Host, config.Server.GRPCPort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(grpcCredentials()),
 	)
 	if err != nil {
 		fmt.Printf("✗ gRPC connection failed: %v\n", err)
@@ -1359,18 +2686,117 @@ func cmdGRPCGet() {
 	}
 }
 
-func cmdGRPCSearch() {
-	query := getFlag("--query")
-	if query == "" {
-		fmt.Println("Usage: mangahub grpc search --query <text>")
+func cmdGRPCSearch() {
+	query := getFlag("--query")
+	if query == "" {
+		fmt.Println("Usage: mangahub grpc search --query <text>")
+		os.Exit(1)
+	}
+
+	fmt.Printf("🔍 Searching via gRPC: %s\n", query)
+
+	conn, err := grpc.NewClient(
+		fmt.Sprintf("%s:%d", config.Server.Host, config.Server.GRPCPort),
+		grpc.WithTransportCredentials(grpcCredentials()),
+	)
+	if err != nil {
+		fmt.Printf("✗ gRPC connection failed: %v\n", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	client := pb.NewMangaServiceClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := client.SearchManga(ctx, &pb.SearchRequest{
+		Query:  query,
+		Limit:  10,
+		Offset: 0,
+	})
+	if err != nil {
+		fmt.Printf("✗ gRPC request failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(resp.Mangas) == 0 {
+		fmt.Println("No results found")
+		return
+	}
+
+	fmt.Printf("\n✓ Found %d results via gRPC:\n\n", len(resp.Mangas))
+	for i, manga := range resp.Mangas {
+		fmt.Printf("%d. %s\n", i+1, manga.Title)
+		fmt.Printf("   ID: %s | Author: %s | Status: %s | Chapters: %d\n",
+			manga.Id, manga.Author, manga.Status, manga.TotalChapters)
+	}
+}
+
+func cmdGRPCUpdate() {
+	requireAuth()
+
+	mangaID := getFlag("--manga-id")
+	chapter := getFlag("--chapter")
+
+	if mangaID == "" || chapter == "" {
+		fmt.Println("Usage: mangahub grpc update --manga-id <id> --chapter <number>")
+		os.Exit(1)
+	}
+
+	var chapterNum int32
+	fmt.Sscanf(chapter, "%d", &chapterNum)
+
+	fmt.Printf("📖 Updating progress via gRPC...\n")
+
+	conn, err := grpc.NewClient(
+		fmt.Sprintf("%s:%d", config.Server.Host, config.Server.GRPCPort),
+		grpc.WithTransportCredentials(grpcCredentials()),
+	)
+	if err != nil {
+		fmt.Printf("✗ gRPC connection failed: %v\n", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	client := pb.NewMangaServiceClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := client.UpdateProgress(withAuth(ctx), &pb.UpdateProgressRequest{
+		UserId:  config.User.UserID,
+		MangaId: mangaID,
+		Chapter: chapterNum,
+	})
+	if err != nil {
+		fmt.Printf("✗ gRPC request failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if resp.Success {
+		fmt.Println("✓ Progress updated successfully via gRPC!")
+		fmt.Printf("  Chapter: %d\n", resp.CurrentChapter)
+		fmt.Println("\n💡 This update triggered TCP broadcast to connected clients")
+	} else {
+		fmt.Printf("✗ %s\n", resp.Message)
+	}
+}
+
+func cmdGRPCMark() {
+	requireAuth()
+
+	mangaID := getFlag("--manga-id")
+	chapters := getFlag("--chapters")
+
+	if mangaID == "" || chapters == "" {
+		fmt.Println("Usage: mangahub grpc mark --manga-id <id> --chapters <list> [--unread]")
 		os.Exit(1)
 	}
 
-	fmt.Printf("🔍 Searching via gRPC: %s\n", query)
+	fmt.Printf("📖 Updating read chapters via gRPC...\n")
 
 	conn, err := grpc.NewClient(
 		fmt.Sprintf("%s:%d", config.Server.Host, config.Server.GRPCPort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(grpcCredentials()),
 	)
 	if err != nil {
 		fmt.Printf("✗ gRPC connection failed: %v\n", err)
@@ -1382,48 +2808,39 @@ func cmdGRPCSearch() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	resp, err := client.SearchManga(ctx, &pb.SearchRequest{
-		Query:  query,
-		Limit:  10,
-		Offset: 0,
+	resp, err := client.MarkChaptersRead(withAuth(ctx), &pb.MarkChaptersRequest{
+		UserId:   config.User.UserID,
+		MangaId:  mangaID,
+		Chapters: chapters,
+		Unread:   hasFlag("--unread"),
 	})
 	if err != nil {
 		fmt.Printf("✗ gRPC request failed: %v\n", err)
 		os.Exit(1)
 	}
 
-	if len(resp.Mangas) == 0 {
-		fmt.Println("No results found")
-		return
-	}
-
-	fmt.Printf("\n✓ Found %d results via gRPC:\n\n", len(resp.Mangas))
-	for i, manga := range resp.Mangas {
-		fmt.Printf("%d. %s\n", i+1, manga.Title)
-		fmt.Printf("   ID: %s | Author: %s | Status: %s | Chapters: %d\n",
-			manga.Id, manga.Author, manga.Status, manga.TotalChapters)
+	if resp.Success {
+		fmt.Println("✓ Read chapters updated successfully via gRPC!")
+		fmt.Printf("  Current Chapter: %d\n", resp.CurrentChapter)
+		fmt.Printf("  Read Chapters: %s (%d total)\n", resp.ReadChapters, resp.ReadCount)
+	} else {
+		fmt.Printf("✗ %s\n", resp.Message)
 	}
 }
 
-func cmdGRPCUpdate() {
+func cmdGRPCStats() {
 	requireAuth()
 
-	mangaID := getFlag("--manga-id")
-	chapter := getFlag("--chapter")
-
-	if mangaID == "" || chapter == "" {
-		fmt.Println("Usage: mangahub grpc update --manga-id <id> --chapter <number>")
-		os.Exit(1)
+	period := getFlag("--period")
+	if period == "" {
+		period = "month"
 	}
 
-	var chapterNum int32
-	fmt.Sscanf(chapter, "%d", &chapterNum)
-
-	fmt.Printf("📖 Updating progress via gRPC...\n")
+	fmt.Printf("📊 Fetching statistics via gRPC...\n")
 
 	conn, err := grpc.NewClient(
 		fmt.Sprintf("%s:%d", config.Server.Host, config.Server.GRPCPort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(grpcCredentials()),
 	)
 	if err != nil {
 		fmt.Printf("✗ gRPC connection failed: %v\n", err)
@@ -1435,23 +2852,32 @@ func cmdGRPCUpdate() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	resp, err := client.UpdateProgress(ctx, &pb.UpdateProgressRequest{
-		UserId:  config.User.UserID,
-		MangaId: mangaID,
-		Chapter: chapterNum,
+	resp, err := client.GetUserStats(withAuth(ctx), &pb.UserStatsRequest{
+		UserId:   config.User.UserID,
+		Period:   period,
+		Timezone: getFlag("--tz"),
 	})
 	if err != nil {
 		fmt.Printf("✗ gRPC request failed: %v\n", err)
 		os.Exit(1)
 	}
 
-	if resp.Success {
-		fmt.Println("✓ Progress updated successfully via gRPC!")
-		fmt.Printf("  Chapter: %d\n", resp.CurrentChapter)
-		fmt.Println("\n💡 This update triggered TCP broadcast to connected clients")
-	} else {
-		fmt.Printf("✗ %s\n", resp.Message)
+	fmt.Printf("✓ Reading Statistics (%s) via gRPC\n", resp.Period)
+	fmt.Printf("  Chapters Read: %d\n", resp.ChaptersRead)
+	fmt.Printf("  Library: %d manga, %.0f%% completed\n", resp.LibraryTotal, resp.CompletionRate*100)
+	if resp.RatedCount > 0 {
+		fmt.Printf("  Average Rating: %.1f/10\n", resp.AverageRating)
 	}
+	fmt.Printf("  Streak: %d days (longest %d)\n", resp.CurrentStreak, resp.LongestStreak)
+	if resp.LongestFinishedTitle != "" {
+		fmt.Printf("  Longest Finished: %s (%d chapters)\n", resp.LongestFinishedTitle, resp.LongestFinishedChapters)
+	}
+
+	var buckets []interface{}
+	for _, bucket := range resp.ChaptersPerBucket {
+		buckets = append(buckets, map[string]interface{}{"label": bucket.Label, "count": float64(bucket.Count)})
+	}
+	printStatChart("Chapters Read", buckets)
 }
 
 // ===== SERVER =====
@@ -1470,10 +2896,10 @@ func handleServer() {
 }
 
 func cmdServerStatus() {
-	baseURL := fmt.Sprintf("http://%s:%d", config.Server.Host, config.Server.HTTPPort)
+	client := httpClient(10 * time.Second)
 
 	fmt.Println("🔍 Checking server status...")
-	resp, err := http.Get(baseURL + "/health")
+	resp, err := client.Get(httpURL("/health"))
 	if err != nil {
 		fmt.Println("✗ Server is not running")
 		fmt.Println("\n💡 Start server: go run cmd/server/main.go")
@@ -1495,7 +2921,7 @@ func cmdServerStatus() {
 		}
 	}
 
-	resp2, err := http.Get(baseURL + "/stats")
+	resp2, err := client.Get(httpURL("/stats"))
 	if err == nil {
 		defer resp2.Body.Close()
 		body2, _ := io.ReadAll(resp2.Body)
@@ -1518,10 +2944,8 @@ func cmdServerStatus() {
 func cmdServerPing() {
 	fmt.Println("🏓 Pinging all server protocols...\n")
 
-	baseURL := fmt.Sprintf("http://%s:%d", config.Server.Host, config.Server.HTTPPort)
-
 	start := time.Now()
-	resp, err := http.Get(baseURL + "/health")
+	resp, err := httpClient(3 * time.Second).Get(httpURL("/health"))
 	latency := time.Since(start)
 
 	if err != nil {
@@ -1532,7 +2956,7 @@ func cmdServerPing() {
 	}
 
 	start = time.Now()
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", config.Server.Host, config.Server.TCPPort), 3*time.Second)
+	conn, err := dialTCP(3 * time.Second)
 	latency = time.Since(start)
 
 	if err != nil {
@@ -1557,7 +2981,7 @@ func cmdServerPing() {
 	start = time.Now()
 	grpcConn, err := grpc.NewClient(
 		fmt.Sprintf("%s:%d", config.Server.Host, config.Server.GRPCPort),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(grpcCredentials()),
 	)
 	latency = time.Since(start)
 
@@ -1572,7 +2996,7 @@ func cmdServerPing() {
 // ===== CONFIG =====
 func handleConfig() {
 	if len(os.Args) < 3 {
-		fmt.Println("Usage: mangahub config show")
+		fmt.Println("Usage: mangahub config <show|tls>")
 		os.Exit(1)
 	}
 
@@ -1581,13 +3005,52 @@ func handleConfig() {
 		data, _ := yaml.Marshal(config)
 		fmt.Println("Current Configuration:")
 		fmt.Println(string(data))
+	case "tls":
+		cmdConfigTLS()
+	}
+}
+
+// Workflow: cmdConfigTLS -> Set TLS options from flags -> Check the files load -> Save config
+func cmdConfigTLS() {
+	if hasFlag("--off") {
+		config.TLS.Enabled = false
+		saveConfig()
+		fmt.Println("✓ TLS turned off")
+		return
+	}
+
+	config.TLS.Enabled = true
+	if ca := getFlag("--ca"); ca != "" {
+		config.TLS.CAFile, _ = filepath.Abs(ca)
+	}
+	if cert := getFlag("--cert"); cert != "" {
+		config.TLS.CertFile, _ = filepath.Abs(cert)
+	}
+	if key := getFlag("--key"); key != "" {
+		config.TLS.KeyFile, _ = filepath.Abs(key)
+	}
+	config.TLS.InsecureSkipVerify = hasFlag("--insecure")
+
+	// Exits if the files can't be loaded
+	clientTLS()
+	saveConfig()
+
+	fmt.Println("✓ TLS turned on")
+	if config.TLS.CAFile != "" {
+		fmt.Printf("  CA bundle: %s\n", config.TLS.CAFile)
+	}
+	if config.TLS.CertFile != "" {
+		fmt.Printf("  Client certificate: %s\n", config.TLS.CertFile)
+	}
+	if config.TLS.InsecureSkipVerify {
+		fmt.Println("  ⚠️  Server certificates are not verified; use this for development only")
 	}
 }
 
 // ===== STATS =====
 func handleStats() {
 	if len(os.Args) < 3 {
-		fmt.Println("Usage: mangahub stats overview")
+		fmt.Println("Usage: mangahub stats overview [--period week|month|year|all] [--tz <zone>]")
 		os.Exit(1)
 	}
 
@@ -1599,41 +3062,109 @@ func handleStats() {
 	}
 }
 
+// Workflow: cmdStatsOverview -> Input period -> HTTP request to /users/me/stats -> Render charts
+// Statistics are computed on the server (see internal/stats/repository.go)
 func cmdStatsOverview() {
+	period := getFlag("--period")
+	if period == "" {
+		period = "month"
+	}
+	tz := getFlag("--tz")
+	if tz == "" {
+		tz = os.Getenv("TZ")
+	}
+	if tz == "" {
+		tz = "UTC"
+	}
+
 	fmt.Println("📊 Fetching statistics via HTTP...")
-	resp, err := makeRequest("GET", "/library", nil, config.User.Token)
+	resp, err := makeRequest("GET", "/users/me/stats?period="+url.QueryEscape(period)+"&tz="+url.QueryEscape(tz), nil, config.User.Token)
 	if err != nil {
 		fmt.Printf("✗ Failed: %v\n", err)
 		os.Exit(1)
 	}
 
-	if data, ok := resp["data"].(map[string]interface{}); ok {
-		if library, ok := data["library"].([]interface{}); ok {
-			fmt.Println("\n✓ Reading Statistics")
-			fmt.Println("====================")
-			fmt.Printf("Total Manga: %d\n", len(library))
+	data, ok := resp["data"].(map[string]interface{})
+	if !ok {
+		return
+	}
 
-			statusCount := make(map[string]int)
-			totalChapters := 0
+	fmt.Printf("\n✓ Reading Statistics (%s)\n", period)
+	fmt.Println("==============================")
+	fmt.Printf("Chapters Read:     %.0f\n", data["chapters_read"])
+	fmt.Printf("Library:           %.0f manga\n", data["library_total"])
+	fmt.Printf("Completion Rate:   %.0f%%\n", data["completion_rate"].(float64)*100)
+	if rated, _ := data["rated_count"].(float64); rated > 0 {
+		fmt.Printf("Average Rating:    %.1f/10 (%.0f rated)\n", data["average_rating"], rated)
+	}
+	fmt.Printf("Current Streak:    %.0f days\n", data["current_streak"])
+	fmt.Printf("Longest Streak:    %.0f days\n", data["longest_streak"])
+	if finished, ok := data["longest_finished"].(map[string]interface{}); ok {
+		fmt.Printf("Longest Finished:  %s (%.0f chapters)\n", finished["title"], finished["total_chapters"])
+	}
+	if hour, _ := data["most_active_hour"].(float64); hour >= 0 {
+		fmt.Printf("Most Active Hour:  %02.0f:00\n", hour)
+	}
 
-			for _, entry := range library {
-				e := entry.(map[string]interface{})
-				progress := e["progress"].(map[string]interface{})
-				status := progress["status"].(string)
-				statusCount[status]++
+	printStatChart("Chapters Read", data["chapters_per_bucket"])
 
-				if ch, ok := progress["current_chapter"].(float64); ok {
-					totalChapters += int(ch)
-				}
+	if counts, ok := data["status_counts"].(map[string]interface{}); ok && len(counts) > 0 {
+		var statuses []interface{}
+		for _, status := range []string{"reading", "completed", "plan-to-read", "on-hold", "dropped"} {
+			if count, ok := counts[status]; ok {
+				statuses = append(statuses, map[string]interface{}{"label": status, "count": count})
 			}
+		}
+		printStatChart("By Status", statuses)
+	}
 
-			fmt.Println("\nBy Status:")
-			for status, count := range statusCount {
-				fmt.Printf("  %-15s: %d\n", status, count)
-			}
+	genres, _ := data["genres"].([]interface{})
+	if len(genres) > 10 {
+		genres = genres[:10]
+	}
+	printStatChart("Top Genres", genres)
+
+	if hours, ok := data["active_hours"].([]interface{}); ok {
+		var rows []interface{}
+		for hour, count := range hours {
+			rows = append(rows, map[string]interface{}{"label": fmt.Sprintf("%02d:00", hour), "count": count})
+		}
+		printStatChart("Active Hours", rows)
+	}
+}
+
+// printStatChart renders a list of {label, count} objects as a horizontal ASCII bar chart
+func printStatChart(title string, rows interface{}) {
+	items, _ := rows.([]interface{})
+	if len(items) == 0 {
+		return
+	}
 
-			fmt.Printf("\nTotal Chapters Read: %d\n", totalChapters)
+	const width = 40
+	max := 0.0
+	labelWidth := 0
+	for _, item := range items {
+		row := item.(map[string]interface{})
+		if count, _ := row["count"].(float64); count > max {
+			max = count
 		}
+		if label, _ := row["label"].(string); len(label) > labelWidth {
+			labelWidth = len(label)
+		}
+	}
+
+	fmt.Printf("\n%s:\n", title)
+	for _, item := range items {
+		row := item.(map[string]interface{})
+		count, _ := row["count"].(float64)
+		bar := 0
+		if max > 0 {
+			bar = int(count / max * width)
+		}
+		if count > 0 && bar == 0 {
+			bar = 1
+		}
+		fmt.Printf("  %-*s │%s %.0f\n", labelWidth, row["label"], strings.Repeat("█", bar), count)
 	}
 }
 
@@ -1675,6 +3206,214 @@ func cmdExportLibrary() {
 	fmt.Printf("✓ Exported to: %s\n", output)
 }
 
+// ===== PROFILE - HTTP =====
+func handleProfile() {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: mangahub profile <show|edit>")
+		os.Exit(1)
+	}
+
+	requireAuth()
+
+	switch os.Args[2] {
+	case "show":
+		cmdProfileShow()
+	case "edit":
+		cmdProfileEdit()
+	default:
+		fmt.Printf("Unknown profile command: %s\n", os.Args[2])
+		os.Exit(1)
+	}
+}
+
+// Workflow: cmdProfileShow -> HTTP GET /users/me -> print profile and preferences
+func cmdProfileShow() {
+	resp, err := makeRequest("GET", "/users/me", nil, config.User.Token)
+	if err != nil {
+		fmt.Printf("✗ Failed to get profile: %v\n", err)
+		os.Exit(1)
+	}
+	profile, _ := resp["data"].(map[string]interface{})
+	printProfile(profile)
+}
+
+func printProfile(profile map[string]interface{}) {
+	verified := "not verified"
+	if v, _ := profile["email_verified"].(bool); v {
+		verified = "verified"
+	}
+
+	fmt.Printf("👤 %s\n", profile["username"])
+	if name, _ := profile["display_name"].(string); name != "" {
+		fmt.Printf("  Display name:   %s\n", name)
+	}
+	fmt.Printf("  Email:          %s (%s)\n", profile["email"], verified)
+	if bio, _ := profile["bio"].(string); bio != "" {
+		fmt.Printf("  Bio:            %s\n", bio)
+	}
+	if avatar, _ := profile["avatar_url"].(string); avatar != "" {
+		fmt.Printf("  Avatar:         %s\n", avatar)
+	}
+	fmt.Printf("  Timezone:       %s\n", profile["timezone"])
+	fmt.Printf("  Title language: %s\n", profile["title_language"])
+	fmt.Printf("  Privacy:        %s\n", profile["privacy"])
+
+	if prefs, ok := profile["notification_preferences"].(map[string]interface{}); ok {
+		kinds := make([]string, 0, len(prefs))
+		for kind := range prefs {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		fmt.Println("  Notifications:")
+		for _, kind := range kinds {
+			state := "off"
+			if on, _ := prefs[kind].(bool); on {
+				state = "on"
+			}
+			fmt.Printf("    %-18s %s\n", kind, state)
+		}
+	}
+}
+
+// Workflow: cmdProfileEdit -> collect flags (password prompt for --email) -> HTTP PATCH /users/me
+func cmdProfileEdit() {
+	body := make(map[string]interface{})
+	for flag, field := range map[string]string{
+		"--display-name":   "display_name",
+		"--bio":            "bio",
+		"--avatar":         "avatar_url",
+		"--timezone":       "timezone",
+		"--title-language": "title_language",
+		"--privacy":        "privacy",
+		"--email":          "email",
+	} {
+		if hasFlag(flag) {
+			body[field] = getFlag(flag)
+		}
+	}
+
+	// --notify chapter_releases=off,system_updates=on
+	if notify := getFlag("--notify"); notify != "" {
+		prefs := make(map[string]bool)
+		for _, pair := range strings.Split(notify, ",") {
+			kind, state, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || (state != "on" && state != "off") {
+				fmt.Printf("✗ Invalid --notify value %q, expected <type>=on|off\n", pair)
+				os.Exit(1)
+			}
+			prefs[kind] = state == "on"
+		}
+		body["notification_preferences"] = prefs
+	}
+
+	if len(body) == 0 {
+		fmt.Println("Usage: mangahub profile edit [--display-name <name>] [--bio <text>] [--avatar <url>]")
+		fmt.Println("         [--timezone <zone>] [--title-language english|romaji|native]")
+		fmt.Println("         [--privacy public|members|private] [--email <address>]")
+		fmt.Println("         [--notify chapter_releases=on|off,system_updates=on|off]")
+		os.Exit(1)
+	}
+
+	if _, ok := body["email"]; ok {
+		fmt.Print("Current password: ")
+		body["current_password"] = readPassword()
+	}
+
+	resp, err := makeRequest("PATCH", "/users/me", body, config.User.Token)
+	if err != nil {
+		fmt.Printf("✗ Failed to update profile: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ %s\n", resp["message"])
+	if profile, ok := resp["data"].(map[string]interface{}); ok {
+		printProfile(profile)
+	}
+}
+
+// ===== ACCOUNT - HTTP =====
+func handleAccount() {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: mangahub account <export|delete>")
+		os.Exit(1)
+	}
+
+	requireAuth()
+
+	switch os.Args[2] {
+	case "export":
+		cmdAccountExport()
+	case "delete":
+		cmdAccountDelete()
+	default:
+		fmt.Printf("Unknown account command: %s\n", os.Args[2])
+		os.Exit(1)
+	}
+}
+
+// Workflow: cmdAccountExport -> HTTP GET /users/me/data-export -> write the zip archive
+func cmdAccountExport() {
+	output := getFlag("--output")
+	if output == "" {
+		output = fmt.Sprintf("mangahub-export-%s.zip", time.Now().Format("2006-01-02"))
+	}
+
+	fmt.Println("📦 Downloading your data...")
+	data, err := downloadFile("/users/me/data-export", config.User.Token)
+	if err != nil {
+		fmt.Printf("✗ Export failed: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(output, data, 0600); err != nil {
+		fmt.Printf("✗ Failed to write file: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Exported to: %s\n", output)
+}
+
+// Workflow: cmdAccountDelete -> confirm username and password -> HTTP DELETE /users/me -> clear local login
+func cmdAccountDelete() {
+	fmt.Println("⚠️  This deletes your library, progress, goals and every other piece of your data.")
+	fmt.Println("   Run 'mangahub account export' first if you want a copy.")
+	fmt.Printf("Type your username (%s) to confirm: ", config.User.Username)
+	var confirm string
+	fmt.Scanln(&confirm)
+	if confirm != config.User.Username {
+		fmt.Println("✗ Username doesn't match, nothing was deleted")
+		os.Exit(1)
+	}
+
+	fmt.Print("Password: ")
+	body := map[string]string{"password": readPassword()}
+	if code := getFlag("--code"); code != "" {
+		body["code"] = code
+	}
+
+	resp, err := makeRequest("DELETE", "/users/me", body, config.User.Token)
+	if err != nil {
+		fmt.Printf("✗ Failed to delete account: %v\n", err)
+		if strings.Contains(err.Error(), "two-factor") {
+			fmt.Println("  Pass your authenticator or recovery code with --code <code>")
+		}
+		os.Exit(1)
+	}
+
+	// Every login was revoked on the server
+	config.User = Config{}.User
+	config.Session.ActiveID = ""
+	saveConfig()
+
+	fmt.Println("✓ Account scheduled for deletion")
+	if respData, ok := resp["data"].(map[string]interface{}); ok {
+		if at, ok := respData["deletion_scheduled_at"].(string); ok {
+			if t, err := time.Parse(time.RFC3339, at); err == nil {
+				fmt.Printf("  Your data will be removed on %s\n", t.Local().Format("2006-01-02 15:04"))
+			}
+		}
+	}
+	fmt.Println("  Changed your mind? Log in again before then to keep your account.")
+}
+
 // ===== HELPER FUNCTIONS =====
 
 func loadConfig() {
@@ -1689,14 +3428,80 @@ func loadConfig() {
 	yaml.Unmarshal(data, &config)
 }
 
+// saveConfig writes the config, readable only by its owner because it holds
+// the access and refresh tokens
 func saveConfig() {
 	data, _ := yaml.Marshal(config)
-	os.WriteFile(configPath, data, 0644)
+	os.WriteFile(configPath, data, 0600)
+	// WriteFile keeps the mode of a config written by older versions
+	os.Chmod(configPath, 0600)
+}
+
+// clientTLS is the TLS configuration for connecting to the servers, or nil
+// when TLS is off in the config
+func clientTLS() *tls.Config {
+	if !config.TLS.Enabled {
+		return nil
+	}
+	tlsConfig, err := tlsconfig.Client(config.TLS.CAFile, config.TLS.CertFile, config.TLS.KeyFile, config.TLS.InsecureSkipVerify)
+	if err != nil {
+		fmt.Printf("✗ Invalid TLS configuration: %v\n", err)
+		os.Exit(1)
+	}
+	return tlsConfig
+}
+
+// httpURL is the address of path on the HTTP server
+func httpURL(path string) string {
+	scheme := "http"
+	if config.TLS.Enabled {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d%s", scheme, config.Server.Host, config.Server.HTTPPort, path)
+}
+
+// httpClient returns an HTTP client using the configured TLS settings
+func httpClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = clientTLS()
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// grpcCredentials returns the transport credentials for the gRPC server
+func grpcCredentials() credentials.TransportCredentials {
+	if tlsConfig := clientTLS(); tlsConfig != nil {
+		return credentials.NewTLS(tlsConfig)
+	}
+	return insecure.NewCredentials()
+}
+
+// dialTCP connects to the TCP sync server, over TLS if configured
+func dialTCP(timeout time.Duration) (net.Conn, error) {
+	addr := net.JoinHostPort(config.Server.Host, fmt.Sprint(config.Server.TCPPort))
+	dialer := &net.Dialer{Timeout: timeout}
+	if tlsConfig := clientTLS(); tlsConfig != nil {
+		return tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	}
+	return dialer.Dial("tcp", addr)
 }
 
+// Workflow: makeRequest -> refresh access token if it expires soon -> doRequest -> on 401 refresh once and retry
 func makeRequest(method, endpoint string, body interface{}, token string) (map[string]interface{}, error) {
-	baseURL := fmt.Sprintf("http://%s:%d/api", config.Server.Host, config.Server.HTTPPort)
-	url := baseURL + endpoint
+	// Only the saved login is refreshed; other tokens are sent as given
+	saved := token != "" && token == config.User.Token
+	if saved && tokenExpiresSoon(token) && refreshTokens() {
+		token = config.User.Token
+	}
+
+	result, status, err := doRequest(method, endpoint, body, token)
+	if status == http.StatusUnauthorized && saved && refreshTokens() {
+		result, _, err = doRequest(method, endpoint, body, config.User.Token)
+	}
+	return result, err
+}
+
+func doRequest(method, endpoint string, body interface{}, token string) (map[string]interface{}, int, error) {
+	url := httpURL("/api" + endpoint)
 
 	var reqBody io.Reader
 	if body != nil {
@@ -1706,18 +3511,19 @@ func makeRequest(method, endpoint string, body interface{}, token string) (map[s
 
 	req, err := http.NewRequest(method, url, reqBody)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "mangahub-cli/"+VERSION)
 	if token != "" {
 		req.Header.Set("Authorization", "Bearer "+token)
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := httpClient(10 * time.Second)
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
 
@@ -1727,13 +3533,109 @@ func makeRequest(method, endpoint string, body interface{}, token string) (map[s
 	json.Unmarshal(respBody, &result)
 
 	if resp.StatusCode >= 400 {
+		if errMsg, ok := result["error"].(string); ok {
+			return nil, resp.StatusCode, fmt.Errorf("%s", errMsg)
+		}
+		return nil, resp.StatusCode, fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+
+	return result, resp.StatusCode, nil
+}
+
+// downloadFile fetches a non-JSON response body, refreshing the saved login like makeRequest
+func downloadFile(endpoint, token string) ([]byte, error) {
+	if tokenExpiresSoon(token) && refreshTokens() {
+		token = config.User.Token
+	}
+
+	url := httpURL("/api" + endpoint)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "mangahub-cli/"+VERSION)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := httpClient(60 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		var result map[string]interface{}
+		json.Unmarshal(data, &result)
 		if errMsg, ok := result["error"].(string); ok {
 			return nil, fmt.Errorf("%s", errMsg)
 		}
 		return nil, fmt.Errorf("request failed with status %d", resp.StatusCode)
 	}
+	return data, nil
+}
+
+// refreshTokens exchanges the saved refresh token for a new pair and saves it
+func refreshTokens() bool {
+	if config.User.RefreshToken == "" {
+		return false
+	}
+
+	data := map[string]string{"refresh_token": config.User.RefreshToken}
+	resp, _, err := doRequest("POST", "/auth/refresh", data, "")
+	if err != nil {
+		return false
+	}
+
+	respData, ok := resp["data"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	token, _ := respData["token"].(string)
+	refreshToken, _ := respData["refresh_token"].(string)
+	if token == "" || refreshToken == "" {
+		return false
+	}
+
+	config.User.Token = token
+	config.User.RefreshToken = refreshToken
+	saveConfig()
+	return true
+}
+
+// tokenExpiresSoon reads the exp claim of a JWT without verifying it
+func tokenExpiresSoon(token string) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+	var claims struct {
+		ExpiresAt int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.ExpiresAt == 0 {
+		return false
+	}
+	return time.Until(time.Unix(claims.ExpiresAt, 0)) < time.Minute
+}
+
+// currentToken returns the saved access token, refreshing it first if it is about to expire
+func currentToken() string {
+	if tokenExpiresSoon(config.User.Token) {
+		refreshTokens()
+	}
+	return config.User.Token
+}
 
-	return result, nil
+// withAuth attaches the access token to an outgoing gRPC call
+func withAuth(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+currentToken())
 }
 
 func getFlag(flag string) string {
@@ -4,14 +4,25 @@ import (
 	"log"
 	"net"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"mangahub/internal/auth"
 	grpcServer "mangahub/internal/grpc"
+	"mangahub/internal/mail"
 	"mangahub/internal/manga"
+	"mangahub/internal/ratelimit"
+	"mangahub/internal/stats"
+	"mangahub/internal/tlsconfig"
+	"mangahub/internal/user"
 	"mangahub/pkg/database"
 	pb "mangahub/proto/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 func main() {
@@ -25,8 +36,34 @@ func main() {
 	}
 	defer db.Close()
 
-	// Initialize repository
+	// Initialize repositories
 	mangaRepo := manga.NewRepository(db)
+	statsRepo := stats.NewRepository(db)
+
+	// Tokens are checked against the same signing keys and revocation lists
+	// as the HTTP API, which share the database
+	tokenStore := auth.NewTokenStore(db)
+	keyRotation, err := time.ParseDuration(getEnv("JWT_KEY_ROTATION", auth.DefaultKeyRotation.String()))
+	if err != nil {
+		log.Fatalf("Invalid JWT_KEY_ROTATION: %v", err)
+	}
+	signingKeys, err := auth.NewKeySet(tokenStore, getEnv("JWT_ALGORITHM", auth.AlgEdDSA), keyRotation)
+	if err != nil {
+		log.Fatalf("Failed to load signing keys: %v", err)
+	}
+	tokenManager := auth.NewTokenManager(signingKeys, tokenStore)
+
+	// Profile changes send the same emails as the HTTP API
+	smtpPort, _ := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+	mailer := mail.New(mail.Config{
+		Host:     getEnv("SMTP_HOST", ""),
+		Port:     smtpPort,
+		Username: getEnv("SMTP_USERNAME", ""),
+		Password: getEnv("SMTP_PASSWORD", ""),
+		From:     getEnv("MAIL_FROM", "noreply@mangahub.local"),
+		Dir:      getEnv("MAIL_DIR", filepath.Join(filepath.Dir(dbPath), "mail")), // used when SMTP_HOST is unset
+	})
+	userService := user.NewService(user.NewRepository(db), tokenManager, mailer, getEnv("PUBLIC_URL", "http://localhost:8080"))
 
 	// Create gRPC server
 	lis, err := net.Listen("tcp", port)
@@ -34,9 +71,37 @@ func main() {
 		log.Fatalf("Failed to listen: %v", err)
 	}
 
-	grpcSrv := grpc.NewServer()
-	server := grpcServer.NewServer(mangaRepo)
+	// Calls per user (or IP without a token), configurable like the HTTP API
+	rateLimits, err := ratelimit.ParseConfig(getEnv("RATE_LIMITS", ""))
+	if err != nil {
+		log.Fatalf("Invalid RATE_LIMITS: %v", err)
+	}
+
+	options := []grpc.ServerOption{grpc.ChainUnaryInterceptor(
+		grpcServer.AuthInterceptor(tokenManager),
+		grpcServer.RateLimitInterceptor(ratelimit.NewLimiter(rateLimits[ratelimit.ClassGRPC])),
+	)}
+
+	// TLS, configured like cmd/server
+	tlsConfig, err := tlsconfig.New(tlsconfig.Config{
+		CertFile: getEnv("TLS_CERT", ""),
+		KeyFile:  getEnv("TLS_KEY", ""),
+		ClientCA: getEnv("TLS_CLIENT_CA", ""),
+		Dev:      getEnv("TLS_DEV", "") == "true",
+		DevDir:   getEnv("TLS_DEV_DIR", filepath.Join(filepath.Dir(dbPath), "tls")),
+		Hosts:    strings.Fields(strings.ReplaceAll(getEnv("TLS_HOSTS", ""), ",", " ")),
+	})
+	if err != nil {
+		log.Fatalf("Invalid TLS configuration: %v", err)
+	}
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		log.Println("🔒 TLS enabled")
+	}
+	grpcSrv := grpc.NewServer(options...)
+	server := grpcServer.NewServer(mangaRepo, statsRepo, nil)
 	pb.RegisterMangaServiceServer(grpcSrv, server)
+	pb.RegisterUserServiceServer(grpcSrv, grpcServer.NewUserServer(userService))
 
 	// Handle shutdown gracefully
 	sigChan := make(chan os.Signal, 1)
@@ -55,6 +120,10 @@ func main() {
 	log.Println("   - GetManga")
 	log.Println("   - SearchManga")
 	log.Println("   - UpdateProgress")
+	log.Println("   - MarkChaptersRead")
+	log.Println("   - GetUserStats")
+	log.Println("   - UserService.GetProfile")
+	log.Println("   - UserService.UpdateProfile")
 	log.Printf("📚 Database: %s", dbPath)
 	
 	if err := grpcSrv.Serve(lis); err != nil {
@@ -7,26 +7,39 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"mangahub/internal/auth"
+	"mangahub/internal/goals"
 	grpcServer "mangahub/internal/grpc"
+	"mangahub/internal/mail"
+	"mangahub/internal/oidc"
 	"mangahub/internal/manga"
+	"mangahub/internal/ratelimit"
+	"mangahub/internal/session"
+	"mangahub/internal/stats"
 	"mangahub/internal/tcp"
+	"mangahub/internal/tlsconfig"
 	"mangahub/internal/udp"
 	"mangahub/internal/user"
 	ws "mangahub/internal/websocket"
 	"mangahub/pkg/database"
 	"mangahub/pkg/models"
+	"mangahub/pkg/protocol"
 	pb "mangahub/proto/proto"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 var upgrader = websocket.Upgrader{
+	// Clients pick the protocol version and encoding, e.g. mangahub.v1.protobuf
+	Subprotocols: protocol.Subprotocols(),
 	CheckOrigin: func(r *http.Request) bool {
 		return true
 	},
@@ -38,7 +51,6 @@ var upgrader = websocket.Upgrader{
 func main() {
 	// Configuration
 	dbPath := getEnv("DB_PATH", "./data/mangahub.db")
-	jwtSecret := getEnv("JWT_SECRET", "your-secret-key-change-this")
 	httpPort := getEnv("HTTP_PORT", ":8080")
 	tcpPort := getEnv("TCP_PORT", ":9090")
 	udpPort := getEnv("UDP_PORT", ":9091")
@@ -54,6 +66,24 @@ func main() {
 		log.Fatalf("❌ Failed to create data directory: %v", err)
 	}
 
+	// TLS for the HTTP/WebSocket, gRPC and TCP listeners. UDP stays plaintext.
+	tlsConfig, err := tlsconfig.New(tlsconfig.Config{
+		CertFile: getEnv("TLS_CERT", ""),
+		KeyFile:  getEnv("TLS_KEY", ""),
+		ClientCA: getEnv("TLS_CLIENT_CA", ""), // require client certificates it signed
+		Dev:      getEnv("TLS_DEV", "") == "true", // generate a self-signed certificate
+		DevDir:   getEnv("TLS_DEV_DIR", filepath.Join(dataDir, "tls")),
+		Hosts:    splitList(getEnv("TLS_HOSTS", "")),
+	})
+	if err != nil {
+		log.Fatalf("❌ Invalid TLS configuration: %v", err)
+	}
+	httpScheme, wsScheme, tcpScheme, grpcScheme := "http", "ws", "tcp", "grpc"
+	if tlsConfig != nil {
+		httpScheme, wsScheme, tcpScheme, grpcScheme = "https", "wss", "tls", "grpcs"
+		log.Printf("🔒 TLS enabled (client certificates required: %t)", tlsConfig.ClientCAs != nil)
+	}
+
 	// Initialize database
 	log.Printf("📊 Initializing database at: %s", dbPath)
 	db, err := database.InitDB(dbPath)
@@ -73,37 +103,126 @@ func main() {
 
 	// Initialize repositories
 	userRepo := user.NewRepository(db)
+	if err := userRepo.SetAdmins(splitList(getEnv("ADMIN_USERS", ""))); err != nil {
+		log.Fatalf("❌ Failed to set admins: %v", err)
+	}
 	mangaRepo := manga.NewRepository(db)
+	sessionRepo := session.NewRepository(db)
+	statsRepo := stats.NewRepository(db)
+	goalRepo := goals.NewRepository(db)
+
+	// Rate limits per IP, user and route class
+	rateLimits, err := ratelimit.ParseConfig(getEnv("RATE_LIMITS", ""))
+	if err != nil {
+		log.Fatalf("❌ Invalid RATE_LIMITS: %v", err)
+	}
+	limits := ratelimit.New(rateLimits)
 
 	// Initialize services
-	userService := user.NewService(userRepo, jwtSecret)
+	// Access tokens are signed with keys kept in the database and rotated
+	tokenStore := auth.NewTokenStore(db)
+	keyRotation, err := time.ParseDuration(getEnv("JWT_KEY_ROTATION", auth.DefaultKeyRotation.String()))
+	if err != nil {
+		log.Fatalf("❌ Invalid JWT_KEY_ROTATION: %v", err)
+	}
+	signingKeys, err := auth.NewKeySet(tokenStore, getEnv("JWT_ALGORITHM", auth.AlgEdDSA), keyRotation)
+	if err != nil {
+		log.Fatalf("❌ Failed to load signing keys: %v", err)
+	}
+	tokenManager := auth.NewTokenManager(signingKeys, tokenStore)
+	go tokenManager.RunCleanup(time.Hour)
+	go signingKeys.RunRotation(time.Hour)
+	smtpPort, _ := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+	mailer := mail.New(mail.Config{
+		Host:     getEnv("SMTP_HOST", ""),
+		Port:     smtpPort,
+		Username: getEnv("SMTP_USERNAME", ""),
+		Password: getEnv("SMTP_PASSWORD", ""),
+		From:     getEnv("MAIL_FROM", "noreply@mangahub.local"),
+		Dir:      getEnv("MAIL_DIR", filepath.Join(dataDir, "mail")), // used when SMTP_HOST is unset
+	})
+	publicURL := getEnv("PUBLIC_URL", httpScheme+"://localhost"+httpPort) // base of links in emails
+	userService := user.NewService(userRepo, tokenManager, mailer, publicURL)
+
+	// Password hashing cost, optionally raised until a hash takes PASSWORD_HASH_TARGET
+	passwordParams, err := auth.ParsePasswordParams(getEnv("PASSWORD_HASH_PARAMS", ""))
+	if err != nil {
+		log.Fatalf("❌ Invalid PASSWORD_HASH_PARAMS: %v", err)
+	}
+	if target := getEnv("PASSWORD_HASH_TARGET", ""); target != "" {
+		duration, err := time.ParseDuration(target)
+		if err != nil {
+			log.Fatalf("❌ Invalid PASSWORD_HASH_TARGET: %v", err)
+		}
+		passwordParams = auth.CalibratePasswordParams(passwordParams, duration)
+		log.Printf("🔑 Password hashing calibrated to argon2id %s", passwordParams)
+	}
+	userService.SetPasswordHasher(auth.NewPasswordHasher(passwordParams))
+
+	// Single sign-on through an OpenID Connect provider, if one is configured
+	if issuer := getEnv("OIDC_ISSUER", ""); issuer != "" {
+		userService.UseSSO(oidc.NewProvider(oidc.Config{
+			Issuer:       issuer,
+			ClientID:     getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+			Scopes:       strings.Fields(getEnv("OIDC_SCOPES", "")),
+		}))
+		log.Printf("🔐 Single sign-on enabled with %s", issuer)
+	}
 
 	// Create progress broadcast channel
 	progressBroadcast := make(chan models.ProgressUpdate, 100)
 
+	// Coalesce page-level reading position writes
+	positionTracker := manga.NewPositionTracker(mangaRepo, 5*time.Second)
+	go positionTracker.Run()
+
+	// Infer reading sessions from progress events (started once UDP is up)
+	sessionTracker := session.NewTracker(sessionRepo)
+
 	// Initialize WebSocket hub
 	chatHub := ws.NewHub()
+	chatHub.LimitMessages(limits.Get(ratelimit.ClassChat))
 	go chatHub.Run()
 	log.Println("✅ WebSocket Chat Hub initialized")
 
 	// Start TCP Server
 	log.Printf("🔄 Starting TCP Sync Server on %s...", tcpPort)
-	tcpServer := tcp.NewServer(tcpPort)
+	tcpServer := tcp.NewServer(tcpPort, tokenManager)
+	// Frames wait in a bounded queue per connection; slow clients overflow it
+	tcpOverflow, err := tcp.ParseOverflowPolicy(getEnv("TCP_OVERFLOW", ""))
+	if err != nil {
+		log.Fatalf("❌ Invalid TCP_OVERFLOW: %v", err)
+	}
+	tcpQueueSize, err := strconv.Atoi(getEnv("TCP_QUEUE_SIZE", strconv.Itoa(tcp.DefaultQueueSize)))
+	if err != nil {
+		log.Fatalf("❌ Invalid TCP_QUEUE_SIZE: %v", err)
+	}
+	tcpServer.SetQueue(tcpQueueSize, tcpOverflow)
+	// TCP clients can push progress and library changes too
+	tcpServer.UseLibrary(mangaRepo, positionTracker)
+	tcpServer.UseEventLog(tcp.NewEventLog(db, tcp.DefaultEventLimit))
+	tcpServer.OnProgress(sessionTracker.Observe)
+	if tlsConfig != nil {
+		tcpServer.UseTLS(tlsConfig)
+	}
 	if err := tcpServer.Start(); err != nil {
 		log.Fatalf("❌ TCP server failed to start: %v", err)
 	}
 	log.Printf("✅ TCP Sync Server started on %s", tcpPort)
 
-	// Connect TCP broadcast to HTTP API
+	// Connect TCP broadcast and session tracking to HTTP API
 	go func() {
 		for update := range progressBroadcast {
+			sessionTracker.Observe(update)
 			tcpServer.GetBroadcastChannel() <- update
 		}
 	}()
 
 	// Start UDP Server (in goroutine to avoid blocking)
 	log.Printf("📢 Starting UDP Notification Server on %s...", udpPort)
-	udpServer := udp.NewServer(udpPort)
+	udpServer := udp.NewServer(udpPort, tokenManager)
+	udpServer.LimitRequests(limits.Get(ratelimit.ClassUDP))
 	go func() {
 		if err := udpServer.Start(); err != nil {
 			log.Fatalf("❌ UDP server failed to start: %v", err)
@@ -113,9 +232,38 @@ func main() {
 	time.Sleep(100 * time.Millisecond)
 	log.Printf("✅ UDP Notification Server started on %s", udpPort)
 
+	// Disconnect a device everywhere when its login is revoked
+	tokenManager.OnSessionRevoked(tcpServer.DisconnectSession)
+	tokenManager.OnSessionRevoked(udpServer.DisconnectSession)
+	tokenManager.OnSessionRevoked(chatHub.DisconnectSession)
+
+	// Deleted accounts are disconnected everywhere and their chat messages dropped
+	userService.OnAccountDeleted(func(u *models.User) {
+		tcpServer.DisconnectUser(u.ID)
+		udpServer.DisconnectUser(u.ID)
+		chatHub.DisconnectUser(u.Username)
+		chatHub.ForgetUser(u.Username)
+	})
+	userService.ExportChatFrom(chatHub.MessagesBy)
+	go userService.RunAccountPurge(time.Hour)
+
+	// Unverified accounts can't subscribe to notifications
+	udpServer.RequireVerifiedEmail(userService.IsEmailVerified)
+	udpServer.DefaultPreferences(userService.NotificationPreferences)
+
 	// Initialize handlers WITH UDP server
 	userHandler := user.NewHandler(userService)
-	mangaHandler := manga.NewHandler(mangaRepo, progressBroadcast, udpServer)
+	mangaHandler := manga.NewHandler(mangaRepo, progressBroadcast, udpServer, positionTracker)
+	sessionHandler := session.NewHandler(sessionRepo)
+	statsHandler := stats.NewHandler(statsRepo)
+	deviceHandler := tcp.NewHandler(tcpServer)
+
+	// Check goals and achievements after each recorded session update
+	goalService := goals.NewService(goalRepo, udpServer)
+	goalHandler := goals.NewHandler(goalService)
+	sessionTracker.OnProcessed(goalService.Observe)
+	go goalService.Run()
+	go sessionTracker.Run()
 
 	// Start gRPC Server (with better error handling)
 	log.Printf("⚡ Starting gRPC Internal Service on %s...", grpcPort)
@@ -127,9 +275,17 @@ func main() {
 			return
 		}
 
-		grpcSrv := grpc.NewServer()
-		server := grpcServer.NewServer(mangaRepo, progressBroadcast)
+		grpcOptions := []grpc.ServerOption{grpc.ChainUnaryInterceptor(
+			grpcServer.AuthInterceptor(tokenManager),
+			grpcServer.RateLimitInterceptor(limits.Get(ratelimit.ClassGRPC)),
+		)}
+		if tlsConfig != nil {
+			grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		}
+		grpcSrv := grpc.NewServer(grpcOptions...)
+		server := grpcServer.NewServer(mangaRepo, statsRepo, progressBroadcast)
 		pb.RegisterMangaServiceServer(grpcSrv, server)
+		pb.RegisterUserServiceServer(grpcSrv, grpcServer.NewUserServer(userService))
 
 		log.Printf("✅ gRPC Internal Service started on %s", grpcPort)
 		if err := grpcSrv.Serve(lis); err != nil {
@@ -140,11 +296,16 @@ func main() {
 	// Setup HTTP API Server
 	log.Println("🌐 Setting up HTTP API Server...")
 	router := gin.Default()
+	// Without this gin believes any X-Forwarded-For header, letting clients
+	// pick the IP their rate limits are counted against
+	if err := router.SetTrustedProxies(splitList(getEnv("TRUSTED_PROXIES", ""))); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// CORS middleware
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		if c.Request.Method == "OPTIONS" {
@@ -155,6 +316,14 @@ func main() {
 		c.Next()
 	})
 
+	// Every request counts against its client IP
+	router.Use(ratelimit.Middleware(limits.Get(ratelimit.ClassIP), ratelimit.ByIP))
+
+	// Public keys for verifying access tokens
+	router.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.JSON(200, signingKeys.JWKS())
+	})
+
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -181,34 +350,90 @@ func main() {
 	// Public routes
 	public := router.Group("/api")
 	{
-		public.POST("/auth/register", userHandler.Register) // UC-001
-		public.POST("/auth/login", userHandler.Login) // UC-002
-		public.GET("/manga", mangaHandler.SearchManga)
-		public.GET("/manga/:id", mangaHandler.GetManga)
+		authLimit := ratelimit.Middleware(limits.Get(ratelimit.ClassAuth), ratelimit.ByIP)
+		searchLimit := ratelimit.Middleware(limits.Get(ratelimit.ClassSearch), ratelimit.ByIP)
+
+		public.POST("/auth/register", authLimit, userHandler.Register) // UC-001
+		public.POST("/auth/login", authLimit, userHandler.Login)
+		public.POST("/auth/login/2fa", authLimit, userHandler.LoginTwoFactor) // UC-002
+		public.POST("/auth/refresh", authLimit, userHandler.Refresh)
+		public.POST("/auth/forgot", authLimit, userHandler.ForgotPassword)
+		public.POST("/auth/reset", authLimit, userHandler.ResetPassword)
+		public.GET("/auth/verify", authLimit, userHandler.VerifyEmail)
+		public.POST("/auth/sso/start", authLimit, userHandler.StartSSO)
+		public.POST("/auth/sso/callback", authLimit, userHandler.CompleteSSO)
+		public.GET("/manga", searchLimit, mangaHandler.SearchManga)
+		public.GET("/manga/:id", searchLimit, mangaHandler.GetManga)
 	}
 
-	// Protected routes
-	protected := router.Group("/api")
-	protected.Use(auth.JWTMiddleware(jwtSecret))
+	// Routes personal access tokens can use, each checked for its scope
+	scoped := router.Group("/api")
+	scoped.Use(auth.JWTMiddleware(tokenManager), ratelimit.Middleware(limits.Get(ratelimit.ClassAPI), ratelimit.ByUser))
 	{
-		protected.GET("/users/profile", userHandler.GetProfile)
-		protected.GET("/library", mangaHandler.GetLibrary)
-		protected.POST("/library", mangaHandler.AddToLibrary)
-		protected.DELETE("/library/:id", mangaHandler.RemoveFromLibrary)
-		protected.PUT("/progress", mangaHandler.UpdateProgress)
+		library := auth.RequireScope(auth.ScopeWriteLibrary)
+		scoped.GET("/library", library, mangaHandler.GetLibrary)
+		scoped.POST("/library", library, mangaHandler.AddToLibrary)
+		scoped.DELETE("/library/:id", library, mangaHandler.RemoveFromLibrary)
+		scoped.PUT("/progress", library, mangaHandler.UpdateProgress)
+		scoped.GET("/progress/:manga_id/chapters", library, mangaHandler.GetReadChapters)
+		scoped.POST("/progress/:manga_id/chapters", library, mangaHandler.MarkChaptersRead)
+		scoped.DELETE("/progress/:manga_id/chapters", library, mangaHandler.MarkChaptersUnread)
+		scoped.GET("/progress/:manga_id/position", library, mangaHandler.GetPosition)
+		scoped.POST("/sessions", library, sessionHandler.StartSession)
+		scoped.GET("/sessions", library, sessionHandler.ListSessions)
+		scoped.GET("/sessions/summary", library, sessionHandler.GetSummary)
+		scoped.GET("/sessions/:id", library, sessionHandler.GetSession)
+		scoped.PATCH("/sessions/:id", library, sessionHandler.UpdateSession)
 
 		// Admin-only notification endpoint
-		protected.POST("/notify/chapter", mangaHandler.SendNotification)
+		scoped.POST("/notify/chapter", auth.RequireScope(auth.ScopeAdminNotify), userHandler.RequireVerifiedEmail(), userHandler.RequireAdmin(), mangaHandler.SendNotification)
+	}
+
+	// Protected routes (login tokens only)
+	protected := router.Group("/api")
+	protected.Use(auth.JWTMiddleware(tokenManager), auth.RequireSession(), ratelimit.Middleware(limits.Get(ratelimit.ClassAPI), ratelimit.ByUser))
+	{
+		protected.POST("/auth/logout", userHandler.Logout)
+		protected.POST("/auth/verify/resend", userHandler.ResendVerification)
+		protected.GET("/users/profile", userHandler.GetProfile)
+		protected.GET("/users/me", userHandler.GetProfile)
+		protected.PATCH("/users/me", userHandler.UpdateProfile)
+		protected.GET("/users/me/stats", statsHandler.GetMyStats)
+		protected.PUT("/users/me/timezone", userHandler.UpdateTimezone)
+		protected.POST("/users/me/password", userHandler.ChangePassword)
+		protected.POST("/users/me/2fa/enroll", userHandler.EnrollTwoFactor)
+		protected.POST("/users/me/2fa/confirm", userHandler.ConfirmTwoFactor)
+		protected.DELETE("/users/me/2fa", userHandler.DisableTwoFactor)
+		protected.GET("/users/me/tokens", userHandler.ListTokens)
+		protected.POST("/users/me/tokens", userHandler.CreateToken)
+		protected.DELETE("/users/me/tokens/:id", userHandler.RevokeToken)
+		protected.GET("/users/me/sessions", userHandler.ListSessions)
+		protected.GET("/users/me/devices", deviceHandler.ListDevices)
+		protected.DELETE("/users/me/sessions/:id", userHandler.RevokeSession)
+		protected.GET("/users/me/data-export", userHandler.ExportData)
+		protected.DELETE("/users/me", userHandler.DeleteAccount)
+		protected.GET("/goals", goalHandler.ListGoals)
+		protected.POST("/goals", goalHandler.CreateGoal)
+		protected.DELETE("/goals/:id", goalHandler.DeleteGoal)
+		protected.GET("/achievements", goalHandler.ListAchievements)
 	}
 
 	// WebSocket route
 	router.GET("/ws", func(c *gin.Context) {
-		username := c.Query("username")
 		room := c.Query("room")
+		if room == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "room required"})
+			return
+		}
 
-		// Validate required parameters
-		if username == "" || room == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "username and room required"})
+		// The chat username comes from the token, not the query string
+		claims, err := tokenManager.Validate(auth.TokenFromRequest(c.Request))
+		if err != nil || claims.IsPersonalToken() {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or missing token"})
+			return
+		}
+		if verified, _ := userService.IsEmailVerified(claims.UserID); !verified {
+			c.JSON(http.StatusForbidden, gin.H{"error": "verify your email address to use chat"})
 			return
 		}
 
@@ -219,7 +444,7 @@ func main() {
 			return
 		}
 
-		ws.ServeWs(chatHub, conn, username, room)
+		ws.ServeWs(chatHub, conn, claims.Username, room, claims.SessionID)
 	})
 
 	// Legacy WebSocket route with token (for backward compatibility)
@@ -235,11 +460,15 @@ func main() {
 			return
 		}
 
-		claims, err := auth.ValidateToken(token, jwtSecret)
-		if err != nil {
+		claims, err := tokenManager.Validate(token)
+		if err != nil || claims.IsPersonalToken() {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
+		if verified, _ := userService.IsEmailVerified(claims.UserID); !verified {
+			c.JSON(http.StatusForbidden, gin.H{"error": "verify your email address to use chat"})
+			return
+		}
 
 		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 		if err != nil {
@@ -247,7 +476,7 @@ func main() {
 			return
 		}
 
-		ws.ServeWs(chatHub, conn, claims.Username, room)
+		ws.ServeWs(chatHub, conn, claims.Username, room, claims.SessionID)
 	})
 
 	// Graceful shutdown
@@ -261,6 +490,9 @@ func main() {
 		// Shutdown TCP server
 		tcpServer.Shutdown()
 
+		// Persist reading positions that haven't been flushed yet
+		positionTracker.Flush()
+
 		// Close channels
 		close(progressBroadcast)
 
@@ -272,14 +504,14 @@ func main() {
 	log.Println("╔════════════════════════════════════════════════════════════╗")
 	log.Println("║              ✨ All Services Running ✨                    ║")
 	log.Println("╠════════════════════════════════════════════════════════════╣")
-	log.Printf("║ 🌐 HTTP API:      http://localhost%s                     ║\n", httpPort)
-	log.Printf("║ 🔄 TCP Sync:      tcp://localhost%s                      ║\n", tcpPort)
+	log.Printf("║ 🌐 HTTP API:      %s://localhost%s                     ║\n", httpScheme, httpPort)
+	log.Printf("║ 🔄 TCP Sync:      %s://localhost%s                      ║\n", tcpScheme, tcpPort)
 	log.Printf("║ 📢 UDP Notify:    udp://localhost%s                      ║\n", udpPort)
-	log.Printf("║ ⚡ gRPC Service:  grpc://localhost%s                     ║\n", grpcPort)
-	log.Printf("║ 💬 WebSocket:     ws://localhost%s/ws                   ║\n", httpPort)
+	log.Printf("║ ⚡ gRPC Service:  %s://localhost%s                     ║\n", grpcScheme, grpcPort)
+	log.Printf("║ 💬 WebSocket:     %s://localhost%s/ws                   ║\n", wsScheme, httpPort)
 	log.Println("╠════════════════════════════════════════════════════════════╣")
-	log.Println("║ 📊 Health Check:  http://localhost:8080/health            ║")
-	log.Println("║ 📈 Statistics:    http://localhost:8080/stats             ║")
+	log.Printf("║ 📊 Health Check:  %s://localhost%s/health            ║\n", httpScheme, httpPort)
+	log.Printf("║ 📈 Statistics:    %s://localhost%s/stats             ║\n", httpScheme, httpPort)
 	log.Println("╚════════════════════════════════════════════════════════════╝")
 
 	// Start HTTP server
@@ -288,7 +520,14 @@ func main() {
 	log.Println("📡 Server is ready to accept connections...")
 	log.Println()
 
-	if err := router.Run(httpPort); err != nil {
+	httpServer := &http.Server{Addr: httpPort, Handler: router, TLSConfig: tlsConfig}
+	if tlsConfig != nil {
+		// The certificate is already in TLSConfig
+		err = httpServer.ListenAndServeTLS("", "")
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if err != nil {
 		log.Fatalf("❌ Failed to start HTTP server: %v", err)
 	}
 }
@@ -299,3 +538,14 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// splitList splits a comma separated environment variable, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -4,16 +4,66 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 
+	"mangahub/internal/auth"
 	"mangahub/internal/tcp"
+	"mangahub/internal/tlsconfig"
 )
 
 func main() {
 	port := getEnv("TCP_PORT", ":9090")
 
+	// Without the database, tokens are verified against the keys the HTTP
+	// server publishes. Revoked tokens stay valid here until they expire.
+	jwksURL := getEnv("JWKS_URL", "http://localhost:8080/.well-known/jwks.json")
+	if !strings.HasPrefix(jwksURL, "https://") {
+		// Anyone on the path could swap in their own keys and forge tokens
+		log.Printf("Warning: JWKS_URL %s isn't https; use it only on a trusted network", jwksURL)
+	}
+
+	verifier := auth.NewJWKSVerifier(jwksURL)
+	if caFile := getEnv("JWKS_CA", ""); caFile != "" {
+		// Trust the HTTP server's certificate, e.g. its development one
+		jwksTLS, err := tlsconfig.Client(caFile, "", "", false)
+		if err != nil {
+			log.Fatalf("Invalid JWKS_CA: %v", err)
+		}
+		verifier.UseTLS(jwksTLS)
+	}
+
 	// Create TCP server
-	server := tcp.NewServer(port)
+	server := tcp.NewServer(port, verifier)
+
+	// Frames wait in a bounded queue per connection; slow clients overflow it
+	overflow, err := tcp.ParseOverflowPolicy(getEnv("TCP_OVERFLOW", ""))
+	if err != nil {
+		log.Fatalf("Invalid TCP_OVERFLOW: %v", err)
+	}
+	queueSize, err := strconv.Atoi(getEnv("TCP_QUEUE_SIZE", strconv.Itoa(tcp.DefaultQueueSize)))
+	if err != nil {
+		log.Fatalf("Invalid TCP_QUEUE_SIZE: %v", err)
+	}
+	server.SetQueue(queueSize, overflow)
+
+	// TLS, configured like cmd/server
+	tlsConfig, err := tlsconfig.New(tlsconfig.Config{
+		CertFile: getEnv("TLS_CERT", ""),
+		KeyFile:  getEnv("TLS_KEY", ""),
+		ClientCA: getEnv("TLS_CLIENT_CA", ""),
+		Dev:      getEnv("TLS_DEV", "") == "true",
+		DevDir:   getEnv("TLS_DEV_DIR", "./data/tls"),
+		Hosts:    strings.Fields(strings.ReplaceAll(getEnv("TLS_HOSTS", ""), ",", " ")),
+	})
+	if err != nil {
+		log.Fatalf("Invalid TLS configuration: %v", err)
+	}
+	if tlsConfig != nil {
+		server.UseTLS(tlsConfig)
+		log.Println("🔒 TLS enabled")
+	}
 
 	// Handle shutdown gracefully
 	sigChan := make(chan os.Signal, 1)
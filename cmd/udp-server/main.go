@@ -4,16 +4,45 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
+	"mangahub/internal/auth"
+	"mangahub/internal/ratelimit"
+	"mangahub/internal/tlsconfig"
 	"mangahub/internal/udp"
 )
 
 func main() {
 	port := getEnv("UDP_PORT", ":9091")
 
+	// Without the database, tokens are verified against the keys the HTTP
+	// server publishes. Revoked tokens stay valid here until they expire.
+	jwksURL := getEnv("JWKS_URL", "http://localhost:8080/.well-known/jwks.json")
+	if !strings.HasPrefix(jwksURL, "https://") {
+		// Anyone on the path could swap in their own keys and forge tokens
+		log.Printf("Warning: JWKS_URL %s isn't https; use it only on a trusted network", jwksURL)
+	}
+
+	verifier := auth.NewJWKSVerifier(jwksURL)
+	if caFile := getEnv("JWKS_CA", ""); caFile != "" {
+		// Trust the HTTP server's certificate, e.g. its development one
+		jwksTLS, err := tlsconfig.Client(caFile, "", "", false)
+		if err != nil {
+			log.Fatalf("Invalid JWKS_CA: %v", err)
+		}
+		verifier.UseTLS(jwksTLS)
+	}
+
 	// Create UDP server
-	server := udp.NewServer(port)
+	server := udp.NewServer(port, verifier)
+
+	// Drop register/ping floods
+	rateLimits, err := ratelimit.ParseConfig(getEnv("RATE_LIMITS", ""))
+	if err != nil {
+		log.Fatalf("Invalid RATE_LIMITS: %v", err)
+	}
+	server.LimitRequests(ratelimit.NewLimiter(rateLimits[ratelimit.ClassUDP]))
 
 	// Handle shutdown gracefully
 	sigChan := make(chan os.Signal, 1)
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwksRefetchInterval limits how often an unknown kid triggers a JWKS fetch
+const jwksRefetchInterval = 10 * time.Second
+
+// JWK is a public key in JSON Web Key form
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	// Ed25519 keys
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	// RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set, as served at /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func newJWK(kid, algorithm string, public crypto.PublicKey) (*JWK, error) {
+	jwk := &JWK{KeyID: kid, Algorithm: algorithm, Use: "sig"}
+	switch key := public.(type) {
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(key)
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	default:
+		return nil, ErrInvalidAlgorithm
+	}
+	return jwk, nil
+}
+
+// PublicKey decodes the key
+func (j *JWK) PublicKey() (crypto.PublicKey, error) {
+	switch j.KeyType {
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil || j.Curve != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, ErrInvalidAlgorithm
+		}
+		return ed25519.PublicKey(x), nil
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	}
+	return nil, ErrInvalidAlgorithm
+}
+
+// JWKSVerifier validates access tokens against the keys another server
+// publishes. It can't see the revocation denylist, so revoked tokens stay
+// valid here until they expire.
+type JWKSVerifier struct {
+	url       string
+	client    *http.Client
+	mu        sync.Mutex
+	keys      map[string]*JWK
+	lastFetch time.Time
+}
+
+// NewJWKSVerifier creates a verifier fetching keys from a JWKS URL
+func NewJWKSVerifier(url string) *JWKSVerifier {
+	return &JWKSVerifier{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		keys:   make(map[string]*JWK),
+	}
+}
+
+// UseTLS sets the TLS configuration for fetching keys from an HTTPS URL, such
+// as one trusting the HTTP server's development certificate
+func (v *JWKSVerifier) UseTLS(config *tls.Config) {
+	v.client.Transport = &http.Transport{TLSClientConfig: config}
+}
+
+// PublicKey returns the public key of a key ID, fetching the key set again
+// when the ID is unknown. Other lookups aren't held up by the fetch.
+func (v *JWKSVerifier) PublicKey(kid string) (crypto.PublicKey, string, error) {
+	v.mu.Lock()
+	jwk, ok := v.keys[kid]
+	refetch := !ok && time.Since(v.lastFetch) >= jwksRefetchInterval
+	if refetch {
+		v.lastFetch = time.Now()
+	}
+	v.mu.Unlock()
+
+	if refetch {
+		keys, err := v.fetch()
+		if err != nil {
+			return nil, "", err
+		}
+		v.mu.Lock()
+		v.keys = keys
+		v.mu.Unlock()
+		jwk, ok = keys[kid]
+	}
+	if !ok {
+		return nil, "", ErrUnknownKey
+	}
+
+	public, err := jwk.PublicKey()
+	if err != nil {
+		return nil, "", err
+	}
+	return public, jwk.Algorithm, nil
+}
+
+// Validate checks an access token's signature and expiry. Personal access
+// tokens can only be checked by the server holding the database.
+func (v *JWKSVerifier) Validate(tokenString string) (*Claims, error) {
+	if strings.HasPrefix(tokenString, PersonalTokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+	return ParseAccessToken(tokenString, v)
+}
+
+// fetch downloads the key set
+func (v *JWKSVerifier) fetch() (map[string]*JWK, error) {
+	resp, err := v.client.Get(v.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var set JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+	if len(set.Keys) == 0 {
+		return nil, errors.New("JWKS has no keys")
+	}
+
+	keys := make(map[string]*JWK, len(set.Keys))
+	for i := range set.Keys {
+		keys[set.Keys[i].KeyID] = &set.Keys[i]
+	}
+	return keys, nil
+}
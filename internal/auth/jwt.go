@@ -5,7 +5,6 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var (
@@ -17,34 +16,42 @@ var (
 type Claims struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
+	// SessionID identifies the login the token was issued for
+	SessionID string `json:"sid,omitempty"`
+	// Set only for personal access tokens, which are never encoded as JWTs
+	PersonalTokenID string   `json:"-"`
+	Scopes          []string `json:"-"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken generates a new JWT token for a user
-func GenerateToken(userID, username, secret string) (string, error) {
-	claims := Claims{
-		UserID:   userID,
-		Username: username,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+// Verifier validates access tokens
+type Verifier interface {
+	Validate(tokenString string) (*Claims, error)
 }
 
-// ValidateToken validates a JWT token and returns the claims
-func ValidateToken(tokenString, secret string) (*Claims, error) {
+// ParseAccessToken checks a JWT's signature against the key named in its
+// header and returns the claims
+func ParseAccessToken(tokenString string, keys KeyLookup) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, ErrInvalidToken
+		}
+		public, algorithm, err := keys.PublicKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		// The key decides the algorithm, never the token
+		if token.Method.Alg() != algorithm {
 			return nil, ErrInvalidToken
 		}
-		return []byte(secret), nil
+		return public, nil
 	})
 
 	if err != nil {
+		if ve, ok := err.(*jwt.ValidationError); ok && ve.Errors&jwt.ValidationErrorExpired != 0 {
+			return nil, ErrExpiredToken
+		}
 		return nil, err
 	}
 
@@ -53,21 +60,9 @@ func ValidateToken(tokenString, secret string) (*Claims, error) {
 		return nil, ErrInvalidToken
 	}
 
-	if claims.ExpiresAt.Before(time.Now()) {
+	if claims.ExpiresAt == nil || claims.ExpiresAt.Before(time.Now()) {
 		return nil, ErrExpiredToken
 	}
 
 	return claims, nil
 }
-
-// HashPassword hashes a password using bcrypt
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
-}
-
-// CheckPassword checks if a password matches the hash
-func CheckPassword(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
-}
\ No newline at end of file
@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// Supported signing algorithms
+const (
+	AlgEdDSA = "EdDSA"
+	AlgRS256 = "RS256"
+)
+
+const (
+	// DefaultKeyRotation is how long a key signs tokens before a new one takes over
+	DefaultKeyRotation = 30 * 24 * time.Hour
+
+	// keyRetention is how long a retired key stays published. It must outlast
+	// every access token the key signed.
+	keyRetention = AccessTokenTTL + time.Hour
+
+	// keyReloadInterval limits how often an unknown kid sends PublicKey back
+	// to the store, so tokens with made-up kids can't cause a read each
+	keyReloadInterval = 10 * time.Second
+
+	rsaKeyBits = 2048
+)
+
+var (
+	ErrUnknownKey       = errors.New("unknown signing key")
+	ErrInvalidAlgorithm = errors.New("unsupported signing algorithm")
+)
+
+// KeyLookup finds the public key and algorithm of a key ID
+type KeyLookup interface {
+	PublicKey(kid string) (crypto.PublicKey, string, error)
+}
+
+// signingKey is a key pair identified by its kid
+type signingKey struct {
+	id        string
+	algorithm string
+	private   crypto.Signer
+	createdAt time.Time
+	retiredAt *time.Time
+}
+
+// KeySet holds the keys access tokens are signed with. The newest key signs;
+// older keys keep verifying until every token they signed has expired.
+type KeySet struct {
+	store      *TokenStore
+	algorithm  string
+	rotation   time.Duration
+	mu         sync.RWMutex
+	keys       map[string]*signingKey
+	active     *signingKey
+	lastReload time.Time
+}
+
+// NewKeySet loads the signing keys from the store, creating a new one if
+// there is no current key for the algorithm or it is due for rotation
+func NewKeySet(store *TokenStore, algorithm string, rotation time.Duration) (*KeySet, error) {
+	if algorithm != AlgEdDSA && algorithm != AlgRS256 {
+		return nil, ErrInvalidAlgorithm
+	}
+
+	k := &KeySet{
+		store:     store,
+		algorithm: algorithm,
+		rotation:  rotation,
+	}
+	if err := k.reload(); err != nil {
+		return nil, err
+	}
+
+	k.mu.RLock()
+	current := k.active != nil && k.active.algorithm == algorithm && time.Since(k.active.createdAt) < rotation
+	k.mu.RUnlock()
+	if !current {
+		if err := k.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// Rotate creates a new signing key and retires the others
+func (k *KeySet) Rotate() error {
+	private, err := generateKey(k.algorithm)
+	if err != nil {
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return fmt.Errorf("failed to encode signing key: %w", err)
+	}
+	encoded := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	kid := uuid.New().String()
+	now := time.Now()
+	if err := k.store.CreateSigningKey(kid, k.algorithm, string(encoded), now); err != nil {
+		return err
+	}
+	if err := k.store.RetireSigningKeys(kid, now); err != nil {
+		return err
+	}
+
+	log.Printf("Rotated JWT signing key, new kid %s (%s)", kid, k.algorithm)
+	return k.reload()
+}
+
+// RunRotation periodically replaces the signing key once it is older than the
+// rotation period and drops retired keys no token can still use
+func (k *KeySet) RunRotation(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := k.rotateIfDue(time.Now()); err != nil {
+			log.Printf("Error rotating signing keys: %v", err)
+		}
+	}
+}
+
+func (k *KeySet) rotateIfDue(now time.Time) error {
+	// Another server sharing the database may have rotated already
+	if err := k.reload(); err != nil {
+		return err
+	}
+
+	k.mu.RLock()
+	due := k.active == nil || now.Sub(k.active.createdAt) >= k.rotation
+	k.mu.RUnlock()
+	if due {
+		if err := k.Rotate(); err != nil {
+			return err
+		}
+	}
+
+	if err := k.store.DeleteRetiredSigningKeys(now.Add(-keyRetention)); err != nil {
+		return err
+	}
+	return k.reload()
+}
+
+// PublicKey returns the public key of a key ID. Unknown IDs are looked up in
+// the store again in case another server rotated, at most once per
+// keyReloadInterval.
+func (k *KeySet) PublicKey(kid string) (crypto.PublicKey, string, error) {
+	k.mu.Lock()
+	key, ok := k.keys[kid]
+	reload := !ok && time.Since(k.lastReload) >= keyReloadInterval
+	if reload {
+		k.lastReload = time.Now()
+	}
+	k.mu.Unlock()
+
+	if reload {
+		if err := k.reload(); err != nil {
+			return nil, "", err
+		}
+		k.mu.RLock()
+		key, ok = k.keys[kid]
+		k.mu.RUnlock()
+	}
+	if !ok {
+		return nil, "", ErrUnknownKey
+	}
+	return key.private.Public(), key.algorithm, nil
+}
+
+// JWKS returns the public keys in JSON Web Key Set form
+func (k *KeySet) JWKS() *JWKS {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	set := &JWKS{Keys: make([]JWK, 0, len(k.keys))}
+	for _, key := range k.keys {
+		jwk, err := newJWK(key.id, key.algorithm, key.private.Public())
+		if err != nil {
+			log.Printf("Skipping signing key %s in JWKS: %v", key.id, err)
+			continue
+		}
+		set.Keys = append(set.Keys, *jwk)
+	}
+	return set
+}
+
+// sign signs claims with the active key
+func (k *KeySet) sign(claims jwt.Claims) (string, error) {
+	k.mu.RLock()
+	key := k.active
+	k.mu.RUnlock()
+	if key == nil {
+		return "", ErrUnknownKey
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.algorithm), claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.private)
+}
+
+// reload replaces the in-memory keys with the stored ones
+func (k *KeySet) reload() error {
+	stored, err := k.store.listSigningKeys()
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*signingKey, len(stored))
+	var active *signingKey
+	for _, s := range stored {
+		private, err := parsePrivateKey(s.privateKeyPEM)
+		if err != nil {
+			log.Printf("Skipping signing key %s: %v", s.kid, err)
+			continue
+		}
+		key := &signingKey{
+			id:        s.kid,
+			algorithm: s.algorithm,
+			private:   private,
+			createdAt: s.createdAt,
+			retiredAt: s.retiredAt,
+		}
+		keys[key.id] = key
+		// Keys are listed newest first
+		if active == nil && key.retiredAt == nil {
+			active = key
+		}
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.active = active
+	k.lastReload = time.Now()
+	k.mu.Unlock()
+	return nil
+}
+
+func generateKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case AlgEdDSA:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		return private, err
+	case AlgRS256:
+		return rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	}
+	return nil, ErrInvalidAlgorithm
+}
+
+func parsePrivateKey(encoded string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil {
+		return nil, errors.New("invalid PEM block")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrInvalidAlgorithm
+	}
+	return signer, nil
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestRotationKeepsOldTokensValid(t *testing.T) {
+	m := setupTestManager(t)
+
+	pair, err := m.Issue("test-user-1", "tester", Device{})
+	if err != nil {
+		t.Fatalf("Failed to issue tokens: %v", err)
+	}
+
+	if err := m.Keys().Rotate(); err != nil {
+		t.Fatalf("Failed to rotate keys: %v", err)
+	}
+	if _, err := m.Validate(pair.AccessToken); err != nil {
+		t.Errorf("Expected a token signed before rotation to stay valid, got %v", err)
+	}
+
+	newPair, err := m.Refresh(pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+	oldKid := headerKid(t, pair.AccessToken)
+	if kid := headerKid(t, newPair.AccessToken); kid == oldKid {
+		t.Error("Expected new tokens to be signed with the new key")
+	}
+	if len(m.Keys().JWKS().Keys) != 2 {
+		t.Errorf("Expected both keys to be published, got %d", len(m.Keys().JWKS().Keys))
+	}
+
+	// Once every token the old key signed has expired, the key is dropped
+	if err := m.Keys().rotateIfDue(time.Now().Add(keyRetention + time.Minute)); err != nil {
+		t.Fatalf("Failed to clean up keys: %v", err)
+	}
+	if _, _, err := m.Keys().PublicKey(oldKid); err != ErrUnknownKey {
+		t.Errorf("Expected the retired key to be deleted, got %v", err)
+	}
+}
+
+func TestRejectsOtherAlgorithms(t *testing.T) {
+	m := setupTestManager(t)
+
+	pair, _ := m.Issue("test-user-1", "tester", Device{})
+	kid := headerKid(t, pair.AccessToken)
+
+	// A token claiming HS256 with the public key as secret must not verify
+	public, _, _ := m.Keys().PublicKey(kid)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		UserID: "test-user-1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+	token.Header["kid"] = kid
+	forged, err := token.SignedString([]byte(public.(ed25519.PublicKey)))
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	if _, err := m.Validate(forged); err == nil {
+		t.Error("Expected a token with a different algorithm to be rejected")
+	}
+}
+
+func TestUnknownKidReloadIsThrottled(t *testing.T) {
+	m := setupTestManager(t)
+
+	// Another server sharing the database rotates
+	other, err := NewKeySet(m.store, AlgEdDSA, DefaultKeyRotation)
+	if err != nil {
+		t.Fatalf("Failed to create keys: %v", err)
+	}
+	if err := other.Rotate(); err != nil {
+		t.Fatalf("Failed to rotate keys: %v", err)
+	}
+	kid := other.active.id
+
+	// Keys were loaded moments ago, so the store isn't asked again yet
+	if _, _, err := m.Keys().PublicKey(kid); err != ErrUnknownKey {
+		t.Errorf("Expected ErrUnknownKey within the reload interval, got %v", err)
+	}
+
+	m.Keys().lastReload = time.Now().Add(-keyReloadInterval)
+	if _, _, err := m.Keys().PublicKey(kid); err != nil {
+		t.Errorf("Expected the rotated key to be found after the interval, got %v", err)
+	}
+}
+
+func TestJWKSVerifier(t *testing.T) {
+	for _, algorithm := range []string{AlgEdDSA, AlgRS256} {
+		t.Run(algorithm, func(t *testing.T) {
+			m := setupTestManager(t)
+			keys, err := NewKeySet(m.store, algorithm, DefaultKeyRotation)
+			if err != nil {
+				t.Fatalf("Failed to create keys: %v", err)
+			}
+			m.keys = keys
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(keys.JWKS())
+			}))
+			defer server.Close()
+
+			pair, err := m.Issue("test-user-1", "tester", Device{})
+			if err != nil {
+				t.Fatalf("Failed to issue tokens: %v", err)
+			}
+
+			verifier := NewJWKSVerifier(server.URL)
+			claims, err := verifier.Validate(pair.AccessToken)
+			if err != nil {
+				t.Fatalf("Failed to verify with JWKS: %v", err)
+			}
+			if claims.UserID != "test-user-1" {
+				t.Errorf("Unexpected claims: %+v", claims)
+			}
+
+			if _, err := verifier.Validate(PersonalTokenPrefix + "abc"); err != ErrInvalidToken {
+				t.Errorf("Expected personal access tokens to be rejected, got %v", err)
+			}
+		})
+	}
+}
+
+func headerKid(t *testing.T, tokenString string) string {
+	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, &Claims{})
+	if err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+	kid, _ := token.Header["kid"].(string)
+	return kid
+}
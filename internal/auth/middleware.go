@@ -8,7 +8,7 @@ import (
 )
 
 // JWTMiddleware creates a middleware for JWT authentication
-func JWTMiddleware(secret string) gin.HandlerFunc {
+func JWTMiddleware(tokens *TokenManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -32,7 +32,7 @@ func JWTMiddleware(secret string) gin.HandlerFunc {
 		}
 
 		token := parts[1]
-		claims, err := ValidateToken(token, secret)
+		claims, err := tokens.Validate(token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success": false,
@@ -45,10 +45,54 @@ func JWTMiddleware(secret string) gin.HandlerFunc {
 		// Set user info in context
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("claims", claims)
 		c.Next()
 	}
 }
 
+// RequireScope restricts a route to login tokens and personal access tokens
+// granted the scope. It must run after JWTMiddleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := GetClaims(c)
+		if claims == nil || !claims.HasScope(scope) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "token is missing the " + scope + " scope",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession restricts routes to tokens from an interactive login, so a
+// personal access token can't be used to manage the account or mint more tokens
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := GetClaims(c)
+		if claims == nil || claims.IsPersonalToken() {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "personal access tokens cannot be used for this route",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// TokenFromRequest extracts a bearer token from the Authorization header or,
+// for WebSocket handshakes from browsers that can't set headers, the token query parameter
+func TokenFromRequest(r *http.Request) string {
+	if token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); token != "" {
+		return token
+	}
+	return r.URL.Query().Get("token")
+}
+
 // GetUserID retrieves user ID from context
 func GetUserID(c *gin.Context) string {
 	userID, exists := c.Get("user_id")
@@ -65,4 +109,13 @@ func GetUsername(c *gin.Context) string {
 		return ""
 	}
 	return username.(string)
+}
+
+// GetClaims retrieves the validated token claims from context
+func GetClaims(c *gin.Context) *Claims {
+	claims, exists := c.Get("claims")
+	if !exists {
+		return nil
+	}
+	return claims.(*Claims)
 }
\ No newline at end of file
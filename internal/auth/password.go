@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// MaxPasswordLength bounds the work a single login can cause. argon2id has
+	// no length limit of its own.
+	MaxPasswordLength = 1024
+
+	// bcryptMaxLength is the number of bytes bcrypt looks at; it ignores the rest
+	bcryptMaxLength = 72
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+var (
+	ErrPasswordTooLong     = fmt.Errorf("password is longer than %d bytes", MaxPasswordLength)
+	ErrInvalidPasswordHash = errors.New("invalid password hash")
+	ErrInvalidHashParams   = errors.New("invalid password hash parameters")
+)
+
+// PasswordParams are the argon2id cost parameters new hashes are made with
+type PasswordParams struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// DefaultPasswordParams takes around 150ms per hash on a small server (see
+// BenchmarkHashPassword), slow for guessing but not for logging in. The memory
+// cost follows the OWASP recommendation for argon2id.
+var DefaultPasswordParams = PasswordParams{Memory: 64 * 1024, Iterations: 2, Parallelism: 2}
+
+// String writes the parameters as they appear in a hash, such as "m=65536,t=2,p=2"
+func (p PasswordParams) String() string {
+	return fmt.Sprintf("m=%d,t=%d,p=%d", p.Memory, p.Iterations, p.Parallelism)
+}
+
+// weakerThan reports whether hashes made with p cost less in any parameter
+// than ones made with q
+func (p PasswordParams) weakerThan(q PasswordParams) bool {
+	return p.Memory < q.Memory || p.Iterations < q.Iterations || p.Parallelism < q.Parallelism
+}
+
+// ParsePasswordParams parses parameters written as "m=65536,t=2,p=2". Missing
+// ones keep their default.
+func ParsePasswordParams(s string) (PasswordParams, error) {
+	params := DefaultPasswordParams
+	for _, item := range strings.Split(s, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		n, err := strconv.ParseUint(value, 10, 32)
+		if !ok || err != nil || n == 0 {
+			return PasswordParams{}, fmt.Errorf("%w: %q", ErrInvalidHashParams, item)
+		}
+		switch key {
+		case "m":
+			params.Memory = uint32(n)
+		case "t":
+			params.Iterations = uint32(n)
+		case "p":
+			if n > 255 {
+				return PasswordParams{}, fmt.Errorf("%w: %q", ErrInvalidHashParams, item)
+			}
+			params.Parallelism = uint8(n)
+		default:
+			return PasswordParams{}, fmt.Errorf("%w: %q", ErrInvalidHashParams, item)
+		}
+	}
+	if params.Memory < 8*uint32(params.Parallelism) {
+		return PasswordParams{}, fmt.Errorf("%w: memory must be at least 8 KiB per thread", ErrInvalidHashParams)
+	}
+	return params, nil
+}
+
+// CalibratePasswordParams raises the iterations of base until hashing takes
+// at least target on this machine
+func CalibratePasswordParams(base PasswordParams, target time.Duration) PasswordParams {
+	params := base
+	salt := make([]byte, argon2SaltLength)
+	for params.Iterations < 64 {
+		start := time.Now()
+		argon2.IDKey([]byte("calibration"), salt, params.Iterations, params.Memory, params.Parallelism, argon2KeyLength)
+		if time.Since(start) >= target {
+			break
+		}
+		params.Iterations++
+	}
+	return params
+}
+
+// PasswordHasher hashes passwords with argon2id, stored in the PHC string
+// format: $argon2id$v=19$m=65536,t=2,p=2$<salt>$<hash>. Hashes made with
+// weaker parameters, or with bcrypt before argon2id was introduced, still
+// verify and are reported as needing a rehash. Stronger ones are kept, so
+// parameters that differ slightly between calibrations don't rewrite hashes.
+type PasswordHasher struct {
+	params PasswordParams
+}
+
+// NewPasswordHasher creates a hasher making new hashes with params
+func NewPasswordHasher(params PasswordParams) *PasswordHasher {
+	return &PasswordHasher{params: params}
+}
+
+// Hash hashes a password with a random salt
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	if len(password) > MaxPasswordLength {
+		return "", ErrPasswordTooLong
+	}
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, argon2KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$%s$%s$%s", argon2.Version, h.params,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Check reports whether the password matches the hash, and whether the hash
+// should be replaced by one made with the current, stronger parameters
+func (h *PasswordHasher) Check(password, hash string) (ok, needsRehash bool) {
+	if len(password) > MaxPasswordLength {
+		return false, false
+	}
+
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := decodeArgon2Hash(hash)
+		if err != nil {
+			return false, false
+		}
+		other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(key, other) != 1 {
+			return false, false
+		}
+		return true, params.weakerThan(h.params)
+	}
+
+	// bcrypt only looks at the first 72 bytes, so a longer password would
+	// match the hash of its prefix. No bcrypt hash was ever made from one.
+	if len(password) > bcryptMaxLength {
+		return false, false
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return false, false
+	}
+	return true, true
+}
+
+func decodeArgon2Hash(hash string) (PasswordParams, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return PasswordParams{}, nil, nil, ErrInvalidPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return PasswordParams{}, nil, nil, ErrInvalidPasswordHash
+	}
+
+	var params PasswordParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return PasswordParams{}, nil, nil, ErrInvalidPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return PasswordParams{}, nil, nil, ErrInvalidPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return PasswordParams{}, nil, nil, ErrInvalidPasswordHash
+	}
+	return params, salt, key, nil
+}
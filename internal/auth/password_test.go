@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testParams keeps tests fast; the defaults are measured by the benchmark
+var testParams = PasswordParams{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func TestHashPassword(t *testing.T) {
+	h := NewPasswordHasher(testParams)
+
+	hash, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Failed to hash: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("Unexpected hash format: %s", hash)
+	}
+
+	if ok, rehash := h.Check("correct horse", hash); !ok || rehash {
+		t.Errorf("Expected a current match, got ok=%v rehash=%v", ok, rehash)
+	}
+	if ok, _ := h.Check("wrong horse", hash); ok {
+		t.Error("Expected a wrong password to fail")
+	}
+
+	// Stronger parameters make existing hashes outdated
+	stronger := NewPasswordHasher(PasswordParams{Memory: 2048, Iterations: 1, Parallelism: 1})
+	if ok, rehash := stronger.Check("correct horse", hash); !ok || !rehash {
+		t.Errorf("Expected a match needing a rehash, got ok=%v rehash=%v", ok, rehash)
+	}
+
+	// ...but weaker ones, such as a calibration landing lower, keep them
+	strongHash, _ := NewPasswordHasher(PasswordParams{Memory: 1024, Iterations: 2, Parallelism: 1}).Hash("correct horse")
+	if ok, rehash := h.Check("correct horse", strongHash); !ok || rehash {
+		t.Errorf("Expected a stronger hash to be kept, got ok=%v rehash=%v", ok, rehash)
+	}
+}
+
+func TestLongPasswords(t *testing.T) {
+	h := NewPasswordHasher(testParams)
+
+	// Passwords that differ only after bcrypt's 72 bytes must not match
+	long := strings.Repeat("a", 80)
+	hash, err := h.Hash(long + "1")
+	if err != nil {
+		t.Fatalf("Failed to hash a long password: %v", err)
+	}
+	if ok, _ := h.Check(long+"1", hash); !ok {
+		t.Error("Expected the long password to match")
+	}
+	if ok, _ := h.Check(long+"2", hash); ok {
+		t.Error("Expected a password differing after 72 bytes to fail")
+	}
+
+	if _, err := h.Hash(strings.Repeat("a", MaxPasswordLength+1)); err != ErrPasswordTooLong {
+		t.Errorf("Expected ErrPasswordTooLong, got %v", err)
+	}
+}
+
+func TestLegacyBcryptHashes(t *testing.T) {
+	h := NewPasswordHasher(testParams)
+
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	if ok, rehash := h.Check("old-password", string(legacy)); !ok || !rehash {
+		t.Errorf("Expected bcrypt hash to match and need a rehash, got ok=%v rehash=%v", ok, rehash)
+	}
+
+	// bcrypt would accept anything sharing the first 72 bytes
+	prefix := strings.Repeat("b", 72)
+	legacy, _ = bcrypt.GenerateFromPassword([]byte(prefix), bcrypt.MinCost)
+	if ok, _ := h.Check(prefix+"extra", string(legacy)); ok {
+		t.Error("Expected a password longer than 72 bytes not to match a bcrypt hash")
+	}
+}
+
+func TestParsePasswordParams(t *testing.T) {
+	params, err := ParsePasswordParams("m=32768, t=4")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	want := PasswordParams{Memory: 32768, Iterations: 4, Parallelism: DefaultPasswordParams.Parallelism}
+	if params != want {
+		t.Errorf("Expected %v, got %v", want, params)
+	}
+
+	if params, _ := ParsePasswordParams(""); params != DefaultPasswordParams {
+		t.Errorf("Expected defaults, got %v", params)
+	}
+
+	for _, bad := range []string{"m=0", "x=1", "t=abc", "p=300", "m=8,p=2"} {
+		if _, err := ParsePasswordParams(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+// BenchmarkHashPassword measures DefaultPasswordParams. Aim for roughly
+// 100-250ms per op on production hardware and adjust PASSWORD_HASH_PARAMS,
+// or set PASSWORD_HASH_TARGET, if it is far off.
+func BenchmarkHashPassword(b *testing.B) {
+	h := NewPasswordHasher(DefaultPasswordParams)
+	for i := 0; i < b.N; i++ {
+		if _, err := h.Hash("correct horse battery staple"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
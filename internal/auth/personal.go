@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"mangahub/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// Scopes a personal access token can be granted
+const (
+	ScopeReadCatalog  = "read:catalog"
+	ScopeWriteLibrary = "write:library"
+	ScopeAdminNotify  = "admin:notify"
+)
+
+// PersonalTokenPrefix marks personal access tokens so they are never parsed as JWTs
+const PersonalTokenPrefix = "mhp_"
+
+var Scopes = []string{ScopeReadCatalog, ScopeWriteLibrary, ScopeAdminNotify}
+
+var ErrInvalidScope = errors.New("invalid scope")
+
+// lastUsedInterval limits how often a token's last-used time is written
+const lastUsedInterval = time.Minute
+
+// CreatePersonalToken creates a personal access token. The returned raw
+// token is not stored and can't be retrieved again.
+func (m *TokenManager) CreatePersonalToken(userID string, req *models.CreateTokenRequest) (string, *models.PersonalAccessToken, error) {
+	for _, scope := range req.Scopes {
+		if !validScope(scope) {
+			return "", nil, ErrInvalidScope
+		}
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return "", nil, err
+	}
+	raw := PersonalTokenPrefix + secret
+
+	now := time.Now()
+	token := &models.PersonalAccessToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		CreatedAt: now,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := now.AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := m.store.CreatePersonalToken(token, hashToken(raw)); err != nil {
+		return "", nil, err
+	}
+	return raw, token, nil
+}
+
+// ListPersonalTokens returns a user's personal access tokens
+func (m *TokenManager) ListPersonalTokens(userID string) ([]*models.PersonalAccessToken, error) {
+	return m.store.ListPersonalTokens(userID)
+}
+
+// RevokePersonalToken deletes one of a user's personal access tokens
+func (m *TokenManager) RevokePersonalToken(userID, id string) error {
+	return m.store.DeletePersonalToken(userID, id)
+}
+
+// validatePersonal resolves a personal access token to claims carrying its scopes
+func (m *TokenManager) validatePersonal(raw string) (*Claims, error) {
+	token, username, err := m.store.GetPersonalToken(hashToken(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if token.ExpiresAt != nil && token.ExpiresAt.Before(now) {
+		return nil, ErrExpiredToken
+	}
+	if err := m.store.TouchPersonalToken(token.ID, now, lastUsedInterval); err != nil {
+		log.Printf("Error updating token last used time: %v", err)
+	}
+
+	return &Claims{
+		UserID:          token.UserID,
+		Username:        username,
+		PersonalTokenID: token.ID,
+		Scopes:          token.Scopes,
+	}, nil
+}
+
+// IsPersonalToken reports whether the claims come from a personal access token
+func (c *Claims) IsPersonalToken() bool {
+	return c.PersonalTokenID != ""
+}
+
+// HasScope reports whether the token may be used for a scope. Login tokens
+// carry the user's full access.
+func (c *Claims) HasScope(scope string) bool {
+	if !c.IsPersonalToken() {
+		return true
+	}
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func validScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
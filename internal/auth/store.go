@@ -0,0 +1,407 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"mangahub/pkg/models"
+)
+
+var (
+	ErrTokenNotFound   = errors.New("token not found")
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// RefreshToken represents a stored refresh token. Tokens issued from the same
+// login share a family so a replayed token can revoke the whole chain.
+type RefreshToken struct {
+	TokenHash string
+	UserID    string
+	Username  string
+	FamilyID  string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// TokenStore persists refresh tokens and revoked access token IDs
+type TokenStore struct {
+	db *sql.DB
+}
+
+func NewTokenStore(db *sql.DB) *TokenStore {
+	return &TokenStore{db: db}
+}
+
+// CreateRefreshToken stores a new refresh token
+func (s *TokenStore) CreateRefreshToken(token *RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (token_hash, user_id, username, family_id, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err := s.db.Exec(query, token.TokenHash, token.UserID, token.Username, token.FamilyID,
+		token.ExpiresAt.UTC(), token.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return nil
+}
+
+// GetRefreshToken retrieves a refresh token by its hash
+func (s *TokenStore) GetRefreshToken(tokenHash string) (*RefreshToken, error) {
+	token := &RefreshToken{}
+	query := `
+		SELECT token_hash, user_id, username, family_id, expires_at, used_at, revoked_at, created_at
+		FROM refresh_tokens
+		WHERE token_hash = ?
+	`
+	err := s.db.QueryRow(query, tokenHash).Scan(
+		&token.TokenHash,
+		&token.UserID,
+		&token.Username,
+		&token.FamilyID,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.RevokedAt,
+		&token.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	return token, nil
+}
+
+// MarkRefreshTokenUsed marks a token as rotated, reporting false if it was
+// already used or revoked, so concurrent refreshes can't both succeed
+func (s *TokenStore) MarkRefreshTokenUsed(tokenHash string, at time.Time) (bool, error) {
+	result, err := s.db.Exec(`
+		UPDATE refresh_tokens SET used_at = ?
+		WHERE token_hash = ? AND used_at IS NULL AND revoked_at IS NULL
+	`, at.UTC(), tokenHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark refresh token used: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// RevokeFamily revokes every refresh token issued from the same login
+func (s *TokenStore) RevokeFamily(familyID string, at time.Time) error {
+	_, err := s.db.Exec(`
+		UPDATE refresh_tokens SET revoked_at = ?
+		WHERE family_id = ? AND revoked_at IS NULL
+	`, at.UTC(), familyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// IsFamilyRevoked reports whether the login a token family belongs to was revoked
+func (s *TokenStore) IsFamilyRevoked(familyID string) (bool, error) {
+	var count int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM refresh_tokens WHERE family_id = ? AND revoked_at IS NOT NULL
+	`, familyID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check refresh tokens: %w", err)
+	}
+	return count > 0, nil
+}
+
+// RevokeAccessToken adds an access token ID to the denylist until it expires
+func (s *TokenStore) RevokeAccessToken(jti, userID string, expiresAt time.Time) error {
+	_, err := s.db.Exec(`
+		INSERT OR IGNORE INTO revoked_tokens (jti, user_id, expires_at) VALUES (?, ?, ?)
+	`, jti, userID, expiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+	return nil
+}
+
+// IsAccessTokenRevoked reports whether an access token ID is on the denylist
+func (s *TokenStore) IsAccessTokenRevoked(jti string) (bool, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM revoked_tokens WHERE jti = ?`, jti).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check revoked tokens: %w", err)
+	}
+	return count > 0, nil
+}
+
+// DeleteExpired removes refresh tokens, denylist entries and logins that have expired
+func (s *TokenStore) DeleteExpired(now time.Time) error {
+	if _, err := s.db.Exec(`DELETE FROM refresh_tokens WHERE expires_at < ?`, now.UTC()); err != nil {
+		return fmt.Errorf("failed to delete expired refresh tokens: %w", err)
+	}
+	if _, err := s.db.Exec(`DELETE FROM revoked_tokens WHERE expires_at < ?`, now.UTC()); err != nil {
+		return fmt.Errorf("failed to delete expired revoked tokens: %w", err)
+	}
+	// Logins whose refresh tokens have all expired can't be resumed
+	if _, err := s.db.Exec(`
+		DELETE FROM login_sessions WHERE id NOT IN (SELECT family_id FROM refresh_tokens)
+	`); err != nil {
+		return fmt.Errorf("failed to delete expired login sessions: %w", err)
+	}
+	return nil
+}
+
+// CreateLoginSession records a new login on a device
+func (s *TokenStore) CreateLoginSession(session *models.LoginSession) error {
+	query := `
+		INSERT INTO login_sessions (id, user_id, device_name, user_agent, ip, created_at, last_seen_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := s.db.Exec(query, session.ID, session.UserID, session.DeviceName, session.UserAgent, session.IP,
+		session.CreatedAt.UTC(), session.LastSeenAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create login session: %w", err)
+	}
+	return nil
+}
+
+// ListLoginSessions retrieves a user's active logins seen since the given time, most recent first
+func (s *TokenStore) ListLoginSessions(userID string, since time.Time) ([]*models.LoginSession, error) {
+	query := `
+		SELECT id, user_id, device_name, user_agent, ip, created_at, last_seen_at
+		FROM login_sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND last_seen_at >= ?
+		ORDER BY last_seen_at DESC
+	`
+	rows, err := s.db.Query(query, userID, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list login sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []*models.LoginSession{}
+	for rows.Next() {
+		session := &models.LoginSession{}
+		err := rows.Scan(&session.ID, &session.UserID, &session.DeviceName, &session.UserAgent, &session.IP,
+			&session.CreatedAt, &session.LastSeenAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan login session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeLoginSession marks one of a user's logins as revoked
+func (s *TokenStore) RevokeLoginSession(userID, id string, at time.Time) error {
+	result, err := s.db.Exec(`
+		UPDATE login_sessions SET revoked_at = ?
+		WHERE id = ? AND user_id = ? AND revoked_at IS NULL
+	`, at.UTC(), id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke login session: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// TouchLoginSession records when a login was last seen, writing at most once per interval
+func (s *TokenStore) TouchLoginSession(id string, at time.Time, interval time.Duration) error {
+	_, err := s.db.Exec(`
+		UPDATE login_sessions SET last_seen_at = ?
+		WHERE id = ? AND last_seen_at < ?
+	`, at.UTC(), id, at.Add(-interval).UTC())
+	if err != nil {
+		return fmt.Errorf("failed to update login session: %w", err)
+	}
+	return nil
+}
+
+// CreatePersonalToken stores a new personal access token
+func (s *TokenStore) CreatePersonalToken(token *models.PersonalAccessToken, tokenHash string) error {
+	var expiresAt interface{}
+	if token.ExpiresAt != nil {
+		expiresAt = token.ExpiresAt.UTC()
+	}
+	query := `
+		INSERT INTO personal_access_tokens (id, user_id, name, token_hash, scopes, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := s.db.Exec(query, token.ID, token.UserID, token.Name, tokenHash,
+		strings.Join(token.Scopes, ","), expiresAt, token.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create personal access token: %w", err)
+	}
+	return nil
+}
+
+// GetPersonalToken retrieves a personal access token and its owner's username by hash
+func (s *TokenStore) GetPersonalToken(tokenHash string) (*models.PersonalAccessToken, string, error) {
+	query := `
+		SELECT t.id, t.user_id, t.name, t.scopes, t.expires_at, t.last_used_at, t.created_at, u.username
+		FROM personal_access_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = ?
+	`
+	var username string
+	token, err := scanPersonalToken(s.db.QueryRow(query, tokenHash), &username)
+	if err == sql.ErrNoRows {
+		return nil, "", ErrInvalidToken
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get personal access token: %w", err)
+	}
+	return token, username, nil
+}
+
+// ListPersonalTokens retrieves a user's personal access tokens
+func (s *TokenStore) ListPersonalTokens(userID string) ([]*models.PersonalAccessToken, error) {
+	query := `
+		SELECT id, user_id, name, scopes, expires_at, last_used_at, created_at
+		FROM personal_access_tokens
+		WHERE user_id = ?
+		ORDER BY created_at
+	`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list personal access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []*models.PersonalAccessToken{}
+	for rows.Next() {
+		token, err := scanPersonalToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan personal access token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// DeletePersonalToken revokes one of a user's personal access tokens
+func (s *TokenStore) DeletePersonalToken(userID, id string) error {
+	result, err := s.db.Exec(`DELETE FROM personal_access_tokens WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete personal access token: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+// TouchPersonalToken records when a personal access token was last used,
+// writing at most once per interval so busy scripts don't write on every request
+func (s *TokenStore) TouchPersonalToken(id string, at time.Time, interval time.Duration) error {
+	_, err := s.db.Exec(`
+		UPDATE personal_access_tokens SET last_used_at = ?
+		WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)
+	`, at.UTC(), id, at.Add(-interval).UTC())
+	if err != nil {
+		return fmt.Errorf("failed to update personal access token: %w", err)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPersonalToken(row rowScanner, extra ...interface{}) (*models.PersonalAccessToken, error) {
+	token := &models.PersonalAccessToken{}
+	var scopes string
+	dest := []interface{}{
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&scopes,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.CreatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	token.Scopes = strings.Split(scopes, ",")
+	return token, nil
+}
+
+// CreateSigningKey stores a new JWT signing key
+func (s *TokenStore) CreateSigningKey(kid, algorithm, privateKeyPEM string, createdAt time.Time) error {
+	_, err := s.db.Exec(`
+		INSERT INTO signing_keys (kid, algorithm, private_key, created_at) VALUES (?, ?, ?, ?)
+	`, kid, algorithm, privateKeyPEM, createdAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create signing key: %w", err)
+	}
+	return nil
+}
+
+// storedKey is a signing key as stored in the database
+type storedKey struct {
+	kid           string
+	algorithm     string
+	privateKeyPEM string
+	createdAt     time.Time
+	retiredAt     *time.Time
+}
+
+// listSigningKeys returns every signing key, newest first
+func (s *TokenStore) listSigningKeys() ([]*storedKey, error) {
+	rows, err := s.db.Query(`
+		SELECT kid, algorithm, private_key, created_at, retired_at FROM signing_keys
+		ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signing keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*storedKey
+	for rows.Next() {
+		var key storedKey
+		if err := rows.Scan(&key.kid, &key.algorithm, &key.privateKeyPEM, &key.createdAt, &key.retiredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan signing key: %w", err)
+		}
+		keys = append(keys, &key)
+	}
+	return keys, rows.Err()
+}
+
+// RetireSigningKeys stops every key but one from signing. Retired keys still
+// verify the tokens they signed until DeleteRetiredSigningKeys removes them.
+func (s *TokenStore) RetireSigningKeys(exceptKid string, at time.Time) error {
+	_, err := s.db.Exec(`
+		UPDATE signing_keys SET retired_at = ? WHERE kid != ? AND retired_at IS NULL
+	`, at.UTC(), exceptKid)
+	if err != nil {
+		return fmt.Errorf("failed to retire signing keys: %w", err)
+	}
+	return nil
+}
+
+// DeleteRetiredSigningKeys removes keys retired before a time
+func (s *TokenStore) DeleteRetiredSigningKeys(before time.Time) error {
+	_, err := s.db.Exec(`DELETE FROM signing_keys WHERE retired_at < ?`, before.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to delete signing keys: %w", err)
+	}
+	return nil
+}
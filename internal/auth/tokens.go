@@ -0,0 +1,328 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"mangahub/pkg/models"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrRevokedToken = errors.New("token has been revoked")
+	ErrTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// TokenPair is returned on login and refresh
+type TokenPair struct {
+	AccessToken      string    `json:"token"`
+	RefreshToken     string    `json:"refresh_token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// Device describes where a login happened
+type Device struct {
+	Name      string
+	UserAgent string
+	IP        string
+}
+
+// TokenManager issues short-lived access tokens with rotating refresh tokens
+// and checks access tokens against the revocation denylist
+type TokenManager struct {
+	keys      *KeySet
+	store     *TokenStore
+	listeners []func(sessionID string)
+}
+
+func NewTokenManager(keys *KeySet, store *TokenStore) *TokenManager {
+	return &TokenManager{
+		keys:  keys,
+		store: store,
+	}
+}
+
+// Keys returns the keys access tokens are signed with
+func (m *TokenManager) Keys() *KeySet {
+	return m.keys
+}
+
+// OnSessionRevoked registers a function called with the ID of every login
+// that is revoked, so live connections made with it can be closed
+func (m *TokenManager) OnSessionRevoked(fn func(sessionID string)) {
+	m.listeners = append(m.listeners, fn)
+}
+
+// Issue starts a new login for a user on a device and returns its first token pair
+func (m *TokenManager) Issue(userID, username string, device Device) (*TokenPair, error) {
+	now := time.Now()
+	session := &models.LoginSession{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		DeviceName: device.Name,
+		UserAgent:  device.UserAgent,
+		IP:         device.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	if err := m.store.CreateLoginSession(session); err != nil {
+		return nil, err
+	}
+	return m.issue(userID, username, session.ID)
+}
+
+// Refresh rotates a refresh token. Presenting a token that was already
+// rotated means it leaked, so the whole login is revoked.
+func (m *TokenManager) Refresh(refreshToken string) (*TokenPair, error) {
+	now := time.Now()
+	stored, err := m.store.GetRefreshToken(hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.RevokedAt != nil {
+		return nil, ErrRevokedToken
+	}
+	if stored.UsedAt != nil {
+		log.Printf("Refresh token reuse detected for user %s, revoking login %s", stored.UserID, stored.FamilyID)
+		if err := m.revokeLogin(stored.UserID, stored.FamilyID, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrTokenReused
+	}
+	if stored.ExpiresAt.Before(now) {
+		return nil, ErrExpiredToken
+	}
+
+	ok, err := m.store.MarkRefreshTokenUsed(stored.TokenHash, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// Lost a race with another refresh of the same token
+		if err := m.revokeLogin(stored.UserID, stored.FamilyID, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrTokenReused
+	}
+
+	m.touchSession(stored.FamilyID, now)
+	return m.issue(stored.UserID, stored.Username, stored.FamilyID)
+}
+
+// Validate checks an access token's signature, expiry and revocation, or
+// looks up a personal access token
+func (m *TokenManager) Validate(tokenString string) (*Claims, error) {
+	if strings.HasPrefix(tokenString, PersonalTokenPrefix) {
+		return m.validatePersonal(tokenString)
+	}
+
+	claims, err := ParseAccessToken(tokenString, m.keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.ID != "" {
+		revoked, err := m.store.IsAccessTokenRevoked(claims.ID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, ErrRevokedToken
+		}
+	}
+	if claims.SessionID != "" {
+		revoked, err := m.store.IsFamilyRevoked(claims.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, ErrRevokedToken
+		}
+		m.touchSession(claims.SessionID, time.Now())
+	}
+
+	return claims, nil
+}
+
+// Revoke ends the login an access token belongs to: the token itself is
+// denylisted until it expires and its refresh tokens stop working
+func (m *TokenManager) Revoke(claims *Claims) error {
+	now := time.Now()
+	if claims.ID != "" && claims.ExpiresAt != nil {
+		if err := m.store.RevokeAccessToken(claims.ID, claims.UserID, claims.ExpiresAt.Time); err != nil {
+			return err
+		}
+	}
+	if claims.SessionID != "" {
+		return m.revokeLogin(claims.UserID, claims.SessionID, now)
+	}
+	return nil
+}
+
+// RevokeAllSessions logs a user out everywhere except the given login, which
+// may be empty
+func (m *TokenManager) RevokeAllSessions(userID, exceptSessionID string) error {
+	sessions, err := m.store.ListLoginSessions(userID, time.Time{})
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if session.ID == exceptSessionID {
+			continue
+		}
+		if err := m.RevokeSession(userID, session.ID); err != nil && err != ErrSessionNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListSessions returns the devices a user is logged in on, flagging currentID
+func (m *TokenManager) ListSessions(userID, currentID string) ([]*models.LoginSession, error) {
+	sessions, err := m.store.ListLoginSessions(userID, time.Now().Add(-RefreshTokenTTL))
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		session.Current = session.ID == currentID
+	}
+	return sessions, nil
+}
+
+// RevokeSession logs one of a user's devices out
+func (m *TokenManager) RevokeSession(userID, sessionID string) error {
+	now := time.Now()
+	if err := m.store.RevokeLoginSession(userID, sessionID, now); err != nil {
+		return err
+	}
+	if err := m.store.RevokeFamily(sessionID, now); err != nil {
+		return err
+	}
+	m.notifyRevoked(sessionID)
+	return nil
+}
+
+// RunCleanup periodically removes expired tokens and denylist entries
+func (m *TokenManager) RunCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := m.store.DeleteExpired(time.Now()); err != nil {
+			log.Printf("Error cleaning up tokens: %v", err)
+		}
+	}
+}
+
+func (m *TokenManager) issue(userID, username, familyID string) (*TokenPair, error) {
+	now := time.Now()
+	accessExpires := now.Add(AccessTokenTTL)
+
+	claims := Claims{
+		UserID:    userID,
+		Username:  username,
+		SessionID: familyID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(accessExpires),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	accessToken, err := m.keys.sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshExpires := now.Add(RefreshTokenTTL)
+	err = m.store.CreateRefreshToken(&RefreshToken{
+		TokenHash: hashToken(refreshToken),
+		UserID:    userID,
+		Username:  username,
+		FamilyID:  familyID,
+		ExpiresAt: refreshExpires,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiresAt:        accessExpires,
+		RefreshExpiresAt: refreshExpires,
+	}, nil
+}
+
+// revokeLogin revokes a login's refresh tokens and session record
+func (m *TokenManager) revokeLogin(userID, sessionID string, at time.Time) error {
+	if err := m.store.RevokeFamily(sessionID, at); err != nil {
+		return err
+	}
+	if err := m.store.RevokeLoginSession(userID, sessionID, at); err != nil && err != ErrSessionNotFound {
+		return err
+	}
+	m.notifyRevoked(sessionID)
+	return nil
+}
+
+func (m *TokenManager) notifyRevoked(sessionID string) {
+	for _, fn := range m.listeners {
+		fn(sessionID)
+	}
+}
+
+func (m *TokenManager) touchSession(sessionID string, at time.Time) {
+	if err := m.store.TouchLoginSession(sessionID, at, lastUsedInterval); err != nil {
+		log.Printf("Error updating session last seen time: %v", err)
+	}
+}
+
+// NewOpaqueToken creates a random single-use token such as a password reset
+// token, returning the raw value to hand out and the hash to store
+func NewOpaqueToken() (raw, hash string, err error) {
+	raw, err = randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	return raw, hashToken(raw), nil
+}
+
+// HashOpaqueToken returns the stored hash of a token from NewOpaqueToken
+func HashOpaqueToken(raw string) string {
+	return hashToken(raw)
+}
+
+// randomToken returns 32 random bytes encoded for use in URLs and headers
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken hashes an opaque token for storage; the raw value is never stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
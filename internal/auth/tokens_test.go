@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"testing"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+func setupTestManager(t *testing.T) *TokenManager {
+	db, err := database.InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO users (id, username, email, password_hash)
+		VALUES ('test-user-1', 'tester', 'tester@example.com', 'hash')
+	`)
+	if err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	store := NewTokenStore(db)
+	keys, err := NewKeySet(store, AlgEdDSA, DefaultKeyRotation)
+	if err != nil {
+		t.Fatalf("Failed to create signing keys: %v", err)
+	}
+	return NewTokenManager(keys, store)
+}
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	m := setupTestManager(t)
+
+	pair, err := m.Issue("test-user-1", "tester", Device{})
+	if err != nil {
+		t.Fatalf("Failed to issue tokens: %v", err)
+	}
+
+	claims, err := m.Validate(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("Failed to validate access token: %v", err)
+	}
+	if claims.UserID != "test-user-1" || claims.ID == "" || claims.SessionID == "" {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+
+	rotated, err := m.Refresh(pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+	if rotated.RefreshToken == pair.RefreshToken {
+		t.Error("Expected a new refresh token")
+	}
+
+	rotatedClaims, err := m.Validate(rotated.AccessToken)
+	if err != nil {
+		t.Fatalf("Failed to validate refreshed access token: %v", err)
+	}
+	if rotatedClaims.SessionID != claims.SessionID {
+		t.Error("Expected refreshed tokens to belong to the same login")
+	}
+
+	if _, err := m.Refresh("not-a-token"); err != ErrInvalidToken {
+		t.Errorf("Expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesLogin(t *testing.T) {
+	m := setupTestManager(t)
+
+	pair, _ := m.Issue("test-user-1", "tester", Device{})
+	rotated, err := m.Refresh(pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+
+	// Replaying the first refresh token means it leaked
+	if _, err := m.Refresh(pair.RefreshToken); err != ErrTokenReused {
+		t.Fatalf("Expected ErrTokenReused, got %v", err)
+	}
+
+	if _, err := m.Refresh(rotated.RefreshToken); err != ErrRevokedToken {
+		t.Errorf("Expected rotated refresh token to be revoked, got %v", err)
+	}
+	if _, err := m.Validate(rotated.AccessToken); err != ErrRevokedToken {
+		t.Errorf("Expected access token to be revoked, got %v", err)
+	}
+
+	// Other logins are unaffected
+	other, _ := m.Issue("test-user-1", "tester", Device{})
+	if _, err := m.Validate(other.AccessToken); err != nil {
+		t.Errorf("Expected other login to stay valid, got %v", err)
+	}
+}
+
+func TestRevokeLogout(t *testing.T) {
+	m := setupTestManager(t)
+
+	pair, _ := m.Issue("test-user-1", "tester", Device{})
+	claims, err := m.Validate(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("Failed to validate access token: %v", err)
+	}
+
+	if err := m.Revoke(claims); err != nil {
+		t.Fatalf("Failed to revoke: %v", err)
+	}
+
+	if _, err := m.Validate(pair.AccessToken); err != ErrRevokedToken {
+		t.Errorf("Expected access token to be denylisted, got %v", err)
+	}
+	if _, err := m.Refresh(pair.RefreshToken); err != ErrRevokedToken {
+		t.Errorf("Expected refresh token to be revoked, got %v", err)
+	}
+}
+
+func TestPersonalToken(t *testing.T) {
+	m := setupTestManager(t)
+
+	if _, _, err := m.CreatePersonalToken("test-user-1", &models.CreateTokenRequest{
+		Name:   "bad",
+		Scopes: []string{"write:everything"},
+	}); err != ErrInvalidScope {
+		t.Errorf("Expected ErrInvalidScope, got %v", err)
+	}
+
+	raw, token, err := m.CreatePersonalToken("test-user-1", &models.CreateTokenRequest{
+		Name:          "backup script",
+		Scopes:        []string{ScopeReadCatalog, ScopeWriteLibrary},
+		ExpiresInDays: 30,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+
+	claims, err := m.Validate(raw)
+	if err != nil {
+		t.Fatalf("Failed to validate personal token: %v", err)
+	}
+	if claims.UserID != "test-user-1" || claims.Username != "tester" || !claims.IsPersonalToken() {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+	if !claims.HasScope(ScopeWriteLibrary) || claims.HasScope(ScopeAdminNotify) {
+		t.Errorf("Unexpected scopes: %v", claims.Scopes)
+	}
+
+	tokens, err := m.ListPersonalTokens("test-user-1")
+	if err != nil {
+		t.Fatalf("Failed to list tokens: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0].LastUsedAt == nil || tokens[0].ExpiresAt == nil {
+		t.Errorf("Expected one token with last used and expiry set, got %+v", tokens)
+	}
+
+	if err := m.RevokePersonalToken("someone-else", token.ID); err != ErrTokenNotFound {
+		t.Errorf("Expected ErrTokenNotFound for another user, got %v", err)
+	}
+	if err := m.RevokePersonalToken("test-user-1", token.ID); err != nil {
+		t.Fatalf("Failed to revoke token: %v", err)
+	}
+	if _, err := m.Validate(raw); err != ErrInvalidToken {
+		t.Errorf("Expected revoked token to be invalid, got %v", err)
+	}
+}
+
+func TestLoginTokensHaveFullAccess(t *testing.T) {
+	m := setupTestManager(t)
+
+	pair, _ := m.Issue("test-user-1", "tester", Device{})
+	claims, err := m.Validate(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("Failed to validate access token: %v", err)
+	}
+	if claims.IsPersonalToken() || !claims.HasScope(ScopeAdminNotify) {
+		t.Error("Expected login token to have every scope")
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	m := setupTestManager(t)
+
+	var revoked []string
+	m.OnSessionRevoked(func(sessionID string) {
+		revoked = append(revoked, sessionID)
+	})
+
+	phone, _ := m.Issue("test-user-1", "tester", Device{Name: "phone", UserAgent: "mangahub-cli/1.0.0", IP: "10.0.0.2"})
+	laptop, _ := m.Issue("test-user-1", "tester", Device{Name: "laptop"})
+	laptopClaims, _ := m.Validate(laptop.AccessToken)
+	phoneClaims, _ := m.Validate(phone.AccessToken)
+
+	sessions, err := m.ListSessions("test-user-1", laptopClaims.SessionID)
+	if err != nil {
+		t.Fatalf("Failed to list sessions: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(sessions))
+	}
+	for _, session := range sessions {
+		if session.Current != (session.DeviceName == "laptop") {
+			t.Errorf("Expected only the laptop to be current, got %+v", session)
+		}
+	}
+
+	if err := m.RevokeSession("someone-else", phoneClaims.SessionID); err != ErrSessionNotFound {
+		t.Errorf("Expected ErrSessionNotFound for another user, got %v", err)
+	}
+	if err := m.RevokeSession("test-user-1", phoneClaims.SessionID); err != nil {
+		t.Fatalf("Failed to revoke session: %v", err)
+	}
+
+	if len(revoked) != 1 || revoked[0] != phoneClaims.SessionID {
+		t.Errorf("Expected listeners to be told about the phone session, got %v", revoked)
+	}
+	if _, err := m.Validate(phone.AccessToken); err != ErrRevokedToken {
+		t.Errorf("Expected phone access token to be revoked, got %v", err)
+	}
+	if _, err := m.Validate(laptop.AccessToken); err != nil {
+		t.Errorf("Expected laptop to stay logged in, got %v", err)
+	}
+
+	sessions, _ = m.ListSessions("test-user-1", "")
+	if len(sessions) != 1 || sessions[0].DeviceName != "laptop" {
+		t.Errorf("Expected only the laptop session to remain, got %+v", sessions)
+	}
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 test key from RFC 6238 appendix B, base32 encoded
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// The RFC lists 8 digit codes; these are their last 6 digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode failed: %v", err)
+		}
+		if code != tt.code {
+			t.Errorf("At %d: expected %s, got %s", tt.unix, tt.code, code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	step, ok := ValidateTOTP(rfc6238Secret, "081804", now)
+	if !ok || step != TOTPStep(now) {
+		t.Errorf("Expected current code to match step %d, got %d, %v", TOTPStep(now), step, ok)
+	}
+
+	// One period of clock drift is tolerated, more is not
+	if _, ok := ValidateTOTP(rfc6238Secret, "081804", now.Add(TOTPPeriod)); !ok {
+		t.Error("Expected code from the previous period to be accepted")
+	}
+	if _, ok := ValidateTOTP(rfc6238Secret, "081804", now.Add(3*TOTPPeriod)); ok {
+		t.Error("Expected an old code to be rejected")
+	}
+	if _, ok := ValidateTOTP(rfc6238Secret, "12345", now); ok {
+		t.Error("Expected a short code to be rejected")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("Failed to generate secret: %v", err)
+	}
+
+	uri := TOTPURI("MangaHub", "reader", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/MangaHub:reader?") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("Unexpected URI: %s", uri)
+	}
+}
@@ -0,0 +1,137 @@
+package goals
+
+import (
+	"net/http"
+	"time"
+
+	"mangahub/internal/auth"
+	"mangahub/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ListGoals handles listing the user's goals with progress and streaks
+func (h *Handler) ListGoals(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	now := time.Now()
+
+	goals, err := h.service.Check(userID, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to get goals",
+		})
+		return
+	}
+
+	current, longest, err := h.service.GetStreaks(userID, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to get streaks",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data: gin.H{
+			"goals":          goals,
+			"count":          len(goals),
+			"current_streak": current,
+			"longest_streak": longest,
+		},
+	})
+}
+
+// CreateGoal handles creating a reading goal
+func (h *Handler) CreateGoal(c *gin.Context) {
+	userID := auth.GetUserID(c)
+
+	var req models.CreateGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "invalid goal. kind must be chapters or series, period day, week, month or year",
+		})
+		return
+	}
+
+	goal, err := h.service.CreateGoal(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to create goal",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{
+		Success: true,
+		Message: "goal created",
+		Data:    goal,
+	})
+}
+
+// DeleteGoal handles removing a reading goal
+func (h *Handler) DeleteGoal(c *gin.Context) {
+	userID := auth.GetUserID(c)
+
+	if err := h.service.repo.Delete(userID, c.Param("id")); err != nil {
+		if err == ErrGoalNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Success: false,
+				Error:   "goal not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to delete goal",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "goal deleted",
+	})
+}
+
+// ListAchievements handles listing all badges and which ones the user has unlocked
+func (h *Handler) ListAchievements(c *gin.Context) {
+	userID := auth.GetUserID(c)
+
+	achievements, err := h.service.GetAchievements(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to get achievements",
+		})
+		return
+	}
+
+	unlocked := 0
+	for _, achievement := range achievements {
+		if achievement.UnlockedAt != nil {
+			unlocked++
+		}
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data: gin.H{
+			"achievements": achievements,
+			"unlocked":     unlocked,
+			"total":        len(achievements),
+		},
+	})
+}
@@ -0,0 +1,229 @@
+package goals
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"mangahub/internal/manga"
+	"mangahub/pkg/models"
+)
+
+var ErrGoalNotFound = errors.New("goal not found")
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Create creates a new goal
+func (r *Repository) Create(goal *models.Goal) error {
+	query := `
+		INSERT INTO reading_goals (id, user_id, kind, target, period, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.db.Exec(query, goal.ID, goal.UserID, goal.Kind, goal.Target, goal.Period, goal.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create goal: %w", err)
+	}
+	return nil
+}
+
+// List retrieves a user's goals
+func (r *Repository) List(userID string) ([]*models.Goal, error) {
+	query := `
+		SELECT id, user_id, kind, target, period, created_at
+		FROM reading_goals
+		WHERE user_id = ?
+		ORDER BY created_at
+	`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list goals: %w", err)
+	}
+	defer rows.Close()
+
+	var goals []*models.Goal
+	for rows.Next() {
+		goal := &models.Goal{}
+		err := rows.Scan(&goal.ID, &goal.UserID, &goal.Kind, &goal.Target, &goal.Period, &goal.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan goal: %w", err)
+		}
+		goals = append(goals, goal)
+	}
+	return goals, rows.Err()
+}
+
+// Delete removes a user's goal
+func (r *Repository) Delete(userID, id string) error {
+	result, err := r.db.Exec(`DELETE FROM reading_goals WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete goal: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrGoalNotFound
+	}
+	return nil
+}
+
+// GetNotified returns the highest progress percentage already announced for
+// the goal in the period instance starting on periodKey (YYYY-MM-DD)
+func (r *Repository) GetNotified(goalID, periodKey string) (int, error) {
+	var notifiedPeriod string
+	var percent int
+	err := r.db.QueryRow(`SELECT notified_period, notified_percent FROM reading_goals WHERE id = ?`, goalID).
+		Scan(&notifiedPeriod, &percent)
+	if err == sql.ErrNoRows {
+		return 0, ErrGoalNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get goal notifications: %w", err)
+	}
+	if notifiedPeriod != periodKey {
+		return 0, nil
+	}
+	return percent, nil
+}
+
+// SetNotified records the highest progress percentage announced in a period instance
+func (r *Repository) SetNotified(goalID, periodKey string, percent int) error {
+	_, err := r.db.Exec(`
+		UPDATE reading_goals SET notified_period = ?, notified_percent = ? WHERE id = ?
+	`, periodKey, percent, goalID)
+	if err != nil {
+		return fmt.Errorf("failed to update goal notifications: %w", err)
+	}
+	return nil
+}
+
+// GetTimezone returns the user's time zone, falling back to UTC
+func (r *Repository) GetTimezone(userID string) *time.Location {
+	var timezone string
+	r.db.QueryRow(`SELECT timezone FROM users WHERE id = ?`, userID).Scan(&timezone)
+	loc, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "" {
+		return time.UTC
+	}
+	return loc
+}
+
+// CountChaptersRead counts chapters recorded in the user's reading sessions between from and to
+func (r *Repository) CountChaptersRead(userID string, from, to time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*)
+		FROM session_chapters sc
+		JOIN reading_sessions rs ON rs.id = sc.session_id
+		WHERE rs.user_id = ? AND sc.read_at >= ? AND sc.read_at < ?
+	`, userID, from.UTC(), to.UTC()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count chapters read: %w", err)
+	}
+	return count, nil
+}
+
+// CountSeriesCompleted counts library entries marked completed between from and to
+func (r *Repository) CountSeriesCompleted(userID string, from, to time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*)
+		FROM user_progress
+		WHERE user_id = ? AND status = 'completed' AND completed_at >= ? AND completed_at < ?
+	`, userID, from.UTC(), to.UTC()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count completed series: %w", err)
+	}
+	return count, nil
+}
+
+// GetReadingDays returns the days (YYYY-MM-DD in loc) on which the user read a chapter
+func (r *Repository) GetReadingDays(userID string, loc *time.Location) (map[string]bool, error) {
+	rows, err := r.db.Query(`
+		SELECT sc.read_at
+		FROM session_chapters sc
+		JOIN reading_sessions rs ON rs.id = sc.session_id
+		WHERE rs.user_id = ?
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reading days: %w", err)
+	}
+	defer rows.Close()
+
+	days := make(map[string]bool)
+	for rows.Next() {
+		var readAt time.Time
+		if err := rows.Scan(&readAt); err != nil {
+			return nil, fmt.Errorf("failed to scan reading day: %w", err)
+		}
+		days[readAt.In(loc).Format("2006-01-02")] = true
+	}
+	return days, rows.Err()
+}
+
+// GetLifetimeTotals returns the number of chapters marked read across the
+// library and the number of completed series
+func (r *Repository) GetLifetimeTotals(userID string) (chapters, completed int, err error) {
+	rows, err := r.db.Query(`SELECT read_chapters, status FROM user_progress WHERE user_id = ?`, userID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get library totals: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var readChapters, status string
+		if err := rows.Scan(&readChapters, &status); err != nil {
+			return 0, 0, fmt.Errorf("failed to scan library totals: %w", err)
+		}
+		if set, err := manga.ParseChapterSet(readChapters); err == nil {
+			chapters += set.Count()
+		}
+		if status == "completed" {
+			completed++
+		}
+	}
+	return chapters, completed, rows.Err()
+}
+
+// GetAchievements returns the badges a user has unlocked, keyed by badge
+func (r *Repository) GetAchievements(userID string) (map[string]time.Time, error) {
+	rows, err := r.db.Query(`SELECT badge, unlocked_at FROM user_achievements WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get achievements: %w", err)
+	}
+	defer rows.Close()
+
+	unlocked := make(map[string]time.Time)
+	for rows.Next() {
+		var badge string
+		var unlockedAt time.Time
+		if err := rows.Scan(&badge, &unlockedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan achievement: %w", err)
+		}
+		unlocked[badge] = unlockedAt
+	}
+	return unlocked, rows.Err()
+}
+
+// Unlock records a badge, reporting false if the user already had it
+func (r *Repository) Unlock(userID, badge string, at time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		INSERT OR IGNORE INTO user_achievements (user_id, badge, unlocked_at) VALUES (?, ?, ?)
+	`, userID, badge, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to unlock achievement: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
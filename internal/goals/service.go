@@ -0,0 +1,283 @@
+package goals
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"mangahub/internal/stats"
+	"mangahub/internal/udp"
+	"mangahub/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// Badge describes a milestone achievement
+type Badge struct {
+	ID          string
+	Name        string
+	Description string
+	unlocked    func(m milestones) bool
+}
+
+// milestones holds the lifetime numbers badges are checked against
+type milestones struct {
+	chapters      int
+	completed     int
+	longestStreak int
+	goalsReached  int
+}
+
+// Badges lists every achievement in the order they are shown
+var Badges = []Badge{
+	{"first_chapter", "First Steps", "Read your first chapter", func(m milestones) bool { return m.chapters >= 1 }},
+	{"chapters_100", "Centurion", "Read 100 chapters", func(m milestones) bool { return m.chapters >= 100 }},
+	{"chapters_1000", "Bookworm", "Read 1,000 chapters", func(m milestones) bool { return m.chapters >= 1000 }},
+	{"first_series", "Finisher", "Complete your first series", func(m milestones) bool { return m.completed >= 1 }},
+	{"series_10", "Completionist", "Complete 10 series", func(m milestones) bool { return m.completed >= 10 }},
+	{"streak_7", "On a Roll", "Read 7 days in a row", func(m milestones) bool { return m.longestStreak >= 7 }},
+	{"streak_30", "Dedicated", "Read 30 days in a row", func(m milestones) bool { return m.longestStreak >= 30 }},
+	{"first_goal", "Goal Getter", "Reach a reading goal", func(m milestones) bool { return m.goalsReached >= 1 }},
+}
+
+// notifyThresholds are the progress percentages announced for each goal period
+var notifyThresholds = []int{50, 100}
+
+// Service tracks goal progress and achievements from progress updates and
+// notifies users over UDP when they hit a milestone
+type Service struct {
+	repo      *Repository
+	udpServer *udp.Server
+	events    chan string
+}
+
+// NewService creates a goal service. udpServer may be nil, in which case no
+// notifications are sent.
+func NewService(repo *Repository, udpServer *udp.Server) *Service {
+	return &Service{
+		repo:      repo,
+		udpServer: udpServer,
+		events:    make(chan string, 256),
+	}
+}
+
+// Observe queues a check of the user's goals without blocking the caller
+func (s *Service) Observe(update models.ProgressUpdate) {
+	select {
+	case s.events <- update.UserID:
+	default:
+	}
+}
+
+// Run checks goals and achievements for users with new progress
+func (s *Service) Run() {
+	for userID := range s.events {
+		if _, err := s.Check(userID, time.Now()); err != nil {
+			log.Printf("Error checking goals: %v", err)
+		}
+	}
+}
+
+// Check refreshes progress on all of the user's goals, announces newly
+// crossed thresholds and unlocks any badges earned
+func (s *Service) Check(userID string, now time.Time) ([]*models.Goal, error) {
+	goals, err := s.GetGoals(userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	reached := 0
+	for _, goal := range goals {
+		if goal.Completed {
+			reached++
+		}
+		if err := s.announceProgress(goal); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.unlockBadges(userID, reached, now); err != nil {
+		return nil, err
+	}
+	return goals, nil
+}
+
+// GetGoals returns the user's goals with progress in the current period
+func (s *Service) GetGoals(userID string, now time.Time) ([]*models.Goal, error) {
+	goals, err := s.repo.List(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := s.repo.GetTimezone(userID)
+	for _, goal := range goals {
+		goal.PeriodStart, goal.PeriodEnd = PeriodBounds(goal.Period, now.In(loc))
+
+		switch goal.Kind {
+		case "series":
+			goal.Progress, err = s.repo.CountSeriesCompleted(userID, goal.PeriodStart, goal.PeriodEnd)
+		default:
+			goal.Progress, err = s.repo.CountChaptersRead(userID, goal.PeriodStart, goal.PeriodEnd)
+		}
+		if err != nil {
+			return nil, err
+		}
+		goal.Completed = goal.Progress >= goal.Target
+	}
+	return goals, nil
+}
+
+// CreateGoal creates a goal for the user
+func (s *Service) CreateGoal(userID string, req *models.CreateGoalRequest) (*models.Goal, error) {
+	goal := &models.Goal{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Kind:      req.Kind,
+		Target:    req.Target,
+		Period:    req.Period,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.Create(goal); err != nil {
+		return nil, err
+	}
+
+	loc := s.repo.GetTimezone(userID)
+	goal.PeriodStart, goal.PeriodEnd = PeriodBounds(goal.Period, time.Now().In(loc))
+	return goal, nil
+}
+
+// GetStreaks returns the user's current and longest reading streaks in their time zone
+func (s *Service) GetStreaks(userID string, now time.Time) (current, longest int, err error) {
+	loc := s.repo.GetTimezone(userID)
+	days, err := s.repo.GetReadingDays(userID, loc)
+	if err != nil {
+		return 0, 0, err
+	}
+	current, longest = stats.Streaks(days, now.In(loc))
+	return current, longest, nil
+}
+
+// GetAchievements returns every badge, with the unlock time for those the user has earned
+func (s *Service) GetAchievements(userID string) ([]models.Achievement, error) {
+	unlocked, err := s.repo.GetAchievements(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	achievements := make([]models.Achievement, 0, len(Badges))
+	for _, badge := range Badges {
+		achievement := models.Achievement{
+			Badge:       badge.ID,
+			Name:        badge.Name,
+			Description: badge.Description,
+		}
+		if at, ok := unlocked[badge.ID]; ok {
+			achievement.UnlockedAt = &at
+		}
+		achievements = append(achievements, achievement)
+	}
+	return achievements, nil
+}
+
+// announceProgress notifies the user the first time a goal crosses a threshold in a period
+func (s *Service) announceProgress(goal *models.Goal) error {
+	periodKey := goal.PeriodStart.Format("2006-01-02")
+	notified, err := s.repo.GetNotified(goal.ID, periodKey)
+	if err != nil {
+		return err
+	}
+
+	percent := goal.Progress * 100 / goal.Target
+	crossed := 0
+	for _, threshold := range notifyThresholds {
+		if percent >= threshold && threshold > notified {
+			crossed = threshold
+		}
+	}
+	if crossed == 0 {
+		return nil
+	}
+
+	if err := s.repo.SetNotified(goal.ID, periodKey, crossed); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Halfway there: %d/%d %s %s", goal.Progress, goal.Target, goal.Kind, periodLabel(goal.Period))
+	if crossed >= 100 {
+		message = fmt.Sprintf("Goal reached: %d/%d %s %s 🎉", goal.Progress, goal.Target, goal.Kind, periodLabel(goal.Period))
+	}
+	s.notify(goal.UserID, "goal_progress", message)
+	return nil
+}
+
+// unlockBadges awards badges whose milestones have been reached and returns the new ones
+func (s *Service) unlockBadges(userID string, goalsReached int, now time.Time) ([]Badge, error) {
+	chapters, completed, err := s.repo.GetLifetimeTotals(userID)
+	if err != nil {
+		return nil, err
+	}
+	_, longest, err := s.GetStreaks(userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	m := milestones{
+		chapters:      chapters,
+		completed:     completed,
+		longestStreak: longest,
+		goalsReached:  goalsReached,
+	}
+
+	var unlocked []Badge
+	for _, badge := range Badges {
+		if !badge.unlocked(m) {
+			continue
+		}
+		isNew, err := s.repo.Unlock(userID, badge.ID, now)
+		if err != nil {
+			return nil, err
+		}
+		if isNew {
+			unlocked = append(unlocked, badge)
+			s.notify(userID, "achievement", fmt.Sprintf("Achievement unlocked: %s - %s 🏆", badge.Name, badge.Description))
+		}
+	}
+	return unlocked, nil
+}
+
+func (s *Service) notify(userID, kind, message string) {
+	if s.udpServer == nil {
+		return
+	}
+	s.udpServer.SendNotificationToUser(userID, models.Notification{
+		Type:      kind,
+		Message:   message,
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// PeriodBounds returns the calendar period containing now, in now's location.
+// Weeks start on Monday.
+func PeriodBounds(period string, now time.Time) (time.Time, time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch period {
+	case "week":
+		start := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		return start, start.AddDate(0, 0, 7)
+	case "month":
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 1, 0)
+	case "year":
+		start := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(1, 0, 0)
+	}
+	return today, today.AddDate(0, 0, 1)
+}
+
+func periodLabel(period string) string {
+	if period == "day" {
+		return "today"
+	}
+	return "this " + period
+}
@@ -0,0 +1,122 @@
+package goals
+
+import (
+	"testing"
+	"time"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+func setupTestService(t *testing.T) *Service {
+	db, err := database.InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO users (id, username, email, password_hash, timezone)
+		VALUES ('test-user-1', 'tester', 'tester@example.com', 'hash', 'Asia/Tokyo');
+
+		INSERT INTO manga (id, title, author, genres, status, total_chapters, description, cover_url, manga_url, year)
+		VALUES ('test-manga-1', 'Test Manga 1', 'Test Author 1', '["Action"]', 'completed', 100, '', '', '', 2020);
+
+		INSERT INTO user_progress (user_id, manga_id, current_chapter, read_chapters, status)
+		VALUES ('test-user-1', 'test-manga-1', 3, '1-3', 'reading');
+
+		INSERT INTO reading_sessions (id, user_id, source, started_at, last_activity_at)
+		VALUES ('s1', 'test-user-1', 'inferred', '2024-03-01 00:00:00', '2024-03-01 00:00:00');
+	`)
+	if err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	return NewService(NewRepository(db), nil)
+}
+
+func addRead(t *testing.T, s *Service, chapter int, at time.Time) {
+	_, err := s.repo.db.Exec(`
+		INSERT INTO session_chapters (session_id, manga_id, chapter, read_at) VALUES ('s1', 'test-manga-1', ?, ?)
+	`, chapter, at)
+	if err != nil {
+		t.Fatalf("Failed to add chapter read: %v", err)
+	}
+}
+
+func TestGoalProgress(t *testing.T) {
+	s := setupTestService(t)
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	goal, err := s.CreateGoal("test-user-1", &models.CreateGoalRequest{Kind: "chapters", Target: 2, Period: "month"})
+	if err != nil {
+		t.Fatalf("Failed to create goal: %v", err)
+	}
+
+	addRead(t, s, 1, now.Add(-time.Hour))
+	// 2024-02-29 20:00 UTC is already March 1st in Tokyo
+	addRead(t, s, 2, time.Date(2024, 2, 29, 20, 0, 0, 0, time.UTC))
+	// Last month in Tokyo too, so it doesn't count
+	addRead(t, s, 3, time.Date(2024, 2, 28, 10, 0, 0, 0, time.UTC))
+
+	goals, err := s.Check("test-user-1", now)
+	if err != nil {
+		t.Fatalf("Failed to check goals: %v", err)
+	}
+	if len(goals) != 1 || goals[0].ID != goal.ID {
+		t.Fatalf("Expected the created goal, got %+v", goals)
+	}
+	if goals[0].Progress != 2 || !goals[0].Completed {
+		t.Errorf("Expected goal completed with 2 chapters, got %d", goals[0].Progress)
+	}
+
+	notified, _ := s.repo.GetNotified(goal.ID, goals[0].PeriodStart.Format("2006-01-02"))
+	if notified != 100 {
+		t.Errorf("Expected 100%% to be announced, got %d", notified)
+	}
+
+	achievements, err := s.GetAchievements("test-user-1")
+	if err != nil {
+		t.Fatalf("Failed to get achievements: %v", err)
+	}
+	unlocked := make(map[string]bool)
+	for _, achievement := range achievements {
+		unlocked[achievement.Badge] = achievement.UnlockedAt != nil
+	}
+	if !unlocked["first_chapter"] || !unlocked["first_goal"] {
+		t.Errorf("Expected first_chapter and first_goal to be unlocked, got %v", unlocked)
+	}
+	if unlocked["chapters_100"] || unlocked["first_series"] {
+		t.Errorf("Unexpected badges unlocked: %v", unlocked)
+	}
+}
+
+func TestStreaksUseUserTimezone(t *testing.T) {
+	s := setupTestService(t)
+
+	// Consecutive days in Tokyo, though both fall on March 9th in UTC
+	addRead(t, s, 1, time.Date(2024, 3, 9, 1, 0, 0, 0, time.UTC))
+	addRead(t, s, 2, time.Date(2024, 3, 9, 16, 0, 0, 0, time.UTC))
+
+	current, longest, err := s.GetStreaks("test-user-1", time.Date(2024, 3, 10, 2, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to get streaks: %v", err)
+	}
+	if current != 2 || longest != 2 {
+		t.Errorf("Expected a 2 day streak, got current %d longest %d", current, longest)
+	}
+}
+
+func TestPeriodBounds(t *testing.T) {
+	// Wednesday
+	now := time.Date(2024, 3, 13, 15, 0, 0, 0, time.UTC)
+
+	start, end := PeriodBounds("week", now)
+	if start.Format("2006-01-02") != "2024-03-11" || end.Format("2006-01-02") != "2024-03-18" {
+		t.Errorf("Expected week of Monday 2024-03-11, got %s to %s", start, end)
+	}
+
+	start, end = PeriodBounds("year", now)
+	if start.Format("2006-01-02") != "2024-01-01" || end.Format("2006-01-02") != "2025-01-01" {
+		t.Errorf("Unexpected year bounds %s to %s", start, end)
+	}
+}
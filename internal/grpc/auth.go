@@ -0,0 +1,83 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"mangahub/internal/auth"
+	pb "mangahub/proto/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type claimsKey struct{}
+
+// publicMethods can be called without a token, like the public HTTP catalog routes
+var publicMethods = map[string]bool{
+	pb.MangaService_GetManga_FullMethodName:    true,
+	pb.MangaService_SearchManga_FullMethodName: true,
+}
+
+// methodScopes lists the scope a personal access token needs for each method.
+// Methods not listed only accept login tokens.
+var methodScopes = map[string]string{
+	pb.MangaService_GetManga_FullMethodName:         auth.ScopeReadCatalog,
+	pb.MangaService_SearchManga_FullMethodName:      auth.ScopeReadCatalog,
+	pb.MangaService_UpdateProgress_FullMethodName:   auth.ScopeWriteLibrary,
+	pb.MangaService_MarkChaptersRead_FullMethodName: auth.ScopeWriteLibrary,
+}
+
+// AuthInterceptor validates the bearer token in the "authorization" metadata,
+// enforces personal access token scopes and stores the claims in the request context
+func AuthInterceptor(tokens auth.Verifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		token := bearerToken(ctx)
+		if token == "" {
+			if publicMethods[info.FullMethod] {
+				return handler(ctx, req)
+			}
+			return nil, status.Error(codes.Unauthenticated, "authorization metadata required")
+		}
+
+		claims, err := tokens.Validate(token)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+		}
+		if claims.IsPersonalToken() {
+			scope, ok := methodScopes[info.FullMethod]
+			if !ok || !claims.HasScope(scope) {
+				return nil, status.Error(codes.PermissionDenied, "token is not allowed to call this method")
+			}
+		}
+
+		return handler(context.WithValue(ctx, claimsKey{}, claims), req)
+	}
+}
+
+// authorizeUser returns the user an RPC acts on: the token's user, which a
+// request may repeat but not override
+func authorizeUser(ctx context.Context, requested string) (string, error) {
+	claims, ok := ctx.Value(claimsKey{}).(*auth.Claims)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if requested != "" && requested != claims.UserID {
+		return "", status.Error(codes.PermissionDenied, "cannot act on behalf of another user")
+	}
+	return claims.UserID, nil
+}
+
+func bearerToken(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(values[0], "Bearer ")
+}
@@ -0,0 +1,42 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"mangahub/internal/auth"
+	"mangahub/internal/ratelimit"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RateLimitInterceptor limits calls per user, or per client IP for calls made
+// without a token. It must run after AuthInterceptor.
+func RateLimitInterceptor(l *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if ok, wait := l.Allow(rateLimitKey(ctx)); !ok {
+			seconds := ratelimit.RetryAfterSeconds(wait)
+			grpc.SetHeader(ctx, metadata.Pairs("retry-after", fmt.Sprint(seconds)))
+			return nil, status.Errorf(codes.ResourceExhausted, "too many requests, retry in %d seconds", seconds)
+		}
+		return handler(ctx, req)
+	}
+}
+
+func rateLimitKey(ctx context.Context) string {
+	if claims, ok := ctx.Value(claimsKey{}).(*auth.Claims); ok {
+		return "user:" + claims.UserID
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return "ip:" + host
+		}
+		return "ip:" + p.Addr.String()
+	}
+	return "ip:unknown"
+}
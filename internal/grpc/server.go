@@ -6,7 +6,11 @@ import (
 	"log"
 	"time"
 
+	"mangahub/internal/auth"
 	"mangahub/internal/manga"
+	"mangahub/internal/ratelimit"
+	"mangahub/internal/stats"
+	"mangahub/internal/user"
 	"mangahub/pkg/models"
 	pb "mangahub/proto/proto"
 	"net"
@@ -19,12 +23,14 @@ import (
 type Server struct {
 	pb.UnimplementedMangaServiceServer
 	repo              *manga.Repository
+	statsRepo         *stats.Repository
 	progressBroadcast chan models.ProgressUpdate
 }
 
-func NewServer(repo *manga.Repository, progressBroadcast chan models.ProgressUpdate) *Server {
+func NewServer(repo *manga.Repository, statsRepo *stats.Repository, progressBroadcast chan models.ProgressUpdate) *Server {
 	return &Server{
 		repo:              repo,
+		statsRepo:         statsRepo,
 		progressBroadcast: progressBroadcast,
 	}
 }
@@ -100,6 +106,12 @@ func (s *Server) SearchManga(ctx context.Context, req *pb.SearchRequest) (*pb.Se
 
 // UpdateProgress updates reading progress
 func (s *Server) UpdateProgress(ctx context.Context, req *pb.UpdateProgressRequest) (*pb.UpdateProgressResponse, error) {
+	userID, err := authorizeUser(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	req.UserId = userID
+
 	log.Printf("gRPC UpdateProgress called for user %s, manga %s, chapter %d",
 		req.UserId, req.MangaId, req.Chapter)
 
@@ -154,8 +166,139 @@ func (s *Server) UpdateProgress(ctx context.Context, req *pb.UpdateProgressReque
 	}, nil
 }
 
-// StartGRPCServer starts the gRPC server
-func StartGRPCServer(port string, repo *manga.Repository, progressBroadcast chan models.ProgressUpdate) error {
+// MarkChaptersRead marks or unmarks chapters and ranges as read
+func (s *Server) MarkChaptersRead(ctx context.Context, req *pb.MarkChaptersRequest) (*pb.MarkChaptersResponse, error) {
+	userID, err := authorizeUser(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	req.UserId = userID
+
+	log.Printf("gRPC MarkChaptersRead called for user %s, manga %s, chapters %s (unread: %v)",
+		req.UserId, req.MangaId, req.Chapters, req.Unread)
+
+	chapters, err := manga.ParseChapterSet(req.Chapters)
+	if err != nil || len(chapters) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "chapters must be a list of chapters or ranges, e.g. 1-20,25")
+	}
+
+	// Validate manga exists
+	m, err := s.repo.GetByID(req.MangaId)
+	if err != nil {
+		if err == manga.ErrMangaNotFound {
+			return nil, status.Error(codes.NotFound, "manga not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to verify manga")
+	}
+
+	if chapters.Max() > m.TotalChapters {
+		return &pb.MarkChaptersResponse{
+			Success: false,
+			Message: "chapter number exceeds total chapters",
+		}, nil
+	}
+
+	progress, err := s.repo.MarkChapters(req.UserId, req.MangaId, chapters, !req.Unread)
+	if err != nil {
+		if err == manga.ErrProgressNotFound {
+			return &pb.MarkChaptersResponse{
+				Success: false,
+				Message: "manga not in library",
+			}, nil
+		}
+		return nil, status.Error(codes.Internal, "failed to update read chapters")
+	}
+
+	// Broadcast progress update via TCP (non-blocking)
+	if s.progressBroadcast != nil {
+		update := models.ProgressUpdate{
+			UserID:    req.UserId,
+			MangaID:   progress.MangaID,
+			Chapter:   progress.CurrentChapter,
+			Timestamp: time.Now().Unix(),
+		}
+		select {
+		case s.progressBroadcast <- update:
+		default:
+		}
+	}
+
+	read, _ := manga.ParseChapterSet(progress.ReadChapters)
+	return &pb.MarkChaptersResponse{
+		Success:        true,
+		Message:        "read chapters updated successfully",
+		CurrentChapter: int32(progress.CurrentChapter),
+		ReadChapters:   progress.ReadChapters,
+		ReadCount:      int32(read.Count()),
+	}, nil
+}
+
+// GetUserStats computes a user's reading statistics for a period
+func (s *Server) GetUserStats(ctx context.Context, req *pb.UserStatsRequest) (*pb.UserStatsResponse, error) {
+	userID, err := authorizeUser(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	req.UserId = userID
+
+	log.Printf("gRPC GetUserStats called for user %s, period %s", req.UserId, req.Period)
+
+	period := req.Period
+	if period == "" {
+		period = "month"
+	}
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid time zone")
+	}
+
+	st, err := s.statsRepo.GetUserStats(req.UserId, period, time.Now(), loc)
+	if err != nil {
+		if err == stats.ErrInvalidPeriod {
+			return nil, status.Error(codes.InvalidArgument, "invalid period. Valid periods: week, month, year, all")
+		}
+		return nil, status.Error(codes.Internal, "failed to compute statistics")
+	}
+
+	resp := &pb.UserStatsResponse{
+		Period:         st.Period,
+		ChaptersRead:   int32(st.ChaptersRead),
+		LibraryTotal:   int32(st.LibraryTotal),
+		StatusCounts:   make(map[string]int32),
+		CompletionRate: st.CompletionRate,
+		AverageRating:  st.AverageRating,
+		RatedCount:     int32(st.RatedCount),
+		CurrentStreak:  int32(st.CurrentStreak),
+		LongestStreak:  int32(st.LongestStreak),
+		MostActiveHour: int32(st.MostActiveHour),
+	}
+	for _, bucket := range st.ChaptersPerBucket {
+		resp.ChaptersPerBucket = append(resp.ChaptersPerBucket, &pb.StatCount{Label: bucket.Label, Count: int32(bucket.Count)})
+	}
+	for statusName, count := range st.StatusCounts {
+		resp.StatusCounts[statusName] = int32(count)
+	}
+	for _, genre := range st.Genres {
+		resp.Genres = append(resp.Genres, &pb.StatCount{Label: genre.Label, Count: int32(genre.Count)})
+	}
+	if st.LongestFinished != nil {
+		resp.LongestFinishedId = st.LongestFinished.MangaID
+		resp.LongestFinishedTitle = st.LongestFinished.Title
+		resp.LongestFinishedChapters = int32(st.LongestFinished.TotalChapters)
+	}
+	for _, count := range st.ActiveHours {
+		resp.ActiveHours = append(resp.ActiveHours, int32(count))
+	}
+
+	return resp, nil
+}
+
+// StartGRPCServer starts the gRPC server. UserService is only registered when users is set.
+func StartGRPCServer(port string, repo *manga.Repository, statsRepo *stats.Repository, users *user.Service, tokens *auth.TokenManager, limiter *ratelimit.Limiter, progressBroadcast chan models.ProgressUpdate) error {
 	// Tạo TCP listener
 	lis, err := net.Listen("tcp", ":"+port)
 	if err != nil {
@@ -163,11 +306,14 @@ func StartGRPCServer(port string, repo *manga.Repository, progressBroadcast chan
 	}
 
 	// Tạo gRPC server
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(AuthInterceptor(tokens), RateLimitInterceptor(limiter)))
 
 	// Khởi tạo server và đăng ký service
-	srv := NewServer(repo, progressBroadcast)
+	srv := NewServer(repo, statsRepo, progressBroadcast)
 	pb.RegisterMangaServiceServer(grpcServer, srv)
+	if users != nil {
+		pb.RegisterUserServiceServer(grpcServer, NewUserServer(users))
+	}
 
 	log.Printf("gRPC server listening on %s", port)
 
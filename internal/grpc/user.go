@@ -0,0 +1,101 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"mangahub/internal/user"
+	"mangahub/pkg/models"
+	pb "mangahub/proto/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UserServer implements UserService for the signed-in user
+type UserServer struct {
+	pb.UnimplementedUserServiceServer
+	users *user.Service
+}
+
+func NewUserServer(users *user.Service) *UserServer {
+	return &UserServer{users: users}
+}
+
+// GetProfile returns the caller's profile
+func (s *UserServer) GetProfile(ctx context.Context, req *pb.GetProfileRequest) (*pb.UserProfile, error) {
+	userID, err := authorizeUser(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := s.users.GetProfile(userID)
+	if err != nil {
+		if err == user.ErrUserNotFound {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to get profile")
+	}
+	return profileToProto(profile), nil
+}
+
+// UpdateProfile changes the fields set in the request
+func (s *UserServer) UpdateProfile(ctx context.Context, req *pb.UpdateProfileRequest) (*pb.UpdateProfileResponse, error) {
+	userID, err := authorizeUser(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("gRPC UpdateProfile called for user %s", userID)
+
+	update, err := s.users.UpdateProfile(userID, &models.UpdateProfileRequest{
+		DisplayName:             req.DisplayName,
+		Bio:                     req.Bio,
+		AvatarURL:               req.AvatarUrl,
+		Timezone:                req.Timezone,
+		TitleLanguage:           req.TitleLanguage,
+		Privacy:                 req.Privacy,
+		Email:                   req.Email,
+		NotificationPreferences: req.NotificationPreferences,
+		CurrentPassword:         req.CurrentPassword,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrInvalidProfile):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case err == user.ErrWrongPassword:
+			return nil, status.Error(codes.PermissionDenied, "current_password is required to change the email address")
+		case err == user.ErrEmailExists:
+			return nil, status.Error(codes.AlreadyExists, "email already exists")
+		case err == user.ErrUserNotFound:
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to update profile")
+	}
+
+	return &pb.UpdateProfileResponse{
+		Profile:          profileToProto(update.Profile),
+		EmailChanged:     update.EmailChanged,
+		VerificationSent: update.VerificationSent,
+	}, nil
+}
+
+func profileToProto(p *models.UserProfile) *pb.UserProfile {
+	return &pb.UserProfile{
+		UserId:                  p.UserID,
+		Username:                p.Username,
+		Email:                   p.Email,
+		EmailVerified:           p.EmailVerified,
+		IsAdmin:                 p.IsAdmin,
+		TwoFactorEnabled:        p.TwoFactorEnabled,
+		DisplayName:             p.DisplayName,
+		Bio:                     p.Bio,
+		AvatarUrl:               p.AvatarURL,
+		Timezone:                p.Timezone,
+		TitleLanguage:           p.TitleLanguage,
+		Privacy:                 p.Privacy,
+		NotificationPreferences: p.NotificationPreferences,
+		CreatedAt:               p.CreatedAt.Unix(),
+	}
+}
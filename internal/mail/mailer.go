@@ -0,0 +1,124 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Message represents a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// Config selects and configures a mailer. SMTP is used when Host is set,
+// otherwise messages are written to Dir for development.
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	Dir      string
+}
+
+// New creates the mailer described by cfg
+func New(cfg Config) Mailer {
+	if cfg.Host != "" {
+		return NewSMTPMailer(cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.From)
+	}
+	if cfg.Dir != "" {
+		return NewFileMailer(cfg.Dir, cfg.From)
+	}
+	return LogMailer{}
+}
+
+// SMTPMailer sends messages through an SMTP server
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPMailer{
+		addr: fmt.Sprintf("%s:%d", host, port),
+		auth: auth,
+		from: from,
+	}
+}
+
+// Send delivers a message via SMTP
+func (m *SMTPMailer) Send(msg *Message) error {
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, format(m.from, msg)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// FileMailer writes each message to its own .eml file in a directory
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+// Send writes a message to the mail directory
+func (m *FileMailer) Send(msg *Message) error {
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102T150405.000000000"), unsafeFileChars.ReplaceAllString(msg.To, "_"))
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, format(m.from, msg), 0600); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+
+	log.Printf("Email to %s written to %s", msg.To, path)
+	return nil
+}
+
+// LogMailer prints messages to the server log
+type LogMailer struct{}
+
+// Send logs a message
+func (LogMailer) Send(msg *Message) error {
+	log.Printf("Email to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// format renders a message with the headers SMTP servers expect
+func format(from string, msg *Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
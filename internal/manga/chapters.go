@@ -0,0 +1,164 @@
+package manga
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidChapters = errors.New("invalid chapter list")
+
+// ChapterRange is an inclusive range of chapter numbers
+type ChapterRange struct {
+	Start int
+	End   int
+}
+
+// ChapterSet is a compact set of chapters stored as sorted, non-overlapping ranges
+type ChapterSet []ChapterRange
+
+// ParseChapterSet parses a list such as "1-20,25" into a ChapterSet
+func ParseChapterSet(s string) (ChapterSet, error) {
+	var set ChapterSet
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var r ChapterRange
+		var err error
+		if from, to, ok := strings.Cut(part, "-"); ok {
+			r.Start, err = strconv.Atoi(strings.TrimSpace(from))
+			if err == nil {
+				r.End, err = strconv.Atoi(strings.TrimSpace(to))
+			}
+		} else {
+			r.Start, err = strconv.Atoi(part)
+			r.End = r.Start
+		}
+		if err != nil || r.Start < 1 || r.End < r.Start {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidChapters, part)
+		}
+		set = append(set, r)
+	}
+	return set.normalize(), nil
+}
+
+// ChapterSpan returns the set containing chapters start through end
+func ChapterSpan(start, end int) ChapterSet {
+	if start < 1 {
+		start = 1
+	}
+	if end < start {
+		return nil
+	}
+	return ChapterSet{{Start: start, End: end}}
+}
+
+// String formats the set in the same form accepted by ParseChapterSet
+func (s ChapterSet) String() string {
+	parts := make([]string, 0, len(s))
+	for _, r := range s {
+		if r.Start == r.End {
+			parts = append(parts, strconv.Itoa(r.Start))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", r.Start, r.End))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Add returns the union of both sets
+func (s ChapterSet) Add(other ChapterSet) ChapterSet {
+	merged := make(ChapterSet, 0, len(s)+len(other))
+	merged = append(merged, s...)
+	merged = append(merged, other...)
+	return merged.normalize()
+}
+
+// Remove returns the chapters of s that are not in other
+func (s ChapterSet) Remove(other ChapterSet) ChapterSet {
+	result := make(ChapterSet, 0, len(s))
+	for _, r := range s {
+		pieces := []ChapterRange{r}
+		for _, cut := range other {
+			var next []ChapterRange
+			for _, p := range pieces {
+				if cut.End < p.Start || cut.Start > p.End {
+					next = append(next, p)
+					continue
+				}
+				if cut.Start > p.Start {
+					next = append(next, ChapterRange{Start: p.Start, End: cut.Start - 1})
+				}
+				if cut.End < p.End {
+					next = append(next, ChapterRange{Start: cut.End + 1, End: p.End})
+				}
+			}
+			pieces = next
+		}
+		result = append(result, pieces...)
+	}
+	return result.normalize()
+}
+
+// Contains reports whether the chapter is in the set
+func (s ChapterSet) Contains(chapter int) bool {
+	for _, r := range s {
+		if chapter >= r.Start && chapter <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Count returns the number of chapters in the set
+func (s ChapterSet) Count() int {
+	total := 0
+	for _, r := range s {
+		total += r.End - r.Start + 1
+	}
+	return total
+}
+
+// Max returns the highest chapter in the set, or 0 if empty
+func (s ChapterSet) Max() int {
+	if len(s) == 0 {
+		return 0
+	}
+	return s[len(s)-1].End
+}
+
+// HighestContinuous returns the last chapter of the unbroken run starting at chapter 1
+func (s ChapterSet) HighestContinuous() int {
+	if len(s) == 0 || s[0].Start != 1 {
+		return 0
+	}
+	return s[0].End
+}
+
+// normalize sorts the ranges and merges overlapping or adjacent ones
+func (s ChapterSet) normalize() ChapterSet {
+	if len(s) == 0 {
+		return nil
+	}
+	sorted := make(ChapterSet, len(s))
+	copy(sorted, s)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	merged := ChapterSet{sorted[0]}
+	for _, r := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start <= last.End+1 {
+			if r.End > last.End {
+				last.End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
@@ -0,0 +1,71 @@
+package manga
+
+import "testing"
+
+func TestParseChapterSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "Single range", input: "1-20", want: "1-20"},
+		{name: "Range and chapter", input: "1-20,25", want: "1-20,25"},
+		{name: "Unsorted and overlapping", input: "25, 10-30 ,1-12", want: "1-30"},
+		{name: "Adjacent ranges merge", input: "1-5,6-10,12", want: "1-10,12"},
+		{name: "Empty", input: "", want: ""},
+		{name: "Reversed range", input: "10-5", wantErr: true},
+		{name: "Zero chapter", input: "0-5", wantErr: true},
+		{name: "Not a number", input: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := ParseChapterSet(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %v", tt.input, set)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", tt.input, err)
+			}
+			if set.String() != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, set.String())
+			}
+		})
+	}
+}
+
+func TestChapterSetAddRemove(t *testing.T) {
+	set, _ := ParseChapterSet("1-20,25")
+
+	set = set.Add(ChapterSpan(21, 24))
+	if set.String() != "1-25" {
+		t.Errorf("Expected 1-25 after filling gap, got %s", set.String())
+	}
+
+	removed, _ := ParseChapterSet("5,10-12")
+	set = set.Remove(removed)
+	if set.String() != "1-4,6-9,13-25" {
+		t.Errorf("Expected 1-4,6-9,13-25 after removal, got %s", set.String())
+	}
+
+	if set.Count() != 21 {
+		t.Errorf("Expected 21 chapters, got %d", set.Count())
+	}
+	if set.HighestContinuous() != 4 {
+		t.Errorf("Expected highest continuous 4, got %d", set.HighestContinuous())
+	}
+	if set.Contains(5) || !set.Contains(13) {
+		t.Errorf("Unexpected membership in %s", set.String())
+	}
+}
+
+func TestHighestContinuousSkipsGaps(t *testing.T) {
+	set, _ := ParseChapterSet("2-50")
+	if set.HighestContinuous() != 0 {
+		t.Errorf("Expected 0 when chapter 1 is unread, got %d", set.HighestContinuous())
+	}
+}
@@ -1,6 +1,8 @@
 package manga
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -11,17 +13,27 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// ValidStatuses are the statuses a library entry can have
+var ValidStatuses = map[string]bool{
+	"reading": true, "completed": true, "plan-to-read": true,
+	"on-hold": true, "dropped": true,
+}
+
 type Handler struct {
 	repo              *Repository
 	progressBroadcast chan models.ProgressUpdate
 	udpServer         *udp.Server
+	positions         *PositionTracker
 }
 
-func NewHandler(repo *Repository, progressBroadcast chan models.ProgressUpdate, udpServer *udp.Server) *Handler {
+// NewHandler creates a manga handler. positions may be nil, in which case
+// reading positions are written straight to the database.
+func NewHandler(repo *Repository, progressBroadcast chan models.ProgressUpdate, udpServer *udp.Server, positions *PositionTracker) *Handler {
 	return &Handler{
 		repo:              repo,
 		progressBroadcast: progressBroadcast,
 		udpServer:         udpServer,
+		positions:         positions,
 	}
 }
 
@@ -154,11 +166,7 @@ func (h *Handler) AddToLibrary(c *gin.Context) {
 	}
 
 	// Validate status
-	validStatuses := map[string]bool{
-		"reading": true, "completed": true, "plan-to-read": true,
-		"on-hold": true, "dropped": true,
-	}
-	if !validStatuses[req.Status] {
+	if !ValidStatuses[req.Status] {
 		c.JSON(http.StatusBadRequest, models.Response{
 			Success: false,
 			Error:   "invalid status. must be: reading, completed, plan-to-read, on-hold, or dropped",
@@ -281,9 +289,230 @@ func (h *Handler) UpdateProgress(c *gin.Context) {
 		return
 	}
 
+	var position *models.ReadingPosition
+	if req.Page != nil || req.ScrollPercent != nil {
+		position = &models.ReadingPosition{
+			UserID:    userID,
+			MangaID:   manga.ID,
+			Chapter:   req.Chapter,
+			UpdatedAt: time.Now(),
+		}
+		if req.Page != nil {
+			position.Page = *req.Page
+		}
+		if req.ScrollPercent != nil {
+			position.ScrollPercent = *req.ScrollPercent
+		}
+	}
+
+	// Page turns within an already stored chapter only move the position
+	chapterStored := position != nil && h.positions != nil &&
+		h.positions.ChapterPersisted(userID, manga.ID, req.Chapter)
+
 	// Update progress
-	if err := h.repo.UpdateProgress(userID, req.MangaID, req.Chapter); err != nil {
+	if !chapterStored {
+		if err := h.repo.UpdateProgress(userID, req.MangaID, req.Chapter); err != nil {
+			if err == ErrProgressNotFound {
+				c.JSON(http.StatusNotFound, models.Response{
+					Success: false,
+					Error:   "manga not in library. Add it first",
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, models.Response{
+				Success: false,
+				Error:   "failed to update progress",
+			})
+			return
+		}
+	}
+
+	if position != nil {
+		var err error
+		if h.positions != nil {
+			err = h.positions.Record(*position)
+		} else {
+			err = h.repo.SavePosition(position)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.Response{
+				Success: false,
+				Error:   "failed to save reading position",
+			})
+			return
+		}
+	}
+
+	// Broadcast progress update via TCP (non-blocking)
+	if h.progressBroadcast != nil {
+		update := models.ProgressUpdate{
+			UserID:    userID,
+			MangaID:   req.MangaID,
+			Chapter:   req.Chapter,
+			SessionID: req.SessionID,
+			Timestamp: time.Now().Unix(),
+		}
+		if position != nil {
+			update.Page = position.Page
+			update.ScrollPercent = position.ScrollPercent
+		}
+		select {
+		case h.progressBroadcast <- update:
+		default:
+		}
+	}
+
+	// Send UDP notification to user for chapter changes only
+	if h.udpServer != nil && !chapterStored {
+		notification := models.Notification{
+			Type:      "progress_update",
+			MangaID:   req.MangaID,
+			Message:   "Updated progress to chapter " + string(rune(req.Chapter)),
+			Timestamp: time.Now().Unix(),
+		}
+		h.udpServer.SendNotificationToUser(userID, notification)
+	}
+
+	data := gin.H{
+		"manga_id":    req.MangaID,
+		"chapter":     req.Chapter,
+		"manga_title": manga.Title,
+	}
+	if position != nil {
+		data["page"] = position.Page
+		data["scroll_percent"] = position.ScrollPercent
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "progress updated successfully",
+		Data:    data,
+	})
+}
+
+// GetPosition handles getting the user's position within a manga for resuming
+func (h *Handler) GetPosition(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	mangaID := c.Param("manga_id")
+
+	var position *models.ReadingPosition
+	var err error
+	if h.positions != nil {
+		position, err = h.positions.Get(userID, mangaID)
+	} else {
+		position, err = h.repo.GetPosition(userID, mangaID)
+	}
+	if err != nil {
+		if err == ErrPositionNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Success: false,
+				Error:   "no reading position recorded",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to get reading position",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    position,
+	})
+}
+
+// GetReadChapters handles getting the set of chapters a user has read
+func (h *Handler) GetReadChapters(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	mangaID := c.Param("manga_id")
+
+	progress, err := h.repo.GetProgress(userID, mangaID)
+	if err != nil {
 		if err == ErrProgressNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Success: false,
+				Error:   "manga not in library",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to get read chapters",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    readChaptersData(progress),
+	})
+}
+
+// MarkChaptersRead handles marking chapters or chapter ranges as read
+func (h *Handler) MarkChaptersRead(c *gin.Context) {
+	h.markChapters(c, true)
+}
+
+// MarkChaptersUnread handles unmarking chapters or chapter ranges
+func (h *Handler) MarkChaptersUnread(c *gin.Context) {
+	h.markChapters(c, false)
+}
+
+func (h *Handler) markChapters(c *gin.Context, read bool) {
+	userID := auth.GetUserID(c)
+	mangaID := c.Param("manga_id")
+
+	var req models.MarkChaptersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	chapters, err := ParseChapterSet(req.Chapters)
+	if err != nil || len(chapters) == 0 {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "chapters must be a list of chapters or ranges, e.g. 1-20,25",
+		})
+		return
+	}
+
+	manga, err := h.repo.GetByID(mangaID)
+	if err != nil {
+		if err == ErrMangaNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Success: false,
+				Error:   "manga not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to verify manga",
+		})
+		return
+	}
+
+	if chapters.Max() > manga.TotalChapters {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "chapter number exceeds total chapters",
+			Data: gin.H{
+				"requested_chapter": chapters.Max(),
+				"total_chapters":    manga.TotalChapters,
+			},
+		})
+		return
+	}
+
+	progress, err := h.repo.MarkChapters(userID, mangaID, chapters, read)
+	if err != nil {
+		if errors.Is(err, ErrProgressNotFound) {
 			c.JSON(http.StatusNotFound, models.Response{
 				Success: false,
 				Error:   "manga not in library. Add it first",
@@ -292,17 +521,17 @@ func (h *Handler) UpdateProgress(c *gin.Context) {
 		}
 		c.JSON(http.StatusInternalServerError, models.Response{
 			Success: false,
-			Error:   "failed to update progress",
+			Error:   "failed to update read chapters",
 		})
 		return
 	}
 
-	// Broadcast progress update via TCP (non-blocking)
+	// Broadcast the derived current chapter via TCP (non-blocking)
 	if h.progressBroadcast != nil {
 		update := models.ProgressUpdate{
 			UserID:    userID,
-			MangaID:   req.MangaID,
-			Chapter:   req.Chapter,
+			MangaID:   progress.MangaID,
+			Chapter:   progress.CurrentChapter,
 			Timestamp: time.Now().Unix(),
 		}
 		select {
@@ -311,28 +540,41 @@ func (h *Handler) UpdateProgress(c *gin.Context) {
 		}
 	}
 
-	// Send UDP notification to user
+	message := "chapters marked as read"
+	if !read {
+		message = "chapters marked as unread"
+	}
+
 	if h.udpServer != nil {
 		notification := models.Notification{
 			Type:      "progress_update",
-			MangaID:   req.MangaID,
-			Message:   "Updated progress to chapter " + string(rune(req.Chapter)),
+			MangaID:   progress.MangaID,
+			Message:   fmt.Sprintf("%s: %s %s", manga.Title, chapters.String(), message),
 			Timestamp: time.Now().Unix(),
 		}
 		h.udpServer.SendNotificationToUser(userID, notification)
 	}
 
+	data := readChaptersData(progress)
+	data["manga_title"] = manga.Title
 	c.JSON(http.StatusOK, models.Response{
 		Success: true,
-		Message: "progress updated successfully",
-		Data: gin.H{
-			"manga_id":    req.MangaID,
-			"chapter":     req.Chapter,
-			"manga_title": manga.Title,
-		},
+		Message: message,
+		Data:    data,
 	})
 }
 
+// readChaptersData builds the response payload describing a user's read chapters
+func readChaptersData(progress *models.UserProgress) gin.H {
+	chapters, _ := ParseChapterSet(progress.ReadChapters)
+	return gin.H{
+		"manga_id":        progress.MangaID,
+		"current_chapter": progress.CurrentChapter,
+		"read_chapters":   progress.ReadChapters,
+		"read_count":      chapters.Count(),
+	}
+}
+
 // RemoveFromLibrary handles removing manga from library
 func (h *Handler) RemoveFromLibrary(c *gin.Context) {
 	userID := auth.GetUserID(c)
@@ -356,6 +598,10 @@ func (h *Handler) RemoveFromLibrary(c *gin.Context) {
 		return
 	}
 
+	if h.positions != nil {
+		h.positions.Forget(userID, mangaID)
+	}
+
 	// Send UDP notification
 	if h.udpServer != nil && manga != nil {
 		notification := models.Notification{
@@ -0,0 +1,136 @@
+package manga
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"mangahub/pkg/models"
+)
+
+// PositionTracker keeps the latest reading position per user and manga in memory
+// and writes it to the database at most once per interval, so rapid page turns
+// don't turn into a database write each.
+type PositionTracker struct {
+	repo     *Repository
+	interval time.Duration
+	mu       sync.Mutex
+	entries  map[string]*positionEntry
+}
+
+type positionEntry struct {
+	position models.ReadingPosition
+	dirty    bool      // position changed since the last write
+	written  time.Time // last time the position was written
+}
+
+// NewPositionTracker creates a tracker that flushes positions every interval
+func NewPositionTracker(repo *Repository, interval time.Duration) *PositionTracker {
+	return &PositionTracker{
+		repo:     repo,
+		interval: interval,
+		entries:  make(map[string]*positionEntry),
+	}
+}
+
+// Run periodically flushes pending positions and forgets idle entries
+func (t *PositionTracker) Run() {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		t.flush(false)
+	}
+}
+
+// Flush writes all pending positions immediately
+func (t *PositionTracker) Flush() {
+	t.flush(true)
+}
+
+// ChapterPersisted reports whether chapters 1 through chapter are already
+// stored as read, meaning a position update within it doesn't need a progress
+// write. It asks the database rather than remembering earlier writes, since
+// progress can also be changed by unmarking chapters or editing the library
+// entry.
+func (t *PositionTracker) ChapterPersisted(userID, mangaID string, chapter int) bool {
+	progress, err := t.repo.GetProgress(userID, mangaID)
+	return err == nil && progress.CurrentChapter >= chapter
+}
+
+// Record stores the latest position. It is written through when the chapter
+// changed or the interval has elapsed since the last write, otherwise it is
+// left pending for the next flush.
+func (t *PositionTracker) Record(position models.ReadingPosition) error {
+	key := positionKey(position.UserID, position.MangaID)
+
+	t.mu.Lock()
+	entry, ok := t.entries[key]
+	if !ok {
+		entry = &positionEntry{}
+		t.entries[key] = entry
+	}
+	chapterChanged := entry.position.Chapter != position.Chapter
+	entry.position = position
+	if !chapterChanged && time.Since(entry.written) < t.interval {
+		entry.dirty = true
+		t.mu.Unlock()
+		return nil
+	}
+	entry.dirty = false
+	entry.written = time.Now()
+	t.mu.Unlock()
+
+	return t.repo.SavePosition(&position)
+}
+
+// Forget drops any tracked position, e.g. after the manga left the library
+func (t *PositionTracker) Forget(userID, mangaID string) {
+	t.mu.Lock()
+	delete(t.entries, positionKey(userID, mangaID))
+	t.mu.Unlock()
+}
+
+// Get returns the latest known position, preferring one not yet flushed
+func (t *PositionTracker) Get(userID, mangaID string) (*models.ReadingPosition, error) {
+	t.mu.Lock()
+	if entry, ok := t.entries[positionKey(userID, mangaID)]; ok {
+		position := entry.position
+		t.mu.Unlock()
+		return &position, nil
+	}
+	t.mu.Unlock()
+
+	return t.repo.GetPosition(userID, mangaID)
+}
+
+func (t *PositionTracker) flush(all bool) {
+	now := time.Now()
+	var pending []models.ReadingPosition
+
+	t.mu.Lock()
+	for key, entry := range t.entries {
+		if entry.dirty && (all || now.Sub(entry.written) >= t.interval) {
+			pending = append(pending, entry.position)
+			entry.dirty = false
+			entry.written = now
+			continue
+		}
+		// Forget positions nobody has touched for a while
+		if !entry.dirty && now.Sub(entry.written) > 10*t.interval {
+			delete(t.entries, key)
+		}
+	}
+	t.mu.Unlock()
+
+	for i := range pending {
+		if err := t.repo.SavePosition(&pending[i]); err != nil {
+			log.Printf("Error flushing reading position: %v", err)
+		}
+	}
+}
+
+func positionKey(userID, mangaID string) string {
+	return userID + "|" + strings.ToLower(mangaID)
+}
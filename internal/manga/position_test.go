@@ -0,0 +1,114 @@
+package manga
+
+import (
+	"testing"
+	"time"
+
+	"mangahub/pkg/models"
+)
+
+func TestPositionTrackerThrottlesWrites(t *testing.T) {
+	repo := setupTestRepo(t)
+	tracker := NewPositionTracker(repo, time.Hour)
+
+	position := models.ReadingPosition{
+		UserID:    "test-user-1",
+		MangaID:   "test-manga-1",
+		Chapter:   3,
+		Page:      1,
+		UpdatedAt: time.Now(),
+	}
+
+	// First position in a chapter is written through
+	if err := tracker.Record(position); err != nil {
+		t.Fatalf("Failed to record position: %v", err)
+	}
+
+	// Page turns within the interval stay in memory
+	position.Page = 7
+	position.ScrollPercent = 40
+	if err := tracker.Record(position); err != nil {
+		t.Fatalf("Failed to record position: %v", err)
+	}
+
+	stored, err := repo.GetPosition("test-user-1", "test-manga-1")
+	if err != nil {
+		t.Fatalf("Failed to get stored position: %v", err)
+	}
+	if stored.Page != 1 {
+		t.Errorf("Expected stored page 1 before flush, got %d", stored.Page)
+	}
+
+	latest, err := tracker.Get("test-user-1", "TEST-MANGA-1")
+	if err != nil {
+		t.Fatalf("Failed to get tracked position: %v", err)
+	}
+	if latest.Page != 7 || latest.ScrollPercent != 40 {
+		t.Errorf("Expected page 7 at 40%%, got page %d at %.0f%%", latest.Page, latest.ScrollPercent)
+	}
+
+	tracker.Flush()
+
+	stored, _ = repo.GetPosition("test-user-1", "test-manga-1")
+	if stored.Page != 7 {
+		t.Errorf("Expected stored page 7 after flush, got %d", stored.Page)
+	}
+}
+
+func TestChapterPersisted(t *testing.T) {
+	repo := setupTestRepo(t)
+	tracker := NewPositionTracker(repo, time.Hour)
+
+	if tracker.ChapterPersisted("test-user-1", "test-manga-1", 1) {
+		t.Errorf("Expected a manga outside the library to require a progress write")
+	}
+
+	repo.AddToLibrary(&models.UserProgress{
+		UserID:         "test-user-1",
+		MangaID:        "test-manga-1",
+		CurrentChapter: 3,
+		Status:         "reading",
+		UpdatedAt:      time.Now(),
+		StartedAt:      time.Now(),
+	})
+	if !tracker.ChapterPersisted("test-user-1", "TEST-MANGA-1", 3) || !tracker.ChapterPersisted("test-user-1", "test-manga-1", 2) {
+		t.Errorf("Expected chapters up to 3 to be reported as persisted")
+	}
+	if tracker.ChapterPersisted("test-user-1", "test-manga-1", 4) {
+		t.Errorf("Expected chapter 4 to require a progress write")
+	}
+
+	// Unmarking a chapter elsewhere means reading it must be stored again
+	if _, err := repo.MarkChapters("test-user-1", "test-manga-1", ChapterSpan(3, 3), false); err != nil {
+		t.Fatalf("Failed to unmark chapter 3: %v", err)
+	}
+	if tracker.ChapterPersisted("test-user-1", "test-manga-1", 3) {
+		t.Errorf("Expected the unmarked chapter 3 to require a progress write")
+	}
+}
+
+func TestPositionTrackerWritesChapterChanges(t *testing.T) {
+	repo := setupTestRepo(t)
+	tracker := NewPositionTracker(repo, time.Hour)
+
+	position := models.ReadingPosition{
+		UserID:    "test-user-1",
+		MangaID:   "test-manga-1",
+		Chapter:   3,
+		Page:      12,
+		UpdatedAt: time.Now(),
+	}
+	tracker.Record(position)
+
+	position.Chapter = 4
+	position.Page = 1
+	tracker.Record(position)
+
+	stored, err := repo.GetPosition("test-user-1", "test-manga-1")
+	if err != nil {
+		t.Fatalf("Failed to get stored position: %v", err)
+	}
+	if stored.Chapter != 4 || stored.Page != 1 {
+		t.Errorf("Expected chapter 4 page 1 to be written immediately, got chapter %d page %d", stored.Chapter, stored.Page)
+	}
+}
@@ -13,6 +13,7 @@ import (
 var (
 	ErrMangaNotFound    = errors.New("manga not found")
 	ErrProgressNotFound = errors.New("progress not found")
+	ErrPositionNotFound = errors.New("position not found")
 )
 
 type Repository struct {
@@ -112,7 +113,7 @@ func (r *Repository) GetByID(id string) (*models.Manga, error) {
 // GetUserLibrary retrieves user's manga library
 func (r *Repository) GetUserLibrary(userID, status string) ([]*models.UserProgress, error) {
 	query := `
-		SELECT up.user_id, up.manga_id, up.current_chapter, up.status, up.rating, up.updated_at, up.started_at
+		SELECT up.user_id, up.manga_id, up.current_chapter, up.read_chapters, up.status, up.rating, up.updated_at, up.started_at, up.completed_at
 		FROM user_progress up
 		WHERE up.user_id = ?
 	`
@@ -138,10 +139,12 @@ func (r *Repository) GetUserLibrary(userID, status string) ([]*models.UserProgre
 			&progress.UserID,
 			&progress.MangaID,
 			&progress.CurrentChapter,
+			&progress.ReadChapters,
 			&progress.Status,
 			&progress.Rating,
 			&progress.UpdatedAt,
 			&progress.StartedAt,
+			&progress.CompletedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan progress: %w", err)
@@ -154,24 +157,42 @@ func (r *Repository) GetUserLibrary(userID, status string) ([]*models.UserProgre
 
 // AddToLibrary adds manga to user's library
 func (r *Repository) AddToLibrary(progress *models.UserProgress) error {
+	// Starting at chapter N means chapters 1..N have been read
+	if progress.ReadChapters == "" {
+		progress.ReadChapters = ChapterSpan(1, progress.CurrentChapter).String()
+	}
+	if progress.Status == "completed" && progress.CompletedAt == nil {
+		// Stored in UTC so completion times compare correctly as text
+		completedAt := progress.UpdatedAt.UTC()
+		progress.CompletedAt = &completedAt
+	}
+
+	// Keep the original completion time when a completed entry is updated again
 	query := `
-		INSERT INTO user_progress (user_id, manga_id, current_chapter, status, rating, updated_at, started_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO user_progress (user_id, manga_id, current_chapter, read_chapters, status, rating, updated_at, started_at, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(user_id, manga_id) DO UPDATE SET
 			current_chapter = excluded.current_chapter,
+			read_chapters = excluded.read_chapters,
 			status = excluded.status,
 			rating = excluded.rating,
-			updated_at = excluded.updated_at
+			updated_at = excluded.updated_at,
+			completed_at = CASE
+				WHEN excluded.status = 'completed' THEN COALESCE(user_progress.completed_at, excluded.completed_at)
+				ELSE NULL
+			END
 	`
 	_, err := r.db.Exec(
 		query,
 		progress.UserID,
 		progress.MangaID,
 		progress.CurrentChapter,
+		progress.ReadChapters,
 		progress.Status,
 		progress.Rating,
 		progress.UpdatedAt,
 		progress.StartedAt,
+		progress.CompletedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to add to library: %w", err)
@@ -179,34 +200,62 @@ func (r *Repository) AddToLibrary(progress *models.UserProgress) error {
 	return nil
 }
 
-// UpdateProgress updates reading progress
+// UpdateProgress marks chapters 1 through chapter as read
 func (r *Repository) UpdateProgress(userID, mangaID string, chapter int) error {
-	query := `
-		UPDATE user_progress 
-		SET current_chapter = ?, updated_at = ?
-		WHERE user_id = ? AND LOWER(manga_id) = LOWER(?)
-	`
-	result, err := r.db.Exec(query, chapter, time.Now(), userID, mangaID)
+	_, err := r.MarkChapters(userID, mangaID, ChapterSpan(1, chapter), true)
+	return err
+}
+
+// MarkChapters marks (read=true) or unmarks (read=false) chapters as read and
+// recomputes current_chapter as the highest continuous chapter read
+func (r *Repository) MarkChapters(userID, mangaID string, chapters ChapterSet, read bool) (*models.UserProgress, error) {
+	tx, err := r.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to update progress: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	rows, err := result.RowsAffected()
+	var storedID, stored string
+	query := `SELECT manga_id, read_chapters FROM user_progress WHERE user_id = ? AND LOWER(manga_id) = LOWER(?)`
+	err = tx.QueryRow(query, userID, mangaID).Scan(&storedID, &stored)
+	if err == sql.ErrNoRows {
+		return nil, ErrProgressNotFound
+	}
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to get read chapters: %w", err)
 	}
-	if rows == 0 {
-		return ErrProgressNotFound
+
+	current, err := ParseChapterSet(stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse read chapters: %w", err)
+	}
+	if read {
+		current = current.Add(chapters)
+	} else {
+		current = current.Remove(chapters)
 	}
 
-	return nil
+	_, err = tx.Exec(`
+		UPDATE user_progress
+		SET current_chapter = ?, read_chapters = ?, updated_at = ?
+		WHERE user_id = ? AND manga_id = ?
+	`, current.HighestContinuous(), current.String(), time.Now(), userID, storedID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update read chapters: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit read chapters: %w", err)
+	}
+
+	return r.GetProgress(userID, storedID)
 }
 
 // GetProgress retrieves user's progress for a manga
 func (r *Repository) GetProgress(userID, mangaID string) (*models.UserProgress, error) {
 	progress := &models.UserProgress{}
 	query := `
-		SELECT user_id, manga_id, current_chapter, status, rating, updated_at, started_at
+		SELECT user_id, manga_id, current_chapter, read_chapters, status, rating, updated_at, started_at, completed_at
 		FROM user_progress
 		WHERE user_id = ? AND LOWER(manga_id) = LOWER(?)
 	`
@@ -214,10 +263,12 @@ func (r *Repository) GetProgress(userID, mangaID string) (*models.UserProgress,
 		&progress.UserID,
 		&progress.MangaID,
 		&progress.CurrentChapter,
+		&progress.ReadChapters,
 		&progress.Status,
 		&progress.Rating,
 		&progress.UpdatedAt,
 		&progress.StartedAt,
+		&progress.CompletedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, ErrProgressNotFound
@@ -244,5 +295,61 @@ func (r *Repository) RemoveFromLibrary(userID, mangaID string) error {
 		return ErrProgressNotFound
 	}
 
+	_, err = r.db.Exec(`DELETE FROM reading_positions WHERE user_id = ? AND LOWER(manga_id) = LOWER(?)`, userID, mangaID)
+	if err != nil {
+		return fmt.Errorf("failed to remove reading position: %w", err)
+	}
+
 	return nil
 }
+
+// SavePosition stores the user's position within a chapter
+func (r *Repository) SavePosition(position *models.ReadingPosition) error {
+	query := `
+		INSERT INTO reading_positions (user_id, manga_id, chapter, page, scroll_percent, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, manga_id) DO UPDATE SET
+			chapter = excluded.chapter,
+			page = excluded.page,
+			scroll_percent = excluded.scroll_percent,
+			updated_at = excluded.updated_at
+	`
+	_, err := r.db.Exec(
+		query,
+		position.UserID,
+		position.MangaID,
+		position.Chapter,
+		position.Page,
+		position.ScrollPercent,
+		position.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save position: %w", err)
+	}
+	return nil
+}
+
+// GetPosition retrieves the user's position within a manga
+func (r *Repository) GetPosition(userID, mangaID string) (*models.ReadingPosition, error) {
+	position := &models.ReadingPosition{}
+	query := `
+		SELECT user_id, manga_id, chapter, page, scroll_percent, updated_at
+		FROM reading_positions
+		WHERE user_id = ? AND LOWER(manga_id) = LOWER(?)
+	`
+	err := r.db.QueryRow(query, userID, mangaID).Scan(
+		&position.UserID,
+		&position.MangaID,
+		&position.Chapter,
+		&position.Page,
+		&position.ScrollPercent,
+		&position.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrPositionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get position: %w", err)
+	}
+	return position, nil
+}
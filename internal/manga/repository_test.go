@@ -325,3 +325,82 @@ func TestSearchPagination(t *testing.T) {
 		t.Errorf("Pages should have different results")
 	}
 }
+
+func TestMarkChapters(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	progress := &models.UserProgress{
+		UserID:    "test-user-1",
+		MangaID:   "test-manga-1",
+		Status:    "reading",
+		UpdatedAt: time.Now(),
+		StartedAt: time.Now(),
+	}
+	repo.AddToLibrary(progress)
+
+	// Mark chapters out of order
+	chapters, _ := ParseChapterSet("1-20,25")
+	updated, err := repo.MarkChapters("test-user-1", "test-manga-1", chapters, true)
+	if err != nil {
+		t.Fatalf("Failed to mark chapters: %v", err)
+	}
+
+	if updated.ReadChapters != "1-20,25" {
+		t.Errorf("Expected read chapters 1-20,25, got %s", updated.ReadChapters)
+	}
+	if updated.CurrentChapter != 20 {
+		t.Errorf("Expected current chapter 20, got %d", updated.CurrentChapter)
+	}
+
+	// Unmark a chapter in the middle of the continuous run
+	updated, err = repo.MarkChapters("test-user-1", "test-manga-1", ChapterSpan(10, 10), false)
+	if err != nil {
+		t.Fatalf("Failed to unmark chapter: %v", err)
+	}
+
+	if updated.ReadChapters != "1-9,11-20,25" {
+		t.Errorf("Expected read chapters 1-9,11-20,25, got %s", updated.ReadChapters)
+	}
+	if updated.CurrentChapter != 9 {
+		t.Errorf("Expected current chapter 9, got %d", updated.CurrentChapter)
+	}
+
+	// Marking chapters for manga not in library
+	_, err = repo.MarkChapters("test-user-1", "test-manga-2", chapters, true)
+	if err != ErrProgressNotFound {
+		t.Errorf("Expected ErrProgressNotFound, got: %v", err)
+	}
+}
+
+func TestUpdateProgressKeepsReadChapters(t *testing.T) {
+	repo := setupTestRepo(t)
+
+	progress := &models.UserProgress{
+		UserID:         "test-user-1",
+		MangaID:        "test-manga-1",
+		CurrentChapter: 5,
+		Status:         "reading",
+		UpdatedAt:      time.Now(),
+		StartedAt:      time.Now(),
+	}
+	repo.AddToLibrary(progress)
+
+	chapters, _ := ParseChapterSet("30")
+	repo.MarkChapters("test-user-1", "test-manga-1", chapters, true)
+
+	if err := repo.UpdateProgress("test-user-1", "test-manga-1", 29); err != nil {
+		t.Fatalf("Failed to update progress: %v", err)
+	}
+
+	retrieved, err := repo.GetProgress("test-user-1", "test-manga-1")
+	if err != nil {
+		t.Fatalf("Failed to get progress: %v", err)
+	}
+
+	if retrieved.ReadChapters != "1-30" {
+		t.Errorf("Expected read chapters 1-30, got %s", retrieved.ReadChapters)
+	}
+	if retrieved.CurrentChapter != 30 {
+		t.Errorf("Expected current chapter 30, got %d", retrieved.CurrentChapter)
+	}
+}
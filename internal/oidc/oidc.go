@@ -0,0 +1,361 @@
+// Package oidc signs users in with an external OpenID Connect provider using
+// the authorization code flow with PKCE.
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"mangahub/internal/auth"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	// LoginTTL is how long a started login waits for the provider's redirect
+	LoginTTL = 10 * time.Minute
+
+	// maxPendingLogins bounds the logins waiting for a redirect at once
+	maxPendingLogins = 10000
+
+	// clockSkew is how far the provider's clock may be off from ours
+	clockSkew = time.Minute
+)
+
+var (
+	ErrNotConfigured   = errors.New("single sign-on is not configured")
+	ErrInvalidRedirect = errors.New("redirect_uri must be a loopback http URL with a port")
+	ErrInvalidState    = errors.New("unknown or expired login state")
+	ErrTooManyLogins   = errors.New("too many logins in progress")
+	ErrDiscoveryFailed = errors.New("failed to discover provider")
+	ErrExchangeFailed  = errors.New("failed to exchange authorization code")
+	ErrInvalidIDToken  = errors.New("invalid ID token")
+)
+
+var (
+	defaultScopes = []string{"openid", "email", "profile"}
+
+	// idTokenAlgorithms are the signatures accepted on ID tokens
+	idTokenAlgorithms = []string{"RS256", "EdDSA"}
+)
+
+// Config names the provider and the client registered with it
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string // defaults to openid, email and profile
+}
+
+// Identity is the user an ID token was issued for
+type Identity struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+// metadata is the part of the provider's discovery document we use
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// pendingLogin is a login waiting for the provider to redirect back
+type pendingLogin struct {
+	verifier    string
+	nonce       string
+	redirectURI string
+	expiresAt   time.Time
+}
+
+type idTokenClaims struct {
+	Nonce             string      `json:"nonce"`
+	Email             string      `json:"email"`
+	EmailVerified     interface{} `json:"email_verified"` // some providers send a string
+	Name              string      `json:"name"`
+	PreferredUsername string      `json:"preferred_username"`
+	AuthorizedParty   string      `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+// Provider runs logins against one OpenID Connect provider. The discovery
+// document is fetched on first use, so the server starts even while the
+// provider is unreachable.
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mu       sync.Mutex
+	metadata *metadata
+	keys     *auth.JWKSVerifier
+	pending  map[string]*pendingLogin
+}
+
+// NewProvider creates a provider client
+func NewProvider(config Config) *Provider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = defaultScopes
+	}
+	return &Provider{
+		config:  config,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		pending: make(map[string]*pendingLogin),
+	}
+}
+
+// AuthCodeURL starts a login that the provider will redirect to redirectURI,
+// and returns the URL to open in a browser and the state identifying the login
+func (p *Provider) AuthCodeURL(redirectURI string) (authURL, state string, err error) {
+	if p == nil {
+		return "", "", ErrNotConfigured
+	}
+	if !IsLoopbackRedirect(redirectURI) {
+		return "", "", ErrInvalidRedirect
+	}
+	meta, err := p.discover()
+	if err != nil {
+		return "", "", err
+	}
+
+	var secrets [3]string
+	for i := range secrets {
+		if secrets[i], err = randomString(); err != nil {
+			return "", "", fmt.Errorf("failed to generate login state: %w", err)
+		}
+	}
+	state = secrets[0]
+	login := &pendingLogin{
+		verifier:    secrets[1],
+		nonce:       secrets[2],
+		redirectURI: redirectURI,
+		expiresAt:   time.Now().Add(LoginTTL),
+	}
+
+	p.mu.Lock()
+	p.prune(time.Now())
+	if len(p.pending) >= maxPendingLogins {
+		p.mu.Unlock()
+		return "", "", ErrTooManyLogins
+	}
+	p.pending[state] = login
+	p.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(login.verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {login.nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return meta.AuthorizationEndpoint + separator + query.Encode(), state, nil
+}
+
+// Exchange finishes the login identified by state: it trades the code for
+// tokens and returns the identity in the verified ID token. Each state can
+// be used once.
+func (p *Provider) Exchange(state, code string) (*Identity, error) {
+	if p == nil {
+		return nil, ErrNotConfigured
+	}
+
+	p.mu.Lock()
+	login, ok := p.pending[state]
+	delete(p.pending, state)
+	p.mu.Unlock()
+	if !ok || time.Now().After(login.expiresAt) {
+		return nil, ErrInvalidState
+	}
+
+	meta, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {login.redirectURI},
+		"code_verifier": {login.verifier},
+		"client_id":     {p.config.ClientID},
+	}
+	req, err := http.NewRequest("POST", meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchangeFailed, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchangeFailed, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%w: status %d", ErrExchangeFailed, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrExchangeFailed, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id_token in response", ErrExchangeFailed)
+	}
+
+	return p.verifyIDToken(body.IDToken, login.nonce, meta.Issuer)
+}
+
+// verifyIDToken checks the token's signature, issuer, audience, expiry and nonce
+func (p *Provider) verifyIDToken(raw, nonce, issuer string) (*Identity, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods(idTokenAlgorithms), jwt.WithoutClaimsValidation())
+	token, err := parser.ParseWithClaims(raw, &idTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		public, algorithm, err := p.keys.PublicKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		// Providers may leave alg out of their keys; the key type still has to fit
+		if algorithm != "" && algorithm != token.Method.Alg() {
+			return nil, ErrInvalidIDToken
+		}
+		return public, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	claims, ok := token.Claims.(*idTokenClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidIDToken
+	}
+
+	now := time.Now()
+	switch {
+	case claims.Issuer != issuer:
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidIDToken)
+	case !claims.VerifyAudience(p.config.ClientID, true):
+		return nil, fmt.Errorf("%w: not issued for this client", ErrInvalidIDToken)
+	case len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientID:
+		return nil, fmt.Errorf("%w: not issued for this client", ErrInvalidIDToken)
+	case claims.ExpiresAt == nil || claims.ExpiresAt.Add(clockSkew).Before(now):
+		return nil, fmt.Errorf("%w: expired", ErrInvalidIDToken)
+	case claims.IssuedAt != nil && claims.IssuedAt.After(now.Add(clockSkew)):
+		return nil, fmt.Errorf("%w: issued in the future", ErrInvalidIDToken)
+	case claims.Nonce != nonce:
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+
+	verified := false
+	switch v := claims.EmailVerified.(type) {
+	case bool:
+		verified = v
+	case string:
+		verified = v == "true"
+	}
+
+	return &Identity{
+		Issuer:            claims.Issuer,
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     verified,
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+// discover fetches the provider's discovery document once it is needed, and
+// again on the next login if that failed
+func (p *Provider) discover() (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	resp, err := p.client.Get(strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscoveryFailed, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrDiscoveryFailed, resp.StatusCode)
+	}
+
+	var meta metadata
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscoveryFailed, err)
+	}
+	if meta.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("%w: issuer %q doesn't match %q", ErrDiscoveryFailed, meta.Issuer, p.config.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete discovery document", ErrDiscoveryFailed)
+	}
+
+	p.metadata = &meta
+	p.keys = auth.NewJWKSVerifier(meta.JWKSURI)
+	return p.metadata, nil
+}
+
+// prune drops logins whose redirect never came
+func (p *Provider) prune(now time.Time) {
+	for state, login := range p.pending {
+		if now.After(login.expiresAt) {
+			delete(p.pending, state)
+		}
+	}
+}
+
+// IsLoopbackRedirect reports whether uri is an http URL on a loopback
+// address with an explicit port, where a native app such as the CLI can
+// receive the provider's redirect (RFC 8252)
+func IsLoopbackRedirect(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "http" || u.Port() == "" || u.User != nil || u.Fragment != "" {
+		return false
+	}
+	if u.Hostname() == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(u.Hostname())
+	return ip != nil && ip.IsLoopback()
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
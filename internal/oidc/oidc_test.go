@@ -0,0 +1,119 @@
+package oidc
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"mangahub/internal/oidc/oidctest"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const redirectURI = "http://127.0.0.1:53682/callback"
+
+func setupProvider(t *testing.T) (*Provider, *oidctest.Provider) {
+	mock, err := oidctest.NewProvider("mangahub", "client-secret")
+	if err != nil {
+		t.Fatalf("Failed to start mock provider: %v", err)
+	}
+	t.Cleanup(mock.Close)
+	mock.SetUser(oidctest.User{Subject: "user-1", Email: "reader@example.com", EmailVerified: true, PreferredUsername: "reader"})
+
+	return NewProvider(Config{Issuer: mock.URL, ClientID: "mangahub", ClientSecret: "client-secret"}), mock
+}
+
+func TestLogin(t *testing.T) {
+	provider, mock := setupProvider(t)
+
+	authURL, state, err := provider.AuthCodeURL(redirectURI)
+	if err != nil {
+		t.Fatalf("Failed to start login: %v", err)
+	}
+	u, _ := url.Parse(authURL)
+	if u.Query().Get("code_challenge") == "" || u.Query().Get("nonce") == "" || u.Query().Get("scope") != "openid email profile" {
+		t.Errorf("Expected PKCE, nonce and scopes in %s", authURL)
+	}
+
+	gotState, code, err := mock.Login(authURL)
+	if err != nil {
+		t.Fatalf("Provider rejected the login: %v", err)
+	}
+	if gotState != state {
+		t.Fatalf("Expected state %q back, got %q", state, gotState)
+	}
+
+	identity, err := provider.Exchange(state, code)
+	if err != nil {
+		t.Fatalf("Failed to exchange code: %v", err)
+	}
+	want := Identity{Issuer: mock.URL, Subject: "user-1", Email: "reader@example.com", EmailVerified: true, PreferredUsername: "reader"}
+	if *identity != want {
+		t.Errorf("Expected %+v, got %+v", want, *identity)
+	}
+
+	// Each state works once
+	if _, err := provider.Exchange(state, code); err != ErrInvalidState {
+		t.Errorf("Expected a replayed state to be rejected, got %v", err)
+	}
+}
+
+func TestRejectsBadIDTokens(t *testing.T) {
+	provider, mock := setupProvider(t)
+
+	cases := map[string]jwt.MapClaims{
+		"wrong audience": {"aud": "someone-else"},
+		"wrong issuer":   {"iss": "https://evil.example.com"},
+		"wrong nonce":    {"nonce": "replayed"},
+		"expired":        {"exp": 1},
+	}
+	for name, extra := range cases {
+		mock.SetUser(oidctest.User{Subject: "user-1", Email: "reader@example.com", EmailVerified: true, Extra: extra})
+
+		authURL, _, _ := provider.AuthCodeURL(redirectURI)
+		state, code, err := mock.Login(authURL)
+		if err != nil {
+			t.Fatalf("%s: provider rejected the login: %v", name, err)
+		}
+		if _, err := provider.Exchange(state, code); !errors.Is(err, ErrInvalidIDToken) {
+			t.Errorf("%s: expected ErrInvalidIDToken, got %v", name, err)
+		}
+	}
+}
+
+func TestExchangeNeedsMatchingLogin(t *testing.T) {
+	provider, mock := setupProvider(t)
+
+	// A code issued for another login fails PKCE at the provider
+	first, _, _ := provider.AuthCodeURL(redirectURI)
+	_, code, _ := mock.Login(first)
+	_, otherState, _ := provider.AuthCodeURL(redirectURI)
+	if _, err := provider.Exchange(otherState, code); !errors.Is(err, ErrExchangeFailed) {
+		t.Errorf("Expected a code from another login to fail, got %v", err)
+	}
+
+	if _, err := provider.Exchange("made-up", code); err != ErrInvalidState {
+		t.Errorf("Expected an unknown state to be rejected, got %v", err)
+	}
+}
+
+func TestLoopbackRedirects(t *testing.T) {
+	for uri, want := range map[string]bool{
+		"http://127.0.0.1:8000/callback": true,
+		"http://localhost:9999/":         true,
+		"http://[::1]:8000/callback":     true,
+		"http://127.0.0.1/callback":      false,
+		"https://example.com:443/cb":     false,
+		"http://example.com:8000/cb":     false,
+		"javascript:alert(1)":            false,
+	} {
+		if got := IsLoopbackRedirect(uri); got != want {
+			t.Errorf("IsLoopbackRedirect(%q) = %v, want %v", uri, got, want)
+		}
+	}
+
+	var unconfigured *Provider
+	if _, _, err := unconfigured.AuthCodeURL(redirectURI); err != ErrNotConfigured {
+		t.Errorf("Expected ErrNotConfigured, got %v", err)
+	}
+}
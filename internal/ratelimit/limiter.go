@@ -0,0 +1,186 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrInvalidLimit = errors.New("invalid rate limit")
+
+// sweepInterval is how often idle buckets and old lockouts are dropped
+const sweepInterval = time.Minute
+
+// Limit is a token bucket: Burst requests at once, refilled at Rate per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute allows n requests a minute, all of which may come at once
+func PerMinute(n int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: n}
+}
+
+// ParseLimit parses a limit written as "<requests>/<s|m|h>", such as "10/m"
+func ParseLimit(s string) (Limit, error) {
+	count, unit, ok := strings.Cut(strings.TrimSpace(s), "/")
+	n, err := strconv.Atoi(count)
+	if !ok || err != nil || n <= 0 {
+		return Limit{}, ErrInvalidLimit
+	}
+
+	var period time.Duration
+	switch unit {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return Limit{}, ErrInvalidLimit
+	}
+	return Limit{Rate: float64(n) / period.Seconds(), Burst: n}, nil
+}
+
+// Limiter keeps a token bucket per key, such as a client IP or user ID
+type Limiter struct {
+	limit     Limit
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewLimiter creates a limiter applying the same limit to every key
+func NewLimiter(limit Limit) *Limiter {
+	return &Limiter{
+		limit:     limit,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Allow takes a token from the key's bucket. When the bucket is empty it
+// returns false and how long until a token is available. A nil limiter
+// allows everything.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit.Burst), updated: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(float64(l.limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*l.limit.Rate)
+		b.updated = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.limit.Rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep drops buckets that have refilled, since they behave like new ones
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	full := time.Duration(float64(l.limit.Burst) / l.limit.Rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Route classes with their own limits
+const (
+	ClassIP     = "ip"     // every HTTP request, per client IP
+	ClassAuth   = "auth"   // login, registration and password reset, per IP
+	ClassSearch = "search" // public catalog, per IP
+	ClassAPI    = "api"    // authenticated HTTP routes, per user
+	ClassChat   = "chat"   // WebSocket chat messages, per user
+	ClassGRPC   = "grpc"   // gRPC calls, per user or IP
+	ClassUDP    = "udp"    // UDP register and ping packets, per IP
+)
+
+// Config holds the limit for each route class
+type Config map[string]Limit
+
+// DefaultConfig returns the limits used unless configured otherwise
+func DefaultConfig() Config {
+	return Config{
+		ClassIP:     PerMinute(300),
+		ClassAuth:   PerMinute(10),
+		ClassSearch: PerMinute(60),
+		ClassAPI:    PerMinute(120),
+		ClassChat:   PerMinute(30),
+		ClassGRPC:   PerMinute(120),
+		ClassUDP:    PerMinute(30),
+	}
+}
+
+// ParseConfig overrides the default limits with a list like "auth=5/m,search=2/s"
+func ParseConfig(s string) (Config, error) {
+	cfg := DefaultConfig()
+	for _, item := range strings.Split(s, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		class, value, ok := strings.Cut(item, "=")
+		class = strings.TrimSpace(class)
+		if _, known := cfg[class]; !ok || !known {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidLimit, item)
+		}
+		limit, err := ParseLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", err, item)
+		}
+		cfg[class] = limit
+	}
+	return cfg, nil
+}
+
+// Limiters holds a limiter per route class
+type Limiters struct {
+	limiters map[string]*Limiter
+}
+
+// New creates a limiter for every class in cfg
+func New(cfg Config) *Limiters {
+	limiters := make(map[string]*Limiter, len(cfg))
+	for class, limit := range cfg {
+		limiters[class] = NewLimiter(limit)
+	}
+	return &Limiters{limiters: limiters}
+}
+
+// Get returns the limiter of a route class
+func (s *Limiters) Get(class string) *Limiter {
+	return s.limiters[class]
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable time source for limiters and lockouts
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func TestLimiterAllow(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	l := NewLimiter(PerMinute(3))
+	l.now = clock.now
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("ip:1"); !ok {
+			t.Fatalf("Expected request %d to be allowed", i+1)
+		}
+	}
+
+	ok, wait := l.Allow("ip:1")
+	if ok {
+		t.Fatal("Expected the fourth request to be limited")
+	}
+	if wait != 20*time.Second {
+		t.Errorf("Expected to wait 20s for a token, got %v", wait)
+	}
+
+	// Other keys have their own bucket
+	if ok, _ := l.Allow("ip:2"); !ok {
+		t.Error("Expected another key to be allowed")
+	}
+
+	clock.t = clock.t.Add(20 * time.Second)
+	if ok, _ := l.Allow("ip:1"); !ok {
+		t.Error("Expected a token to be available after waiting")
+	}
+	if ok, _ := l.Allow("ip:1"); ok {
+		t.Error("Expected only one token to have refilled")
+	}
+}
+
+func TestNilLimiterAllowsEverything(t *testing.T) {
+	var l *Limiter
+	if ok, _ := l.Allow("anyone"); !ok {
+		t.Error("Expected a nil limiter to allow requests")
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig("auth=5/m, search=2/s")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	if cfg[ClassAuth] != PerMinute(5) {
+		t.Errorf("Expected auth limit 5/m, got %+v", cfg[ClassAuth])
+	}
+	if cfg[ClassSearch] != (Limit{Rate: 2, Burst: 2}) {
+		t.Errorf("Expected search limit 2/s, got %+v", cfg[ClassSearch])
+	}
+	if cfg[ClassAPI] != DefaultConfig()[ClassAPI] {
+		t.Errorf("Expected unlisted classes to keep their default")
+	}
+
+	for _, bad := range []string{"auth", "auth=5", "auth=0/m", "auth=5/d", "unknown=5/m"} {
+		if _, err := ParseConfig(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
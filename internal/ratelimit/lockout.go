@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// failureMemory is how long failures are remembered without another one
+const failureMemory = 24 * time.Hour
+
+// Lockout locks a key, such as a username, after repeated failures. Each
+// failure past the threshold doubles the lock, up to a maximum.
+type Lockout struct {
+	threshold int
+	base      time.Duration
+	max       time.Duration
+	mu        sync.Mutex
+	entries   map[string]*lockEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type lockEntry struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// NewLockout locks a key for base once it fails threshold times in a row
+func NewLockout(threshold int, base, max time.Duration) *Lockout {
+	return &Lockout{
+		threshold: threshold,
+		base:      base,
+		max:       max,
+		entries:   make(map[string]*lockEntry),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Locked returns how long the key stays locked, or 0 if it isn't
+func (l *Lockout) Locked(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[key]
+	if !ok {
+		return 0
+	}
+	if wait := entry.lockedUntil.Sub(l.now()); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// Failure records a failed attempt and returns how long the key is now locked
+func (l *Lockout) Failure(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	entry, ok := l.entries[key]
+	if !ok {
+		entry = &lockEntry{}
+		l.entries[key] = entry
+	}
+	entry.failures++
+	entry.lastFailure = now
+
+	if entry.failures < l.threshold {
+		return 0
+	}
+	lock := l.base
+	for i := l.threshold; i < entry.failures && lock < l.max; i++ {
+		lock *= 2
+	}
+	if lock > l.max {
+		lock = l.max
+	}
+	entry.lockedUntil = now.Add(lock)
+	return lock
+}
+
+// Success forgets the key's failures
+func (l *Lockout) Success(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, key)
+}
+
+func (l *Lockout) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, entry := range l.entries {
+		if now.Sub(entry.lastFailure) > failureMemory && now.After(entry.lockedUntil) {
+			delete(l.entries, key)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutProgressive(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	l := NewLockout(3, time.Minute, 5*time.Minute)
+	l.now = clock.now
+
+	for i := 0; i < 2; i++ {
+		if lock := l.Failure("reader"); lock != 0 {
+			t.Fatalf("Expected no lock after %d failures, got %v", i+1, lock)
+		}
+	}
+
+	// Each failure from the threshold on doubles the lock, up to the maximum
+	for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute} {
+		if lock := l.Failure("reader"); lock != want {
+			t.Errorf("Expected a %v lock, got %v", want, lock)
+		}
+	}
+	if wait := l.Locked("reader"); wait != 5*time.Minute {
+		t.Errorf("Expected to be locked for 5m, got %v", wait)
+	}
+	if wait := l.Locked("someone-else"); wait != 0 {
+		t.Errorf("Expected other keys to be unlocked, got %v", wait)
+	}
+
+	clock.t = clock.t.Add(5 * time.Minute)
+	if wait := l.Locked("reader"); wait != 0 {
+		t.Errorf("Expected the lock to expire, got %v", wait)
+	}
+
+	l.Success("reader")
+	if lock := l.Failure("reader"); lock != 0 {
+		t.Errorf("Expected success to reset failures, got %v", lock)
+	}
+}
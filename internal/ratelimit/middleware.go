@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"mangahub/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc picks the bucket a request counts against
+type KeyFunc func(c *gin.Context) string
+
+// ByIP counts requests per client IP
+func ByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUser counts requests per authenticated user, falling back to the client IP.
+// It must run after the JWT middleware.
+func ByUser(c *gin.Context) string {
+	if userID := c.GetString("user_id"); userID != "" {
+		return "user:" + userID
+	}
+	return ByIP(c)
+}
+
+// Middleware rejects requests over the limiter's limit with 429 Too Many Requests
+func Middleware(l *Limiter, key KeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ok, wait := l.Allow(key(c)); !ok {
+			Reject(c, wait)
+			return
+		}
+		c.Next()
+	}
+}
+
+// Reject answers 429 Too Many Requests with a Retry-After header
+func Reject(c *gin.Context, wait time.Duration) {
+	seconds := RetryAfterSeconds(wait)
+	c.Header("Retry-After", fmt.Sprint(seconds))
+	c.JSON(http.StatusTooManyRequests, models.Response{
+		Success: false,
+		Error:   fmt.Sprintf("too many requests, retry in %d seconds", seconds),
+	})
+	c.Abort()
+}
+
+// RetryAfterSeconds rounds a wait up to whole seconds, as Retry-After expects
+func RetryAfterSeconds(wait time.Duration) int {
+	return int(math.Max(1, math.Ceil(wait.Seconds())))
+}
@@ -0,0 +1,254 @@
+package session
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"mangahub/internal/auth"
+	"mangahub/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	repo *Repository
+}
+
+func NewHandler(repo *Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+// StartSession handles starting a manual reading session. Any session the user
+// still has open is ended first.
+func (h *Handler) StartSession(c *gin.Context) {
+	userID := auth.GetUserID(c)
+
+	var req models.StartSessionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.Response{
+				Success: false,
+				Error:   "invalid request data",
+			})
+			return
+		}
+	}
+
+	if req.MangaID != "" {
+		mangaID, err := h.repo.ResolveManga(req.MangaID)
+		if err != nil {
+			if err == ErrMangaNotFound {
+				c.JSON(http.StatusNotFound, models.Response{
+					Success: false,
+					Error:   "manga not found",
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, models.Response{
+				Success: false,
+				Error:   "failed to get manga",
+			})
+			return
+		}
+		req.MangaID = mangaID
+	}
+
+	now := time.Now()
+	if active, err := h.repo.GetActive(userID); err == nil {
+		h.repo.End(active, now)
+	}
+
+	session := &models.ReadingSession{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		MangaID:        req.MangaID,
+		Source:         SourceManual,
+		StartedAt:      now,
+		LastActivityAt: now,
+	}
+	if err := h.repo.Create(session); err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to start session",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{
+		Success: true,
+		Message: "reading session started",
+		Data:    session,
+	})
+}
+
+// UpdateSession handles updating a reading session; currently only ending it
+func (h *Handler) UpdateSession(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	sessionID := c.Param("id")
+
+	var req models.UpdateSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "invalid request data",
+		})
+		return
+	}
+
+	session, err := h.repo.GetByID(userID, sessionID)
+	if err != nil {
+		h.sessionError(c, err)
+		return
+	}
+
+	if !req.Ended {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "only ending a session is supported",
+		})
+		return
+	}
+
+	if err := h.repo.End(session, time.Now()); err != nil {
+		if err == ErrSessionEnded {
+			c.JSON(http.StatusConflict, models.Response{
+				Success: false,
+				Error:   "session already ended",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to end session",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "reading session ended",
+		Data:    session,
+	})
+}
+
+// GetSession handles getting a session with the chapters it covered
+func (h *Handler) GetSession(c *gin.Context) {
+	userID := auth.GetUserID(c)
+
+	session, err := h.repo.GetByID(userID, c.Param("id"))
+	if err != nil {
+		h.sessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    session,
+	})
+}
+
+// ListSessions handles listing the user's recent sessions
+func (h *Handler) ListSessions(c *gin.Context) {
+	userID := auth.GetUserID(c)
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit > 100 {
+		limit = 100
+	}
+
+	sessions, err := h.repo.List(userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to list sessions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data: gin.H{
+			"sessions": sessions,
+			"count":    len(sessions),
+		},
+	})
+}
+
+// GetSummary handles aggregating reading time per day, manga and genre.
+// Query parameters: from, to (YYYY-MM-DD, default last 7 days) and tz (IANA name, default UTC).
+func (h *Handler) GetSummary(c *gin.Context) {
+	userID := auth.GetUserID(c)
+
+	loc, err := time.LoadLocation(c.DefaultQuery("tz", "UTC"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "invalid time zone",
+		})
+		return
+	}
+
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	from := today.AddDate(0, 0, -6)
+	to := today.AddDate(0, 0, 1)
+
+	if value := c.Query("from"); value != "" {
+		if from, err = time.ParseInLocation("2006-01-02", value, loc); err != nil {
+			c.JSON(http.StatusBadRequest, models.Response{
+				Success: false,
+				Error:   "invalid from date, expected YYYY-MM-DD",
+			})
+			return
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if to, err = time.ParseInLocation("2006-01-02", value, loc); err != nil {
+			c.JSON(http.StatusBadRequest, models.Response{
+				Success: false,
+				Error:   "invalid to date, expected YYYY-MM-DD",
+			})
+			return
+		}
+		// Include the whole "to" day
+		to = to.AddDate(0, 0, 1)
+	}
+
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "from must be before to",
+		})
+		return
+	}
+
+	summary, err := h.repo.Summarize(userID, from, to, loc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to summarize sessions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    summary,
+	})
+}
+
+func (h *Handler) sessionError(c *gin.Context, err error) {
+	if err == ErrSessionNotFound {
+		c.JSON(http.StatusNotFound, models.Response{
+			Success: false,
+			Error:   "session not found",
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, models.Response{
+		Success: false,
+		Error:   "failed to get session",
+	})
+}
@@ -0,0 +1,400 @@
+package session
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"mangahub/pkg/models"
+)
+
+const (
+	SourceManual   = "manual"
+	SourceInferred = "inferred"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionEnded    = errors.New("session already ended")
+	ErrMangaNotFound   = errors.New("manga not found")
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Create creates a new reading session
+func (r *Repository) Create(session *models.ReadingSession) error {
+	query := `
+		INSERT INTO reading_sessions (id, user_id, manga_id, source, started_at, last_activity_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.db.Exec(
+		query,
+		session.ID,
+		session.UserID,
+		session.MangaID,
+		session.Source,
+		session.StartedAt.UTC(),
+		session.LastActivityAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a user's session along with the chapters it covered
+func (r *Repository) GetByID(userID, id string) (*models.ReadingSession, error) {
+	query := `
+		SELECT id, user_id, manga_id, source, started_at, last_activity_at, ended_at, duration_seconds
+		FROM reading_sessions
+		WHERE id = ? AND user_id = ?
+	`
+	session, err := scanSession(r.db.QueryRow(query, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	session.Chapters, err = r.getChapters(session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetActive retrieves the user's most recent session that hasn't ended
+func (r *Repository) GetActive(userID string) (*models.ReadingSession, error) {
+	query := `
+		SELECT id, user_id, manga_id, source, started_at, last_activity_at, ended_at, duration_seconds
+		FROM reading_sessions
+		WHERE user_id = ? AND ended_at IS NULL
+		ORDER BY last_activity_at DESC
+		LIMIT 1
+	`
+	session, err := scanSession(r.db.QueryRow(query, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active session: %w", err)
+	}
+	return session, nil
+}
+
+// List retrieves the user's most recent sessions
+func (r *Repository) List(userID string, limit int) ([]*models.ReadingSession, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+
+	query := `
+		SELECT id, user_id, manga_id, source, started_at, last_activity_at, ended_at, duration_seconds
+		FROM reading_sessions
+		WHERE user_id = ?
+		ORDER BY started_at DESC
+		LIMIT ?
+	`
+	rows, err := r.db.Query(query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*models.ReadingSession
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// RecordActivity links a chapter to an open session and extends its last activity
+func (r *Repository) RecordActivity(sessionID, mangaID string, chapter int, at time.Time) error {
+	// Timestamps are stored in UTC so they compare correctly as text
+	at = at.UTC()
+
+	result, err := r.db.Exec(`
+		UPDATE reading_sessions
+		SET last_activity_at = MAX(last_activity_at, ?)
+		WHERE id = ? AND ended_at IS NULL
+	`, at, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to update session activity: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrSessionEnded
+	}
+
+	if chapter <= 0 {
+		return nil
+	}
+
+	_, err = r.db.Exec(`
+		INSERT OR IGNORE INTO session_chapters (session_id, manga_id, chapter, read_at)
+		VALUES (?, ?, ?, ?)
+	`, sessionID, mangaID, chapter, at)
+	if err != nil {
+		return fmt.Errorf("failed to link chapter to session: %w", err)
+	}
+	return nil
+}
+
+// End ends a session at the given time and stores its duration
+func (r *Repository) End(session *models.ReadingSession, at time.Time) error {
+	if at.Before(session.LastActivityAt) {
+		at = session.LastActivityAt
+	}
+	duration := int64(at.Sub(session.StartedAt).Seconds())
+
+	result, err := r.db.Exec(`
+		UPDATE reading_sessions
+		SET ended_at = ?, duration_seconds = ?
+		WHERE id = ? AND ended_at IS NULL
+	`, at.UTC(), duration, session.ID)
+	if err != nil {
+		return fmt.Errorf("failed to end session: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrSessionEnded
+	}
+
+	session.EndedAt = &at
+	session.DurationSeconds = duration
+	return nil
+}
+
+// EndIdle ends open sessions of the given source with no activity since the cutoff.
+// They are closed at their last activity so idle time isn't counted.
+func (r *Repository) EndIdle(source string, cutoff time.Time) (int, error) {
+	query := `
+		SELECT id, user_id, manga_id, source, started_at, last_activity_at, ended_at, duration_seconds
+		FROM reading_sessions
+		WHERE ended_at IS NULL AND source = ? AND last_activity_at < ?
+	`
+	rows, err := r.db.Query(query, source, cutoff.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to find idle sessions: %w", err)
+	}
+
+	var idle []*models.ReadingSession
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan session: %w", err)
+		}
+		idle = append(idle, session)
+	}
+	rows.Close()
+
+	ended := 0
+	for _, session := range idle {
+		if err := r.End(session, session.LastActivityAt); err != nil && err != ErrSessionEnded {
+			return ended, err
+		}
+		ended++
+	}
+	return ended, nil
+}
+
+// Summarize aggregates time spent reading between from and to, bucketing days in loc
+func (r *Repository) Summarize(userID string, from, to time.Time, loc *time.Location) (*models.ReadingSummary, error) {
+	query := `
+		SELECT id, user_id, manga_id, source, started_at, last_activity_at, ended_at, duration_seconds
+		FROM reading_sessions
+		WHERE user_id = ? AND started_at >= ? AND started_at < ?
+		ORDER BY started_at
+	`
+	rows, err := r.db.Query(query, userID, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	var sessions []*models.ReadingSession
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	rows.Close()
+
+	summary := &models.ReadingSummary{From: from, To: to, Sessions: len(sessions)}
+	perDay := make(map[string]*models.ReadingTotal)
+	perManga := make(map[string]*models.ReadingTotal)
+	perGenre := make(map[string]*models.ReadingTotal)
+
+	for _, session := range sessions {
+		if session.Chapters, err = r.getChapters(session.ID); err != nil {
+			return nil, err
+		}
+
+		seconds := sessionSeconds(session)
+		summary.TotalSeconds += seconds
+
+		day := session.StartedAt.In(loc).Format("2006-01-02")
+		addTotal(perDay, day, seconds, len(session.Chapters))
+
+		// Split the session's time across manga by the chapters read in each
+		chaptersPerManga := make(map[string]int)
+		for _, ch := range session.Chapters {
+			chaptersPerManga[ch.MangaID]++
+		}
+		if len(chaptersPerManga) == 0 && session.MangaID != "" {
+			chaptersPerManga[session.MangaID] = 0
+		}
+
+		total := len(session.Chapters)
+		for mangaID, count := range chaptersPerManga {
+			share := seconds
+			if total > 0 {
+				share = seconds * int64(count) / int64(total)
+			}
+			addTotal(perManga, mangaID, share, count)
+		}
+	}
+
+	// Attach titles and spread each manga's time across its genres
+	for mangaID, total := range perManga {
+		var title, genresJSON string
+		err := r.db.QueryRow(`SELECT title, genres FROM manga WHERE LOWER(id) = LOWER(?)`, mangaID).Scan(&title, &genresJSON)
+		if err != nil {
+			continue
+		}
+		total.Label = title
+
+		var genres []string
+		json.Unmarshal([]byte(genresJSON), &genres)
+		for _, genre := range genres {
+			addTotal(perGenre, genre, total.Seconds, total.Chapters)
+		}
+	}
+
+	summary.PerDay = sortedTotals(perDay, func(a, b models.ReadingTotal) bool { return a.Key < b.Key })
+	bySeconds := func(a, b models.ReadingTotal) bool {
+		if a.Seconds != b.Seconds {
+			return a.Seconds > b.Seconds
+		}
+		return a.Key < b.Key
+	}
+	summary.PerManga = sortedTotals(perManga, bySeconds)
+	summary.PerGenre = sortedTotals(perGenre, bySeconds)
+
+	return summary, nil
+}
+
+// ResolveManga returns the stored ID of a manga, matched case-insensitively
+func (r *Repository) ResolveManga(mangaID string) (string, error) {
+	var id string
+	err := r.db.QueryRow(`SELECT id FROM manga WHERE LOWER(id) = LOWER(?)`, mangaID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", ErrMangaNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get manga: %w", err)
+	}
+	return id, nil
+}
+
+// getChapters retrieves the chapters covered by a session
+func (r *Repository) getChapters(sessionID string) ([]models.SessionChapter, error) {
+	rows, err := r.db.Query(`
+		SELECT manga_id, chapter, read_at
+		FROM session_chapters
+		WHERE session_id = ?
+		ORDER BY read_at
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session chapters: %w", err)
+	}
+	defer rows.Close()
+
+	var chapters []models.SessionChapter
+	for rows.Next() {
+		var ch models.SessionChapter
+		if err := rows.Scan(&ch.MangaID, &ch.Chapter, &ch.ReadAt); err != nil {
+			return nil, fmt.Errorf("failed to scan session chapter: %w", err)
+		}
+		chapters = append(chapters, ch)
+	}
+	return chapters, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSession(row rowScanner) (*models.ReadingSession, error) {
+	session := &models.ReadingSession{}
+	var endedAt sql.NullTime
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.MangaID,
+		&session.Source,
+		&session.StartedAt,
+		&session.LastActivityAt,
+		&endedAt,
+		&session.DurationSeconds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if endedAt.Valid {
+		session.EndedAt = &endedAt.Time
+	}
+	return session, nil
+}
+
+// sessionSeconds returns the time spent in a session, counting open sessions up to their last activity
+func sessionSeconds(session *models.ReadingSession) int64 {
+	if session.EndedAt != nil {
+		return session.DurationSeconds
+	}
+	return int64(session.LastActivityAt.Sub(session.StartedAt).Seconds())
+}
+
+func addTotal(totals map[string]*models.ReadingTotal, key string, seconds int64, chapters int) {
+	total, ok := totals[key]
+	if !ok {
+		total = &models.ReadingTotal{Key: key}
+		totals[key] = total
+	}
+	total.Seconds += seconds
+	total.Chapters += chapters
+}
+
+func sortedTotals(totals map[string]*models.ReadingTotal, less func(a, b models.ReadingTotal) bool) []models.ReadingTotal {
+	result := make([]models.ReadingTotal, 0, len(totals))
+	for _, total := range totals {
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool { return less(result[i], result[j]) })
+	return result
+}
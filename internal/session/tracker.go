@@ -0,0 +1,129 @@
+package session
+
+import (
+	"log"
+	"time"
+
+	"mangahub/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// InferredIdleTimeout ends an inferred session after this long without progress
+	InferredIdleTimeout = 15 * time.Minute
+	// ManualIdleTimeout ends a forgotten manual session after this long without progress
+	ManualIdleTimeout = 2 * time.Hour
+)
+
+// Tracker turns the stream of progress updates into reading sessions. Updates
+// carrying a session ID are attributed to that session; otherwise they extend
+// the user's open session or start an inferred one.
+type Tracker struct {
+	repo      *Repository
+	events    chan models.ProgressUpdate
+	listeners []func(models.ProgressUpdate)
+}
+
+// NewTracker creates a new session tracker
+func NewTracker(repo *Repository) *Tracker {
+	return &Tracker{
+		repo:   repo,
+		events: make(chan models.ProgressUpdate, 256),
+	}
+}
+
+// OnProcessed registers fn to be called after an update has been recorded in a
+// session. It must be called before Run.
+func (t *Tracker) OnProcessed(fn func(models.ProgressUpdate)) {
+	t.listeners = append(t.listeners, fn)
+}
+
+// Observe queues a progress update without blocking the caller
+func (t *Tracker) Observe(update models.ProgressUpdate) {
+	select {
+	case t.events <- update:
+	default:
+		log.Printf("Session tracker queue full, dropping update for user %s", update.UserID)
+	}
+}
+
+// Run processes queued updates and periodically ends idle sessions
+func (t *Tracker) Run() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case update := <-t.events:
+			if err := t.Process(update); err != nil {
+				log.Printf("Error tracking reading session: %v", err)
+				continue
+			}
+			for _, fn := range t.listeners {
+				fn(update)
+			}
+		case <-ticker.C:
+			t.EndIdle(time.Now())
+		}
+	}
+}
+
+// Process attributes a single progress update to a reading session
+func (t *Tracker) Process(update models.ProgressUpdate) error {
+	at := time.Unix(update.Timestamp, 0)
+	if update.Timestamp == 0 {
+		at = time.Now()
+	}
+
+	// Explicitly linked session, as long as it belongs to the user and is open
+	if update.SessionID != "" {
+		session, err := t.repo.GetByID(update.UserID, update.SessionID)
+		if err == nil && session.EndedAt == nil {
+			return t.repo.RecordActivity(session.ID, update.MangaID, update.Chapter, at)
+		}
+	}
+
+	session, err := t.repo.GetActive(update.UserID)
+	if err != nil && err != ErrSessionNotFound {
+		return err
+	}
+
+	if session != nil {
+		if at.Sub(session.LastActivityAt) <= idleTimeout(session.Source) {
+			return t.repo.RecordActivity(session.ID, update.MangaID, update.Chapter, at)
+		}
+		if err := t.repo.End(session, session.LastActivityAt); err != nil && err != ErrSessionEnded {
+			return err
+		}
+	}
+
+	session = &models.ReadingSession{
+		ID:             uuid.New().String(),
+		UserID:         update.UserID,
+		MangaID:        update.MangaID,
+		Source:         SourceInferred,
+		StartedAt:      at,
+		LastActivityAt: at,
+	}
+	if err := t.repo.Create(session); err != nil {
+		return err
+	}
+	return t.repo.RecordActivity(session.ID, update.MangaID, update.Chapter, at)
+}
+
+// EndIdle ends sessions that have seen no progress for their idle timeout
+func (t *Tracker) EndIdle(now time.Time) {
+	for _, source := range []string{SourceInferred, SourceManual} {
+		if _, err := t.repo.EndIdle(source, now.Add(-idleTimeout(source))); err != nil {
+			log.Printf("Error ending idle %s sessions: %v", source, err)
+		}
+	}
+}
+
+func idleTimeout(source string) time.Duration {
+	if source == SourceManual {
+		return ManualIdleTimeout
+	}
+	return InferredIdleTimeout
+}
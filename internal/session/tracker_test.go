@@ -0,0 +1,149 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+func setupTestRepo(t *testing.T) *Repository {
+	db, err := database.InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO manga (id, title, author, genres, status, total_chapters, description, cover_url, manga_url, year)
+		VALUES
+			('test-manga-1', 'Test Manga 1', 'Test Author 1', '["Action","Comedy"]', 'ongoing', 100, '', '', '', 2020),
+			('test-manga-2', 'Test Manga 2', 'Test Author 2', '["Romance"]', 'completed', 50, '', '', '', 2019)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	return NewRepository(db)
+}
+
+func progressAt(mangaID string, chapter int, at time.Time) models.ProgressUpdate {
+	return models.ProgressUpdate{
+		UserID:    "test-user-1",
+		MangaID:   mangaID,
+		Chapter:   chapter,
+		Timestamp: at.Unix(),
+	}
+}
+
+func TestTrackerInfersSessions(t *testing.T) {
+	repo := setupTestRepo(t)
+	tracker := NewTracker(repo)
+	start := time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC)
+
+	// Three chapters ten minutes apart form one session
+	for i := 0; i < 3; i++ {
+		if err := tracker.Process(progressAt("test-manga-1", i+1, start.Add(time.Duration(i)*10*time.Minute))); err != nil {
+			t.Fatalf("Failed to process update: %v", err)
+		}
+	}
+
+	// A gap longer than the idle timeout starts a new session
+	if err := tracker.Process(progressAt("test-manga-2", 1, start.Add(2*time.Hour))); err != nil {
+		t.Fatalf("Failed to process update: %v", err)
+	}
+
+	sessions, err := repo.List("test-user-1", 10)
+	if err != nil {
+		t.Fatalf("Failed to list sessions: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(sessions))
+	}
+
+	first, err := repo.GetByID("test-user-1", sessions[1].ID)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if first.Source != SourceInferred {
+		t.Errorf("Expected inferred session, got %s", first.Source)
+	}
+	if first.EndedAt == nil || first.DurationSeconds != 20*60 {
+		t.Errorf("Expected first session to end after 20 minutes, got %d seconds", first.DurationSeconds)
+	}
+	if len(first.Chapters) != 3 {
+		t.Errorf("Expected 3 chapters in first session, got %d", len(first.Chapters))
+	}
+}
+
+func TestTrackerUsesExplicitSession(t *testing.T) {
+	repo := setupTestRepo(t)
+	tracker := NewTracker(repo)
+	start := time.Now().Add(-time.Hour)
+
+	session := &models.ReadingSession{
+		ID:             "manual-1",
+		UserID:         "test-user-1",
+		Source:         SourceManual,
+		StartedAt:      start,
+		LastActivityAt: start,
+	}
+	if err := repo.Create(session); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	// Manual sessions tolerate longer pauses than inferred ones
+	update := progressAt("test-manga-1", 5, start.Add(40*time.Minute))
+	update.SessionID = "manual-1"
+	if err := tracker.Process(update); err != nil {
+		t.Fatalf("Failed to process update: %v", err)
+	}
+
+	// Updates from another user can't be attributed to the session
+	other := progressAt("test-manga-1", 6, start.Add(41*time.Minute))
+	other.UserID = "test-user-2"
+	other.SessionID = "manual-1"
+	if err := tracker.Process(other); err != nil {
+		t.Fatalf("Failed to process update: %v", err)
+	}
+
+	stored, err := repo.GetByID("test-user-1", "manual-1")
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if len(stored.Chapters) != 1 || stored.Chapters[0].Chapter != 5 {
+		t.Errorf("Expected only chapter 5 in manual session, got %+v", stored.Chapters)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	repo := setupTestRepo(t)
+	tracker := NewTracker(repo)
+	start := time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC)
+
+	// 10 minutes split evenly between two manga
+	tracker.Process(progressAt("test-manga-1", 1, start))
+	tracker.Process(progressAt("test-manga-2", 1, start.Add(10*time.Minute)))
+	tracker.EndIdle(start.Add(time.Hour))
+
+	summary, err := repo.Summarize("test-user-1", start.Add(-time.Hour), start.Add(time.Hour), time.UTC)
+	if err != nil {
+		t.Fatalf("Failed to summarize: %v", err)
+	}
+
+	if summary.TotalSeconds != 10*60 || summary.Sessions != 1 {
+		t.Errorf("Expected 600 seconds in 1 session, got %d in %d", summary.TotalSeconds, summary.Sessions)
+	}
+	if len(summary.PerDay) != 1 || summary.PerDay[0].Key != "2024-03-01" {
+		t.Errorf("Expected a single day 2024-03-01, got %+v", summary.PerDay)
+	}
+	if len(summary.PerManga) != 2 || summary.PerManga[0].Seconds != 5*60 {
+		t.Errorf("Expected time split across 2 manga, got %+v", summary.PerManga)
+	}
+	if summary.PerManga[0].Label == "" {
+		t.Errorf("Expected manga titles in summary")
+	}
+	if len(summary.PerGenre) != 3 {
+		t.Errorf("Expected 3 genres, got %+v", summary.PerGenre)
+	}
+}
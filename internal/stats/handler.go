@@ -0,0 +1,56 @@
+package stats
+
+import (
+	"net/http"
+	"time"
+
+	"mangahub/internal/auth"
+	"mangahub/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	repo *Repository
+}
+
+func NewHandler(repo *Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+// GetMyStats handles getting the current user's reading statistics.
+// Query parameters: period (week, month, year, all; default month) and tz (IANA name, default UTC).
+func (h *Handler) GetMyStats(c *gin.Context) {
+	userID := auth.GetUserID(c)
+	period := c.DefaultQuery("period", "month")
+
+	loc, err := time.LoadLocation(c.DefaultQuery("tz", "UTC"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "invalid time zone",
+		})
+		return
+	}
+
+	stats, err := h.repo.GetUserStats(userID, period, time.Now(), loc)
+	if err != nil {
+		if err == ErrInvalidPeriod {
+			c.JSON(http.StatusBadRequest, models.Response{
+				Success: false,
+				Error:   "invalid period. Valid periods: week, month, year, all",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to compute statistics",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data:    stats,
+	})
+}
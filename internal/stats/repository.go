@@ -0,0 +1,267 @@
+package stats
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"mangahub/pkg/models"
+)
+
+var ErrInvalidPeriod = errors.New("invalid period")
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetUserStats computes a user's reading statistics for a period ending at now.
+// Days, streaks and active hours are computed in loc.
+func (r *Repository) GetUserStats(userID, period string, now time.Time, loc *time.Location) (*models.UserStats, error) {
+	now = now.In(loc)
+	from, err := periodStart(period, now)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.UserStats{
+		Period:         period,
+		From:           from,
+		To:             now,
+		StatusCounts:   make(map[string]int),
+		MostActiveHour: -1,
+	}
+
+	if err := r.addLibraryStats(userID, stats); err != nil {
+		return nil, err
+	}
+
+	reads, err := r.getChapterReads(userID)
+	if err != nil {
+		return nil, err
+	}
+	addActivityStats(stats, reads, now, loc)
+
+	return stats, nil
+}
+
+// addLibraryStats fills in status counts, completion rate, ratings and genres
+func (r *Repository) addLibraryStats(userID string, stats *models.UserStats) error {
+	query := `
+		SELECT up.status, up.rating, m.id, m.title, m.genres, m.total_chapters
+		FROM user_progress up
+		JOIN manga m ON m.id = up.manga_id
+		WHERE up.user_id = ?
+	`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get library: %w", err)
+	}
+	defer rows.Close()
+
+	genres := make(map[string]int)
+	ratingSum := 0
+	for rows.Next() {
+		var status, mangaID, title, genresJSON string
+		var rating, totalChapters int
+		if err := rows.Scan(&status, &rating, &mangaID, &title, &genresJSON, &totalChapters); err != nil {
+			return fmt.Errorf("failed to scan library entry: %w", err)
+		}
+
+		stats.LibraryTotal++
+		stats.StatusCounts[status]++
+		if rating > 0 {
+			ratingSum += rating
+			stats.RatedCount++
+		}
+
+		var mangaGenres []string
+		json.Unmarshal([]byte(genresJSON), &mangaGenres)
+		for _, genre := range mangaGenres {
+			genres[genre]++
+		}
+
+		if status == "completed" && (stats.LongestFinished == nil || totalChapters > stats.LongestFinished.TotalChapters) {
+			stats.LongestFinished = &models.FinishedSeries{
+				MangaID:       mangaID,
+				Title:         title,
+				TotalChapters: totalChapters,
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Planned manga haven't been started, so they don't count against completion
+	started := stats.LibraryTotal - stats.StatusCounts["plan-to-read"]
+	if started > 0 {
+		stats.CompletionRate = float64(stats.StatusCounts["completed"]) / float64(started)
+	}
+	if stats.RatedCount > 0 {
+		stats.AverageRating = float64(ratingSum) / float64(stats.RatedCount)
+	}
+
+	for genre, count := range genres {
+		stats.Genres = append(stats.Genres, models.StatCount{Label: genre, Count: count})
+	}
+	sort.Slice(stats.Genres, func(i, j int) bool {
+		if stats.Genres[i].Count != stats.Genres[j].Count {
+			return stats.Genres[i].Count > stats.Genres[j].Count
+		}
+		return stats.Genres[i].Label < stats.Genres[j].Label
+	})
+
+	return nil
+}
+
+// getChapterReads returns when each chapter recorded in a reading session was read
+func (r *Repository) getChapterReads(userID string) ([]time.Time, error) {
+	query := `
+		SELECT sc.read_at
+		FROM session_chapters sc
+		JOIN reading_sessions rs ON rs.id = sc.session_id
+		WHERE rs.user_id = ?
+		ORDER BY sc.read_at
+	`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chapter reads: %w", err)
+	}
+	defer rows.Close()
+
+	var reads []time.Time
+	for rows.Next() {
+		var readAt time.Time
+		if err := rows.Scan(&readAt); err != nil {
+			return nil, fmt.Errorf("failed to scan chapter read: %w", err)
+		}
+		reads = append(reads, readAt)
+	}
+	return reads, rows.Err()
+}
+
+// addActivityStats fills in chapters per bucket, streaks and active hours.
+// Streaks always look at all activity, the rest only at reads within the period.
+func addActivityStats(stats *models.UserStats, reads []time.Time, now time.Time, loc *time.Location) {
+	layout := bucketLayout(stats.Period)
+	buckets := make(map[string]int)
+	days := make(map[string]bool)
+
+	for _, readAt := range reads {
+		local := readAt.In(loc)
+		days[local.Format("2006-01-02")] = true
+
+		if (stats.From != nil && local.Before(*stats.From)) || local.After(now) {
+			continue
+		}
+		stats.ChaptersRead++
+		stats.ActiveHours[local.Hour()]++
+		buckets[local.Format(layout)]++
+	}
+
+	// Emit every bucket in the period so charts show quiet days too
+	if stats.From != nil {
+		for t := *stats.From; !t.After(now); t = nextBucket(stats.Period, t) {
+			label := t.Format(layout)
+			stats.ChaptersPerBucket = append(stats.ChaptersPerBucket, models.StatCount{Label: label, Count: buckets[label]})
+		}
+	} else {
+		for label, count := range buckets {
+			stats.ChaptersPerBucket = append(stats.ChaptersPerBucket, models.StatCount{Label: label, Count: count})
+		}
+		sort.Slice(stats.ChaptersPerBucket, func(i, j int) bool {
+			return stats.ChaptersPerBucket[i].Label < stats.ChaptersPerBucket[j].Label
+		})
+	}
+
+	for hour, count := range stats.ActiveHours {
+		if count > 0 && (stats.MostActiveHour < 0 || count > stats.ActiveHours[stats.MostActiveHour]) {
+			stats.MostActiveHour = hour
+		}
+	}
+
+	stats.CurrentStreak, stats.LongestStreak = Streaks(days, now)
+}
+
+// Streaks returns the current and longest runs of consecutive reading days.
+// days holds dates formatted as YYYY-MM-DD; the current streak may end today or
+// yesterday, so it isn't broken before the user had a chance to read today.
+func Streaks(days map[string]bool, now time.Time) (current, longest int) {
+	sorted := make([]string, 0, len(days))
+	for day := range days {
+		sorted = append(sorted, day)
+	}
+	sort.Strings(sorted)
+
+	run := 0
+	var prev time.Time
+	for i, day := range sorted {
+		t, err := time.Parse("2006-01-02", day)
+		if err != nil {
+			continue
+		}
+		if i > 0 && t.Sub(prev) == 24*time.Hour {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+		prev = t
+	}
+
+	today := now.Format("2006-01-02")
+	yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
+	if len(sorted) > 0 {
+		last := sorted[len(sorted)-1]
+		if last == today || last == yesterday {
+			current = run
+		}
+	}
+	return current, longest
+}
+
+// periodStart returns the first instant of the period, or nil for all time
+func periodStart(period string, now time.Time) (*time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var start time.Time
+	switch period {
+	case "week":
+		start = today.AddDate(0, 0, -6)
+	case "month":
+		start = today.AddDate(0, 0, -29)
+	case "year":
+		start = time.Date(now.Year()-1, now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+	case "all":
+		return nil, nil
+	default:
+		return nil, ErrInvalidPeriod
+	}
+	return &start, nil
+}
+
+func bucketLayout(period string) string {
+	switch period {
+	case "year":
+		return "2006-01"
+	case "all":
+		return "2006"
+	}
+	return "2006-01-02"
+}
+
+func nextBucket(period string, t time.Time) time.Time {
+	if period == "year" {
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
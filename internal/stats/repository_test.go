@@ -0,0 +1,126 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"mangahub/pkg/database"
+)
+
+func setupTestRepo(t *testing.T) *Repository {
+	db, err := database.InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO manga (id, title, author, genres, status, total_chapters, description, cover_url, manga_url, year)
+		VALUES
+			('test-manga-1', 'Test Manga 1', 'Test Author 1', '["Action","Comedy"]', 'completed', 100, '', '', '', 2020),
+			('test-manga-2', 'Test Manga 2', 'Test Author 2', '["Action"]', 'completed', 50, '', '', '', 2019),
+			('test-manga-3', 'Test Manga 3', 'Test Author 3', '["Romance"]', 'ongoing', 80, '', '', '', 2021)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO user_progress (user_id, manga_id, current_chapter, status, rating)
+		VALUES
+			('test-user-1', 'test-manga-1', 100, 'completed', 9),
+			('test-user-1', 'test-manga-2', 50, 'completed', 6),
+			('test-user-1', 'test-manga-3', 10, 'reading', 0)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to seed progress: %v", err)
+	}
+
+	return NewRepository(db)
+}
+
+func TestGetUserStats(t *testing.T) {
+	repo := setupTestRepo(t)
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	_, err := repo.db.Exec(`
+		INSERT INTO reading_sessions (id, user_id, source, started_at, last_activity_at)
+		VALUES ('s1', 'test-user-1', 'inferred', ?, ?)
+	`, now.AddDate(0, 0, -2), now)
+	if err != nil {
+		t.Fatalf("Failed to seed session: %v", err)
+	}
+
+	// Reads on three consecutive days, two of them at 21:00
+	reads := []time.Time{
+		time.Date(2024, 3, 8, 21, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 9, 21, 30, 0, 0, time.UTC),
+		time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC),
+		time.Date(2023, 1, 1, 9, 0, 0, 0, time.UTC),
+	}
+	for i, readAt := range reads {
+		_, err := repo.db.Exec(`
+			INSERT INTO session_chapters (session_id, manga_id, chapter, read_at)
+			VALUES ('s1', 'test-manga-3', ?, ?)
+		`, i+1, readAt)
+		if err != nil {
+			t.Fatalf("Failed to seed chapter read: %v", err)
+		}
+	}
+
+	stats, err := repo.GetUserStats("test-user-1", "week", now, time.UTC)
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+
+	if stats.ChaptersRead != 3 {
+		t.Errorf("Expected 3 chapters read this week, got %d", stats.ChaptersRead)
+	}
+	if len(stats.ChaptersPerBucket) != 7 {
+		t.Errorf("Expected 7 daily buckets, got %d", len(stats.ChaptersPerBucket))
+	}
+	if stats.CompletionRate < 0.66 || stats.CompletionRate > 0.67 {
+		t.Errorf("Expected completion rate 2/3, got %f", stats.CompletionRate)
+	}
+	if stats.AverageRating != 7.5 || stats.RatedCount != 2 {
+		t.Errorf("Expected average rating 7.5 over 2, got %f over %d", stats.AverageRating, stats.RatedCount)
+	}
+	if len(stats.Genres) == 0 || stats.Genres[0].Label != "Action" || stats.Genres[0].Count != 2 {
+		t.Errorf("Expected Action to be the top genre, got %+v", stats.Genres)
+	}
+	if stats.LongestFinished == nil || stats.LongestFinished.MangaID != "test-manga-1" {
+		t.Errorf("Expected test-manga-1 as longest finished, got %+v", stats.LongestFinished)
+	}
+	if stats.CurrentStreak != 3 || stats.LongestStreak != 3 {
+		t.Errorf("Expected streaks 3/3, got %d/%d", stats.CurrentStreak, stats.LongestStreak)
+	}
+	if stats.MostActiveHour != 21 {
+		t.Errorf("Expected most active hour 21, got %d", stats.MostActiveHour)
+	}
+
+	if _, err := repo.GetUserStats("test-user-1", "decade", now, time.UTC); err != ErrInvalidPeriod {
+		t.Errorf("Expected ErrInvalidPeriod, got %v", err)
+	}
+}
+
+func TestStreaks(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	days := map[string]bool{
+		"2024-03-01": true,
+		"2024-03-02": true,
+		"2024-03-03": true,
+		"2024-03-04": true,
+		"2024-03-08": true,
+		"2024-03-09": true,
+	}
+
+	// Not reading yet today doesn't break the streak
+	current, longest := Streaks(days, now)
+	if current != 2 || longest != 4 {
+		t.Errorf("Expected current 2 and longest 4, got %d and %d", current, longest)
+	}
+
+	current, _ = Streaks(days, now.AddDate(0, 0, 2))
+	if current != 0 {
+		t.Errorf("Expected streak to be broken, got %d", current)
+	}
+}
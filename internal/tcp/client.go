@@ -0,0 +1,220 @@
+package tcp
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"mangahub/internal/auth"
+	"mangahub/pkg/protocol"
+)
+
+const (
+	// DefaultQueueSize is how many frames can wait to be written to one connection
+	DefaultQueueSize = 256
+
+	// MinQueueSize fits a goodbye frame and the marker that closes the connection
+	MinQueueSize = 2
+
+	// WriteTimeout bounds each write to a connection
+	WriteTimeout = 5 * time.Second
+)
+
+// OverflowPolicy decides what happens to a frame sent to a connection whose
+// queue is full because the client reads too slowly
+type OverflowPolicy int
+
+const (
+	// Disconnect closes the connection. The client reconnects and resumes
+	// from its last sequence number.
+	Disconnect OverflowPolicy = iota
+	// DropOldest discards the oldest queued frame to make room. The client
+	// sees a gap in sequence numbers.
+	DropOldest
+)
+
+// ParseOverflowPolicy parses "disconnect" or "drop-oldest". An empty name
+// means Disconnect.
+func ParseOverflowPolicy(name string) (OverflowPolicy, error) {
+	switch name {
+	case "", "disconnect":
+		return Disconnect, nil
+	case "drop-oldest":
+		return DropOldest, nil
+	}
+	return Disconnect, fmt.Errorf("unknown overflow policy %q, use disconnect or drop-oldest", name)
+}
+
+func (p OverflowPolicy) String() string {
+	if p == DropOldest {
+		return "drop-oldest"
+	}
+	return "disconnect"
+}
+
+// Client is an authenticated connection. Frames are written by its own
+// goroutine from a bounded queue, so a slow client never holds up others.
+type Client struct {
+	ID        string
+	Conn      net.Conn
+	UserID    string
+	SessionID string    // login the connection was authenticated with
+	ExpiresAt time.Time // when the token expires; zero for tokens that don't
+
+	claims    *auth.Claims // of the token in use, replaced on reauthentication
+
+	expiry    *time.Timer
+	expiryGen int // bumped by each reauthentication to retire old timers
+
+	encoding protocol.Encoding // negotiated in the handshake
+
+	presence bool // declared a device, so it is told about the others
+
+	// Presence, guarded by the server's mutex
+	device   protocol.Presence // declared in the handshake and by now_reading
+	lastSeen time.Time         // when the client last sent a frame
+	away     bool              // disconnected or stopped sending heartbeats
+
+	queue   chan []byte // encoded frames; nil closes the connection
+	policy  OverflowPolicy
+	mu      sync.Mutex
+	closing bool // no more frames are accepted
+	stopped bool
+	dropped int
+	done    chan struct{}
+}
+
+func newClient(id string, conn net.Conn, userID string, queueSize int, policy OverflowPolicy) *Client {
+	return &Client{
+		ID:       id,
+		Conn:     conn,
+		UserID:   userID,
+		queue:    make(chan []byte, queueSize),
+		policy:   policy,
+		done:     make(chan struct{}),
+		encoding: protocol.JSON,
+	}
+}
+
+// Send queues a frame for the client. It returns false if the frame couldn't
+// be queued because the connection is closing or overflowed.
+func (c *Client) Send(msg protocol.Message) bool {
+	data, err := protocol.MarshalFrame(msg, c.encoding)
+	if err != nil {
+		log.Printf("Error marshaling frame for client %s: %v", c.ID, err)
+		return false
+	}
+	return c.enqueue(data)
+}
+
+func (c *Client) enqueue(data []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closing {
+		return false
+	}
+	select {
+	case c.queue <- data:
+		return true
+	default:
+	}
+
+	if c.policy == Disconnect {
+		// Closing the connection stops both the writer and the reader
+		c.closing = true
+		c.Conn.Close()
+		log.Printf("Disconnected client %s: outbound queue full", c.ID)
+		return false
+	}
+	c.pushLocked(data)
+	return true
+}
+
+// CloseAfter queues a last frame and closes the connection once it is written
+func (c *Client) CloseAfter(msg protocol.Message) {
+	data, err := protocol.MarshalFrame(msg, c.encoding)
+	if err != nil {
+		log.Printf("Error marshaling frame for client %s: %v", c.ID, err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closing {
+		return
+	}
+	c.closing = true
+	// The goodbye frame gets room whatever the policy
+	c.pushLocked(data)
+	c.pushLocked(nil)
+}
+
+// pushLocked queues data, dropping the oldest frames until it fits
+func (c *Client) pushLocked(data []byte) {
+	for {
+		select {
+		case c.queue <- data:
+			return
+		default:
+		}
+		select {
+		case <-c.queue:
+			c.dropped++
+		default:
+		}
+	}
+}
+
+// writeLoop writes queued frames until the connection closes, flushing once
+// the queue is empty so bursts go out in few writes
+func (c *Client) writeLoop() {
+	w := bufio.NewWriter(c.Conn)
+	for {
+		select {
+		case data := <-c.queue:
+			c.Conn.SetWriteDeadline(time.Now().Add(WriteTimeout))
+			if data == nil {
+				w.Flush()
+				c.Conn.Close()
+				return
+			}
+			if _, err := w.Write(data); err != nil {
+				c.Conn.Close()
+				return
+			}
+			if len(c.queue) == 0 {
+				if err := w.Flush(); err != nil {
+					// The connection handler removes the client once its read fails
+					c.Conn.Close()
+					return
+				}
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// stop ends the writer once the connection is gone
+func (c *Client) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closing = true
+	if !c.stopped {
+		c.stopped = true
+		close(c.done)
+	}
+}
+
+// queueStats returns how many frames are waiting and how many were dropped
+func (c *Client) queueStats() (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.queue), c.dropped
+}
@@ -0,0 +1,222 @@
+package tcp
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mangahub/pkg/protocol"
+)
+
+// stubConn is a connection that discards what is written, or blocks writes
+// until closed to act as a client that stopped reading
+type stubConn struct {
+	net.Conn
+	stalled   bool
+	written   atomic.Int64
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newStubConn(stalled bool) *stubConn {
+	return &stubConn{stalled: stalled, closed: make(chan struct{})}
+}
+
+func (c *stubConn) Write(p []byte) (int, error) {
+	if c.stalled {
+		<-c.closed
+		return 0, net.ErrClosed
+	}
+	select {
+	case <-c.closed:
+		return 0, net.ErrClosed
+	default:
+	}
+	c.written.Add(int64(len(p)))
+	return len(p), nil
+}
+
+func (c *stubConn) Read(p []byte) (int, error) {
+	<-c.closed
+	return 0, io.EOF
+}
+
+func (c *stubConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *stubConn) SetWriteDeadline(time.Time) error { return nil }
+
+func (c *stubConn) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// addStubClient registers a connection for userID with its writer running
+func addStubClient(s *Server, userID string, conn *stubConn) *Client {
+	client := newClient(fmt.Sprintf("%s_%p", userID, conn), conn, userID, s.queueSize, s.overflow)
+	s.register(client)
+	go client.writeLoop()
+	return client
+}
+
+// waitForQueues waits until every queued frame has been written
+func waitForQueues(tb testing.TB, s *Server) {
+	deadline := time.Now().Add(10 * time.Second)
+	for s.GetStats()["queued_frames"].(int) > 0 {
+		if time.Now().After(deadline) {
+			tb.Fatal("Timed out waiting for queued frames")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSlowClientDoesNotBlockOthers(t *testing.T) {
+	s := NewServer(":0", nil)
+	s.SetQueue(4, Disconnect)
+
+	stalled := newStubConn(true)
+	fast := newStubConn(false)
+	addStubClient(s, "user-1", stalled)
+	fastClient := addStubClient(s, "user-1", fast)
+
+	// The fast client keeps up with every frame while the stalled one fills up
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		s.sendToUser("user-1", "", &protocol.ProgressUpdate{MangaID: "one-piece", Chapter: i + 1})
+		for queued, _ := fastClient.queueStats(); queued > 0; queued, _ = fastClient.queueStats() {
+			time.Sleep(10 * time.Microsecond)
+		}
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("Sending was held up by the stalled client")
+	}
+
+	if !stalled.isClosed() {
+		t.Error("Expected the stalled client to be disconnected once its queue filled")
+	}
+	if fast.isClosed() {
+		t.Error("Expected the fast client to stay connected")
+	}
+	if _, dropped := fastClient.queueStats(); dropped != 0 || fast.written.Load() == 0 {
+		t.Errorf("Expected every frame to reach the fast client, dropped %d", dropped)
+	}
+}
+
+func TestDropOldest(t *testing.T) {
+	s := NewServer(":0", nil)
+	s.SetQueue(4, DropOldest)
+
+	stalled := newStubConn(true)
+	client := addStubClient(s, "user-1", stalled)
+	for i := 0; i < 20; i++ {
+		if !client.Send(&protocol.HeartbeatAck{Timestamp: int64(i)}) {
+			t.Fatalf("Expected frame %d to be queued", i)
+		}
+	}
+
+	if stalled.isClosed() {
+		t.Error("Expected the client to stay connected")
+	}
+	// One frame may already be in the writer, stuck on the stalled write
+	if queued, dropped := client.queueStats(); queued != 4 || dropped < 15 {
+		t.Errorf("Expected a full queue and the oldest frames dropped, got %d queued, %d dropped", queued, dropped)
+	}
+
+	// A goodbye frame still gets through, even with the smallest queue
+	s.SetQueue(1, DropOldest)
+	if s.queueSize != MinQueueSize {
+		t.Errorf("Expected the queue size to be raised to %d, got %d", MinQueueSize, s.queueSize)
+	}
+	// Without a writer, so the queue can be inspected
+	tiny := newClient("tiny", newStubConn(false), "user-1", s.queueSize, s.overflow)
+	tiny.Send(&protocol.HeartbeatAck{Timestamp: 1})
+	tiny.Send(&protocol.HeartbeatAck{Timestamp: 2})
+	tiny.CloseAfter(&protocol.SessionRevoked{Message: "this session was logged out"})
+	if first := <-tiny.queue; !strings.Contains(string(first), "session_revoked") {
+		t.Errorf("Expected the goodbye frame to be kept, got %q", first)
+	}
+
+	client.CloseAfter(&protocol.SessionRevoked{Message: "this session was logged out"})
+	if client.Send(&protocol.HeartbeatAck{Timestamp: 21}) {
+		t.Error("Expected frames after CloseAfter to be refused")
+	}
+}
+
+func TestParseOverflowPolicy(t *testing.T) {
+	for name, want := range map[string]OverflowPolicy{"": Disconnect, "disconnect": Disconnect, "drop-oldest": DropOldest} {
+		if got, err := ParseOverflowPolicy(name); err != nil || got != want {
+			t.Errorf("ParseOverflowPolicy(%q) = %v, %v", name, got, err)
+		}
+	}
+	if _, err := ParseOverflowPolicy("block"); err == nil {
+		t.Error("Expected an unknown policy to be rejected")
+	}
+}
+
+// setupConnections registers 10k connections, two per user
+func setupConnections(b *testing.B) (*Server, []string) {
+	s := NewServer(":0", nil)
+	users := make([]string, 5000)
+	for i := range users {
+		users[i] = fmt.Sprintf("user-%d", i)
+		addStubClient(s, users[i], newStubConn(false))
+		addStubClient(s, users[i], newStubConn(false))
+	}
+	b.Cleanup(func() {
+		for _, client := range s.clients {
+			client.Conn.Close()
+		}
+	})
+	return s, users
+}
+
+// BenchmarkSendToUser measures sending one user's update to their devices
+// among 10k registered connections. The connections are in-memory stubs, so
+// it measures queuing and the writers, not the network.
+func BenchmarkSendToUser(b *testing.B) {
+	s, users := setupConnections(b)
+	frame := &protocol.ProgressUpdate{MangaID: "one-piece", Chapter: 1100}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.sendToUser(users[i%len(users)], "", frame)
+	}
+	waitForQueues(b, s)
+	b.ReportMetric(float64(2*b.N)/b.Elapsed().Seconds(), "frames/s")
+}
+
+// BenchmarkBroadcast10k measures delivering a frame to all 10k connections,
+// with users sending in parallel. Like BenchmarkSendToUser it writes to stub
+// connections, so real sockets and TLS will be slower.
+func BenchmarkBroadcast10k(b *testing.B) {
+	s, users := setupConnections(b)
+	frame := &protocol.ProgressUpdate{MangaID: "one-piece", Chapter: 1100}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var wg sync.WaitGroup
+		for w := 0; w < 8; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for u := w; u < len(users); u += 8 {
+					s.sendToUser(users[u], "", frame)
+				}
+			}(w)
+		}
+		wg.Wait()
+		waitForQueues(b, s)
+	}
+	b.ReportMetric(float64(len(s.clients)*b.N)/b.Elapsed().Seconds(), "frames/s")
+}
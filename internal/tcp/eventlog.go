@@ -0,0 +1,135 @@
+package tcp
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"mangahub/pkg/protocol"
+)
+
+// DefaultEventLimit is how many sync events are kept per user for replay
+const DefaultEventLimit = 500
+
+// ErrResyncRequired means the events after a client's sequence number are no
+// longer kept, so it has to load a full snapshot instead
+var ErrResyncRequired = errors.New("missed events are no longer available, resync required")
+
+// Event is a sync frame sent to a user's devices
+type Event struct {
+	Seq     int64
+	Type    string
+	Payload json.RawMessage // the frame in JSON, including its seq
+}
+
+// EventLog numbers each user's sync events and keeps the latest of them so
+// reconnecting clients can catch up
+type EventLog struct {
+	db    *sql.DB
+	limit int64
+}
+
+// NewEventLog creates an event log keeping up to limit events per user
+func NewEventLog(db *sql.DB, limit int) *EventLog {
+	return &EventLog{db: db, limit: int64(limit)}
+}
+
+// Append stores a frame under the user's next sequence number, which is set
+// as the frame's seq, and drops events beyond the limit
+func (l *EventLog) Append(userID string, frame protocol.Sequenced) (int64, error) {
+	tx, err := l.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var seq int64
+	err = tx.QueryRow(`
+		INSERT INTO sync_sequences (user_id, last_seq) VALUES (?, 1)
+		ON CONFLICT(user_id) DO UPDATE SET last_seq = last_seq + 1
+		RETURNING last_seq
+	`, userID).Scan(&seq)
+	if err != nil {
+		return 0, fmt.Errorf("failed to assign sequence number: %w", err)
+	}
+
+	frame.SetSeq(seq)
+	payload, err := protocol.Marshal(frame, protocol.JSON)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`INSERT INTO sync_events (user_id, seq, type, payload) VALUES (?, ?, ?, ?)`,
+		userID, seq, frame.MessageType(), string(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to store event: %w", err)
+	}
+	_, err = tx.Exec(`DELETE FROM sync_events WHERE user_id = ? AND seq <= ?`, userID, seq-l.limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to trim events: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit event: %w", err)
+	}
+	return seq, nil
+}
+
+// LastSeq returns the sequence number of the user's latest event, or 0
+func (l *EventLog) LastSeq(userID string) (int64, error) {
+	var seq int64
+	err := l.db.QueryRow(`SELECT last_seq FROM sync_sequences WHERE user_id = ?`, userID).Scan(&seq)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get sequence number: %w", err)
+	}
+	return seq, nil
+}
+
+// Since returns the user's events after seq, oldest first, and the latest
+// sequence number. It returns ErrResyncRequired if some of those events were
+// dropped, or seq is ahead of the log.
+func (l *EventLog) Since(userID string, seq int64) ([]Event, int64, error) {
+	last, err := l.LastSeq(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if seq > last || seq < 0 {
+		return nil, last, ErrResyncRequired
+	}
+	if seq == last {
+		return nil, last, nil
+	}
+
+	rows, err := l.db.Query(`
+		SELECT seq, type, payload FROM sync_events
+		WHERE user_id = ? AND seq > ? ORDER BY seq
+	`, userID, seq)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var event Event
+		var payload string
+		if err := rows.Scan(&event.Seq, &event.Type, &payload); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan event: %w", err)
+		}
+		event.Payload = json.RawMessage(payload)
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to get events: %w", err)
+	}
+
+	// Events up to last were appended, so a shorter list means some were dropped
+	if int64(len(events)) < last-seq {
+		return nil, last, ErrResyncRequired
+	}
+	return events, last, nil
+}
@@ -0,0 +1,32 @@
+package tcp
+
+import (
+	"net/http"
+
+	"mangahub/internal/auth"
+	"mangahub/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	server *Server
+}
+
+func NewHandler(server *Server) *Handler {
+	return &Handler{server: server}
+}
+
+// ListDevices handles listing the current user's connected sync clients and
+// what each is reading
+func (h *Handler) ListDevices(c *gin.Context) {
+	devices := h.server.Devices(auth.GetUserID(c))
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data: gin.H{
+			"devices": devices,
+			"count":   len(devices),
+		},
+	})
+}
@@ -0,0 +1,219 @@
+package tcp
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"mangahub/pkg/protocol"
+)
+
+const (
+	// PresenceTimeout is how long a connection can go without sending a
+	// frame before its device is shown as offline. Clients send heartbeats
+	// well within it.
+	PresenceTimeout = 90 * time.Second
+
+	// maxDeviceField bounds the device name and type a client declares
+	maxDeviceField = 64
+)
+
+// device is the presence a client starts with, from its handshake
+func device(client *Client, hello *protocol.Hello, now time.Time) protocol.Presence {
+	return protocol.Presence{
+		ClientID:    client.ID,
+		SessionID:   client.SessionID,
+		DeviceName:  deviceField(hello.DeviceName),
+		DeviceType:  strings.ToLower(deviceField(hello.DeviceType)),
+		ConnectedAt: now.Unix(),
+	}
+}
+
+// deviceField trims a client-supplied name to something safe to show
+func deviceField(value string) string {
+	value = strings.TrimSpace(strings.ToValidUTF8(value, ""))
+	if len(value) > maxDeviceField {
+		// Cut at the start of a character
+		end := maxDeviceField
+		for !utf8.RuneStart(value[end]) {
+			end--
+		}
+		value = value[:end]
+	}
+	return value
+}
+
+// presenceLocked is what the user's other devices see of a client. The caller
+// holds s.mutex.
+func presenceLocked(client *Client) protocol.Presence {
+	p := client.device
+	p.Status = "online"
+	if client.away {
+		p.Status = "offline"
+	}
+	p.LastSeen = client.lastSeen.Unix()
+	return p
+}
+
+// announce tells the user's other devices about a client. Only clients that
+// declared a device in their handshake receive presence frames, so older
+// clients aren't sent a type they don't know. Presence isn't part of the event
+// log; devices that were offline learn it when they connect instead.
+func (s *Server) announce(client *Client, p protocol.Presence) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for clientID, other := range s.users[client.UserID] {
+		if clientID != client.ID && other.presence {
+			other.Send(&p)
+		}
+	}
+}
+
+// arriveLocked introduces a newly registered client and the user's other
+// devices to each other. The caller holds s.mutex, so every pair is
+// introduced exactly once even when devices connect together.
+func (s *Server) arriveLocked(client *Client) {
+	p := presenceLocked(client)
+	for clientID, other := range s.users[client.UserID] {
+		if clientID == client.ID || other.away {
+			continue
+		}
+		if client.presence {
+			o := presenceLocked(other)
+			client.Send(&o)
+		}
+		if other.presence {
+			other.Send(&p)
+		}
+	}
+}
+
+// touch records that a client is still there. A device shown as offline
+// after missing heartbeats is announced again.
+func (s *Server) touch(client *Client) {
+	s.mutex.Lock()
+	client.lastSeen = time.Now()
+	returned := client.away
+	client.away = false
+	p := presenceLocked(client)
+	s.mutex.Unlock()
+
+	if returned {
+		s.announce(client, p)
+	}
+}
+
+// leave tells the user's other devices that a disconnected client has gone,
+// unless they were already told when it stopped sending heartbeats
+func (s *Server) leave(client *Client) {
+	s.mutex.Lock()
+	gone := !client.away
+	client.away = true
+	p := presenceLocked(client)
+	s.mutex.Unlock()
+
+	if gone {
+		s.announce(client, p)
+	}
+}
+
+// setReading handles a now_reading frame
+func (s *Server) setReading(client *Client, frame *protocol.NowReading) error {
+	if err := s.authorize(client); err != nil {
+		return err
+	}
+	if frame.Chapter < 0 || frame.Page < 0 {
+		return &frameError{"invalid_frame", "chapter and page can't be negative"}
+	}
+
+	var title string
+	if frame.MangaID != "" && s.library != nil {
+		m, err := s.lookupManga(frame.MangaID)
+		if err != nil {
+			return err
+		}
+		title = m.Title
+	}
+	s.reading(client, frame.MangaID, title, frame.Chapter, frame.Page)
+	return nil
+}
+
+// reading records what a client is reading and announces it if it changed.
+// An empty mangaID means nothing.
+func (s *Server) reading(client *Client, mangaID, title string, chapter, page int) {
+	if mangaID == "" {
+		title, chapter, page = "", 0, 0
+	}
+
+	s.mutex.Lock()
+	d := &client.device
+	changed := d.MangaID != mangaID || d.MangaTitle != title || d.Chapter != chapter || d.Page != page
+	d.MangaID, d.MangaTitle, d.Chapter, d.Page = mangaID, title, chapter, page
+	p := presenceLocked(client)
+	s.mutex.Unlock()
+
+	if changed {
+		s.announce(client, p)
+	}
+}
+
+// Devices returns the user's online devices in the order they connected
+func (s *Server) Devices(userID string) []protocol.Presence {
+	s.mutex.RLock()
+	devices := make([]protocol.Presence, 0, len(s.users[userID]))
+	for _, client := range s.users[userID] {
+		if !client.away {
+			devices = append(devices, presenceLocked(client))
+		}
+	}
+	s.mutex.RUnlock()
+
+	sort.Slice(devices, func(i, j int) bool {
+		if devices[i].ConnectedAt != devices[j].ConnectedAt {
+			return devices[i].ConnectedAt < devices[j].ConnectedAt
+		}
+		return devices[i].ClientID < devices[j].ClientID
+	})
+	return devices
+}
+
+// expirePresence shows clients that have been quiet since before
+// now - PresenceTimeout as offline. Their connections stay open until the
+// read deadline, so a late heartbeat brings them back.
+func (s *Server) expirePresence(now time.Time) {
+	s.mutex.Lock()
+	var expired []*Client
+	var presences []protocol.Presence
+	for _, client := range s.clients {
+		if !client.away && now.Sub(client.lastSeen) > PresenceTimeout {
+			client.away = true
+			expired = append(expired, client)
+			presences = append(presences, presenceLocked(client))
+		}
+	}
+	s.mutex.Unlock()
+
+	for i, client := range expired {
+		log.Printf("Client %s stopped sending heartbeats", client.ID)
+		s.announce(client, presences[i])
+	}
+}
+
+// handlePresence expires quiet clients until the server shuts down
+func (s *Server) handlePresence() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(PresenceTimeout / 6)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.shutdown:
+			return
+		case now := <-ticker.C:
+			s.expirePresence(now)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package tcp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPresence(t *testing.T) {
+	s := setupSync(t)
+	laptop, laptopReader, laptopWelcome := connectWith(t, s, map[string]interface{}{"device_name": "Laptop", "device_type": "Desktop"})
+
+	// Each device hears about the other when it connects
+	phone, phoneReader, _ := connectWith(t, s, map[string]interface{}{"device_name": "Phone", "device_type": "phone"})
+	if frame := receive(t, phoneReader); frame["type"] != "presence" || frame["device_name"] != "Laptop" || frame["device_type"] != "desktop" {
+		t.Errorf("Expected the laptop's presence on the phone, got %v", frame)
+	}
+	if frame := receive(t, laptopReader); frame["type"] != "presence" || frame["device_name"] != "Phone" || frame["status"] != "online" {
+		t.Errorf("Expected the phone to come online on the laptop, got %v", frame)
+	}
+
+	// Clients that didn't declare a device are listed but not sent presence
+	connect(t, s, "reader")
+	if frame := receive(t, laptopReader); frame["type"] != "presence" || frame["device_name"] != nil {
+		t.Errorf("Expected an unnamed device on the laptop, got %v", frame)
+	}
+	receive(t, phoneReader)
+
+	send(t, laptop, map[string]interface{}{"type": "now_reading", "id": "r1", "manga_id": "test-manga-1", "chapter": 4, "page": 2})
+	if frame := receive(t, phoneReader); frame["manga_title"] != "Test Manga 1" || frame["chapter"] != 4.0 || frame["page"] != 2.0 {
+		t.Errorf("Expected the laptop to be reading chapter 4, got %v", frame)
+	}
+	if ack := receive(t, laptopReader); ack["type"] != "ack" || ack["id"] != "r1" || ack["status"] != "ok" {
+		t.Errorf("Expected ack r1, got %v", ack)
+	}
+	send(t, laptop, map[string]interface{}{"type": "now_reading", "id": "r2", "manga_id": "missing"})
+	if ack := receive(t, laptopReader); ack["code"] != "manga_not_found" {
+		t.Errorf("Expected manga_not_found, got %v", ack)
+	}
+
+	devices := s.Devices("user-1")
+	if len(devices) != 3 || devices[0].ClientID != laptopWelcome["client_id"] || devices[0].MangaID != "test-manga-1" {
+		t.Fatalf("Expected the laptop reading first of 3 devices, got %+v", devices)
+	}
+
+	// A device that stops sending heartbeats goes offline and comes back
+	// with its next frame
+	s.mutex.Lock()
+	for _, client := range s.users["user-1"] {
+		if client.ID != laptopWelcome["client_id"] {
+			client.lastSeen = time.Now().Add(-2 * PresenceTimeout)
+		}
+	}
+	s.mutex.Unlock()
+	s.expirePresence(time.Now())
+	offline := map[interface{}]bool{}
+	for i := 0; i < 2; i++ {
+		if frame := receive(t, laptopReader); frame["status"] == "offline" {
+			offline[frame["device_name"]] = true
+		}
+	}
+	if !offline["Phone"] || !offline[nil] {
+		t.Errorf("Expected both other devices to go offline, got %v", offline)
+	}
+	if devices := s.Devices("user-1"); len(devices) != 1 {
+		t.Errorf("Expected only the laptop online, got %+v", devices)
+	}
+
+	send(t, phone, map[string]interface{}{"type": "heartbeat"})
+	if frame := receive(t, laptopReader); frame["device_name"] != "Phone" || frame["status"] != "online" {
+		t.Errorf("Expected the phone back online, got %v", frame)
+	}
+	receive(t, phoneReader)
+
+	// Disconnecting is announced
+	phone.Close()
+	if frame := receive(t, laptopReader); frame["device_name"] != "Phone" || frame["status"] != "offline" {
+		t.Errorf("Expected the phone to go offline, got %v", frame)
+	}
+}
+
+func TestDeviceField(t *testing.T) {
+	long := strings.Repeat("é", maxDeviceField)
+	if got := deviceField(long); len(got) > maxDeviceField || !strings.HasPrefix(long, got) {
+		t.Errorf("deviceField cut %q badly: %q", long, got)
+	}
+	if got := deviceField("  Kindle \xff"); got != "Kindle" {
+		t.Errorf("deviceField = %q, want Kindle", got)
+	}
+}
@@ -1,52 +1,102 @@
 package tcp
 
 import (
-	"bufio"
-	"encoding/json"
+	"crypto/tls"
 	"fmt"
 	"log"
 	"net"
 	"sync"
 	"time"
 
+	"mangahub/internal/auth"
+	"mangahub/internal/manga"
 	"mangahub/pkg/models"
+	"mangahub/pkg/protocol"
 )
 
-type Client struct {
-	Conn   net.Conn
-	UserID string
-}
+const (
+	// AuthTimeout is how long a new connection has to send its token
+	AuthTimeout = 30 * time.Second
+
+	// ReauthWarning is how long before its token expires a connection is
+	// asked to send a new one
+	ReauthWarning = time.Minute
+)
 
 type Server struct {
 	port      string
+	tokens    auth.Verifier
 	clients   map[string]*Client
+	users     map[string]map[string]*Client // user ID -> client ID -> client
 	mutex     sync.RWMutex
 	broadcast chan models.ProgressUpdate
 	shutdown  chan struct{}
 	wg        sync.WaitGroup
+
+	events            *EventLog
+	publishLocks      userLocks // keep each user's events in sequence order on every connection
+	library           *manga.Repository
+	positions         *manga.PositionTracker
+	progressListeners []func(update models.ProgressUpdate)
+
+	queueSize int
+	overflow  OverflowPolicy
+
+	tlsConfig *tls.Config
 }
 
-func NewServer(port string) *Server {
+// NewServer creates a TCP sync server. A standalone server without access to
+// the user database can verify tokens against a JWKS. Connections must
+// authenticate with a token, so a nil verifier rejects every client.
+func NewServer(port string, tokens auth.Verifier) *Server {
 	return &Server{
 		port:      port,
+		tokens:    tokens,
 		clients:   make(map[string]*Client),
+		users:     make(map[string]map[string]*Client),
 		broadcast: make(chan models.ProgressUpdate, 100),
 		shutdown:  make(chan struct{}),
+		queueSize: DefaultQueueSize,
+		overflow:  Disconnect,
 	}
 }
 
+// SetQueue sets how many frames can wait for each connection and what happens
+// when a slow client lets its queue fill up
+func (s *Server) SetQueue(size int, policy OverflowPolicy) {
+	// A closing connection needs room for its goodbye frame and the close
+	// marker after it
+	if size < MinQueueSize {
+		size = MinQueueSize
+	}
+	s.queueSize = size
+	s.overflow = policy
+}
+
+// UseTLS makes clients connect over TLS. With config.ClientCAs set they also
+// have to present a client certificate.
+func (s *Server) UseTLS(config *tls.Config) {
+	s.tlsConfig = config
+}
+
 // Start starts the TCP server
 func (s *Server) Start() error {
 	listener, err := net.Listen("tcp", s.port)
 	if err != nil {
 		return fmt.Errorf("failed to start TCP server: %w", err)
 	}
+	if s.tlsConfig != nil {
+		// The TLS handshake happens on the first read, within AuthTimeout
+		listener = tls.NewListener(listener, s.tlsConfig)
+	}
 
 	log.Printf("TCP Sync Server listening on %s", s.port)
 
 	// Start broadcast goroutine
 	s.wg.Add(1)
 	go s.handleBroadcasts()
+	s.wg.Add(1)
+	go s.handlePresence()
 
 	// Accept connections
 	go func() {
@@ -101,90 +151,266 @@ func (s *Server) handleConnection(conn net.Conn) {
 	defer s.wg.Done()
 	defer conn.Close()
 
-	reader := bufio.NewReader(conn)
-	
-	// Read authentication message
-	conn.SetReadDeadline(time.Now().Add(30 * time.Second))
-	authData, err := reader.ReadBytes('\n')
+	reader := protocol.NewReader(conn)
+
+	// The first line is a JSON hello carrying a JWT or personal access token
+	conn.SetReadDeadline(time.Now().Add(AuthTimeout))
+	helloData, err := reader.ReadFrame()
 	if err != nil {
 		log.Printf("Error reading auth: %v", err)
 		return
 	}
 
-	var authMsg struct {
-		UserID string `json:"user_id"`
+	var hello protocol.Hello
+	if err := protocol.Decode(helloData, protocol.JSON, &hello); err != nil {
+		sendAuthError(conn, "invalid_handshake", "the first line must be a JSON hello with a token")
+		return
 	}
-	if err := json.Unmarshal(authData, &authMsg); err != nil {
-		log.Printf("Error parsing auth: %v", err)
+	version, err := protocol.Negotiate(hello.Versions)
+	if err != nil {
+		sendAuthError(conn, "unsupported_version", fmt.Sprintf("this server speaks protocol versions %d to %d", protocol.MinVersion, protocol.Version))
 		return
 	}
+	encoding := protocol.NegotiateEncoding(hello.Encodings)
 
-	if authMsg.UserID == "" {
-		log.Printf("Empty user_id in auth message")
+	claims, code, message := s.authenticate(hello.Token)
+	if claims == nil {
+		sendAuthError(conn, code, message)
 		return
 	}
 
-	// Register client with proper locking
-	clientID := fmt.Sprintf("%s_%d", authMsg.UserID, time.Now().UnixNano())
-	client := &Client{
-		Conn:   conn,
-		UserID: authMsg.UserID,
+	clientID := fmt.Sprintf("%s_%d", claims.UserID, time.Now().UnixNano())
+	client := newClient(clientID, conn, claims.UserID, s.queueSize, s.overflow)
+	client.SessionID = claims.SessionID
+	client.encoding = encoding
+	client.lastSeen = time.Now()
+	client.device = device(client, &hello, client.lastSeen)
+	client.presence = hello.DeviceName != "" || hello.DeviceType != ""
+
+	// No events are published between registering the client and loading its
+	// snapshot or what it missed. Events published after that wait in its
+	// queue until these are written, so it sees every event once and in order.
+	var backlog [][]byte
+	s.publishLocks.Lock(claims.UserID)
+	clientCount := s.register(client)
+	seq, seqErr := s.lastSeq(claims.UserID)
+	switch {
+	case hello.Snapshot:
+		// The snapshot already holds everything a replay would
+		backlog = s.initialSnapshot(claims.UserID, seq, seqErr, encoding)
+	case hello.LastSeq != nil && s.events != nil:
+		backlog = s.replay(claims.UserID, *hello.LastSeq, encoding)
 	}
-
-	s.mutex.Lock()
-	s.clients[clientID] = client
-	clientCount := len(s.clients)
-	s.mutex.Unlock()
-
-	log.Printf("Client connected: %s (UserID: %s) - Total clients: %d", clientID, authMsg.UserID, clientCount)
-
-	// Send confirmation
-	response := map[string]interface{}{
-		"status":  "connected",
-		"message": "Successfully connected to TCP sync server",
-		"client_id": clientID,
+	s.publishLocks.Unlock(claims.UserID)
+
+	log.Printf("Client connected: %s (UserID: %s) - Total clients: %d", clientID, claims.UserID, clientCount)
+
+	// Send confirmation. It is still JSON; the negotiated encoding applies
+	// from the next frame on.
+	welcome := &protocol.Welcome{
+		Status:   "connected",
+		Message:  "Successfully connected to TCP sync server",
+		ClientID: clientID,
+		UserID:   claims.UserID,
+		Seq:      seq,
+		Version:  version,
+		Encoding: encoding,
+	}
+	if claims.ExpiresAt != nil {
+		welcome.ExpiresAt = &claims.ExpiresAt.Time
 	}
-	respData, _ := json.Marshal(response)
-	conn.Write(append(respData, '\n'))
+	writeFrame(conn, welcome)
+	reader.SetEncoding(encoding)
+	for _, line := range backlog {
+		conn.SetWriteDeadline(time.Now().Add(WriteTimeout))
+		conn.Write(line)
+	}
+	go client.writeLoop()
+
+	// Watch for the token expiring once the client knows it's connected
+	s.reauthenticate(client, claims)
 
 	// Keep connection alive and handle heartbeats
 	for {
 		select {
 		case <-s.shutdown:
-			return
+			goto cleanup
 		default:
 			// Set read deadline
 			conn.SetReadDeadline(time.Now().Add(5 * time.Minute))
-			
-			data, err := reader.ReadBytes('\n')
+
+			data, err := reader.ReadFrame()
 			if err != nil {
 				goto cleanup
 			}
+			s.touch(client)
 
-			// Handle heartbeat or other messages
-			var msg map[string]interface{}
-			if err := json.Unmarshal(data, &msg); err == nil {
-				if msgType, ok := msg["type"].(string); ok && msgType == "heartbeat" {
-					// Send heartbeat response
-					hbResp := map[string]string{
-						"type": "heartbeat_ack",
-						"timestamp": fmt.Sprintf("%d", time.Now().Unix()),
-					}
-					hbData, _ := json.Marshal(hbResp)
-					conn.Write(append(hbData, '\n'))
-				}
+			// Handle heartbeats, reauthentication and pushed changes
+			msg, err := protocol.Unmarshal(data, encoding)
+			if err != nil {
+				sendFrameError(client, frameID(data, encoding), decodeError(err))
+				continue
 			}
+			s.handleFrame(client, msg)
 		}
 	}
 
 cleanup:
-	// Remove client on disconnect with proper locking
+	remainingClients := s.unregister(client)
+	s.leave(client)
+	log.Printf("Client disconnected: %s - Remaining clients: %d", clientID, remainingClients)
+}
+
+// register adds a client to the server and its user's connections, introduces
+// it to the user's other devices, and returns how many clients are connected
+func (s *Server) register(client *Client) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.clients[client.ID] = client
+	if s.users[client.UserID] == nil {
+		s.users[client.UserID] = make(map[string]*Client)
+	}
+	s.users[client.UserID][client.ID] = client
+	// Queued until the welcome and any backlog are written
+	s.arriveLocked(client)
+	return len(s.clients)
+}
+
+// unregister removes a disconnected client, stops its writer and expiry
+// timer, and returns how many clients remain
+func (s *Server) unregister(client *Client) int {
 	s.mutex.Lock()
-	delete(s.clients, clientID)
-	remainingClients := len(s.clients)
+	delete(s.clients, client.ID)
+	if conns := s.users[client.UserID]; conns != nil {
+		delete(conns, client.ID)
+		if len(conns) == 0 {
+			delete(s.users, client.UserID)
+		}
+	}
+	if client.expiry != nil {
+		client.expiry.Stop()
+	}
+	remaining := len(s.clients)
 	s.mutex.Unlock()
 
-	log.Printf("Client disconnected: %s - Remaining clients: %d", clientID, remainingClients)
+	client.stop()
+	return remaining
+}
+
+// authenticate validates a handshake token. On failure it returns the code
+// and message of the auth_error frame to send.
+func (s *Server) authenticate(token string) (*auth.Claims, string, string) {
+	if token == "" {
+		return nil, "token_required", "send a JWT or personal access token to connect"
+	}
+	if s.tokens == nil {
+		return nil, "auth_unavailable", "this server can't verify tokens"
+	}
+	claims, err := s.tokens.Validate(token)
+	if err == auth.ErrExpiredToken {
+		return nil, "token_expired", "token has expired, refresh it and reconnect"
+	}
+	if err != nil {
+		return nil, "invalid_token", "invalid or revoked token"
+	}
+	// Everything on a sync connection reads or changes the library, so a
+	// narrower token can neither connect nor replace the one in use
+	if !claims.HasScope(auth.ScopeWriteLibrary) {
+		return nil, "forbidden", "token is missing the " + auth.ScopeWriteLibrary + " scope"
+	}
+	return claims, "", ""
+}
+
+// handleReauth replaces a connection's token with a fresh one for the same user
+func (s *Server) handleReauth(client *Client, token string) {
+	claims, code, message := s.authenticate(token)
+	if claims == nil {
+		client.Send(authError(code, message))
+		return
+	}
+	if claims.UserID != client.UserID {
+		client.Send(authError("user_mismatch", "the token belongs to another user"))
+		return
+	}
+
+	s.reauthenticate(client, claims)
+
+	response := &protocol.AuthOK{}
+	if claims.ExpiresAt != nil {
+		response.ExpiresAt = &claims.ExpiresAt.Time
+	}
+	client.Send(response)
+}
+
+// reauthenticate applies a token's login and expiry to a connection. A
+// reauth_required frame is sent ReauthWarning before the token expires, and
+// the connection is closed when it does.
+func (s *Server) reauthenticate(client *Client, claims *auth.Claims) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	client.SessionID = claims.SessionID
+	client.claims = claims
+	client.ExpiresAt = time.Time{}
+	if claims.ExpiresAt != nil {
+		client.ExpiresAt = claims.ExpiresAt.Time
+	}
+
+	client.expiryGen++
+	if client.expiry != nil {
+		client.expiry.Stop()
+		client.expiry = nil
+	}
+	if !client.ExpiresAt.IsZero() {
+		gen := client.expiryGen
+		client.expiry = time.AfterFunc(time.Until(client.ExpiresAt)-ReauthWarning, func() {
+			s.checkExpiry(client, gen)
+		})
+	}
+}
+
+// checkExpiry asks the client for a new token, or closes the connection once
+// the token has expired. gen ignores timers replaced by a reauthentication.
+func (s *Server) checkExpiry(client *Client, gen int) {
+	s.mutex.Lock()
+	if gen != client.expiryGen {
+		s.mutex.Unlock()
+		return
+	}
+	remaining := time.Until(client.ExpiresAt)
+	if remaining > 0 {
+		client.expiry = time.AfterFunc(remaining, func() { s.checkExpiry(client, gen) })
+		s.mutex.Unlock()
+
+		client.Send(&protocol.ReauthRequired{
+			Message:   "token expires soon, send a new one",
+			ExpiresAt: client.ExpiresAt,
+		})
+		return
+	}
+	s.mutex.Unlock()
+
+	// The connection handler removes the client once its read fails
+	client.CloseAfter(authError("token_expired", "token has expired"))
+	log.Printf("Closed connection of user %s: token expired", client.UserID)
+}
+
+// sendAuthError tells a connection why it couldn't authenticate
+func sendAuthError(conn net.Conn, code, message string) {
+	writeFrame(conn, authError(code, message))
+}
+
+// authError is the frame telling a client why it couldn't authenticate
+func authError(code, message string) *protocol.AuthError {
+	return protocol.NewAuthError(code, message)
+}
+
+// writeFrame sends one JSON line straight to a connection, before its
+// writer has started
+func writeFrame(conn net.Conn, msg protocol.Message) error {
+	conn.SetWriteDeadline(time.Now().Add(WriteTimeout))
+	return protocol.WriteFrame(conn, msg, protocol.JSON)
 }
 
 // handleBroadcasts listens for progress updates and broadcasts them
@@ -203,42 +429,99 @@ func (s *Server) handleBroadcasts() {
 
 // broadcastUpdate sends progress update to all clients of the user
 func (s *Server) broadcastUpdate(update models.ProgressUpdate) {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
-
-	data, err := json.Marshal(map[string]interface{}{
-		"type":      "progress_update",
-		"user_id":   update.UserID,
-		"manga_id":  update.MangaID,
-		"chapter":   update.Chapter,
-		"timestamp": update.Timestamp,
-	})
+	seq, err := s.publish(update.UserID, "", progressFrame(update))
 	if err != nil {
-		log.Printf("Error marshaling update: %v", err)
 		return
 	}
 
-	data = append(data, '\n')
+	log.Printf("Broadcasted progress update for user %s (manga: %s, ch: %d, seq: %d)", 
+		update.UserID, update.MangaID, update.Chapter, seq)
+}
 
-	// Send to all clients of this user
-	sentCount := 0
-	failedCount := 0
-	
-	for clientID, client := range s.clients {
-		if client.UserID == update.UserID {
-			client.Conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
-			_, err := client.Conn.Write(data)
-			if err != nil {
-				log.Printf("Error sending to client %s: %v", clientID, err)
-				failedCount++
-			} else {
-				sentCount++
+// progressFrame is the progress_update frame sent to a user's devices
+func progressFrame(update models.ProgressUpdate) *protocol.ProgressUpdate {
+	msg := &protocol.ProgressUpdate{
+		UserID:    update.UserID,
+		MangaID:   update.MangaID,
+		Chapter:   update.Chapter,
+		Timestamp: update.Timestamp,
+	}
+	// Include the position inside the chapter so readers can jump to the exact spot
+	if update.Page > 0 || update.ScrollPercent > 0 {
+		msg.Page = &update.Page
+		msg.ScrollPercent = &update.ScrollPercent
+	}
+	return msg
+}
+
+// sendToUser queues a frame for every connection of a user except the one
+// with ID except, and reports how many accepted and refused it. The frame is
+// encoded once for each encoding in use.
+func (s *Server) sendToUser(userID, except string, msg protocol.Message) (int, int) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	frames := make(map[protocol.Encoding][]byte, 1)
+	queued := 0
+	refused := 0
+	for clientID, client := range s.users[userID] {
+		if clientID == except {
+			continue
+		}
+		data, ok := frames[client.encoding]
+		if !ok {
+			var err error
+			if data, err = protocol.MarshalFrame(msg, client.encoding); err != nil {
+				log.Printf("Error marshaling frame: %v", err)
+				return queued, refused
 			}
+			frames[client.encoding] = data
+		}
+		if client.enqueue(data) {
+			queued++
+		} else {
+			refused++
+		}
+	}
+	return queued, refused
+}
+
+// DisconnectSession closes every connection opened with a revoked login
+func (s *Server) DisconnectSession(sessionID string) {
+	if sessionID == "" {
+		return
+	}
+
+	s.mutex.RLock()
+	var clients []*Client
+	for _, client := range s.clients {
+		if client.SessionID == sessionID {
+			clients = append(clients, client)
 		}
 	}
+	s.mutex.RUnlock()
+
+	disconnect(clients, &protocol.SessionRevoked{Message: "this session was logged out"})
+}
+
+// DisconnectUser closes every connection of a user, such as one deleting their account
+func (s *Server) DisconnectUser(userID string) {
+	s.mutex.RLock()
+	var clients []*Client
+	for _, client := range s.users[userID] {
+		clients = append(clients, client)
+	}
+	s.mutex.RUnlock()
 
-	log.Printf("Broadcasted progress update for user %s (manga: %s, ch: %d) - Sent: %d, Failed: %d", 
-		update.UserID, update.MangaID, update.Chapter, sentCount, failedCount)
+	disconnect(clients, &protocol.AccountDeleted{Message: "this account is being deleted"})
+}
+
+func disconnect(clients []*Client, goodbye protocol.Message) {
+	for _, client := range clients {
+		// The connection handler removes the client once its read fails
+		client.CloseAfter(goodbye)
+		log.Printf("Disconnected client %s: %s", client.ID, goodbye.MessageType())
+	}
 }
 
 // GetStats returns server statistics
@@ -246,18 +529,19 @@ func (s *Server) GetStats() map[string]interface{} {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
+	queued, dropped := 0, 0
+	for _, client := range s.clients {
+		q, d := client.queueStats()
+		queued += q
+		dropped += d
+	}
+
 	return map[string]interface{}{
 		"total_clients":    len(s.clients),
-		"unique_users":     s.countUniqueUsers(),
+		"unique_users":     len(s.users),
 		"broadcast_buffer": len(s.broadcast),
+		"queued_frames":    queued,
+		"dropped_frames":   dropped,
+		"overflow_policy":  s.overflow.String(),
 	}
 }
-
-// countUniqueUsers counts unique connected users
-func (s *Server) countUniqueUsers() int {
-	users := make(map[string]bool)
-	for _, client := range s.clients {
-		users[client.UserID] = true
-	}
-	return len(users)
-}
\ No newline at end of file
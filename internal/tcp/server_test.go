@@ -0,0 +1,229 @@
+package tcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"mangahub/internal/auth"
+	"mangahub/pkg/protocol"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// fakeVerifier accepts the tokens it was given
+type fakeVerifier map[string]*auth.Claims
+
+func (v fakeVerifier) Validate(token string) (*auth.Claims, error) {
+	if token == "expired" {
+		return nil, auth.ErrExpiredToken
+	}
+	claims, ok := v[token]
+	if !ok {
+		return nil, auth.ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func claimsFor(userID string, expiresIn time.Duration) *auth.Claims {
+	return &auth.Claims{
+		UserID:           userID,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn))},
+	}
+}
+
+// connect runs a connection handler and sends the handshake
+func connect(t *testing.T, s *Server, token string) (net.Conn, *bufio.Reader, map[string]interface{}) {
+	server, client := net.Pipe()
+	t.Cleanup(func() { client.Close() })
+	s.wg.Add(1)
+	go s.handleConnection(server)
+
+	send(t, client, map[string]string{"token": token})
+	reader := bufio.NewReader(client)
+	return client, reader, receive(t, reader)
+}
+
+func send(t *testing.T, conn net.Conn, frame interface{}) {
+	data, _ := json.Marshal(frame)
+	conn.SetWriteDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		t.Fatalf("Failed to send frame: %v", err)
+	}
+}
+
+func receive(t *testing.T, reader *bufio.Reader) map[string]interface{} {
+	data, err := reader.ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("Failed to read frame: %v", err)
+	}
+	var frame map[string]interface{}
+	json.Unmarshal(data, &frame)
+	return frame
+}
+
+func TestHandshakeRequiresLibraryScope(t *testing.T) {
+	readOnly := claimsFor("user-1", time.Hour)
+	readOnly.PersonalTokenID = "pat-1"
+	readOnly.Scopes = []string{auth.ScopeReadCatalog}
+	full := claimsFor("user-1", time.Hour)
+	full.PersonalTokenID = "pat-2"
+	full.Scopes = []string{auth.ScopeReadCatalog, auth.ScopeWriteLibrary}
+	s := NewServer(":0", fakeVerifier{"read-only": readOnly, "library": full})
+
+	if _, _, frame := connect(t, s, "read-only"); frame["type"] != "auth_error" || frame["code"] != "forbidden" {
+		t.Errorf("Expected a read-only token to be forbidden, got %v", frame)
+	}
+	if _, _, frame := connect(t, s, "library"); frame["status"] != "connected" {
+		t.Errorf("Expected a write:library token to connect, got %v", frame)
+	}
+}
+
+func TestReauthenticateKeepsScope(t *testing.T) {
+	readOnly := claimsFor("user-1", time.Hour)
+	readOnly.PersonalTokenID = "pat-1"
+	readOnly.Scopes = []string{auth.ScopeReadCatalog}
+	s := NewServer(":0", fakeVerifier{"reader": claimsFor("user-1", time.Hour), "read-only": readOnly})
+	conn, reader, _ := connect(t, s, "reader")
+
+	// A narrower token can't replace the one the connection was opened with
+	send(t, conn, map[string]string{"type": "auth", "token": "read-only"})
+	if frame := receive(t, reader); frame["type"] != "auth_error" || frame["code"] != "forbidden" {
+		t.Errorf("Expected a read-only token to be forbidden, got %v", frame)
+	}
+
+	// Frames are checked against the token in use
+	s.mutex.Lock()
+	for _, client := range s.clients {
+		client.claims = readOnly
+	}
+	s.mutex.Unlock()
+	send(t, conn, map[string]interface{}{"type": "request_snapshot", "id": "s1"})
+	if frame := receive(t, reader); frame["type"] != "ack" || frame["code"] != "forbidden" {
+		t.Errorf("Expected the snapshot to be forbidden, got %v", frame)
+	}
+}
+
+func TestHandshakeRequiresToken(t *testing.T) {
+	s := NewServer(":0", fakeVerifier{})
+
+	for token, code := range map[string]string{
+		"":        "token_required",
+		"forged":  "invalid_token",
+		"expired": "token_expired",
+	} {
+		conn, reader, frame := connect(t, s, token)
+		if frame["type"] != "auth_error" || frame["code"] != code {
+			t.Errorf("Token %q: expected auth_error %s, got %v", token, code, frame)
+		}
+		// The server hangs up after the error
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		if _, err := reader.ReadBytes('\n'); err == nil {
+			t.Errorf("Token %q: expected the connection to be closed", token)
+		}
+	}
+
+	if stats := s.GetStats(); stats["total_clients"] != 0 {
+		t.Errorf("Expected no clients, got %v", stats)
+	}
+}
+
+func TestReauthenticate(t *testing.T) {
+	s := NewServer(":0", fakeVerifier{
+		"reader":  claimsFor("user-1", time.Hour),
+		"fresh":   claimsFor("user-1", 2*time.Hour),
+		"someone": claimsFor("user-2", time.Hour),
+	})
+
+	conn, reader, frame := connect(t, s, "reader")
+	if frame["status"] != "connected" || frame["user_id"] != "user-1" {
+		t.Fatalf("Expected to connect as user-1, got %v", frame)
+	}
+
+	send(t, conn, map[string]string{"type": "auth", "token": "someone"})
+	if frame := receive(t, reader); frame["code"] != "user_mismatch" {
+		t.Errorf("Expected user_mismatch, got %v", frame)
+	}
+
+	send(t, conn, map[string]string{"type": "auth", "token": "fresh"})
+	if frame := receive(t, reader); frame["type"] != "auth_ok" {
+		t.Errorf("Expected auth_ok, got %v", frame)
+	}
+
+	// The connection is still usable
+	send(t, conn, map[string]string{"type": "heartbeat"})
+	if frame := receive(t, reader); frame["type"] != "heartbeat_ack" {
+		t.Errorf("Expected heartbeat_ack, got %v", frame)
+	}
+}
+
+func TestExpiredTokenClosesConnection(t *testing.T) {
+	s := NewServer(":0", fakeVerifier{"short": claimsFor("user-1", 1500*time.Millisecond)})
+
+	conn, reader, _ := connect(t, s, "short")
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+
+	if frame := receive(t, reader); frame["type"] != "reauth_required" {
+		t.Errorf("Expected reauth_required, got %v", frame)
+	}
+	if frame := receive(t, reader); frame["type"] != "auth_error" || frame["code"] != "token_expired" {
+		t.Errorf("Expected token_expired, got %v", frame)
+	}
+	if _, err := reader.ReadBytes('\n'); err == nil {
+		t.Error("Expected the connection to be closed")
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	s := setupSync(t)
+	laptop, laptopReader, _ := connect(t, s, "reader")
+
+	server, phone := net.Pipe()
+	t.Cleanup(func() { phone.Close() })
+	s.wg.Add(1)
+	go s.handleConnection(server)
+
+	send(t, phone, map[string]interface{}{"token": "reader", "versions": []int{protocol.Version, 99}, "encodings": []string{"protobuf", "json"}})
+	phoneReader := protocol.NewReader(phone)
+	var welcome protocol.Welcome
+	data, err := phoneReader.ReadFrame()
+	if err != nil || protocol.Decode(data, protocol.JSON, &welcome) != nil {
+		t.Fatalf("Expected a JSON welcome, got %q, %v", data, err)
+	}
+	if welcome.Version != protocol.Version || welcome.Encoding != protocol.Protobuf {
+		t.Fatalf("Expected version %d in protobuf, got %+v", protocol.Version, welcome)
+	}
+	phoneReader.SetEncoding(protocol.Protobuf)
+
+	// Protobuf frames from then on, and JSON clients see the same events
+	protocol.WriteFrame(phone, &protocol.LibraryUpdate{ID: "c1", MangaID: "test-manga-1", Status: "reading"}, protocol.Protobuf)
+	if frame := receive(t, laptopReader); frame["type"] != "library_update" || frame["seq"] != 1.0 {
+		t.Errorf("Expected library_update seq 1 on the laptop, got %v", frame)
+	}
+	msg, err := phoneReader.ReadMessage()
+	if ack, ok := msg.(*protocol.Ack); !ok || ack.Seq != 1 {
+		t.Errorf("Expected a protobuf ack for seq 1, got %+v, %v", msg, err)
+	}
+
+	send(t, laptop, map[string]interface{}{"type": "progress_update", "manga_id": "test-manga-1", "chapter": 7})
+	msg, err = phoneReader.ReadMessage()
+	if update, ok := msg.(*protocol.ProgressUpdate); !ok || update.Chapter != 7 || update.Seq != 2 {
+		t.Errorf("Expected chapter 7 at seq 2 on the phone, got %+v, %v", msg, err)
+	}
+}
+
+func TestUnsupportedVersion(t *testing.T) {
+	s := NewServer(":0", fakeVerifier{"reader": claimsFor("user-1", time.Hour)})
+
+	server, client := net.Pipe()
+	t.Cleanup(func() { client.Close() })
+	s.wg.Add(1)
+	go s.handleConnection(server)
+
+	send(t, client, map[string]interface{}{"token": "reader", "versions": []int{protocol.Version + 1}})
+	if frame := receive(t, bufio.NewReader(client)); frame["code"] != "unsupported_version" {
+		t.Errorf("Expected unsupported_version, got %v", frame)
+	}
+}
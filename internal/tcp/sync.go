@@ -0,0 +1,506 @@
+package tcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"mangahub/internal/auth"
+	"mangahub/internal/manga"
+	"mangahub/pkg/models"
+	"mangahub/pkg/protocol"
+)
+
+// frameError is why a client frame was rejected
+type frameError struct {
+	Code    string
+	Message string
+}
+
+func (e *frameError) Error() string {
+	return e.Message
+}
+
+var errSyncUnavailable = &frameError{"sync_unavailable", "this server doesn't store progress, use the HTTP API"}
+
+// UseLibrary lets clients push progress and library changes, stored in repo.
+// positions may be nil, in which case reading positions are written straight
+// to the database.
+func (s *Server) UseLibrary(repo *manga.Repository, positions *manga.PositionTracker) {
+	s.library = repo
+	s.positions = positions
+}
+
+// UseEventLog numbers the frames sent to each user's devices and keeps them
+// in log, so reconnecting clients can resume where they left off
+func (s *Server) UseEventLog(log *EventLog) {
+	s.events = log
+}
+
+// OnProgress registers a function called with each progress update a client
+// pushes, after it has been stored
+func (s *Server) OnProgress(fn func(update models.ProgressUpdate)) {
+	s.progressListeners = append(s.progressListeners, fn)
+}
+
+// handleFrame answers a frame sent by a connected client
+func (s *Server) handleFrame(client *Client, msg protocol.Message) {
+	var id string
+	var seq int64
+	var err error
+
+	switch frame := msg.(type) {
+	case *protocol.Heartbeat:
+		client.Send(&protocol.HeartbeatAck{Timestamp: time.Now().Unix()})
+		return
+	case *protocol.Auth:
+		// A fresh token keeps the connection open past the old one's expiry
+		s.handleReauth(client, frame.Token)
+		return
+	case *protocol.RequestSnapshot:
+		s.sendSnapshot(client, frame.ID)
+		return
+	case *protocol.ProgressUpdate:
+		id = frame.ID
+		seq, err = s.pushProgress(client, frame)
+	case *protocol.LibraryUpdate:
+		id = frame.ID
+		seq, err = s.pushLibrary(client, frame)
+	case *protocol.NowReading:
+		id = frame.ID
+		err = s.setReading(client, frame)
+	default:
+		err = &frameError{"unknown_type", fmt.Sprintf("clients can't send %s frames", msg.MessageType())}
+	}
+
+	if err != nil {
+		sendFrameError(client, id, err)
+		return
+	}
+	// The sender doesn't receive its own event, so it learns the number here
+	client.Send(&protocol.Ack{ID: id, Status: "ok", Seq: seq})
+}
+
+// publish sends a frame to every connection of a user except the one with ID
+// except. With an event log the frame is numbered and stored first, and its
+// sequence number is returned. A frame that can't be stored isn't sent, since
+// devices couldn't tell where it belongs in the log.
+func (s *Server) publish(userID, except string, frame protocol.Sequenced) (int64, error) {
+	s.publishLocks.Lock(userID)
+	defer s.publishLocks.Unlock(userID)
+
+	var seq int64
+	if s.events != nil {
+		var err error
+		if seq, err = s.events.Append(userID, frame); err != nil {
+			return 0, storeError(err, "failed to store sync event")
+		}
+	}
+	s.sendToUser(userID, except, frame)
+	return seq, nil
+}
+
+// userLocks hands out a mutex per user, so publishing for one user doesn't
+// wait on another. Locks are dropped once nobody holds or waits for them.
+type userLocks struct {
+	mu    sync.Mutex
+	locks map[string]*userLock
+}
+
+type userLock struct {
+	sync.Mutex
+	refs int
+}
+
+// Lock locks the user's mutex
+func (l *userLocks) Lock(userID string) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*userLock)
+	}
+	lock, ok := l.locks[userID]
+	if !ok {
+		lock = &userLock{}
+		l.locks[userID] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.Lock()
+}
+
+// Unlock unlocks the user's mutex
+func (l *userLocks) Unlock(userID string) {
+	l.mu.Lock()
+	lock := l.locks[userID]
+	lock.refs--
+	if lock.refs == 0 {
+		delete(l.locks, userID)
+	}
+	l.mu.Unlock()
+
+	lock.Unlock()
+}
+
+// replay returns the frames to send a client resuming after seq: the events
+// it missed, or a request to load a snapshot if they are no longer kept
+func (s *Server) replay(userID string, seq int64, enc protocol.Encoding) [][]byte {
+	encode := func(msg protocol.Message) []byte {
+		data, _ := protocol.MarshalFrame(msg, enc)
+		return data
+	}
+
+	events, last, err := s.events.Since(userID, seq)
+	if err != nil {
+		if err != ErrResyncRequired {
+			log.Printf("Error loading sync events of user %s: %v", userID, err)
+		}
+		return [][]byte{encode(&protocol.ResyncRequired{
+			Seq:     last,
+			Message: "missed updates are no longer available, request a snapshot",
+		})}
+	}
+
+	frames := make([][]byte, 0, len(events)+1)
+	for _, event := range events {
+		// Events are stored as JSON, which JSON clients get as it is
+		if enc == protocol.JSON {
+			frames = append(frames, append(event.Payload, '\n'))
+			continue
+		}
+		msg, err := protocol.Unmarshal(event.Payload, protocol.JSON)
+		if err != nil {
+			log.Printf("Error decoding sync event %d of user %s: %v", event.Seq, userID, err)
+			continue
+		}
+		frames = append(frames, encode(msg))
+	}
+	return append(frames, encode(&protocol.ReplayComplete{Seq: last, Count: len(events)}))
+}
+
+// pushProgress stores a progress update from a client and passes it on to the
+// user's other devices
+func (s *Server) pushProgress(client *Client, frame *protocol.ProgressUpdate) (int64, error) {
+	if err := s.authorize(client); err != nil {
+		return 0, err
+	}
+	if s.library == nil {
+		return 0, errSyncUnavailable
+	}
+	if frame.MangaID == "" || frame.Chapter < 1 {
+		return 0, &frameError{"invalid_frame", "manga_id and a chapter of at least 1 are required"}
+	}
+	if (frame.Page != nil && *frame.Page < 1) || (frame.ScrollPercent != nil && (*frame.ScrollPercent < 0 || *frame.ScrollPercent > 100)) {
+		return 0, &frameError{"invalid_frame", "page must be at least 1 and scroll_percent between 0 and 100"}
+	}
+
+	m, err := s.lookupManga(frame.MangaID)
+	if err != nil {
+		return 0, err
+	}
+	if frame.Chapter > m.TotalChapters {
+		return 0, &frameError{"invalid_frame", fmt.Sprintf("chapter number exceeds total chapters (%d)", m.TotalChapters)}
+	}
+
+	var position *models.ReadingPosition
+	if frame.Page != nil || frame.ScrollPercent != nil {
+		position = &models.ReadingPosition{
+			UserID:    client.UserID,
+			MangaID:   m.ID,
+			Chapter:   frame.Chapter,
+			UpdatedAt: time.Now(),
+		}
+		if frame.Page != nil {
+			position.Page = *frame.Page
+		}
+		if frame.ScrollPercent != nil {
+			position.ScrollPercent = *frame.ScrollPercent
+		}
+	}
+
+	// Page turns within an already stored chapter only move the position
+	chapterStored := position != nil && s.positions != nil &&
+		s.positions.ChapterPersisted(client.UserID, m.ID, frame.Chapter)
+
+	if !chapterStored {
+		if err := s.library.UpdateProgress(client.UserID, m.ID, frame.Chapter); err != nil {
+			return 0, storeError(err, "failed to update progress")
+		}
+	}
+
+	if position != nil {
+		var err error
+		if s.positions != nil {
+			err = s.positions.Record(*position)
+		} else {
+			err = s.library.SavePosition(position)
+		}
+		if err != nil {
+			return 0, storeError(err, "failed to save reading position")
+		}
+	}
+
+	update := models.ProgressUpdate{
+		UserID:    client.UserID,
+		MangaID:   m.ID,
+		Chapter:   frame.Chapter,
+		SessionID: frame.SessionID,
+		Timestamp: time.Now().Unix(),
+	}
+	if position != nil {
+		update.Page = position.Page
+		update.ScrollPercent = position.ScrollPercent
+	}
+
+	seq, err := s.publish(client.UserID, client.ID, progressFrame(update))
+	if err != nil {
+		return 0, err
+	}
+	s.reading(client, m.ID, m.Title, update.Chapter, update.Page)
+	for _, fn := range s.progressListeners {
+		fn(update)
+	}
+	return seq, nil
+}
+
+// pushLibrary adds, changes or removes a library entry for a client and
+// passes the change on to the user's other devices. Fields left out of the
+// frame keep their stored value.
+func (s *Server) pushLibrary(client *Client, frame *protocol.LibraryUpdate) (int64, error) {
+	if err := s.authorize(client); err != nil {
+		return 0, err
+	}
+	if s.library == nil {
+		return 0, errSyncUnavailable
+	}
+	if frame.MangaID == "" {
+		return 0, &frameError{"invalid_frame", "manga_id is required"}
+	}
+
+	m, err := s.lookupManga(frame.MangaID)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+
+	if frame.Removed {
+		if err := s.library.RemoveFromLibrary(client.UserID, m.ID); err != nil {
+			return 0, storeError(err, "failed to remove from library")
+		}
+		if s.positions != nil {
+			s.positions.Forget(client.UserID, m.ID)
+		}
+		return s.publish(client.UserID, client.ID, &protocol.LibraryUpdate{
+			MangaID:   m.ID,
+			Removed:   true,
+			Timestamp: now.Unix(),
+		})
+	}
+
+	if frame.Status != "" && !manga.ValidStatuses[frame.Status] {
+		return 0, &frameError{"invalid_frame", "invalid status. must be: reading, completed, plan-to-read, on-hold, or dropped"}
+	}
+	if frame.Rating != nil && (*frame.Rating < 0 || *frame.Rating > 10) {
+		return 0, &frameError{"invalid_frame", "rating must be between 0 and 10"}
+	}
+	if frame.CurrentChapter != nil && (*frame.CurrentChapter < 0 || *frame.CurrentChapter > m.TotalChapters) {
+		return 0, &frameError{"invalid_frame", fmt.Sprintf("current_chapter must be between 0 and %d", m.TotalChapters)}
+	}
+
+	progress, err := s.library.GetProgress(client.UserID, m.ID)
+	switch {
+	case err == manga.ErrProgressNotFound:
+		if frame.Status == "" {
+			return 0, &frameError{"invalid_frame", "status is required to add a manga to the library"}
+		}
+		progress = &models.UserProgress{UserID: client.UserID, MangaID: m.ID, StartedAt: now}
+	case err != nil:
+		return 0, storeError(err, "failed to get library entry")
+	}
+
+	if frame.Status != "" {
+		progress.Status = frame.Status
+	}
+	if frame.Rating != nil {
+		progress.Rating = *frame.Rating
+	}
+	if frame.CurrentChapter != nil {
+		// Starting at chapter N means chapters 1..N have been read
+		progress.CurrentChapter = *frame.CurrentChapter
+		progress.ReadChapters = ""
+	}
+	progress.UpdatedAt = now
+
+	if err := s.library.AddToLibrary(progress); err != nil {
+		return 0, storeError(err, "failed to update library")
+	}
+
+	return s.publish(client.UserID, client.ID, &protocol.LibraryUpdate{
+		MangaID:        progress.MangaID,
+		Status:         progress.Status,
+		Rating:         &progress.Rating,
+		CurrentChapter: &progress.CurrentChapter,
+		ReadChapters:   progress.ReadChapters,
+		Timestamp:      now.Unix(),
+	})
+}
+
+// sendSnapshot replies with the user's whole library
+func (s *Server) sendSnapshot(client *Client, id string) {
+	if err := s.authorize(client); err != nil {
+		sendFrameError(client, id, err)
+		return
+	}
+	// Like the snapshot in a hello, it is taken and queued with the user's
+	// publish lock held, so seq is the last event the library reflects and
+	// later events are queued after it
+	s.publishLocks.Lock(client.UserID)
+	defer s.publishLocks.Unlock(client.UserID)
+
+	seq, err := s.lastSeq(client.UserID)
+	if err != nil {
+		sendFrameError(client, id, err)
+		return
+	}
+	snapshot, err := s.snapshot(client.UserID, seq)
+	if err != nil {
+		sendFrameError(client, id, err)
+		return
+	}
+	snapshot.ID = id
+	client.Send(snapshot)
+}
+
+// lastSeq returns the number of the user's last event, or 0 without an event
+// log. A snapshot can't be numbered when it fails, since seq 0 would send the
+// client back to the start of the log.
+func (s *Server) lastSeq(userID string) (int64, error) {
+	if s.events == nil {
+		return 0, nil
+	}
+	seq, err := s.events.LastSeq(userID)
+	if err != nil {
+		log.Printf("Error loading sequence number of user %s: %v", userID, err)
+		return 0, &frameError{"internal_error", "failed to load library"}
+	}
+	return seq, nil
+}
+
+// initialSnapshot returns the frame with the library a client asked for in
+// its hello. It must be called with the user's publish lock held, so that seq
+// is the last event the library reflects and later events follow it without a
+// gap. seqErr is the error looking up seq, if any.
+func (s *Server) initialSnapshot(userID string, seq int64, seqErr error, enc protocol.Encoding) [][]byte {
+	var msg protocol.Message
+	var snapshot *protocol.Snapshot
+	err := seqErr
+	if err == nil {
+		snapshot, err = s.snapshot(userID, seq)
+	}
+	if err != nil {
+		msg = errorAck("", err)
+	} else {
+		msg = snapshot
+	}
+	data, _ := protocol.MarshalFrame(msg, enc)
+	return [][]byte{data}
+}
+
+// snapshot loads a user's library, including the progress of each entry,
+// into a snapshot tagged with seq
+func (s *Server) snapshot(userID string, seq int64) (*protocol.Snapshot, error) {
+	if s.library == nil {
+		return nil, errSyncUnavailable
+	}
+
+	library, err := s.library.GetUserLibrary(userID, "")
+	if err != nil {
+		log.Printf("Error loading library of user %s: %v", userID, err)
+		return nil, &frameError{"internal_error", "failed to load library"}
+	}
+	if library == nil {
+		library = []*models.UserProgress{}
+	}
+
+	return &protocol.Snapshot{
+		Seq:       seq,
+		Library:   library,
+		Timestamp: time.Now().Unix(),
+	}, nil
+}
+
+// authorize checks that the token the client is using, which may have been
+// replaced since the handshake, still grants access to the library
+func (s *Server) authorize(client *Client) error {
+	s.mutex.RLock()
+	claims := client.claims
+	s.mutex.RUnlock()
+
+	if claims == nil || !claims.HasScope(auth.ScopeWriteLibrary) {
+		return &frameError{"forbidden", "token is missing the " + auth.ScopeWriteLibrary + " scope"}
+	}
+	return nil
+}
+
+// lookupManga finds the manga a frame refers to
+func (s *Server) lookupManga(id string) (*models.Manga, error) {
+	m, err := s.library.GetByID(id)
+	if err == manga.ErrMangaNotFound {
+		return nil, &frameError{"manga_not_found", "manga not found"}
+	}
+	if err != nil {
+		return nil, storeError(err, "failed to verify manga")
+	}
+	return m, nil
+}
+
+// storeError turns a repository error into the error reported to the client
+func storeError(err error, message string) error {
+	if err == manga.ErrProgressNotFound {
+		return &frameError{"not_in_library", "manga not in library. Add it first"}
+	}
+	log.Printf("TCP sync: %s: %v", message, err)
+	return &frameError{"internal_error", message}
+}
+
+// sendFrameError acknowledges a frame that couldn't be applied
+func sendFrameError(client *Client, id string, err error) {
+	client.Send(errorAck(id, err))
+}
+
+// errorAck is the acknowledgement of a frame rejected with err
+func errorAck(id string, err error) *protocol.Ack {
+	fe, ok := err.(*frameError)
+	if !ok {
+		fe = &frameError{"internal_error", err.Error()}
+	}
+	return &protocol.Ack{
+		ID:      id,
+		Status:  "error",
+		Code:    fe.Code,
+		Message: fe.Message,
+	}
+}
+
+// decodeError is why a frame that couldn't be decoded was rejected
+func decodeError(err error) error {
+	if errors.Is(err, protocol.ErrUnknownType) {
+		return &frameError{"unknown_type", err.Error()}
+	}
+	return &frameError{"invalid_frame", err.Error()}
+}
+
+// frameID finds the ID of a JSON frame that couldn't be decoded, so the
+// error can be matched to it
+func frameID(data []byte, enc protocol.Encoding) string {
+	var frame struct {
+		ID string `json:"id"`
+	}
+	if enc == protocol.JSON {
+		json.Unmarshal(data, &frame)
+	}
+	return frame.ID
+}
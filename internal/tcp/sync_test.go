@@ -0,0 +1,324 @@
+package tcp
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"mangahub/internal/manga"
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+func setupSync(t *testing.T) *Server {
+	db, err := database.InitDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	// Every connection to :memory: is a separate database
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec(`
+		INSERT INTO manga (id, title, author, genres, status, total_chapters, description, cover_url, manga_url, year)
+		VALUES ('test-manga-1', 'Test Manga 1', 'Test Author 1', '[]', 'ongoing', 100, '', '', '', 2020)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to seed test data: %v", err)
+	}
+
+	s := NewServer(":0", fakeVerifier{
+		"reader":  claimsFor("user-1", time.Hour),
+		"someone": claimsFor("user-2", time.Hour),
+	})
+	s.UseLibrary(manga.NewRepository(db), nil)
+	s.UseEventLog(NewEventLog(db, 3))
+	return s
+}
+
+func TestPushFanOut(t *testing.T) {
+	s := setupSync(t)
+	var observed []models.ProgressUpdate
+	s.OnProgress(func(update models.ProgressUpdate) { observed = append(observed, update) })
+
+	laptop, laptopReader, _ := connect(t, s, "reader")
+	phone, phoneReader, _ := connect(t, s, "reader")
+	other, otherReader, _ := connect(t, s, "someone")
+
+	// The other device hears about the change, then the sender gets its ack
+	send(t, laptop, map[string]interface{}{"type": "library_update", "id": "c1", "manga_id": "test-manga-1", "status": "reading"})
+	if frame := receive(t, phoneReader); frame["type"] != "library_update" || frame["status"] != "reading" {
+		t.Errorf("Expected a library_update on the phone, got %v", frame)
+	}
+	if frame := receive(t, laptopReader); frame["type"] != "ack" || frame["id"] != "c1" || frame["status"] != "ok" {
+		t.Errorf("Expected ack c1, got %v", frame)
+	}
+
+	send(t, laptop, map[string]interface{}{"type": "progress_update", "id": "c2", "manga_id": "test-manga-1", "chapter": 12, "page": 3})
+	if frame := receive(t, phoneReader); frame["type"] != "progress_update" || frame["chapter"] != 12.0 || frame["page"] != 3.0 {
+		t.Errorf("Expected chapter 12 page 3 on the phone, got %v", frame)
+	}
+	if frame := receive(t, laptopReader); frame["type"] != "ack" || frame["id"] != "c2" {
+		t.Errorf("Expected ack c2, got %v", frame)
+	}
+	if len(observed) != 1 || observed[0].Chapter != 12 {
+		t.Errorf("Expected the update to be observed once, got %v", observed)
+	}
+
+	// Another user hears nothing
+	other.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, err := otherReader.ReadBytes('\n'); err == nil {
+		t.Error("Expected no frames for another user")
+	}
+
+	// Changes are stored for the next snapshot
+	send(t, phone, map[string]interface{}{"type": "request_snapshot", "id": "c3"})
+	frame := receive(t, phoneReader)
+	library, _ := frame["library"].([]interface{})
+	if frame["type"] != "snapshot" || frame["id"] != "c3" || len(library) != 1 {
+		t.Fatalf("Expected a snapshot with one entry, got %v", frame)
+	}
+	if entry := library[0].(map[string]interface{}); entry["current_chapter"] != 12.0 || entry["status"] != "reading" {
+		t.Errorf("Expected chapter 12 while reading, got %v", entry)
+	}
+}
+
+func TestPushErrors(t *testing.T) {
+	s := setupSync(t)
+	conn, reader, _ := connect(t, s, "reader")
+
+	for code, frame := range map[string]map[string]interface{}{
+		"manga_not_found": {"type": "progress_update", "manga_id": "missing", "chapter": 1},
+		"not_in_library":  {"type": "progress_update", "manga_id": "test-manga-1", "chapter": 1},
+		"invalid_frame":   {"type": "library_update", "manga_id": "test-manga-1", "status": "skimming"},
+		"unknown_type":    {"type": "rewind"},
+	} {
+		frame["id"] = code
+		send(t, conn, frame)
+		if ack := receive(t, reader); ack["status"] != "error" || ack["code"] != code || ack["id"] != code {
+			t.Errorf("Expected a %s error, got %v", code, ack)
+		}
+	}
+
+	// A server without the database can't store anything
+	readOnly, readOnlyReader, _ := connect(t, NewServer(":0", fakeVerifier{"reader": claimsFor("user-1", time.Hour)}), "reader")
+	send(t, readOnly, map[string]interface{}{"type": "request_snapshot", "id": "s1"})
+	if ack := receive(t, readOnlyReader); ack["code"] != "sync_unavailable" {
+		t.Errorf("Expected sync_unavailable, got %v", ack)
+	}
+}
+
+func TestPushWithoutEventLog(t *testing.T) {
+	s := setupSync(t)
+	laptop, laptopReader, _ := connect(t, s, "reader")
+	phone, phoneReader, _ := connect(t, s, "reader")
+
+	send(t, laptop, map[string]interface{}{"type": "library_update", "id": "c1", "manga_id": "test-manga-1", "status": "reading"})
+	receive(t, phoneReader)
+	receive(t, laptopReader)
+
+	// An event that can't be numbered fails the ack and isn't sent on
+	if _, err := s.events.db.Exec(`DROP TABLE sync_events`); err != nil {
+		t.Fatalf("Failed to drop events: %v", err)
+	}
+	send(t, laptop, map[string]interface{}{"type": "progress_update", "id": "c2", "manga_id": "test-manga-1", "chapter": 3})
+	if ack := receive(t, laptopReader); ack["id"] != "c2" || ack["status"] != "error" || ack["code"] != "internal_error" {
+		t.Errorf("Expected an internal_error ack, got %v", ack)
+	}
+	phone.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if frame, err := phoneReader.ReadBytes('\n'); err == nil {
+		t.Errorf("Expected nothing on the phone, got %s", frame)
+	}
+}
+
+func TestSnapshotWithoutSequence(t *testing.T) {
+	s := setupSync(t)
+	conn, reader, _ := connect(t, s, "reader")
+
+	// A snapshot numbered 0 would send the client back to the start of the log
+	if _, err := s.events.db.Exec(`DROP TABLE sync_sequences`); err != nil {
+		t.Fatalf("Failed to drop sequences: %v", err)
+	}
+	send(t, conn, map[string]interface{}{"type": "request_snapshot", "id": "s1"})
+	if ack := receive(t, reader); ack["type"] != "ack" || ack["id"] != "s1" || ack["code"] != "internal_error" {
+		t.Errorf("Expected an internal_error ack, got %v", ack)
+	}
+
+	_, reader, _ = connectWith(t, s, map[string]interface{}{"snapshot": true})
+	if ack := receive(t, reader); ack["type"] != "ack" || ack["code"] != "internal_error" {
+		t.Errorf("Expected an internal_error ack instead of the initial snapshot, got %v", ack)
+	}
+}
+
+func TestPageTurnAfterUnmark(t *testing.T) {
+	s := setupSync(t)
+	s.UseLibrary(s.library, manga.NewPositionTracker(s.library, time.Hour))
+	conn, reader, _ := connect(t, s, "reader")
+
+	send(t, conn, map[string]interface{}{"type": "library_update", "manga_id": "test-manga-1", "status": "reading"})
+	receive(t, reader)
+	send(t, conn, map[string]interface{}{"type": "progress_update", "manga_id": "test-manga-1", "chapter": 3, "page": 1})
+	receive(t, reader)
+
+	// Another frontend unmarks the chapter being read, then the reader turns
+	// the page
+	if _, err := s.library.MarkChapters("user-1", "test-manga-1", manga.ChapterSpan(3, 3), false); err != nil {
+		t.Fatalf("Failed to unmark chapter 3: %v", err)
+	}
+	send(t, conn, map[string]interface{}{"type": "progress_update", "id": "p2", "manga_id": "test-manga-1", "chapter": 3, "page": 2})
+	if ack := receive(t, reader); ack["status"] != "ok" {
+		t.Fatalf("Expected ack p2, got %v", ack)
+	}
+
+	progress, err := s.library.GetProgress("user-1", "test-manga-1")
+	if err != nil {
+		t.Fatalf("Failed to get progress: %v", err)
+	}
+	if progress.CurrentChapter != 3 {
+		t.Errorf("Expected chapter 3 to be read again, got current chapter %d", progress.CurrentChapter)
+	}
+}
+
+func TestUserLocks(t *testing.T) {
+	var locks userLocks
+	locks.Lock("user-1")
+
+	// Another user isn't held up
+	done := make(chan struct{})
+	go func() {
+		locks.Lock("user-2")
+		locks.Unlock("user-2")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected user-2 to lock while user-1 is locked")
+	}
+
+	// The same user waits its turn
+	waited := make(chan struct{})
+	go func() {
+		locks.Lock("user-1")
+		locks.Unlock("user-1")
+		close(waited)
+	}()
+	select {
+	case <-waited:
+		t.Fatal("Expected user-1 to stay locked")
+	case <-time.After(50 * time.Millisecond):
+	}
+	locks.Unlock("user-1")
+	<-waited
+
+	if len(locks.locks) != 0 {
+		t.Errorf("Expected unused locks to be dropped, got %d", len(locks.locks))
+	}
+}
+
+// connectWith connects as reader with a handshake that has extra fields
+func connectWith(t *testing.T, s *Server, hello map[string]interface{}) (net.Conn, *bufio.Reader, map[string]interface{}) {
+	server, client := net.Pipe()
+	t.Cleanup(func() { client.Close() })
+	s.wg.Add(1)
+	go s.handleConnection(server)
+
+	hello["token"] = "reader"
+	send(t, client, hello)
+	reader := bufio.NewReader(client)
+	welcome := receive(t, reader)
+	if welcome["status"] != "connected" {
+		t.Fatalf("Expected to connect, got %v", welcome)
+	}
+	return client, reader, welcome
+}
+
+// resume reconnects after the event numbered seq
+func resume(t *testing.T, s *Server, seq int64) (net.Conn, *bufio.Reader) {
+	conn, reader, _ := connectWith(t, s, map[string]interface{}{"last_seq": seq})
+	return conn, reader
+}
+
+func TestResume(t *testing.T) {
+	s := setupSync(t)
+	laptop, laptopReader, _ := connect(t, s, "reader")
+
+	send(t, laptop, map[string]interface{}{"type": "library_update", "id": "c1", "manga_id": "test-manga-1", "status": "reading"})
+	if ack := receive(t, laptopReader); ack["seq"] != 1.0 {
+		t.Fatalf("Expected the first event to be seq 1, got %v", ack)
+	}
+	for chapter := 1; chapter <= 2; chapter++ {
+		send(t, laptop, map[string]interface{}{"type": "progress_update", "manga_id": "test-manga-1", "chapter": chapter})
+		receive(t, laptopReader)
+	}
+
+	// New connections learn where the log is
+	phone, _, hello := connect(t, s, "reader")
+	if hello["seq"] != 3.0 {
+		t.Errorf("Expected the handshake to report seq 3, got %v", hello)
+	}
+	phone.Close()
+
+	// A device that saw the first event gets the two it missed
+	conn, reader := resume(t, s, 1)
+	for _, want := range []float64{2, 3} {
+		if frame := receive(t, reader); frame["type"] != "progress_update" || frame["seq"] != want {
+			t.Errorf("Expected progress_update seq %v, got %v", want, frame)
+		}
+	}
+	if frame := receive(t, reader); frame["type"] != "replay_complete" || frame["count"] != 2.0 {
+		t.Errorf("Expected replay_complete with 2 events, got %v", frame)
+	}
+	conn.Close()
+
+	// Only the last 3 events are kept
+	for chapter := 3; chapter <= 4; chapter++ {
+		send(t, laptop, map[string]interface{}{"type": "progress_update", "manga_id": "test-manga-1", "chapter": chapter})
+		receive(t, laptopReader)
+	}
+	_, reader = resume(t, s, 1)
+	if frame := receive(t, reader); frame["type"] != "resync_required" || frame["seq"] != 5.0 {
+		t.Errorf("Expected resync_required at seq 5, got %v", frame)
+	}
+
+	send(t, laptop, map[string]interface{}{"type": "request_snapshot", "id": "s1"})
+	if frame := receive(t, laptopReader); frame["type"] != "snapshot" || frame["seq"] != 5.0 {
+		t.Errorf("Expected the snapshot at seq 5, got %v", frame)
+	}
+}
+
+func TestInitialSnapshot(t *testing.T) {
+	s := setupSync(t)
+	laptop, laptopReader, _ := connect(t, s, "reader")
+
+	send(t, laptop, map[string]interface{}{"type": "library_update", "id": "c1", "manga_id": "test-manga-1", "status": "reading"})
+	receive(t, laptopReader)
+	send(t, laptop, map[string]interface{}{"type": "progress_update", "id": "c2", "manga_id": "test-manga-1", "chapter": 7})
+	receive(t, laptopReader)
+
+	// The snapshot replaces the replay and is tagged with the last event it holds
+	_, phoneReader, welcome := connectWith(t, s, map[string]interface{}{"snapshot": true, "last_seq": 0})
+	if welcome["seq"] != 2.0 {
+		t.Errorf("Expected the handshake to report seq 2, got %v", welcome)
+	}
+	frame := receive(t, phoneReader)
+	library, _ := frame["library"].([]interface{})
+	if frame["type"] != "snapshot" || frame["seq"] != 2.0 || len(library) != 1 {
+		t.Fatalf("Expected a snapshot at seq 2 with one entry, got %v", frame)
+	}
+	if entry := library[0].(map[string]interface{}); entry["current_chapter"] != 7.0 {
+		t.Errorf("Expected chapter 7 in the snapshot, got %v", entry)
+	}
+
+	// Live changes follow straight after
+	send(t, laptop, map[string]interface{}{"type": "progress_update", "id": "c3", "manga_id": "test-manga-1", "chapter": 8})
+	if frame := receive(t, phoneReader); frame["type"] != "progress_update" || frame["seq"] != 3.0 {
+		t.Errorf("Expected progress_update seq 3 after the snapshot, got %v", frame)
+	}
+
+	// A server without the database says why there is no snapshot
+	readOnly := NewServer(":0", fakeVerifier{"reader": claimsFor("user-1", time.Hour)})
+	_, reader, _ := connectWith(t, readOnly, map[string]interface{}{"snapshot": true})
+	if ack := receive(t, reader); ack["type"] != "ack" || ack["code"] != "sync_unavailable" {
+		t.Errorf("Expected sync_unavailable, got %v", ack)
+	}
+}
@@ -0,0 +1,218 @@
+// Package tlsconfig builds the TLS configuration of the HTTP, gRPC and TCP
+// servers and their clients, including self-signed certificates for
+// development.
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DevValidity is how long a generated development certificate is valid
+const DevValidity = 365 * 24 * time.Hour
+
+var (
+	ErrCertAndKey  = errors.New("a TLS certificate and key must be set together")
+	ErrNoCAs       = errors.New("no certificates found in CA file")
+	ErrNeedsServer = errors.New("a client CA needs a server certificate or development mode")
+)
+
+// Config says where a server's certificate comes from. TLS is off when
+// neither CertFile nor Dev is set.
+type Config struct {
+	CertFile string // PEM certificate chain
+	KeyFile  string // PEM private key
+	ClientCA string // PEM bundle; clients must present a certificate it signed
+	Dev      bool   // generate a self-signed certificate when CertFile is empty
+	DevDir   string // where the generated certificate is kept between restarts
+	Hosts    []string
+}
+
+// New returns the TLS configuration for a server, or nil when TLS is off
+func New(cfg Config) (*tls.Config, error) {
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, ErrCertAndKey
+	}
+
+	certFile, keyFile := cfg.CertFile, cfg.KeyFile
+	if certFile == "" && cfg.Dev {
+		certFile = filepath.Join(cfg.DevDir, "cert.pem")
+		keyFile = filepath.Join(cfg.DevDir, "key.pem")
+		if err := ensureDevCert(certFile, keyFile, cfg.Hosts); err != nil {
+			return nil, err
+		}
+	}
+	if certFile == "" {
+		if cfg.ClientCA != "" {
+			return nil, ErrNeedsServer
+		}
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.ClientCA != "" {
+		pool, err := LoadCAs(cfg.ClientCA)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// Client returns the TLS configuration for connecting to a server. caFile
+// adds to the system roots, and certFile and keyFile are the client
+// certificate for servers that require one. Both may be empty.
+func Client(caFile, certFile, keyFile string, insecureSkipVerify bool) (*tls.Config, error) {
+	if (certFile == "") != (keyFile == "") {
+		return nil, ErrCertAndKey
+	}
+
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: insecureSkipVerify,
+	}
+	if caFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, ErrNoCAs
+		}
+		config.RootCAs = pool
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// LoadCAs reads a PEM bundle of CA certificates
+func LoadCAs(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, ErrNoCAs
+	}
+	return pool, nil
+}
+
+// ensureDevCert keeps the certificate in certFile if it is still valid for a
+// day and covers hosts, and generates a new one otherwise
+func ensureDevCert(certFile, keyFile string, hosts []string) error {
+	if cert, err := tls.LoadX509KeyPair(certFile, keyFile); err == nil {
+		if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil &&
+			time.Now().Add(24*time.Hour).Before(leaf.NotAfter) && covers(leaf, devHosts(hosts)) {
+			return nil
+		}
+	}
+	return GenerateSelfSigned(certFile, keyFile, devHosts(hosts), DevValidity)
+}
+
+// devHosts adds the loopback names to hosts
+func devHosts(hosts []string) []string {
+	return append([]string{"localhost", "127.0.0.1", "::1"}, hosts...)
+}
+
+// covers reports whether cert is valid for every host
+func covers(cert *x509.Certificate, hosts []string) bool {
+	for _, host := range hosts {
+		if cert.VerifyHostname(host) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// GenerateSelfSigned writes a new self-signed certificate for hosts, which
+// may be names or IP addresses, and its private key. The certificate is its
+// own CA, so clients can trust it by adding it to their CA bundle.
+func GenerateSelfSigned(certFile, keyFile string, hosts []string, validFor time.Duration) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"MangaHub development"}, CommonName: hosts[0]},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validFor),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("failed to create certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode key: %w", err)
+	}
+
+	if dir := filepath.Dir(certFile); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	if err := writePEM(keyFile, "PRIVATE KEY", keyDER, 0600); err != nil {
+		return err
+	}
+	return writePEM(certFile, "CERTIFICATE", der, 0644)
+}
+
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
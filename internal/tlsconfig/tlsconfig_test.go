@@ -0,0 +1,149 @@
+package tlsconfig
+
+import (
+	"bytes"
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// handshake connects a client to a server over loopback and returns the
+// client's error, or the server's if only that one failed
+func handshake(server, client *tls.Config) error {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", server)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		// TLS 1.3 client certificates are checked after the client is done,
+		// so the server reads to see the outcome
+		_, err = conn.Read(make([]byte, 1))
+		done <- err
+	}()
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), client)
+	if err == nil {
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		_, err = conn.Write([]byte("x"))
+		conn.Close()
+	}
+	if serverErr := <-done; err == nil {
+		return serverErr
+	}
+	return err
+}
+
+func TestDevCertificate(t *testing.T) {
+	dir := t.TempDir()
+	server, err := New(Config{Dev: true, DevDir: dir, Hosts: []string{"mangahub.test"}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// Clients trust the generated certificate by adding it as a CA
+	client, err := Client(filepath.Join(dir, "cert.pem"), "", "", false)
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	for _, host := range []string{"localhost", "127.0.0.1", "mangahub.test"} {
+		client.ServerName = host
+		if err := handshake(server, client); err != nil {
+			t.Errorf("%s: %v", host, err)
+		}
+	}
+
+	client.ServerName = "example.com"
+	if err := handshake(server, client); err == nil {
+		t.Error("certificate accepted for a host it wasn't made for")
+	}
+
+	// Restarts keep the certificate clients already trust
+	before, _ := os.ReadFile(filepath.Join(dir, "cert.pem"))
+	if _, err := New(Config{Dev: true, DevDir: dir, Hosts: []string{"mangahub.test"}}); err != nil {
+		t.Fatalf("New again: %v", err)
+	}
+	after, _ := os.ReadFile(filepath.Join(dir, "cert.pem"))
+	if !bytes.Equal(before, after) {
+		t.Error("development certificate was regenerated")
+	}
+
+	// ...unless it doesn't cover a newly configured host
+	if _, err := New(Config{Dev: true, DevDir: dir, Hosts: []string{"other.test"}}); err != nil {
+		t.Fatalf("New with another host: %v", err)
+	}
+	after, _ = os.ReadFile(filepath.Join(dir, "cert.pem"))
+	if bytes.Equal(before, after) {
+		t.Error("development certificate kept for a new host")
+	}
+}
+
+func TestClientCertificates(t *testing.T) {
+	dir := t.TempDir()
+	caCert, caKey := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca-key.pem")
+	if err := GenerateSelfSigned(caCert, caKey, []string{"laptop"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	strangerCert, strangerKey := filepath.Join(dir, "stranger.pem"), filepath.Join(dir, "stranger-key.pem")
+	if err := GenerateSelfSigned(strangerCert, strangerKey, []string{"stranger"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := New(Config{Dev: true, DevDir: filepath.Join(dir, "server"), ClientCA: caCert})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	serverCA := filepath.Join(dir, "server", "cert.pem")
+
+	tests := []struct {
+		name      string
+		cert, key string
+		ok        bool
+	}{
+		{"no certificate", "", "", false},
+		{"unknown certificate", strangerCert, strangerKey, false},
+		{"trusted certificate", caCert, caKey, true},
+	}
+	for _, tt := range tests {
+		client, err := Client(serverCA, tt.cert, tt.key, false)
+		if err != nil {
+			t.Fatalf("%s: Client: %v", tt.name, err)
+		}
+		client.ServerName = "localhost"
+		if err := handshake(server, client); (err == nil) != tt.ok {
+			t.Errorf("%s: handshake error %v", tt.name, err)
+		}
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	if config, err := New(Config{}); config != nil || err != nil {
+		t.Errorf("empty config = %v, %v, want TLS off", config, err)
+	}
+	if _, err := New(Config{CertFile: "cert.pem"}); err != ErrCertAndKey {
+		t.Errorf("certificate without key: %v", err)
+	}
+	if _, err := New(Config{ClientCA: "ca.pem"}); err != ErrNeedsServer {
+		t.Errorf("client CA without certificate: %v", err)
+	}
+	if _, err := Client("", "", "key.pem", false); err != ErrCertAndKey {
+		t.Errorf("client key without certificate: %v", err)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	os.WriteFile(empty, []byte("not a certificate"), 0644)
+	if _, err := Client(empty, "", "", false); err != ErrNoCAs {
+		t.Errorf("CA file without certificates: %v", err)
+	}
+}
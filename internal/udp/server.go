@@ -1,37 +1,67 @@
 package udp
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"net"
 	"sync"
 	"time"
 
+	"mangahub/internal/auth"
+	"mangahub/internal/ratelimit"
 	"mangahub/pkg/models"
+	"mangahub/pkg/protocol"
 )
 
 type UDPClient struct {
 	Addr        *net.UDPAddr
 	UserID      string
+	SessionID   string // login the subscription was authenticated with, if a token was sent
 	LastSeen    time.Time
-	Preferences map[string]bool // notification preferences
+	Preferences map[string]bool   // notification preferences
+	Encoding    protocol.Encoding // the client registered with, used for notifications
 }
 
 type Server struct {
-	port    string
-	conn    *net.UDPConn
-	clients map[string]*UDPClient
-	mutex   sync.RWMutex
+	port       string
+	tokens     auth.Verifier
+	isVerified func(userID string) (bool, error)
+	defaults   func(userID string) (map[string]bool, error)
+	limiter    *ratelimit.Limiter // packets per client IP; nil means unlimited
+	conn       *net.UDPConn
+	clients    map[string]*UDPClient
+	mutex      sync.RWMutex
 }
 
-func NewServer(port string) *Server {
+// NewServer creates a UDP notification server. A standalone server without
+// access to the user database can verify tokens against a JWKS. Clients must
+// register with a token, so a nil verifier rejects every registration.
+func NewServer(port string, tokens auth.Verifier) *Server {
 	return &Server{
 		port:    port,
+		tokens:  tokens,
 		clients: make(map[string]*UDPClient),
 	}
 }
 
+// RequireVerifiedEmail only lets users whose email isVerified reports as
+// verified register for notifications
+func (s *Server) RequireVerifiedEmail(isVerified func(userID string) (bool, error)) {
+	s.isVerified = isVerified
+}
+
+// DefaultPreferences makes clients that register without preferences get the
+// ones defaults returns for their user, instead of every notification
+func (s *Server) DefaultPreferences(defaults func(userID string) (map[string]bool, error)) {
+	s.defaults = defaults
+}
+
+// LimitRequests limits how many packets each client IP can send. Packets over
+// the limit are dropped without a reply so floods can't be amplified.
+func (s *Server) LimitRequests(l *ratelimit.Limiter) {
+	s.limiter = l
+}
+
 // Start starts the UDP notification server
 func (s *Server) Start() error {
 	addr, err := net.ResolveUDPAddr("udp", s.port)
@@ -59,51 +89,78 @@ func (s *Server) Start() error {
 			continue
 		}
 
-		go s.handleMessage(buffer[:n], clientAddr)
+		if ok, _ := s.limiter.Allow(clientAddr.IP.String()); !ok {
+			continue
+		}
+
+		// The buffer is reused for the next packet
+		go s.handleMessage(append([]byte(nil), buffer[:n]...), clientAddr)
 	}
 }
 
-// handleMessage processes incoming UDP messages
+// handleMessage processes incoming UDP messages. Replies use the encoding
+// of the message they answer.
 func (s *Server) handleMessage(data []byte, addr *net.UDPAddr) {
-	var msg map[string]interface{}
-	if err := json.Unmarshal(data, &msg); err != nil {
+	enc := protocol.Detect(data)
+	msg, err := protocol.Unmarshal(data, enc)
+	if err != nil {
 		log.Printf("Error parsing UDP message: %v", err)
 		return
 	}
 
-	msgType, ok := msg["type"].(string)
-	if !ok {
-		return
-	}
-
-	switch msgType {
-	case "register":
-		s.handleRegister(msg, addr)
-	case "unregister":
-		s.handleUnregister(msg, addr)
-	case "ping":
-		s.handlePing(addr)
+	switch m := msg.(type) {
+	case *protocol.Register:
+		s.handleRegister(m, addr, enc)
+	case *protocol.Unregister:
+		s.handleUnregister(addr, enc)
+	case *protocol.Ping:
+		s.handlePing(addr, enc)
 	}
 }
 
 // handleRegister registers a client for notifications
-func (s *Server) handleRegister(msg map[string]interface{}, addr *net.UDPAddr) {
-	userID, ok := msg["user_id"].(string)
-	if !ok {
-		log.Printf("Registration failed: missing user_id")
+func (s *Server) handleRegister(msg *protocol.Register, addr *net.UDPAddr, enc protocol.Encoding) {
+	version, err := protocol.Negotiate(msg.Versions)
+	if err != nil {
+		s.sendToClient(addr, enc, protocol.NewError(fmt.Sprintf("this server speaks protocol versions %d to %d", protocol.MinVersion, protocol.Version)))
+		return
+	}
+	// A token identifies the user and the login, so the subscription can be
+	// dropped when that login is revoked. The user ID in the message is
+	// never trusted; anyone could subscribe as any user.
+	if msg.Token == "" {
+		s.sendToClient(addr, enc, protocol.NewError("send a JWT or personal access token to register"))
 		return
 	}
+	if s.tokens == nil {
+		s.sendToClient(addr, enc, protocol.NewError("this server can't verify tokens"))
+		return
+	}
+	claims, err := s.tokens.Validate(msg.Token)
+	if err != nil {
+		s.sendToClient(addr, enc, protocol.NewError("invalid or expired token"))
+		return
+	}
+	userID := claims.UserID
+	sessionID := claims.SessionID
+
+	if s.isVerified != nil {
+		if verified, err := s.isVerified(userID); err != nil || !verified {
+			s.sendToClient(addr, enc, protocol.NewError("verify your email address to receive notifications"))
+			return
+		}
+	}
 
 	clientKey := addr.String()
 
 	// Extract preferences
 	preferences := make(map[string]bool)
-	if prefs, ok := msg["preferences"].(map[string]interface{}); ok {
-		for k, v := range prefs {
-			if boolVal, ok := v.(bool); ok {
-				preferences[k] = boolVal
-			}
+	if len(msg.Preferences) > 0 {
+		for k, v := range msg.Preferences {
+			preferences[k] = v
 		}
+	} else if saved, err := s.savedPreferences(userID); err == nil {
+		preferences = saved
 	} else {
 		// Default preferences
 		preferences["chapter_releases"] = true
@@ -114,25 +171,36 @@ func (s *Server) handleRegister(msg map[string]interface{}, addr *net.UDPAddr) {
 	s.clients[clientKey] = &UDPClient{
 		Addr:        addr,
 		UserID:      userID,
+		SessionID:   sessionID,
 		LastSeen:    time.Now(),
 		Preferences: preferences,
+		Encoding:    enc,
 	}
 	s.mutex.Unlock()
 
 	log.Printf("UDP client registered: %s (UserID: %s) with preferences: %v", clientKey, userID, preferences)
 
 	// Send confirmation
-	response := map[string]interface{}{
-		"status":      "registered",
-		"message":     "Successfully registered for notifications",
-		"preferences": preferences,
-		"timestamp":   time.Now().Unix(),
+	s.sendToClient(addr, enc, &protocol.Registered{
+		Status:      "registered",
+		Message:     "Successfully registered for notifications",
+		Preferences: preferences,
+		Version:     version,
+		Timestamp:   time.Now().Unix(),
+	})
+}
+
+// savedPreferences returns the user's default notification preferences from
+// their profile, if the server has access to it
+func (s *Server) savedPreferences(userID string) (map[string]bool, error) {
+	if s.defaults == nil {
+		return nil, fmt.Errorf("no saved preferences")
 	}
-	s.sendToClient(addr, response)
+	return s.defaults(userID)
 }
 
 // handleUnregister removes a client from notifications
-func (s *Server) handleUnregister(msg map[string]interface{}, addr *net.UDPAddr) {
+func (s *Server) handleUnregister(addr *net.UDPAddr, enc protocol.Encoding) {
 	clientKey := addr.String()
 
 	s.mutex.Lock()
@@ -142,15 +210,14 @@ func (s *Server) handleUnregister(msg map[string]interface{}, addr *net.UDPAddr)
 	log.Printf("UDP client unregistered: %s", clientKey)
 
 	// Send confirmation
-	response := map[string]interface{}{
-		"status":  "unregistered",
-		"message": "Successfully unregistered from notifications",
-	}
-	s.sendToClient(addr, response)
+	s.sendToClient(addr, enc, &protocol.Unregistered{
+		Status:  "unregistered",
+		Message: "Successfully unregistered from notifications",
+	})
 }
 
 // handlePing responds to ping messages
-func (s *Server) handlePing(addr *net.UDPAddr) {
+func (s *Server) handlePing(addr *net.UDPAddr, enc protocol.Encoding) {
 	clientKey := addr.String()
 
 	s.mutex.Lock()
@@ -159,11 +226,7 @@ func (s *Server) handlePing(addr *net.UDPAddr) {
 	}
 	s.mutex.Unlock()
 
-	response := map[string]interface{}{
-		"type":      "pong",
-		"timestamp": time.Now().Unix(),
-	}
-	s.sendToClient(addr, response)
+	s.sendToClient(addr, enc, &protocol.Pong{Timestamp: time.Now().Unix()})
 }
 
 // SendNotificationToUser sends notification to specific user's clients
@@ -171,24 +234,19 @@ func (s *Server) SendNotificationToUser(userID string, notification models.Notif
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
-	data, err := json.Marshal(notification)
-	if err != nil {
-		log.Printf("Error marshaling notification: %v", err)
-		return
-	}
-
-	sentCount := 0
+	var clients []*UDPClient
 	for _, client := range s.clients {
 		if client.UserID == userID {
-			_, err := s.conn.WriteToUDP(data, client.Addr)
-			if err != nil {
-				log.Printf("Error sending notification to %s: %v", client.Addr, err)
-			} else {
-				sentCount++
-			}
+			clients = append(clients, client)
 		}
 	}
 
+	sentCount, _ := s.sendToClients(clients, &protocol.Notification{
+		Kind:      notification.Type,
+		Message:   notification.Message,
+		MangaID:   notification.MangaID,
+		Timestamp: notification.Timestamp,
+	})
 	log.Printf("Sent notification to %d clients for user %s", sentCount, userID)
 }
 
@@ -197,56 +255,95 @@ func (s *Server) SendChapterNotification(mangaTitle string, chapter int, mangaID
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
-	notification := map[string]interface{}{
-		"type":        "chapter_release",
-		"title":       "New Chapter Released! 🔔",
-		"message":     fmt.Sprintf("%s - Chapter %d is now available", mangaTitle, chapter),
-		"manga_id":    mangaID,
-		"manga_title": mangaTitle,
-		"chapter":     chapter,
-		"timestamp":   time.Now().Unix(),
-	}
-
-	data, err := json.Marshal(notification)
-	if err != nil {
-		log.Printf("Error marshaling chapter notification: %v", err)
-		return
-	}
-
-	sentCount := 0
-	failedCount := 0
+	var clients []*UDPClient
 	for _, client := range s.clients {
 		// Check if client wants chapter release notifications
 		if enabled, exists := client.Preferences["chapter_releases"]; exists && !enabled {
 			continue
 		}
+		clients = append(clients, client)
+	}
 
-		_, err := s.conn.WriteToUDP(data, client.Addr)
-		if err != nil {
+	sentCount, failedCount := s.sendToClients(clients, &protocol.Notification{
+		Kind:       "chapter_release",
+		Title:      "New Chapter Released! 🔔",
+		Message:    fmt.Sprintf("%s - Chapter %d is now available", mangaTitle, chapter),
+		MangaID:    mangaID,
+		MangaTitle: mangaTitle,
+		Chapter:    chapter,
+		Timestamp:  time.Now().Unix(),
+	})
+	log.Printf("Chapter notification sent: %s Ch.%d - Success: %d, Failed: %d", mangaTitle, chapter, sentCount, failedCount)
+}
+
+// sendToClients sends a message to each client in the encoding it registered
+// with, and returns how many sends succeeded and failed
+func (s *Server) sendToClients(clients []*UDPClient, msg protocol.Message) (int, int) {
+	encoded := make(map[protocol.Encoding][]byte, 1)
+	sent, failed := 0, 0
+	for _, client := range clients {
+		data, ok := encoded[client.Encoding]
+		if !ok {
+			var err error
+			if data, err = protocol.Marshal(msg, client.Encoding); err != nil {
+				log.Printf("Error marshaling %s: %v", msg.MessageType(), err)
+				return sent, len(clients) - sent
+			}
+			encoded[client.Encoding] = data
+		}
+
+		if _, err := s.conn.WriteToUDP(data, client.Addr); err != nil {
 			log.Printf("Error sending notification to %s: %v", client.Addr, err)
-			failedCount++
+			failed++
 		} else {
-			sentCount++
+			sent++
 		}
 	}
-
-	log.Printf("Chapter notification sent: %s Ch.%d - Success: %d, Failed: %d", mangaTitle, chapter, sentCount, failedCount)
+	return sent, failed
 }
 
-// sendToClient sends data to a specific client
-func (s *Server) sendToClient(addr *net.UDPAddr, data interface{}) {
-	jsonData, err := json.Marshal(data)
+// sendToClient sends a message to a specific client
+func (s *Server) sendToClient(addr *net.UDPAddr, enc protocol.Encoding, msg protocol.Message) {
+	data, err := protocol.Marshal(msg, enc)
 	if err != nil {
 		log.Printf("Error marshaling response: %v", err)
 		return
 	}
 
-	_, err = s.conn.WriteToUDP(jsonData, addr)
+	_, err = s.conn.WriteToUDP(data, addr)
 	if err != nil {
 		log.Printf("Error sending to client %s: %v", addr, err)
 	}
 }
 
+// DisconnectSession drops every subscription made with a revoked login
+func (s *Server) DisconnectSession(sessionID string) {
+	if sessionID == "" {
+		return
+	}
+	s.disconnect(func(client *UDPClient) bool { return client.SessionID == sessionID },
+		&protocol.SessionRevoked{Message: "this session was logged out", Timestamp: time.Now().Unix()})
+}
+
+// DisconnectUser drops every subscription of a user, such as one deleting their account
+func (s *Server) DisconnectUser(userID string) {
+	s.disconnect(func(client *UDPClient) bool { return client.UserID == userID },
+		&protocol.AccountDeleted{Message: "this account is being deleted", Timestamp: time.Now().Unix()})
+}
+
+func (s *Server) disconnect(match func(client *UDPClient) bool, goodbye protocol.Message) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for key, client := range s.clients {
+		if match(client) {
+			s.sendToClient(client.Addr, client.Encoding, goodbye)
+			delete(s.clients, key)
+			log.Printf("Removed UDP client %s: %s", key, goodbye.MessageType())
+		}
+	}
+}
+
 // cleanupInactiveClients removes clients that haven't been seen recently
 func (s *Server) cleanupInactiveClients() {
 	ticker := time.NewTicker(1 * time.Minute)
@@ -0,0 +1,294 @@
+package user
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"mangahub/internal/auth"
+	"mangahub/internal/websocket"
+	"mangahub/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccountDeletionGrace is how long a deleted account can still be restored by
+// logging in before its data is removed for good
+const AccountDeletionGrace = 7 * 24 * time.Hour
+
+// exportReadme is included in every data export
+const exportReadme = `MangaHub data export
+
+profile.json           your account
+library.json           series in your library with status, rating and chapters read
+reading_positions.json where you stopped in each series
+reading_sessions.json  reading sessions with the chapters read in each
+goals.json             reading goals
+achievements.json      unlocked badges
+devices.json           devices you logged in on
+access_tokens.json     personal access tokens (without the secrets)
+linked_accounts.json   single sign-on identities linked to your account
+chat_messages.json     chat messages still in room history
+notifications.json     notifications
+
+Chat history is kept in memory only while a room is active, and notifications
+are delivered live and never stored, so those files may be empty.
+`
+
+// exportQueries are the tables in a data export, keyed by file name
+var exportQueries = []struct {
+	file  string
+	query string
+}{
+	{"library.json", `
+		SELECT p.manga_id, m.title, p.status, p.current_chapter, p.read_chapters, p.rating,
+			p.started_at, p.completed_at, p.updated_at
+		FROM user_progress p LEFT JOIN manga m ON m.id = p.manga_id
+		WHERE p.user_id = ? ORDER BY p.updated_at`},
+	{"reading_positions.json", `
+		SELECT manga_id, chapter, page, scroll_percent, updated_at
+		FROM reading_positions WHERE user_id = ? ORDER BY updated_at`},
+	{"reading_sessions.json", `
+		SELECT s.id AS session_id, s.manga_id, s.source, s.started_at, s.ended_at, s.duration_seconds,
+			c.manga_id AS chapter_manga_id, c.chapter, c.read_at
+		FROM reading_sessions s LEFT JOIN session_chapters c ON c.session_id = s.id
+		WHERE s.user_id = ? ORDER BY s.started_at, c.read_at`},
+	{"goals.json", `
+		SELECT id, kind, target, period, created_at FROM reading_goals WHERE user_id = ? ORDER BY created_at`},
+	{"achievements.json", `
+		SELECT badge, unlocked_at FROM user_achievements WHERE user_id = ? ORDER BY unlocked_at`},
+	{"devices.json", `
+		SELECT device_name, user_agent, ip, created_at, last_seen_at, revoked_at
+		FROM login_sessions WHERE user_id = ? ORDER BY created_at`},
+	{"access_tokens.json", `
+		SELECT id, name, scopes, expires_at, last_used_at, created_at
+		FROM personal_access_tokens WHERE user_id = ? ORDER BY created_at`},
+	{"linked_accounts.json", `
+		SELECT issuer, subject, email, last_login_at, created_at
+		FROM user_identities WHERE user_id = ? ORDER BY created_at`},
+}
+
+// ExportChatFrom includes the chat messages messagesBy returns in data exports
+func (s *Service) ExportChatFrom(messagesBy func(username string) []websocket.Message) {
+	s.chatMessages = messagesBy
+}
+
+// OnAccountDeleted registers a function called when a user asks to delete
+// their account, so live connections can be closed and in-memory data dropped
+func (s *Service) OnAccountDeleted(fn func(user *models.User)) {
+	s.deletedListeners = append(s.deletedListeners, fn)
+}
+
+// ExportData builds a zip archive of everything stored about a user
+func (s *Service) ExportData(userID string) ([]byte, error) {
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	add := func(name string, v interface{}) error {
+		w, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	w, err := archive.Create("README.txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write export: %w", err)
+	}
+	w.Write([]byte(exportReadme))
+
+	if err := add("profile.json", user); err != nil {
+		return nil, fmt.Errorf("failed to write export: %w", err)
+	}
+	for _, q := range exportQueries {
+		rows, err := s.repo.ExportRows(q.query, userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := add(q.file, rows); err != nil {
+			return nil, fmt.Errorf("failed to write export: %w", err)
+		}
+	}
+
+	messages := []websocket.Message{}
+	if s.chatMessages != nil {
+		messages = s.chatMessages(user.Username)
+	}
+	if err := add("chat_messages.json", messages); err != nil {
+		return nil, fmt.Errorf("failed to write export: %w", err)
+	}
+	if err := add("notifications.json", []interface{}{}); err != nil {
+		return nil, fmt.Errorf("failed to write export: %w", err)
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write export: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DeleteAccount schedules a user's account for deletion after checking their
+// password, and two-factor code if enabled. Every login and access token stops
+// working at once; logging in again before the deletion time restores the account.
+func (s *Service) DeleteAccount(userID string, req *models.DeleteAccountRequest) (time.Time, error) {
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if ok, _ := s.passwords.Check(req.Password, user.PasswordHash); !ok {
+		return time.Time{}, ErrWrongPassword
+	}
+	if user.TwoFactorEnabled {
+		if err := s.checkCode(userID, req.Code); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	deleteAt := time.Now().Add(AccountDeletionGrace)
+	if err := s.repo.ScheduleDeletion(userID, deleteAt); err != nil {
+		return time.Time{}, err
+	}
+
+	if err := s.tokens.RevokeAllSessions(userID, ""); err != nil {
+		return time.Time{}, err
+	}
+	personal, err := s.tokens.ListPersonalTokens(userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, token := range personal {
+		if err := s.tokens.RevokePersonalToken(userID, token.ID); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	for _, fn := range s.deletedListeners {
+		fn(user)
+	}
+	log.Printf("Account %s scheduled for deletion at %s", user.Username, deleteAt.Format(time.RFC3339))
+	return deleteAt, nil
+}
+
+// restoreAccount cancels a pending deletion when its owner logs in again
+func (s *Service) restoreAccount(user *models.User) bool {
+	restored, err := s.repo.CancelDeletion(user.ID)
+	if err != nil {
+		log.Printf("Failed to cancel deletion of %s: %v", user.Username, err)
+		return false
+	}
+	if restored {
+		log.Printf("Account %s restored, deletion cancelled", user.Username)
+	}
+	return restored
+}
+
+// PurgeDeletedAccounts removes accounts whose grace period has ended
+func (s *Service) PurgeDeletedAccounts(now time.Time) (int, error) {
+	ids, err := s.repo.DueDeletions(now)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		if err := s.repo.Delete(id); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+// RunAccountPurge periodically removes accounts whose grace period has ended
+func (s *Service) RunAccountPurge(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		count, err := s.PurgeDeletedAccounts(time.Now())
+		if err != nil {
+			log.Printf("Error deleting accounts: %v", err)
+			continue
+		}
+		if count > 0 {
+			log.Printf("Deleted %d accounts", count)
+		}
+	}
+}
+
+// ExportData handles downloading a zip archive of the user's data
+func (h *Handler) ExportData(c *gin.Context) {
+	userID := auth.GetUserID(c)
+
+	data, err := h.service.ExportData(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to export data",
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("mangahub-export-%s.zip", time.Now().UTC().Format("2006-01-02"))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/zip", data)
+}
+
+// DeleteAccount handles scheduling the user's account for deletion
+func (h *Handler) DeleteAccount(c *gin.Context) {
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "password is required",
+		})
+		return
+	}
+
+	deleteAt, err := h.service.DeleteAccount(auth.GetUserID(c), &req)
+	if err != nil {
+		switch err {
+		case ErrWrongPassword:
+			c.JSON(http.StatusUnauthorized, models.Response{
+				Success: false,
+				Error:   "password is incorrect",
+			})
+		case ErrInvalidCode:
+			c.JSON(http.StatusUnauthorized, models.Response{
+				Success: false,
+				Error:   "a valid two-factor code is required",
+			})
+		case ErrDeletionScheduled:
+			c.JSON(http.StatusConflict, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.Response{
+				Success: false,
+				Error:   "failed to delete account",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.Response{
+		Success: true,
+		Message: "account scheduled for deletion. Log in again before then to keep it",
+		Data: gin.H{
+			"deletion_scheduled_at": deleteAt,
+		},
+	})
+}
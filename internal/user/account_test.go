@@ -0,0 +1,139 @@
+package user
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"mangahub/internal/auth"
+	"mangahub/internal/websocket"
+	"mangahub/pkg/models"
+)
+
+// seedUserData gives the test user a library entry, a reading session and a goal
+func seedUserData(t *testing.T, repo *Repository, userID string) {
+	statements := []string{
+		`INSERT INTO manga (id, title, author, genres, status, total_chapters) VALUES ('one-piece', 'One Piece', 'Oda', '[]', 'ongoing', 1100)`,
+		`INSERT INTO user_progress (user_id, manga_id, current_chapter, read_chapters, status) VALUES (:user, 'one-piece', 12, '1-12', 'reading')`,
+		`INSERT INTO reading_sessions (id, user_id, manga_id, source, started_at, last_activity_at) VALUES ('s1', :user, 'one-piece', 'cli', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`,
+		`INSERT INTO session_chapters (session_id, manga_id, chapter, read_at) VALUES ('s1', 'one-piece', 12, CURRENT_TIMESTAMP)`,
+		`INSERT INTO reading_goals (id, user_id, kind, target, period) VALUES ('g1', :user, 'chapters', 10, 'week')`,
+	}
+	for _, stmt := range statements {
+		if _, err := repo.db.Exec(stmt, sql.Named("user", userID)); err != nil {
+			t.Fatalf("Failed to seed data: %v", err)
+		}
+	}
+}
+
+func TestExportData(t *testing.T) {
+	service, _ := setupTestService(t)
+	user, _ := service.repo.GetByUsername("reader")
+	seedUserData(t, service.repo, user.ID)
+	service.ExportChatFrom(func(username string) []websocket.Message {
+		return []websocket.Message{{Type: "chat", Room: "general", Username: username, Text: "hello"}}
+	})
+
+	data, err := service.ExportData(user.ID)
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Export is not a zip archive: %v", err)
+	}
+
+	files := make(map[string]string)
+	for _, f := range archive.File {
+		r, _ := f.Open()
+		content, _ := io.ReadAll(r)
+		r.Close()
+		files[f.Name] = string(content)
+	}
+
+	for _, name := range []string{"README.txt", "profile.json", "library.json", "reading_sessions.json", "goals.json", "chat_messages.json", "notifications.json"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected %s in the export", name)
+		}
+	}
+	if !strings.Contains(files["profile.json"], `"reader@example.com"`) || strings.Contains(files["profile.json"], "argon2id") {
+		t.Errorf("Expected the profile without the password hash, got %s", files["profile.json"])
+	}
+
+	var library []map[string]interface{}
+	json.Unmarshal([]byte(files["library.json"]), &library)
+	if len(library) != 1 || library[0]["title"] != "One Piece" {
+		t.Errorf("Unexpected library export: %s", files["library.json"])
+	}
+	if !strings.Contains(files["chat_messages.json"], "hello") {
+		t.Errorf("Expected chat messages in the export, got %s", files["chat_messages.json"])
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	service, _ := setupTestService(t)
+	user, _ := service.repo.GetByUsername("reader")
+	seedUserData(t, service.repo, user.ID)
+
+	var disconnected []string
+	service.OnAccountDeleted(func(u *models.User) { disconnected = append(disconnected, u.ID) })
+
+	login := &models.LoginRequest{Username: "reader", Password: "old-password"}
+	result, _ := service.Login(login, auth.Device{})
+
+	if _, err := service.DeleteAccount(user.ID, &models.DeleteAccountRequest{Password: "wrong"}); err != ErrWrongPassword {
+		t.Fatalf("Expected ErrWrongPassword, got %v", err)
+	}
+
+	deleteAt, err := service.DeleteAccount(user.ID, &models.DeleteAccountRequest{Password: "old-password"})
+	if err != nil {
+		t.Fatalf("Failed to delete account: %v", err)
+	}
+	if time.Until(deleteAt) < AccountDeletionGrace-time.Minute {
+		t.Errorf("Expected deletion after the grace period, got %v", deleteAt)
+	}
+	if len(disconnected) != 1 {
+		t.Errorf("Expected live connections to be closed")
+	}
+	if _, err := service.tokens.Validate(result.Tokens.AccessToken); err == nil {
+		t.Error("Expected existing logins to be revoked")
+	}
+	if _, err := service.DeleteAccount(user.ID, &models.DeleteAccountRequest{Password: "old-password"}); err != ErrDeletionScheduled {
+		t.Errorf("Expected ErrDeletionScheduled, got %v", err)
+	}
+
+	// Nothing is removed during the grace period, and logging in cancels it
+	if count, _ := service.PurgeDeletedAccounts(time.Now()); count != 0 {
+		t.Errorf("Expected no deletions before the grace period ends, got %d", count)
+	}
+	result, err = service.Login(login, auth.Device{})
+	if err != nil || !result.AccountRestored {
+		t.Fatalf("Expected logging in to restore the account, got %+v, %v", result, err)
+	}
+
+	service.DeleteAccount(user.ID, &models.DeleteAccountRequest{Password: "old-password"})
+	count, err := service.PurgeDeletedAccounts(time.Now().Add(AccountDeletionGrace + time.Minute))
+	if err != nil || count != 1 {
+		t.Fatalf("Expected one account deleted, got %d, %v", count, err)
+	}
+
+	if _, err := service.repo.GetByID(user.ID); err != ErrUserNotFound {
+		t.Errorf("Expected the user to be gone, got %v", err)
+	}
+	for _, table := range userTables {
+		var n int
+		if table == "session_chapters" {
+			service.repo.db.QueryRow(`SELECT COUNT(*) FROM session_chapters WHERE session_id = 's1'`).Scan(&n)
+		} else {
+			service.repo.db.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE user_id = ?`, user.ID).Scan(&n)
+		}
+		if n != 0 {
+			t.Errorf("Expected no rows left in %s, got %d", table, n)
+		}
+	}
+}
@@ -2,31 +2,77 @@ package user
 
 import (
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"mangahub/internal/auth"
+	"mangahub/internal/mail"
+	"mangahub/internal/oidc"
+	"mangahub/internal/ratelimit"
+	"mangahub/internal/websocket"
 	"mangahub/pkg/models"
 	"net/http"
+	"strings"
+)
+
+const (
+	// PasswordResetTTL is how long a password reset token stays valid
+	PasswordResetTTL = time.Hour
+
+	// EmailVerificationTTL is how long an email verification token stays valid
+	EmailVerificationTTL = 24 * time.Hour
+
+	// VerificationResendInterval is the minimum time between verification emails,
+	// and MaxVerificationEmailsPerDay caps how many a user can ask for
+	VerificationResendInterval  = time.Minute
+	MaxVerificationEmailsPerDay = 5
+
+	// After LockoutThreshold failed logins in a row an account is locked for
+	// LockoutBase, doubling with each further failure up to LockoutMax
+	LockoutThreshold = 5
+	LockoutBase      = time.Minute
+	LockoutMax       = time.Hour
 )
 
 type Service struct {
 	repo      *Repository
-	jwtSecret string
+	tokens    *auth.TokenManager
+	mailer    mail.Mailer
+	publicURL string // base URL used in links sent by email
+	lockout   *ratelimit.Lockout
+	passwords *auth.PasswordHasher
+	sso       *oidc.Provider // nil without single sign-on
+
+	chatMessages     func(username string) []websocket.Message
+	deletedListeners []func(user *models.User)
 }
 
-func NewService(repo *Repository, jwtSecret string) *Service {
+func NewService(repo *Repository, tokens *auth.TokenManager, mailer mail.Mailer, publicURL string) *Service {
 	return &Service{
 		repo:      repo,
-		jwtSecret: jwtSecret,
+		tokens:    tokens,
+		mailer:    mailer,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+		lockout:   ratelimit.NewLockout(LockoutThreshold, LockoutBase, LockoutMax),
+		passwords: auth.NewPasswordHasher(auth.DefaultPasswordParams),
 	}
 }
 
+// SetPasswordHasher replaces the default password hashing parameters
+func (s *Service) SetPasswordHasher(h *auth.PasswordHasher) {
+	s.passwords = h
+}
+
 // Register creates a new user account
 func (s *Service) Register(req *models.RegisterRequest) (*models.User, error) {
+	if len(req.Password) > auth.MaxPasswordLength {
+		return nil, auth.ErrPasswordTooLong
+	}
+
 	// Hash password
-	hash, err := auth.HashPassword(req.Password)
+	hash, err := s.passwords.Hash(req.Password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
@@ -43,29 +89,201 @@ func (s *Service) Register(req *models.RegisterRequest) (*models.User, error) {
 		return nil, err
 	}
 
+	// The account exists either way; the user can ask for another email
+	if err := s.sendVerification(user); err != nil {
+		log.Printf("Failed to send verification email to %s: %v", user.Username, err)
+	}
+
 	return user, nil
 }
 
-// Login authenticates a user and returns a JWT token
-func (s *Service) Login(req *models.LoginRequest) (string, *models.User, error) {
+// VerifyEmail marks the email of the token's user as verified
+func (s *Service) VerifyEmail(token string) error {
+	_, err := s.repo.UseEmailVerification(auth.HashOpaqueToken(token), time.Now())
+	return err
+}
+
+// ResendVerification sends a new verification email to a user who hasn't verified yet
+func (s *Service) ResendVerification(userID string) error {
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrAlreadyVerified
+	}
+	return s.sendVerification(user)
+}
+
+// sendVerification emails a verification token, at most once per
+// VerificationResendInterval and MaxVerificationEmailsPerDay times a day
+func (s *Service) sendVerification(user *models.User) error {
+	now := time.Now()
+	recent, err := s.repo.CountEmailVerifications(user.ID, now.Add(-VerificationResendInterval))
+	if err != nil {
+		return err
+	}
+	today, err := s.repo.CountEmailVerifications(user.ID, now.Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+	if recent > 0 || today >= MaxVerificationEmailsPerDay {
+		return ErrTooManyEmails
+	}
+	return s.mailVerification(user, now)
+}
+
+// mailVerification creates a verification token and emails it to the user
+func (s *Service) mailVerification(user *models.User, now time.Time) error {
+	raw, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	if err := s.repo.CreateEmailVerification(user.ID, hash, now.Add(EmailVerificationTTL)); err != nil {
+		return err
+	}
+
+	return s.mailer.Send(&mail.Message{
+		To:      user.Email,
+		Subject: "Verify your MangaHub email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Welcome to MangaHub! Open this link to verify your email address:\n\n"+
+			"    %s/api/auth/verify?token=%s\n\n"+
+			"or run:\n\n"+
+			"    mangahub auth verify --token %s\n\n"+
+			"Chat and notifications unlock once you're verified. "+
+			"The link expires in %d hours.\n",
+			user.Username, s.publicURL, raw, raw, int(EmailVerificationTTL.Hours())),
+	})
+}
+
+// IsEmailVerified reports whether a user may use chat and notifications
+func (s *Service) IsEmailVerified(userID string) (bool, error) {
+	return s.repo.IsEmailVerified(userID)
+}
+
+// Login checks a user's password. Without two-factor authentication it returns
+// an access and refresh token pair for a new session on the device; with it,
+// a challenge token for CompleteLogin.
+func (s *Service) Login(req *models.LoginRequest, device auth.Device) (*LoginResult, error) {
+	// Repeated failures lock the username, whether or not it exists
+	if s.lockout.Locked(req.Username) > 0 {
+		return nil, ErrAccountLocked
+	}
+
 	// Get user by username
 	user, err := s.repo.GetByUsername(req.Username)
 	if err != nil {
-		return "", nil, fmt.Errorf("invalid credentials")
+		s.lockout.Failure(req.Username)
+		return nil, fmt.Errorf("invalid credentials")
 	}
 
 	// Check password
-	if !auth.CheckPassword(req.Password, user.PasswordHash) {
-		return "", nil, fmt.Errorf("invalid credentials")
+	ok, needsRehash := s.passwords.Check(req.Password, user.PasswordHash)
+	if !ok {
+		s.lockout.Failure(req.Username)
+		return nil, fmt.Errorf("invalid credentials")
+	}
+
+	// Upgrade hashes made with bcrypt or older parameters while the password is at hand
+	if needsRehash {
+		if hash, err := s.passwords.Hash(req.Password); err != nil {
+			log.Printf("Failed to rehash password for %s: %v", user.Username, err)
+		} else if err := s.repo.UpdatePassword(user.ID, hash); err != nil {
+			log.Printf("Failed to rehash password for %s: %v", user.Username, err)
+		}
 	}
 
-	// Generate token
-	token, err := auth.GenerateToken(user.ID, user.Username, s.jwtSecret)
+	// Failures are only forgotten once the second factor is right too, so
+	// logging in again doesn't buy more guesses at the code
+	if user.TwoFactorEnabled {
+		return s.startChallenge(user)
+	}
+	s.lockout.Success(req.Username)
+
+	// Generate tokens
+	tokens, err := s.tokens.Issue(user.ID, user.Username, device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &LoginResult{Tokens: tokens, User: user, AccountRestored: s.restoreAccount(user)}, nil
+}
+
+// ChangePassword changes a user's password after checking the current one.
+// Every other login is signed out.
+func (s *Service) ChangePassword(userID, currentSessionID string, req *models.ChangePasswordRequest) error {
+	if len(req.NewPassword) > auth.MaxPasswordLength {
+		return auth.ErrPasswordTooLong
+	}
+
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if ok, _ := s.passwords.Check(req.CurrentPassword, user.PasswordHash); !ok {
+		return ErrWrongPassword
+	}
+
+	return s.setPassword(userID, req.NewPassword, currentSessionID)
+}
+
+// RequestPasswordReset emails a reset token to the account with the address.
+// Unknown addresses are ignored so the endpoint can't be used to find accounts.
+func (s *Service) RequestPasswordReset(email string) error {
+	user, err := s.repo.GetByEmail(email)
+	if err == ErrUserNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	raw, hash, err := auth.NewOpaqueToken()
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to generate token: %w", err)
+		return fmt.Errorf("failed to generate reset token: %w", err)
 	}
+	if err := s.repo.CreatePasswordReset(user.ID, hash, time.Now().Add(PasswordResetTTL)); err != nil {
+		return err
+	}
+
+	return s.mailer.Send(&mail.Message{
+		To:      user.Email,
+		Subject: "Reset your MangaHub password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Someone asked to reset the password for your MangaHub account. "+
+			"If it was you, run:\n\n"+
+			"    mangahub auth reset --token %s\n\n"+
+			"The token works once and expires in %d minutes. "+
+			"If you didn't ask for this, you can ignore this email.\n",
+			user.Username, raw, int(PasswordResetTTL.Minutes())),
+	})
+}
+
+// ResetPassword sets a new password with a reset token and signs out every login
+func (s *Service) ResetPassword(req *models.ResetPasswordRequest) error {
+	// Checked before the token is used up
+	if len(req.NewPassword) > auth.MaxPasswordLength {
+		return auth.ErrPasswordTooLong
+	}
+
+	userID, err := s.repo.UsePasswordReset(auth.HashOpaqueToken(req.Token), time.Now())
+	if err != nil {
+		return err
+	}
+	return s.setPassword(userID, req.NewPassword, "")
+}
 
-	return token, user, nil
+func (s *Service) setPassword(userID, password, keepSessionID string) error {
+	hash, err := s.passwords.Hash(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.repo.SetPassword(userID, hash, time.Now()); err != nil {
+		return err
+	}
+	return s.tokens.RevokeAllSessions(userID, keepSessionID)
 }
 
 // Handler struct
@@ -97,6 +315,8 @@ func (h *Handler) Register(c *gin.Context) {
 		} else if err == ErrEmailExists {
 			statusCode = http.StatusConflict
 			err = fmt.Errorf("email already exists")
+		} else if err == auth.ErrPasswordTooLong {
+			statusCode = http.StatusBadRequest
 		}
 		c.JSON(statusCode, models.Response{
 			Success: false,
@@ -107,11 +327,12 @@ func (h *Handler) Register(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, models.Response{
 		Success: true,
-		Message: "account created successfully",
+		Message: "account created successfully. Check your email to verify your address",
 		Data: gin.H{
-			"user_id":  user.ID,
-			"username": user.Username,
-			"email":    user.Email,
+			"user_id":        user.ID,
+			"username":       user.Username,
+			"email":          user.Email,
+			"email_verified": false,
 		},
 	})
 }
@@ -127,7 +348,17 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	token, user, err := h.service.Login(&req)
+	device := auth.Device{
+		Name:      req.DeviceName,
+		UserAgent: c.GetHeader("User-Agent"),
+		IP:        c.ClientIP(),
+	}
+
+	result, err := h.service.Login(&req, device)
+	if err == ErrAccountLocked {
+		ratelimit.Reject(c, h.service.lockout.Locked(req.Username))
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, models.Response{
 			Success: false,
@@ -136,14 +367,109 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
+	respondLogin(c, result)
+}
+
+// respondLogin sends the tokens of a completed login, or the challenge of a
+// login that needs a two-factor code
+func respondLogin(c *gin.Context, result *LoginResult) {
+	if result.ChallengeToken != "" {
+		c.JSON(http.StatusOK, models.Response{
+			Success: true,
+			Message: "two-factor code required",
+			Data: gin.H{
+				"two_factor_required": true,
+				"challenge_token":     result.ChallengeToken,
+				"expires_at":          result.ChallengeExpiresAt,
+			},
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, models.Response{
 		Success: true,
 		Message: "login successful",
-		Data: gin.H{
-			"token":    token,
-			"user_id":  user.ID,
-			"username": user.Username,
-		},
+		Data:    loginData(result),
+	})
+}
+
+// loginData is the response body of a completed login
+func loginData(result *LoginResult) gin.H {
+	return gin.H{
+		"token":                     result.Tokens.AccessToken,
+		"refresh_token":             result.Tokens.RefreshToken,
+		"expires_at":                result.Tokens.ExpiresAt,
+		"refresh_expires_at":        result.Tokens.RefreshExpiresAt,
+		"user_id":                   result.User.ID,
+		"username":                  result.User.Username,
+		"email_verified":            result.User.EmailVerifiedAt != nil,
+		"two_factor_setup_required": result.User.IsAdmin && !result.User.TwoFactorEnabled,
+		"account_restored":          result.AccountRestored,
+	}
+}
+
+// Refresh handles exchanging a refresh token for a new token pair
+func (h *Handler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "refresh_token is required",
+		})
+		return
+	}
+
+	tokens, err := h.service.tokens.Refresh(req.RefreshToken)
+	if err != nil {
+		switch err {
+		case auth.ErrInvalidToken, auth.ErrExpiredToken, auth.ErrRevokedToken:
+			c.JSON(http.StatusUnauthorized, models.Response{
+				Success: false,
+				Error:   "invalid or expired refresh token",
+			})
+		case auth.ErrTokenReused:
+			c.JSON(http.StatusUnauthorized, models.Response{
+				Success: false,
+				Error:   "refresh token was already used, please log in again",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.Response{
+				Success: false,
+				Error:   "failed to refresh token",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "token refreshed",
+		Data:    tokens,
+	})
+}
+
+// Logout handles revoking the current login's access and refresh tokens
+func (h *Handler) Logout(c *gin.Context) {
+	claims := auth.GetClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, models.Response{
+			Success: false,
+			Error:   "not logged in",
+		})
+		return
+	}
+
+	if err := h.service.tokens.Revoke(claims); err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to log out",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "logged out",
 	})
 }
 
@@ -151,7 +477,7 @@ func (h *Handler) Login(c *gin.Context) {
 func (h *Handler) GetProfile(c *gin.Context) {
 	userID := auth.GetUserID(c)
 	
-	user, err := h.service.repo.GetByID(userID)
+	profile, err := h.service.GetProfile(userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, models.Response{
 			Success: false,
@@ -162,11 +488,366 @@ func (h *Handler) GetProfile(c *gin.Context) {
 
 	c.JSON(http.StatusOK, models.Response{
 		Success: true,
+		Data:    profile,
+	})
+}
+
+// UpdateTimezone handles changing the time zone used for streaks and goals
+func (h *Handler) UpdateTimezone(c *gin.Context) {
+	userID := auth.GetUserID(c)
+
+	var req models.UpdateTimezoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "invalid request data",
+		})
+		return
+	}
+
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "unknown time zone, expected an IANA name such as Europe/Berlin",
+		})
+		return
+	}
+
+	if err := h.service.repo.UpdateTimezone(userID, req.Timezone); err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to update timezone",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "timezone updated",
 		Data: gin.H{
-			"user_id":    user.ID,
-			"username":   user.Username,
-			"email":      user.Email,
-			"created_at": user.CreatedAt,
+			"timezone": req.Timezone,
 		},
 	})
+}
+
+// ListTokens handles listing the user's personal access tokens
+func (h *Handler) ListTokens(c *gin.Context) {
+	userID := auth.GetUserID(c)
+
+	tokens, err := h.service.tokens.ListPersonalTokens(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to get tokens",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data: gin.H{
+			"tokens": tokens,
+			"count":  len(tokens),
+		},
+	})
+}
+
+// CreateToken handles creating a personal access token
+func (h *Handler) CreateToken(c *gin.Context) {
+	userID := auth.GetUserID(c)
+
+	var req models.CreateTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "invalid request: name and at least one scope are required",
+		})
+		return
+	}
+
+	raw, token, err := h.service.tokens.CreatePersonalToken(userID, &req)
+	if err != nil {
+		if err == auth.ErrInvalidScope {
+			c.JSON(http.StatusBadRequest, models.Response{
+				Success: false,
+				Error:   "invalid scope. Valid scopes: " + strings.Join(auth.Scopes, ", "),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to create token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Response{
+		Success: true,
+		Message: "token created. Copy it now, it won't be shown again",
+		Data: gin.H{
+			"token":   raw,
+			"details": token,
+		},
+	})
+}
+
+// RevokeToken handles revoking a personal access token
+func (h *Handler) RevokeToken(c *gin.Context) {
+	userID := auth.GetUserID(c)
+
+	if err := h.service.tokens.RevokePersonalToken(userID, c.Param("id")); err != nil {
+		if err == auth.ErrTokenNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Success: false,
+				Error:   "token not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to revoke token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "token revoked",
+	})
+}
+
+// ListSessions handles listing the devices the user is logged in on
+func (h *Handler) ListSessions(c *gin.Context) {
+	userID := auth.GetUserID(c)
+
+	var currentID string
+	if claims := auth.GetClaims(c); claims != nil {
+		currentID = claims.SessionID
+	}
+
+	sessions, err := h.service.tokens.ListSessions(userID, currentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to get sessions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data: gin.H{
+			"sessions": sessions,
+			"count":    len(sessions),
+		},
+	})
+}
+
+// RevokeSession handles logging a device out and disconnecting it
+func (h *Handler) RevokeSession(c *gin.Context) {
+	userID := auth.GetUserID(c)
+
+	if err := h.service.tokens.RevokeSession(userID, c.Param("id")); err != nil {
+		if err == auth.ErrSessionNotFound {
+			c.JSON(http.StatusNotFound, models.Response{
+				Success: false,
+				Error:   "session not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to revoke session",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "session revoked",
+	})
+}
+
+// ChangePassword handles changing the logged-in user's password
+func (h *Handler) ChangePassword(c *gin.Context) {
+	userID := auth.GetUserID(c)
+
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "invalid request: current_password and a new_password of at least 8 characters are required",
+		})
+		return
+	}
+
+	var sessionID string
+	if claims := auth.GetClaims(c); claims != nil {
+		sessionID = claims.SessionID
+	}
+
+	if err := h.service.ChangePassword(userID, sessionID, &req); err != nil {
+		if err == ErrWrongPassword {
+			c.JSON(http.StatusUnauthorized, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+		if err == auth.ErrPasswordTooLong {
+			c.JSON(http.StatusBadRequest, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to change password",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "password changed. Other devices have been logged out",
+	})
+}
+
+// ForgotPassword handles emailing a password reset token
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "a valid email is required",
+		})
+		return
+	}
+
+	if err := h.service.RequestPasswordReset(req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to send reset email",
+		})
+		return
+	}
+
+	// Same answer whether or not the address has an account
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "if an account uses that email, a reset token has been sent",
+	})
+}
+
+// ResetPassword handles setting a new password with a reset token
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "invalid request: token and a new_password of at least 8 characters are required",
+		})
+		return
+	}
+
+	if err := h.service.ResetPassword(&req); err != nil {
+		if err == ErrInvalidResetToken || err == auth.ErrPasswordTooLong {
+			c.JSON(http.StatusBadRequest, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to reset password",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "password reset. Please log in again",
+	})
+}
+
+// VerifyEmail handles confirming an email address with the token from the verification email
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "token is required",
+		})
+		return
+	}
+
+	if err := h.service.VerifyEmail(token); err != nil {
+		if err == ErrInvalidVerifyToken {
+			c.JSON(http.StatusBadRequest, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to verify email",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "email verified. Chat and notifications are now available",
+	})
+}
+
+// ResendVerification handles sending another verification email
+func (h *Handler) ResendVerification(c *gin.Context) {
+	userID := auth.GetUserID(c)
+
+	if err := h.service.ResendVerification(userID); err != nil {
+		switch err {
+		case ErrAlreadyVerified:
+			c.JSON(http.StatusConflict, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+		case ErrTooManyEmails:
+			c.Header("Retry-After", fmt.Sprintf("%d", int(VerificationResendInterval.Seconds())))
+			c.JSON(http.StatusTooManyRequests, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.Response{
+				Success: false,
+				Error:   "failed to send verification email",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "verification email sent",
+	})
+}
+
+// RequireVerifiedEmail is middleware that rejects users who haven't verified their email
+func (h *Handler) RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		verified, err := h.service.IsEmailVerified(auth.GetUserID(c))
+		if err != nil || !verified {
+			c.JSON(http.StatusForbidden, models.Response{
+				Success: false,
+				Error:   "verify your email address first",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
 }
\ No newline at end of file
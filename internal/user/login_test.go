@@ -0,0 +1,64 @@
+package user
+
+import (
+	"strings"
+	"testing"
+
+	"mangahub/internal/auth"
+	"mangahub/pkg/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoginLockout(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	wrong := &models.LoginRequest{Username: "reader", Password: "wrong-password"}
+	for i := 0; i < LockoutThreshold; i++ {
+		if _, err := service.Login(wrong, auth.Device{}); err == nil || err == ErrAccountLocked {
+			t.Fatalf("Expected invalid credentials on attempt %d, got %v", i+1, err)
+		}
+	}
+
+	// Even the right password is refused while locked
+	right := &models.LoginRequest{Username: "reader", Password: "old-password"}
+	if _, err := service.Login(right, auth.Device{}); err != ErrAccountLocked {
+		t.Fatalf("Expected ErrAccountLocked, got %v", err)
+	}
+	if wait := service.lockout.Locked("reader"); wait <= 0 || wait > LockoutBase {
+		t.Errorf("Expected a lock of at most %v, got %v", LockoutBase, wait)
+	}
+
+	// Unknown usernames lock the same way, so locks don't reveal accounts
+	ghost := &models.LoginRequest{Username: "ghost", Password: "whatever"}
+	for i := 0; i < LockoutThreshold; i++ {
+		service.Login(ghost, auth.Device{})
+	}
+	if _, err := service.Login(ghost, auth.Device{}); err != ErrAccountLocked {
+		t.Errorf("Expected unknown username to be locked too, got %v", err)
+	}
+}
+
+func TestLoginRehashesOutdatedPasswords(t *testing.T) {
+	service, _ := setupTestService(t)
+	user, _ := service.repo.GetByUsername("reader")
+
+	// An account from before argon2id still has a bcrypt hash
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	if err := service.repo.UpdatePassword(user.ID, string(legacy)); err != nil {
+		t.Fatalf("Failed to set legacy hash: %v", err)
+	}
+
+	login := &models.LoginRequest{Username: "reader", Password: "old-password"}
+	if _, err := service.Login(login, auth.Device{}); err != nil {
+		t.Fatalf("Failed to log in with a bcrypt hash: %v", err)
+	}
+
+	user, _ = service.repo.GetByUsername("reader")
+	if !strings.HasPrefix(user.PasswordHash, "$argon2id$") {
+		t.Errorf("Expected the hash to be upgraded to argon2id, got %s", user.PasswordHash)
+	}
+	if _, err := service.Login(login, auth.Device{}); err != nil {
+		t.Errorf("Failed to log in after the rehash: %v", err)
+	}
+}
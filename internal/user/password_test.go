@@ -0,0 +1,138 @@
+package user
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"mangahub/internal/auth"
+	"mangahub/internal/mail"
+	"mangahub/pkg/models"
+)
+
+// recordingMailer keeps sent messages in memory
+type recordingMailer struct {
+	sent []*mail.Message
+}
+
+func (m *recordingMailer) Send(msg *mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var resetTokenPattern = regexp.MustCompile(`--token (\S+)`)
+
+func setupTestService(t *testing.T) (*Service, *recordingMailer) {
+	repo := setupTestDB(t)
+	mailer := &recordingMailer{}
+	store := auth.NewTokenStore(repo.db)
+	keys, err := auth.NewKeySet(store, auth.AlgEdDSA, auth.DefaultKeyRotation)
+	if err != nil {
+		t.Fatalf("Failed to create signing keys: %v", err)
+	}
+	service := NewService(repo, auth.NewTokenManager(keys, store), mailer, "http://localhost:8080")
+
+	_, err = service.Register(&models.RegisterRequest{
+		Username: "reader",
+		Email:    "reader@example.com",
+		Password: "old-password",
+	})
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+	mailer.sent = nil // drop the verification email
+
+	return service, mailer
+}
+
+func TestChangePassword(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	user, _ := service.repo.GetByUsername("reader")
+	current, _ := service.tokens.Issue(user.ID, user.Username, auth.Device{Name: "laptop"})
+	other, _ := service.tokens.Issue(user.ID, user.Username, auth.Device{Name: "phone"})
+	currentClaims, _ := service.tokens.Validate(current.AccessToken)
+
+	err := service.ChangePassword(user.ID, currentClaims.SessionID, &models.ChangePasswordRequest{
+		CurrentPassword: "wrong-password",
+		NewPassword:     "new-password",
+	})
+	if err != ErrWrongPassword {
+		t.Fatalf("Expected ErrWrongPassword, got %v", err)
+	}
+
+	err = service.ChangePassword(user.ID, currentClaims.SessionID, &models.ChangePasswordRequest{
+		CurrentPassword: "old-password",
+		NewPassword:     "new-password",
+	})
+	if err != nil {
+		t.Fatalf("Failed to change password: %v", err)
+	}
+
+	if _, err := service.Login(&models.LoginRequest{Username: "reader", Password: "new-password"}, auth.Device{}); err != nil {
+		t.Errorf("Expected login with the new password, got %v", err)
+	}
+	if _, err := service.tokens.Validate(current.AccessToken); err != nil {
+		t.Errorf("Expected the current login to stay valid, got %v", err)
+	}
+	if _, err := service.tokens.Validate(other.AccessToken); err != auth.ErrRevokedToken {
+		t.Errorf("Expected other logins to be revoked, got %v", err)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	service, mailer := setupTestService(t)
+
+	// Unknown addresses look the same to the caller but send nothing
+	if err := service.RequestPasswordReset("nobody@example.com"); err != nil {
+		t.Fatalf("Expected unknown email to be ignored, got %v", err)
+	}
+	if len(mailer.sent) != 0 {
+		t.Fatalf("Expected no email, got %d", len(mailer.sent))
+	}
+
+	// Asking twice sends two links; using either retires both
+	for i := 0; i < 2; i++ {
+		if err := service.RequestPasswordReset("reader@example.com"); err != nil {
+			t.Fatalf("Failed to request reset: %v", err)
+		}
+	}
+	if len(mailer.sent) != 2 || mailer.sent[0].To != "reader@example.com" {
+		t.Fatalf("Expected two emails to the user, got %+v", mailer.sent)
+	}
+	match := resetTokenPattern.FindStringSubmatch(mailer.sent[1].Body)
+	older := resetTokenPattern.FindStringSubmatch(mailer.sent[0].Body)
+	if match == nil || older == nil {
+		t.Fatalf("Expected a reset token in the emails: %+v", mailer.sent)
+	}
+
+	req := &models.ResetPasswordRequest{Token: match[1], NewPassword: "new-password"}
+	if err := service.ResetPassword(req); err != nil {
+		t.Fatalf("Failed to reset password: %v", err)
+	}
+	if _, err := service.Login(&models.LoginRequest{Username: "reader", Password: "new-password"}, auth.Device{}); err != nil {
+		t.Errorf("Expected login with the new password, got %v", err)
+	}
+
+	if err := service.ResetPassword(req); err != ErrInvalidResetToken {
+		t.Errorf("Expected a used token to be rejected, got %v", err)
+	}
+	if err := service.ResetPassword(&models.ResetPasswordRequest{Token: older[1], NewPassword: "other-password"}); err != ErrInvalidResetToken {
+		t.Errorf("Expected the older token to be rejected, got %v", err)
+	}
+}
+
+func TestPasswordResetExpired(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	user, _ := service.repo.GetByUsername("reader")
+	raw, hash, _ := auth.NewOpaqueToken()
+	if err := service.repo.CreatePasswordReset(user.ID, hash, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Failed to create reset: %v", err)
+	}
+
+	err := service.ResetPassword(&models.ResetPasswordRequest{Token: raw, NewPassword: "new-password"})
+	if err != ErrInvalidResetToken {
+		t.Errorf("Expected an expired token to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,278 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	netmail "net/mail"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"mangahub/internal/auth"
+	"mangahub/internal/mail"
+	"mangahub/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Profile field limits, in characters
+const (
+	MaxDisplayNameLength = 50
+	MaxBioLength         = 500
+	MaxAvatarURLLength   = 500
+)
+
+var (
+	// TitleLanguages are the ways manga titles can be shown
+	TitleLanguages = []string{"english", "romaji", "native"}
+
+	// PrivacyLevels control who can see a profile: anyone, logged-in users or nobody
+	PrivacyLevels = []string{"public", "members", "private"}
+)
+
+var ErrInvalidProfile = errors.New("invalid profile")
+
+// ProfileUpdate is the result of a profile change
+type ProfileUpdate struct {
+	Profile *models.UserProfile
+	// EmailChanged is set when the new address still has to be verified, and
+	// VerificationSent when the verification email went out
+	EmailChanged     bool
+	VerificationSent bool
+}
+
+// GetProfile returns a user's profile with every notification type filled in
+func (s *Service) GetProfile(userID string) (*models.UserProfile, error) {
+	profile, err := s.repo.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, kind := range models.NotificationTypes {
+		if _, ok := profile.NotificationPreferences[kind]; !ok {
+			profile.NotificationPreferences[kind] = true
+		}
+	}
+	return profile, nil
+}
+
+// NotificationPreferences returns the notifications a user wants by default,
+// for subscriptions that don't choose their own
+func (s *Service) NotificationPreferences(userID string) (map[string]bool, error) {
+	profile, err := s.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	return profile.NotificationPreferences, nil
+}
+
+// UpdateProfile validates and applies the fields set in req. Changing the
+// email needs the current password and unverifies the account until the new
+// address is confirmed.
+func (s *Service) UpdateProfile(userID string, req *models.UpdateProfileRequest) (*ProfileUpdate, error) {
+	profile, err := s.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyProfileUpdate(profile, req); err != nil {
+		return nil, err
+	}
+
+	var newEmail string
+	if req.Email != nil {
+		newEmail = strings.TrimSpace(*req.Email)
+		address, err := netmail.ParseAddress(newEmail)
+		if err != nil || address.Address != newEmail {
+			return nil, fmt.Errorf("%w: email is not a valid address", ErrInvalidProfile)
+		}
+		if strings.EqualFold(newEmail, profile.Email) {
+			newEmail = ""
+		}
+	}
+	if newEmail != "" {
+		user, err := s.repo.GetByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		if ok, _ := s.passwords.Check(req.CurrentPassword, user.PasswordHash); !ok {
+			return nil, ErrWrongPassword
+		}
+	}
+
+	// The email goes first so a taken address leaves the profile unchanged
+	if newEmail != "" {
+		if err := s.repo.ChangeEmail(userID, newEmail, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.UpdateProfile(profile); err != nil {
+		return nil, err
+	}
+
+	update := &ProfileUpdate{Profile: profile}
+	if newEmail != "" {
+		oldEmail := profile.Email
+		update.EmailChanged = true
+		update.VerificationSent = s.confirmEmailChange(profile.Username, oldEmail, newEmail, userID)
+
+		profile.Email = newEmail
+		profile.EmailVerified = false
+		profile.EmailVerifiedAt = nil
+	}
+	return update, nil
+}
+
+// confirmEmailChange tells the old address about the change and sends a
+// verification to the new one. It reports whether the verification was sent.
+func (s *Service) confirmEmailChange(username, oldEmail, newEmail, userID string) bool {
+	err := s.mailer.Send(&mail.Message{
+		To:      oldEmail,
+		Subject: "Your MangaHub email address was changed",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"The email address of your MangaHub account was changed to %s. "+
+			"If you didn't do this, reset your password right away.\n",
+			username, newEmail),
+	})
+	if err != nil {
+		log.Printf("Failed to notify %s of email change: %v", username, err)
+	}
+
+	// The new address gets its link straight away, within the daily limit
+	now := time.Now()
+	today, err := s.repo.CountEmailVerifications(userID, now.Add(-24*time.Hour))
+	if err == nil && today >= MaxVerificationEmailsPerDay {
+		err = ErrTooManyEmails
+	}
+	if err == nil {
+		err = s.mailVerification(&models.User{ID: userID, Username: username, Email: newEmail}, now)
+	}
+	if err != nil {
+		log.Printf("Failed to send verification email to %s: %v", username, err)
+		return false
+	}
+	return true
+}
+
+// applyProfileUpdate validates the fields set in req and copies them to profile
+func applyProfileUpdate(profile *models.UserProfile, req *models.UpdateProfileRequest) error {
+	if req.DisplayName != nil {
+		name := strings.TrimSpace(*req.DisplayName)
+		if utf8.RuneCountInString(name) > MaxDisplayNameLength || strings.IndexFunc(name, unicode.IsControl) >= 0 {
+			return fmt.Errorf("%w: display_name must be at most %d characters on one line", ErrInvalidProfile, MaxDisplayNameLength)
+		}
+		profile.DisplayName = name
+	}
+
+	if req.Bio != nil {
+		bio := strings.TrimSpace(*req.Bio)
+		if utf8.RuneCountInString(bio) > MaxBioLength {
+			return fmt.Errorf("%w: bio must be at most %d characters", ErrInvalidProfile, MaxBioLength)
+		}
+		profile.Bio = bio
+	}
+
+	if req.AvatarURL != nil {
+		avatar := strings.TrimSpace(*req.AvatarURL)
+		if avatar != "" {
+			u, err := url.Parse(avatar)
+			if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || len(avatar) > MaxAvatarURLLength {
+				return fmt.Errorf("%w: avatar_url must be an http or https URL", ErrInvalidProfile)
+			}
+		}
+		profile.AvatarURL = avatar
+	}
+
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" {
+			return fmt.Errorf("%w: unknown time zone, expected an IANA name such as Europe/Berlin", ErrInvalidProfile)
+		}
+		profile.Timezone = *req.Timezone
+	}
+
+	if req.TitleLanguage != nil {
+		if !contains(TitleLanguages, *req.TitleLanguage) {
+			return fmt.Errorf("%w: title_language must be one of %s", ErrInvalidProfile, strings.Join(TitleLanguages, ", "))
+		}
+		profile.TitleLanguage = *req.TitleLanguage
+	}
+
+	if req.Privacy != nil {
+		if !contains(PrivacyLevels, *req.Privacy) {
+			return fmt.Errorf("%w: privacy must be one of %s", ErrInvalidProfile, strings.Join(PrivacyLevels, ", "))
+		}
+		profile.Privacy = *req.Privacy
+	}
+
+	for kind, enabled := range req.NotificationPreferences {
+		if !contains(models.NotificationTypes, kind) {
+			return fmt.Errorf("%w: unknown notification type %q, expected one of %s", ErrInvalidProfile, kind, strings.Join(models.NotificationTypes, ", "))
+		}
+		profile.NotificationPreferences[kind] = enabled
+	}
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateProfile handles editing the user's profile and preferences
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "invalid request data",
+		})
+		return
+	}
+
+	update, err := h.service.UpdateProfile(auth.GetUserID(c), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidProfile):
+			c.JSON(http.StatusBadRequest, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+		case err == ErrWrongPassword:
+			c.JSON(http.StatusUnauthorized, models.Response{
+				Success: false,
+				Error:   "current_password is required to change the email address",
+			})
+		case err == ErrEmailExists:
+			c.JSON(http.StatusConflict, models.Response{
+				Success: false,
+				Error:   "email already exists",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.Response{
+				Success: false,
+				Error:   "failed to update profile",
+			})
+		}
+		return
+	}
+
+	message := "profile updated"
+	if update.EmailChanged {
+		message = "profile updated. Check your new email address to verify it"
+		if !update.VerificationSent {
+			message = "profile updated. Ask for a verification email with 'mangahub auth verify --resend'"
+		}
+	}
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: message,
+		Data:    update.Profile,
+	})
+}
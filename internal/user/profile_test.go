@@ -0,0 +1,139 @@
+package user
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"mangahub/pkg/models"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestUpdateProfile(t *testing.T) {
+	service, _ := setupTestService(t)
+	user, _ := service.repo.GetByUsername("reader")
+
+	profile, err := service.GetProfile(user.ID)
+	if err != nil {
+		t.Fatalf("Failed to get profile: %v", err)
+	}
+	if profile.TitleLanguage != "romaji" || profile.Privacy != "public" || !profile.NotificationPreferences["chapter_releases"] {
+		t.Errorf("Unexpected defaults: %+v", profile)
+	}
+
+	update, err := service.UpdateProfile(user.ID, &models.UpdateProfileRequest{
+		DisplayName:             strPtr("  Reader One "),
+		Bio:                     strPtr("Mostly seinen"),
+		Timezone:                strPtr("Asia/Tokyo"),
+		NotificationPreferences: map[string]bool{"system_updates": false},
+	})
+	if err != nil {
+		t.Fatalf("Failed to update profile: %v", err)
+	}
+	if update.EmailChanged {
+		t.Error("Expected the email to be unchanged")
+	}
+
+	// Fields left out keep their values
+	_, err = service.UpdateProfile(user.ID, &models.UpdateProfileRequest{Privacy: strPtr("members")})
+	if err != nil {
+		t.Fatalf("Failed to update privacy: %v", err)
+	}
+	profile, _ = service.GetProfile(user.ID)
+	if profile.DisplayName != "Reader One" || profile.Bio != "Mostly seinen" || profile.Timezone != "Asia/Tokyo" || profile.Privacy != "members" {
+		t.Errorf("Unexpected profile after partial updates: %+v", profile)
+	}
+	if profile.NotificationPreferences["system_updates"] || !profile.NotificationPreferences["chapter_releases"] {
+		t.Errorf("Expected only system updates turned off, got %v", profile.NotificationPreferences)
+	}
+
+	prefs, _ := service.NotificationPreferences(user.ID)
+	if prefs["system_updates"] {
+		t.Errorf("Expected saved notification defaults, got %v", prefs)
+	}
+}
+
+func TestUpdateProfileValidation(t *testing.T) {
+	service, _ := setupTestService(t)
+	user, _ := service.repo.GetByUsername("reader")
+
+	invalid := []*models.UpdateProfileRequest{
+		{DisplayName: strPtr(strings.Repeat("a", MaxDisplayNameLength+1))},
+		{DisplayName: strPtr("two\nlines")},
+		{Bio: strPtr(strings.Repeat("b", MaxBioLength+1))},
+		{AvatarURL: strPtr("javascript:alert(1)")},
+		{Timezone: strPtr("Mars/Olympus")},
+		{TitleLanguage: strPtr("klingon")},
+		{Privacy: strPtr("secret")},
+		{NotificationPreferences: map[string]bool{"spam": true}},
+		{Email: strPtr("not an email")},
+	}
+	for _, req := range invalid {
+		if _, err := service.UpdateProfile(user.ID, req); !errors.Is(err, ErrInvalidProfile) {
+			t.Errorf("Expected %+v to be rejected, got %v", req, err)
+		}
+	}
+
+	// A rejected field leaves the others untouched
+	service.UpdateProfile(user.ID, &models.UpdateProfileRequest{Bio: strPtr("kept"), Privacy: strPtr("secret")})
+	if profile, _ := service.GetProfile(user.ID); profile.Bio != "" {
+		t.Errorf("Expected nothing saved from an invalid update, got bio %q", profile.Bio)
+	}
+
+	if _, err := service.UpdateProfile(user.ID, &models.UpdateProfileRequest{AvatarURL: strPtr("")}); err != nil {
+		t.Errorf("Expected an empty avatar to clear it, got %v", err)
+	}
+}
+
+func TestChangeEmail(t *testing.T) {
+	service, mailer := setupTestService(t)
+	user, _ := service.repo.GetByUsername("reader")
+
+	// Verify the original address first, keeping the token to reuse later
+	service.repo.db.Exec(`DELETE FROM email_verifications`)
+	service.ResendVerification(user.ID)
+	oldToken := verifyTokenPattern.FindStringSubmatch(mailer.sent[0].Body)[1]
+	mailer.sent = nil
+
+	if _, err := service.UpdateProfile(user.ID, &models.UpdateProfileRequest{Email: strPtr("new@example.com")}); err != ErrWrongPassword {
+		t.Fatalf("Expected ErrWrongPassword without the current password, got %v", err)
+	}
+
+	update, err := service.UpdateProfile(user.ID, &models.UpdateProfileRequest{
+		Email:           strPtr("new@example.com"),
+		CurrentPassword: "old-password",
+	})
+	if err != nil {
+		t.Fatalf("Failed to change email: %v", err)
+	}
+	if !update.EmailChanged || !update.VerificationSent || update.Profile.EmailVerified {
+		t.Errorf("Expected an unverified new address with a verification sent, got %+v", update)
+	}
+
+	if len(mailer.sent) != 2 || mailer.sent[0].To != "reader@example.com" || mailer.sent[1].To != "new@example.com" {
+		t.Fatalf("Expected a notice to the old address and a verification to the new one, got %+v", mailer.sent)
+	}
+	if verified, _ := service.IsEmailVerified(user.ID); verified {
+		t.Error("Expected the account to need verification again")
+	}
+
+	// Links sent to the old address no longer verify the account
+	if err := service.VerifyEmail(oldToken); err != ErrInvalidVerifyToken {
+		t.Errorf("Expected the old token to be rejected, got %v", err)
+	}
+	newToken := verifyTokenPattern.FindStringSubmatch(mailer.sent[1].Body)[1]
+	if err := service.VerifyEmail(newToken); err != nil {
+		t.Fatalf("Failed to verify the new address: %v", err)
+	}
+
+	// Addresses in use by someone else are refused
+	service.Register(&models.RegisterRequest{Username: "other", Email: "other@example.com", Password: "password123"})
+	_, err = service.UpdateProfile(user.ID, &models.UpdateProfileRequest{
+		Email:           strPtr("other@example.com"),
+		CurrentPassword: "old-password",
+	})
+	if err != ErrEmailExists {
+		t.Errorf("Expected ErrEmailExists, got %v", err)
+	}
+}
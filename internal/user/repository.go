@@ -2,16 +2,30 @@ package user
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"mangahub/pkg/models"
 )
 
 var (
-	ErrUserNotFound      = errors.New("user not found")
-	ErrUsernameExists    = errors.New("username already exists")
-	ErrEmailExists       = errors.New("email already exists")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrUsernameExists     = errors.New("username already exists")
+	ErrEmailExists        = errors.New("email already exists")
+	ErrInvalidResetToken  = errors.New("invalid or expired reset token")
+	ErrWrongPassword      = errors.New("current password is incorrect")
+	ErrInvalidVerifyToken = errors.New("invalid or expired verification token")
+	ErrAlreadyVerified    = errors.New("email is already verified")
+	ErrTooManyEmails      = errors.New("too many verification emails, try again later")
+	ErrInvalidChallenge   = errors.New("invalid or expired login challenge")
+	ErrInvalidCode        = errors.New("invalid two-factor code")
+	ErrTwoFactorNotSetUp  = errors.New("two-factor authentication is not set up")
+	ErrTwoFactorEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorRequired  = errors.New("admins must keep two-factor authentication enabled")
+	ErrAccountLocked      = errors.New("too many failed logins, try again later")
+	ErrDeletionScheduled  = errors.New("account is already scheduled for deletion")
 )
 
 type Repository struct {
@@ -24,11 +38,15 @@ func NewRepository(db *sql.DB) *Repository {
 
 // Create creates a new user
 func (r *Repository) Create(user *models.User) error {
+	if user.Timezone == "" {
+		user.Timezone = "UTC"
+	}
+
 	query := `
-		INSERT INTO users (id, username, email, password_hash, created_at)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO users (id, username, email, password_hash, timezone, email_verified_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`
-	_, err := r.db.Exec(query, user.ID, user.Username, user.Email, user.PasswordHash, user.CreatedAt)
+	_, err := r.db.Exec(query, user.ID, user.Username, user.Email, user.PasswordHash, user.Timezone, user.EmailVerifiedAt, user.CreatedAt)
 	if err != nil {
 		if isUniqueConstraintError(err, "username") {
 			return ErrUsernameExists
@@ -44,12 +62,16 @@ func (r *Repository) Create(user *models.User) error {
 // GetByUsername retrieves a user by username
 func (r *Repository) GetByUsername(username string) (*models.User, error) {
 	var user models.User
-	query := `SELECT id, username, email, password_hash, created_at FROM users WHERE username = ?`
+	query := `SELECT id, username, email, password_hash, timezone, email_verified_at, is_admin, EXISTS (SELECT 1 FROM user_totp WHERE user_id = users.id AND enabled_at IS NOT NULL), created_at FROM users WHERE username = ?`
 	err := r.db.QueryRow(query, username).Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
 		&user.PasswordHash,
+		&user.Timezone,
+		&user.EmailVerifiedAt,
+		&user.IsAdmin,
+		&user.TwoFactorEnabled,
 		&user.CreatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -64,12 +86,16 @@ func (r *Repository) GetByUsername(username string) (*models.User, error) {
 // GetByEmail retrieves a user by email
 func (r *Repository) GetByEmail(email string) (*models.User, error) {
 	var user models.User
-	query := `SELECT id, username, email, password_hash, created_at FROM users WHERE email = ?`
+	query := `SELECT id, username, email, password_hash, timezone, email_verified_at, is_admin, EXISTS (SELECT 1 FROM user_totp WHERE user_id = users.id AND enabled_at IS NOT NULL), created_at FROM users WHERE email = ?`
 	err := r.db.QueryRow(query, email).Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
 		&user.PasswordHash,
+		&user.Timezone,
+		&user.EmailVerifiedAt,
+		&user.IsAdmin,
+		&user.TwoFactorEnabled,
 		&user.CreatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -84,12 +110,16 @@ func (r *Repository) GetByEmail(email string) (*models.User, error) {
 // GetByID retrieves a user by ID
 func (r *Repository) GetByID(id string) (*models.User, error) {
 	var user models.User
-	query := `SELECT id, username, email, password_hash, created_at FROM users WHERE id = ?`
+	query := `SELECT id, username, email, password_hash, timezone, email_verified_at, is_admin, EXISTS (SELECT 1 FROM user_totp WHERE user_id = users.id AND enabled_at IS NOT NULL), created_at FROM users WHERE id = ?`
 	err := r.db.QueryRow(query, id).Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
 		&user.PasswordHash,
+		&user.Timezone,
+		&user.EmailVerifiedAt,
+		&user.IsAdmin,
+		&user.TwoFactorEnabled,
 		&user.CreatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -101,6 +131,650 @@ func (r *Repository) GetByID(id string) (*models.User, error) {
 	return &user, nil
 }
 
+// UpdateTimezone changes the time zone used for a user's streaks and goals
+func (r *Repository) UpdateTimezone(id, timezone string) error {
+	result, err := r.db.Exec(`UPDATE users SET timezone = ? WHERE id = ?`, timezone, id)
+	if err != nil {
+		return fmt.Errorf("failed to update timezone: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdatePassword replaces a user's password hash
+func (r *Repository) UpdatePassword(id, passwordHash string) error {
+	result, err := r.db.Exec(`UPDATE users SET password_hash = ? WHERE id = ?`, passwordHash, id)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// SetPassword replaces a user's password hash and uses up their outstanding
+// password reset tokens, so an older reset link can't change it again
+func (r *Repository) SetPassword(id, passwordHash string, now time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE users SET password_hash = ? WHERE id = ?`, passwordHash, id)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	_, err = tx.Exec(`
+		UPDATE password_reset_tokens SET used_at = ? WHERE user_id = ? AND used_at IS NULL
+	`, now.UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke password resets: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit password change: %w", err)
+	}
+	return nil
+}
+
+// CreatePasswordReset stores the hash of a password reset token
+func (r *Repository) CreatePasswordReset(userID, tokenHash string, expiresAt time.Time) error {
+	_, err := r.db.Exec(`
+		INSERT INTO password_reset_tokens (token_hash, user_id, expires_at, created_at) VALUES (?, ?, ?, ?)
+	`, tokenHash, userID, expiresAt.UTC(), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create password reset: %w", err)
+	}
+	return nil
+}
+
+// UsePasswordReset consumes a password reset token and returns the user it belongs to.
+// Each token works once and only until it expires.
+func (r *Repository) UsePasswordReset(tokenHash string, now time.Time) (string, error) {
+	result, err := r.db.Exec(`
+		UPDATE password_reset_tokens SET used_at = ?
+		WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?
+	`, now.UTC(), tokenHash, now.UTC())
+	if err != nil {
+		return "", fmt.Errorf("failed to use password reset: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return "", err
+	}
+	if rows == 0 {
+		return "", ErrInvalidResetToken
+	}
+
+	var userID string
+	err = r.db.QueryRow(`SELECT user_id FROM password_reset_tokens WHERE token_hash = ?`, tokenHash).Scan(&userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get password reset: %w", err)
+	}
+	return userID, nil
+}
+
+// IsEmailVerified reports whether a user has confirmed their email address
+func (r *Repository) IsEmailVerified(id string) (bool, error) {
+	var verifiedAt *time.Time
+	err := r.db.QueryRow(`SELECT email_verified_at FROM users WHERE id = ?`, id).Scan(&verifiedAt)
+	if err == sql.ErrNoRows {
+		return false, ErrUserNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get user: %w", err)
+	}
+	return verifiedAt != nil, nil
+}
+
+// CreateEmailVerification stores the hash of an email verification token
+func (r *Repository) CreateEmailVerification(userID, tokenHash string, expiresAt time.Time) error {
+	_, err := r.db.Exec(`
+		INSERT INTO email_verifications (token_hash, user_id, expires_at, created_at) VALUES (?, ?, ?, ?)
+	`, tokenHash, userID, expiresAt.UTC(), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create email verification: %w", err)
+	}
+	return nil
+}
+
+// CountEmailVerifications counts the verification emails sent to a user since a time
+func (r *Repository) CountEmailVerifications(userID string, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM email_verifications WHERE user_id = ? AND created_at > ?
+	`, userID, since.UTC()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count email verifications: %w", err)
+	}
+	return count, nil
+}
+
+// UseEmailVerification consumes a verification token and marks the user's email
+// as verified. Each token works once and only until it expires.
+func (r *Repository) UseEmailVerification(tokenHash string, now time.Time) (string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE email_verifications SET used_at = ?
+		WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?
+	`, now.UTC(), tokenHash, now.UTC())
+	if err != nil {
+		return "", fmt.Errorf("failed to use email verification: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return "", err
+	}
+	if rows == 0 {
+		return "", ErrInvalidVerifyToken
+	}
+
+	var userID string
+	err = tx.QueryRow(`SELECT user_id FROM email_verifications WHERE token_hash = ?`, tokenHash).Scan(&userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get email verification: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users SET email_verified_at = ? WHERE id = ? AND email_verified_at IS NULL
+	`, now.UTC(), userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit email verification: %w", err)
+	}
+	return userID, nil
+}
+
+// SetAdmins makes the users with the usernames admins and every other user not
+func (r *Repository) SetAdmins(usernames []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE users SET is_admin = 0`); err != nil {
+		return fmt.Errorf("failed to reset admins: %w", err)
+	}
+	for _, username := range usernames {
+		if _, err := tx.Exec(`UPDATE users SET is_admin = 1 WHERE username = ?`, username); err != nil {
+			return fmt.Errorf("failed to set admin: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// totpState is a user's authenticator secret and whether enrollment was confirmed
+type totpState struct {
+	secret   string
+	enabled  bool
+	lastStep int64
+}
+
+// SaveTOTPSecret stores a new authenticator secret waiting to be confirmed.
+// An enabled secret is never replaced.
+func (r *Repository) SaveTOTPSecret(userID, secret string) error {
+	result, err := r.db.Exec(`
+		INSERT INTO user_totp (user_id, secret, created_at) VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET secret = excluded.secret, last_step = 0, created_at = excluded.created_at
+		WHERE user_totp.enabled_at IS NULL
+	`, userID, secret, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save TOTP secret: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrTwoFactorEnabled
+	}
+	return nil
+}
+
+// getTOTP returns a user's authenticator secret
+func (r *Repository) getTOTP(userID string) (*totpState, error) {
+	var state totpState
+	var enabledAt *time.Time
+	err := r.db.QueryRow(`
+		SELECT secret, enabled_at, last_step FROM user_totp WHERE user_id = ?
+	`, userID).Scan(&state.secret, &enabledAt, &state.lastStep)
+	if err == sql.ErrNoRows {
+		return nil, ErrTwoFactorNotSetUp
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TOTP secret: %w", err)
+	}
+	state.enabled = enabledAt != nil
+	return &state, nil
+}
+
+// UseTOTPStep records the time step of an accepted code. It returns false if
+// that step or a later one was already used, so each code works once.
+func (r *Repository) UseTOTPStep(userID string, step int64) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE user_totp SET last_step = ? WHERE user_id = ? AND last_step < ?
+	`, step, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to use TOTP code: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// EnableTOTP confirms a user's authenticator and replaces their recovery codes
+func (r *Repository) EnableTOTP(userID string, recoveryCodeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	if _, err := tx.Exec(`UPDATE user_totp SET enabled_at = ? WHERE user_id = ?`, now, userID); err != nil {
+		return fmt.Errorf("failed to enable TOTP: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	for _, hash := range recoveryCodeHashes {
+		_, err := tx.Exec(`
+			INSERT INTO recovery_codes (code_hash, user_id, created_at) VALUES (?, ?, ?)
+		`, hash, userID, now)
+		if err != nil {
+			return fmt.Errorf("failed to create recovery code: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteTOTP turns two-factor authentication off for a user
+func (r *Repository) DeleteTOTP(userID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_totp WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to delete TOTP secret: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	return tx.Commit()
+}
+
+// UseRecoveryCode consumes one of a user's recovery codes
+func (r *Repository) UseRecoveryCode(userID, codeHash string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE recovery_codes SET used_at = ? WHERE code_hash = ? AND user_id = ? AND used_at IS NULL
+	`, time.Now().UTC(), codeHash, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes a user has left
+func (r *Repository) CountRecoveryCodes(userID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM recovery_codes WHERE user_id = ? AND used_at IS NULL
+	`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+// CreateLoginChallenge stores the hash of a token for the second step of a login
+func (r *Repository) CreateLoginChallenge(userID, tokenHash string, expiresAt time.Time) error {
+	_, err := r.db.Exec(`
+		INSERT INTO login_challenges (token_hash, user_id, expires_at, created_at) VALUES (?, ?, ?, ?)
+	`, tokenHash, userID, expiresAt.UTC(), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create login challenge: %w", err)
+	}
+	return nil
+}
+
+// AttemptLoginChallenge counts an attempt at a login challenge and returns the
+// user it belongs to. Challenges that expired, were used or ran out of
+// attempts are rejected.
+func (r *Repository) AttemptLoginChallenge(tokenHash string, maxAttempts int, now time.Time) (string, error) {
+	result, err := r.db.Exec(`
+		UPDATE login_challenges SET attempts = attempts + 1
+		WHERE token_hash = ? AND used_at IS NULL AND expires_at > ? AND attempts < ?
+	`, tokenHash, now.UTC(), maxAttempts)
+	if err != nil {
+		return "", fmt.Errorf("failed to attempt login challenge: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return "", err
+	}
+	if rows == 0 {
+		return "", ErrInvalidChallenge
+	}
+
+	var userID string
+	err = r.db.QueryRow(`SELECT user_id FROM login_challenges WHERE token_hash = ?`, tokenHash).Scan(&userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get login challenge: %w", err)
+	}
+	return userID, nil
+}
+
+// UseLoginChallenge marks a login challenge as completed so it can't be used again
+func (r *Repository) UseLoginChallenge(tokenHash string) error {
+	_, err := r.db.Exec(`
+		UPDATE login_challenges SET used_at = ? WHERE token_hash = ?
+	`, time.Now().UTC(), tokenHash)
+	if err != nil {
+		return fmt.Errorf("failed to use login challenge: %w", err)
+	}
+	return nil
+}
+
+// GetProfile retrieves a user's profile and preferences
+func (r *Repository) GetProfile(id string) (*models.UserProfile, error) {
+	var profile models.UserProfile
+	var prefs string
+	err := r.db.QueryRow(`
+		SELECT id, username, email, email_verified_at, is_admin,
+			EXISTS (SELECT 1 FROM user_totp WHERE user_id = users.id AND enabled_at IS NOT NULL),
+			display_name, bio, avatar_url, timezone, title_language, privacy, notification_prefs, created_at
+		FROM users WHERE id = ?
+	`, id).Scan(
+		&profile.UserID,
+		&profile.Username,
+		&profile.Email,
+		&profile.EmailVerifiedAt,
+		&profile.IsAdmin,
+		&profile.TwoFactorEnabled,
+		&profile.DisplayName,
+		&profile.Bio,
+		&profile.AvatarURL,
+		&profile.Timezone,
+		&profile.TitleLanguage,
+		&profile.Privacy,
+		&prefs,
+		&profile.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile: %w", err)
+	}
+
+	profile.EmailVerified = profile.EmailVerifiedAt != nil
+	if err := json.Unmarshal([]byte(prefs), &profile.NotificationPreferences); err != nil || profile.NotificationPreferences == nil {
+		profile.NotificationPreferences = map[string]bool{}
+	}
+	return &profile, nil
+}
+
+// UpdateProfile saves the editable fields of a profile
+func (r *Repository) UpdateProfile(profile *models.UserProfile) error {
+	prefs, err := json.Marshal(profile.NotificationPreferences)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification preferences: %w", err)
+	}
+
+	result, err := r.db.Exec(`
+		UPDATE users SET display_name = ?, bio = ?, avatar_url = ?, timezone = ?,
+			title_language = ?, privacy = ?, notification_prefs = ?
+		WHERE id = ?
+	`, profile.DisplayName, profile.Bio, profile.AvatarURL, profile.Timezone,
+		profile.TitleLanguage, profile.Privacy, string(prefs), profile.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to update profile: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// ChangeEmail sets a new, unverified email address. Verification tokens sent
+// to the old address stop working.
+func (r *Repository) ChangeEmail(id, email string, now time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE users SET email = ?, email_verified_at = NULL WHERE id = ?`, email, id)
+	if err != nil {
+		if isUniqueConstraintError(err, "email") {
+			return ErrEmailExists
+		}
+		return fmt.Errorf("failed to change email: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrUserNotFound
+	}
+
+	_, err = tx.Exec(`
+		UPDATE email_verifications SET used_at = ? WHERE user_id = ? AND used_at IS NULL
+	`, now.UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate email verifications: %w", err)
+	}
+	return tx.Commit()
+}
+
+// GetByIdentity retrieves the user linked to an identity at an external provider
+func (r *Repository) GetByIdentity(issuer, subject string) (*models.User, error) {
+	var userID string
+	err := r.db.QueryRow(`
+		SELECT user_id FROM user_identities WHERE issuer = ? AND subject = ?
+	`, issuer, subject).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+	return r.GetByID(userID)
+}
+
+// LinkIdentity links an identity at an external provider to a user
+func (r *Repository) LinkIdentity(userID, issuer, subject, email string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO user_identities (issuer, subject, user_id, email) VALUES (?, ?, ?, ?)
+	`, issuer, subject, userID, email)
+	if err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+	return nil
+}
+
+// TouchIdentity records a login through an identity
+func (r *Repository) TouchIdentity(issuer, subject, email string, now time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE user_identities SET email = ?, last_login_at = ? WHERE issuer = ? AND subject = ?
+	`, email, now.UTC(), issuer, subject)
+	if err != nil {
+		return fmt.Errorf("failed to update identity: %w", err)
+	}
+	return nil
+}
+
+// ScheduleDeletion marks an account to be deleted at a time
+func (r *Repository) ScheduleDeletion(id string, at time.Time) error {
+	result, err := r.db.Exec(`
+		UPDATE users SET deletion_scheduled_at = ? WHERE id = ? AND deletion_scheduled_at IS NULL
+	`, at.UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to schedule deletion: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrDeletionScheduled
+	}
+	return nil
+}
+
+// CancelDeletion cancels an account's scheduled deletion. It reports whether
+// a deletion was pending.
+func (r *Repository) CancelDeletion(id string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE users SET deletion_scheduled_at = NULL WHERE id = ? AND deletion_scheduled_at IS NOT NULL
+	`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel deletion: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// DueDeletions returns the IDs of accounts whose deletion time has passed
+func (r *Repository) DueDeletions(now time.Time) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT id FROM users WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?
+	`, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due deletions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// userTables lists every table holding a user's data, children first.
+// Foreign keys aren't enforced, so deletes can't rely on ON DELETE CASCADE.
+var userTables = []string{
+	"session_chapters",
+	"reading_sessions",
+	"reading_positions",
+	"user_progress",
+	"reading_goals",
+	"user_achievements",
+	"refresh_tokens",
+	"login_sessions",
+	"revoked_tokens",
+	"personal_access_tokens",
+	"password_reset_tokens",
+	"email_verifications",
+	"user_totp",
+	"recovery_codes",
+	"login_challenges",
+	"user_identities",
+	"sync_events",
+	"sync_sequences",
+}
+
+// Delete removes a user and everything they own
+func (r *Repository) Delete(id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range userTables {
+		query := `DELETE FROM ` + table + ` WHERE user_id = ?`
+		if table == "session_chapters" {
+			query = `DELETE FROM session_chapters WHERE session_id IN (SELECT id FROM reading_sessions WHERE user_id = ?)`
+		}
+		if _, err := tx.Exec(query, id); err != nil {
+			return fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+	}
+
+	result, err := tx.Exec(`DELETE FROM users WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrUserNotFound
+	}
+	return tx.Commit()
+}
+
+// ExportRows runs a query for a data export and returns each row as a map of
+// column names to values
+func (r *Repository) ExportRows(query string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export data: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []map[string]interface{}{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to scan export row: %w", err)
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			row[column] = values[i]
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
 // Helper function to check for unique constraint errors
 func isUniqueConstraintError(err error, field string) bool {
 	if err == nil {
@@ -0,0 +1,280 @@
+package user
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"mangahub/internal/auth"
+	"mangahub/internal/oidc"
+	"mangahub/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrIdentityUnverified = errors.New("the identity provider hasn't verified your email address")
+	ErrIdentityConflict   = errors.New("an account with this email exists but its address isn't verified; log in with your password and verify it first")
+)
+
+// UseSSO lets users log in through an OpenID Connect provider
+func (s *Service) UseSSO(provider *oidc.Provider) {
+	s.sso = provider
+}
+
+// StartSSO begins a single sign-on login redirecting back to redirectURI. It
+// returns the provider URL to open in a browser and the login's state.
+func (s *Service) StartSSO(redirectURI string) (string, string, error) {
+	return s.sso.AuthCodeURL(redirectURI)
+}
+
+// CompleteSSO finishes a single sign-on login with the code the provider
+// redirected back with. The identity is linked to an existing account with the
+// same verified email, or a new account is created for it.
+func (s *Service) CompleteSSO(state, code string, device auth.Device) (*LoginResult, error) {
+	identity, err := s.sso.Exchange(state, code)
+	if err != nil {
+		return nil, err
+	}
+	return s.loginWithIdentity(identity, device)
+}
+
+// loginWithIdentity logs in the user an identity belongs to. Two-factor
+// authentication is still required for users who enabled it.
+func (s *Service) loginWithIdentity(identity *oidc.Identity, device auth.Device) (*LoginResult, error) {
+	user, err := s.repo.GetByIdentity(identity.Issuer, identity.Subject)
+	switch {
+	case err == ErrUserNotFound:
+		user, err = s.linkIdentity(identity)
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	default:
+		if err := s.repo.TouchIdentity(identity.Issuer, identity.Subject, identity.Email, time.Now()); err != nil {
+			log.Printf("Failed to record login of %s: %v", user.Username, err)
+		}
+	}
+
+	if user.TwoFactorEnabled {
+		return s.startChallenge(user)
+	}
+
+	tokens, err := s.tokens.Issue(user.ID, user.Username, device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return &LoginResult{Tokens: tokens, User: user, AccountRestored: s.restoreAccount(user)}, nil
+}
+
+// linkIdentity connects a new identity to the account with its email, or
+// creates an account for it. Only addresses verified on both sides are
+// linked, so nobody can claim an account by registering its email first.
+func (s *Service) linkIdentity(identity *oidc.Identity) (*models.User, error) {
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, ErrIdentityUnverified
+	}
+
+	user, err := s.repo.GetByEmail(identity.Email)
+	switch {
+	case err == ErrUserNotFound:
+		user, err = s.createFromIdentity(identity)
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case user.EmailVerifiedAt == nil:
+		return nil, ErrIdentityConflict
+	}
+
+	if err := s.repo.LinkIdentity(user.ID, identity.Issuer, identity.Subject, identity.Email); err != nil {
+		return nil, err
+	}
+	log.Printf("Linked %s to identity %s at %s", user.Username, identity.Subject, identity.Issuer)
+	return user, nil
+}
+
+// createFromIdentity creates a verified account for an identity. Its password
+// is random; the user can set one with a password reset.
+func (s *Service) createFromIdentity(identity *oidc.Identity) (*models.User, error) {
+	raw, _, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate password: %w", err)
+	}
+	hash, err := s.passwords.Hash(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	now := time.Now()
+	base := usernameFor(identity)
+	for attempt := 0; attempt < 5; attempt++ {
+		username := base
+		if attempt > 0 {
+			suffix, err := rand.Int(rand.Reader, big.NewInt(10000))
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate username: %w", err)
+			}
+			username = fmt.Sprintf("%s-%04d", base, suffix.Int64())
+		}
+
+		user := &models.User{
+			ID:              uuid.New().String(),
+			Username:        username,
+			Email:           identity.Email,
+			PasswordHash:    hash,
+			EmailVerifiedAt: &now,
+			CreatedAt:       now,
+		}
+		err := s.repo.Create(user)
+		if err == ErrUsernameExists {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Created user %s for identity %s at %s", user.Username, identity.Subject, identity.Issuer)
+		return user, nil
+	}
+	return nil, ErrUsernameExists
+}
+
+// usernameFor picks a username from the identity's preferred username, email
+// or name, keeping letters, digits, dots, dashes and underscores
+func usernameFor(identity *oidc.Identity) string {
+	local, _, _ := strings.Cut(identity.Email, "@")
+	for _, candidate := range []string{identity.PreferredUsername, local, identity.Name} {
+		name := strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+				return r
+			case r == ' ':
+				return '_'
+			}
+			return -1
+		}, candidate)
+		// Leave room for a suffix within the 30 character limit
+		if len(name) > 25 {
+			name = name[:25]
+		}
+		if len(name) >= 3 {
+			return name
+		}
+	}
+	return "reader"
+}
+
+// StartSSO handles starting a single sign-on login
+func (h *Handler) StartSSO(c *gin.Context) {
+	var req models.SSOStartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "redirect_uri is required",
+		})
+		return
+	}
+
+	authURL, state, err := h.service.StartSSO(req.RedirectURI)
+	if err != nil {
+		switch {
+		case err == oidc.ErrNotConfigured:
+			c.JSON(http.StatusNotFound, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+		case err == oidc.ErrInvalidRedirect:
+			c.JSON(http.StatusBadRequest, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+		case errors.Is(err, oidc.ErrDiscoveryFailed):
+			log.Printf("Single sign-on unavailable: %v", err)
+			c.JSON(http.StatusBadGateway, models.Response{
+				Success: false,
+				Error:   "identity provider is unavailable",
+			})
+		default:
+			c.JSON(http.StatusServiceUnavailable, models.Response{
+				Success: false,
+				Error:   "failed to start login",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Data: gin.H{
+			"authorization_url": authURL,
+			"state":             state,
+			"expires_in":        int(oidc.LoginTTL.Seconds()),
+		},
+	})
+}
+
+// CompleteSSO handles the code the identity provider redirected back with
+func (h *Handler) CompleteSSO(c *gin.Context) {
+	var req models.SSOCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "state and code are required",
+		})
+		return
+	}
+
+	device := auth.Device{
+		Name:      req.DeviceName,
+		UserAgent: c.GetHeader("User-Agent"),
+		IP:        c.ClientIP(),
+	}
+
+	result, err := h.service.CompleteSSO(req.State, req.Code, device)
+	if err != nil {
+		switch {
+		case err == oidc.ErrNotConfigured:
+			c.JSON(http.StatusNotFound, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+		case err == oidc.ErrInvalidState:
+			c.JSON(http.StatusBadRequest, models.Response{
+				Success: false,
+				Error:   "login expired or already used, start again",
+			})
+		case errors.Is(err, oidc.ErrExchangeFailed), errors.Is(err, oidc.ErrInvalidIDToken):
+			log.Printf("Single sign-on failed: %v", err)
+			c.JSON(http.StatusUnauthorized, models.Response{
+				Success: false,
+				Error:   "login with the identity provider failed",
+			})
+		case err == ErrIdentityUnverified:
+			c.JSON(http.StatusForbidden, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+		case err == ErrIdentityConflict, err == ErrEmailExists:
+			c.JSON(http.StatusConflict, models.Response{
+				Success: false,
+				Error:   ErrIdentityConflict.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.Response{
+				Success: false,
+				Error:   "failed to log in",
+			})
+		}
+		return
+	}
+
+	respondLogin(c, result)
+}
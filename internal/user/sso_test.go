@@ -0,0 +1,97 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"mangahub/internal/auth"
+	"mangahub/internal/oidc"
+	"mangahub/internal/oidc/oidctest"
+)
+
+func setupSSO(t *testing.T) (*Service, *oidctest.Provider) {
+	service, _ := setupTestService(t)
+	mock, err := oidctest.NewProvider("mangahub", "client-secret")
+	if err != nil {
+		t.Fatalf("Failed to start mock provider: %v", err)
+	}
+	t.Cleanup(mock.Close)
+	service.UseSSO(oidc.NewProvider(oidc.Config{Issuer: mock.URL, ClientID: "mangahub", ClientSecret: "client-secret"}))
+	return service, mock
+}
+
+// ssoLogin runs a whole login as the mock provider's current user
+func ssoLogin(t *testing.T, service *Service, mock *oidctest.Provider) (*LoginResult, error) {
+	authURL, _, err := service.StartSSO("http://127.0.0.1:53682/callback")
+	if err != nil {
+		t.Fatalf("Failed to start login: %v", err)
+	}
+	state, code, err := mock.Login(authURL)
+	if err != nil {
+		t.Fatalf("Provider rejected the login: %v", err)
+	}
+	return service.CompleteSSO(state, code, auth.Device{Name: "laptop"})
+}
+
+func TestSSOCreatesUser(t *testing.T) {
+	service, mock := setupSSO(t)
+	mock.SetUser(oidctest.User{Subject: "sub-1", Email: "new@example.com", EmailVerified: true, PreferredUsername: "New Reader"})
+
+	result, err := ssoLogin(t, service, mock)
+	if err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+	if result.User.Username != "New_Reader" || result.Tokens == nil {
+		t.Errorf("Expected tokens for a new user New_Reader, got %+v", result)
+	}
+	if verified, _ := service.IsEmailVerified(result.User.ID); !verified {
+		t.Error("Expected the provider's verified email to count as verified")
+	}
+
+	// The identity stays linked even after the email changes at the provider
+	mock.SetUser(oidctest.User{Subject: "sub-1", Email: "renamed@example.com", EmailVerified: true})
+	again, err := ssoLogin(t, service, mock)
+	if err != nil || again.User.ID != result.User.ID {
+		t.Errorf("Expected the same user on the next login, got %+v, %v", again, err)
+	}
+
+	// A clashing username gets a suffix
+	mock.SetUser(oidctest.User{Subject: "sub-2", Email: "other@example.com", EmailVerified: true, PreferredUsername: "New Reader"})
+	other, err := ssoLogin(t, service, mock)
+	if err != nil || other.User.Username == "New_Reader" {
+		t.Errorf("Expected a different username, got %+v, %v", other, err)
+	}
+}
+
+func TestSSOLinksVerifiedEmail(t *testing.T) {
+	service, mock := setupSSO(t)
+	user, _ := service.repo.GetByUsername("reader")
+	mock.SetUser(oidctest.User{Subject: "sub-1", Email: "reader@example.com", EmailVerified: true})
+
+	// An unverified local account could have been registered by anyone
+	if _, err := ssoLogin(t, service, mock); err != ErrIdentityConflict {
+		t.Fatalf("Expected ErrIdentityConflict, got %v", err)
+	}
+
+	service.repo.db.Exec(`UPDATE users SET email_verified_at = ? WHERE id = ?`, time.Now(), user.ID)
+	result, err := ssoLogin(t, service, mock)
+	if err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+	if result.User.ID != user.ID {
+		t.Errorf("Expected the existing account to be linked, got %s", result.User.Username)
+	}
+
+	// The provider has to vouch for the address
+	mock.SetUser(oidctest.User{Subject: "sub-2", Email: "someone@example.com", EmailVerified: false})
+	if _, err := ssoLogin(t, service, mock); err != ErrIdentityUnverified {
+		t.Errorf("Expected ErrIdentityUnverified, got %v", err)
+	}
+}
+
+func TestSSONotConfigured(t *testing.T) {
+	service, _ := setupTestService(t)
+	if _, _, err := service.StartSSO("http://127.0.0.1:53682/callback"); err != oidc.ErrNotConfigured {
+		t.Errorf("Expected ErrNotConfigured, got %v", err)
+	}
+}
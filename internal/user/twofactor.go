@@ -0,0 +1,391 @@
+package user
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"mangahub/internal/auth"
+	"mangahub/internal/ratelimit"
+	"mangahub/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// TOTPIssuer names the account in authenticator apps
+	TOTPIssuer = "MangaHub"
+
+	// LoginChallengeTTL is how long the second step of a login can take, and
+	// MaxChallengeAttempts how many codes can be tried in that time
+	LoginChallengeTTL    = 5 * time.Minute
+	MaxChallengeAttempts = 5
+
+	// RecoveryCodeCount is how many one-time recovery codes enrollment creates
+	RecoveryCodeCount = 10
+)
+
+// LoginResult is the outcome of a password check: either a token pair, or a
+// challenge token when the user has to send a two-factor code as well
+type LoginResult struct {
+	Tokens             *auth.TokenPair
+	User               *models.User
+	ChallengeToken     string
+	ChallengeExpiresAt time.Time
+	AccountRestored    bool // a pending deletion was cancelled by logging in
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// startChallenge creates the token a user exchanges for tokens with their code
+func (s *Service) startChallenge(user *models.User) (*LoginResult, error) {
+	raw, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate login challenge: %w", err)
+	}
+	expiresAt := time.Now().Add(LoginChallengeTTL)
+	if err := s.repo.CreateLoginChallenge(user.ID, hash, expiresAt); err != nil {
+		return nil, err
+	}
+	return &LoginResult{User: user, ChallengeToken: raw, ChallengeExpiresAt: expiresAt}, nil
+}
+
+// CompleteLogin finishes a two-factor login with an authenticator or recovery
+// code. Wrong codes count towards the same lockout as wrong passwords; when
+// the account is locked the result still names the user, so the caller can
+// tell for how long.
+func (s *Service) CompleteLogin(req *models.TwoFactorLoginRequest, device auth.Device) (*LoginResult, error) {
+	challengeHash := auth.HashOpaqueToken(req.ChallengeToken)
+	userID, err := s.repo.AttemptLoginChallenge(challengeHash, MaxChallengeAttempts, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if s.lockout.Locked(user.Username) > 0 {
+		return &LoginResult{User: user}, ErrAccountLocked
+	}
+
+	if err := s.checkCode(userID, req.Code); err != nil {
+		if err == ErrInvalidCode {
+			s.lockout.Failure(user.Username)
+		}
+		return nil, err
+	}
+	if err := s.repo.UseLoginChallenge(challengeHash); err != nil {
+		return nil, err
+	}
+	s.lockout.Success(user.Username)
+
+	tokens, err := s.tokens.Issue(user.ID, user.Username, device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return &LoginResult{Tokens: tokens, User: user, AccountRestored: s.restoreAccount(user)}, nil
+}
+
+// EnrollTwoFactor creates a new authenticator secret for a user. It takes
+// effect once ConfirmTwoFactor sees a code generated from it.
+func (s *Service) EnrollTwoFactor(userID string) (secret, uri string, err error) {
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret, err = auth.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	if err := s.repo.SaveTOTPSecret(userID, secret); err != nil {
+		return "", "", err
+	}
+	return secret, auth.TOTPURI(TOTPIssuer, user.Username, secret), nil
+}
+
+// ConfirmTwoFactor enables two-factor authentication once the user proves their
+// authenticator works, and returns their recovery codes. Every other login is
+// signed out, since those didn't use a second factor.
+func (s *Service) ConfirmTwoFactor(userID, currentSessionID, code string) ([]string, error) {
+	state, err := s.repo.getTOTP(userID)
+	if err != nil {
+		return nil, err
+	}
+	if state.enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	if err := s.checkTOTP(userID, state, code); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(b))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = auth.HashOpaqueToken(normalizeRecoveryCode(codes[i]))
+	}
+
+	if err := s.repo.EnableTOTP(userID, hashes); err != nil {
+		return nil, err
+	}
+	if err := s.tokens.RevokeAllSessions(userID, currentSessionID); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off after checking a code.
+// Admins can't turn it off.
+func (s *Service) DisableTwoFactor(userID, code string) error {
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.IsAdmin {
+		return ErrTwoFactorRequired
+	}
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotSetUp
+	}
+	if err := s.checkCode(userID, code); err != nil {
+		return err
+	}
+	return s.repo.DeleteTOTP(userID)
+}
+
+// checkCode accepts a current authenticator code or an unused recovery code
+func (s *Service) checkCode(userID, code string) error {
+	state, err := s.repo.getTOTP(userID)
+	if err != nil {
+		return err
+	}
+	if !state.enabled {
+		return ErrTwoFactorNotSetUp
+	}
+
+	if len(strings.TrimSpace(code)) == auth.TOTPDigits {
+		return s.checkTOTP(userID, state, code)
+	}
+
+	used, err := s.repo.UseRecoveryCode(userID, auth.HashOpaqueToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// checkTOTP accepts an authenticator code that hasn't been used before
+func (s *Service) checkTOTP(userID string, state *totpState, code string) error {
+	step, ok := auth.ValidateTOTP(state.secret, code, time.Now())
+	if !ok || step <= state.lastStep {
+		return ErrInvalidCode
+	}
+	fresh, err := s.repo.UseTOTPStep(userID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
+
+// LoginTwoFactor handles the second step of a login with two-factor authentication
+func (h *Handler) LoginTwoFactor(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "challenge_token and code are required",
+		})
+		return
+	}
+
+	device := auth.Device{
+		Name:      req.DeviceName,
+		UserAgent: c.GetHeader("User-Agent"),
+		IP:        c.ClientIP(),
+	}
+
+	result, err := h.service.CompleteLogin(&req, device)
+	if err == ErrAccountLocked {
+		ratelimit.Reject(c, h.service.lockout.Locked(result.User.Username))
+		return
+	}
+	if err != nil {
+		if err == ErrInvalidChallenge || err == ErrInvalidCode {
+			c.JSON(http.StatusUnauthorized, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to log in",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "login successful",
+		Data:    loginData(result),
+	})
+}
+
+// EnrollTwoFactor handles creating an authenticator secret
+func (h *Handler) EnrollTwoFactor(c *gin.Context) {
+	secret, uri, err := h.service.EnrollTwoFactor(auth.GetUserID(c))
+	if err != nil {
+		if err == ErrTwoFactorEnabled {
+			c.JSON(http.StatusConflict, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.Response{
+			Success: false,
+			Error:   "failed to set up two-factor authentication",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "add the secret to your authenticator app, then confirm with a code",
+		Data: gin.H{
+			"secret":      secret,
+			"otpauth_uri": uri,
+		},
+	})
+}
+
+// ConfirmTwoFactor handles enabling two-factor authentication with a first code
+func (h *Handler) ConfirmTwoFactor(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "code is required",
+		})
+		return
+	}
+
+	codes, err := h.service.ConfirmTwoFactor(auth.GetUserID(c), auth.GetClaims(c).SessionID, req.Code)
+	if err != nil {
+		switch err {
+		case ErrInvalidCode:
+			c.JSON(http.StatusBadRequest, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+		case ErrTwoFactorNotSetUp, ErrTwoFactorEnabled:
+			c.JSON(http.StatusConflict, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.Response{
+				Success: false,
+				Error:   "failed to enable two-factor authentication",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "two-factor authentication enabled. Store the recovery codes somewhere safe; each works once",
+		Data: gin.H{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+// DisableTwoFactor handles turning two-factor authentication off
+func (h *Handler) DisableTwoFactor(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.Response{
+			Success: false,
+			Error:   "code is required",
+		})
+		return
+	}
+
+	if err := h.service.DisableTwoFactor(auth.GetUserID(c), req.Code); err != nil {
+		switch err {
+		case ErrInvalidCode:
+			c.JSON(http.StatusBadRequest, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+		case ErrTwoFactorRequired:
+			c.JSON(http.StatusForbidden, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+		case ErrTwoFactorNotSetUp:
+			c.JSON(http.StatusConflict, models.Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.Response{
+				Success: false,
+				Error:   "failed to disable two-factor authentication",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Response{
+		Success: true,
+		Message: "two-factor authentication disabled",
+	})
+}
+
+// RequireAdmin is middleware that only lets admins with two-factor
+// authentication enabled through
+func (h *Handler) RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := h.service.repo.GetByID(auth.GetUserID(c))
+		if err != nil || !user.IsAdmin {
+			c.JSON(http.StatusForbidden, models.Response{
+				Success: false,
+				Error:   "admin access required",
+			})
+			c.Abort()
+			return
+		}
+		if !user.TwoFactorEnabled {
+			c.JSON(http.StatusForbidden, models.Response{
+				Success: false,
+				Error:   "admins must set up two-factor authentication first: mangahub auth 2fa enable",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
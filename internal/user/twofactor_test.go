@@ -0,0 +1,160 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"mangahub/internal/auth"
+	"mangahub/pkg/models"
+)
+
+// enrollTestUser turns on two-factor authentication for the test user and
+// returns the secret, the step of the code used and the recovery codes
+func enrollTestUser(t *testing.T, service *Service, userID string) (string, int64, []string) {
+	secret, uri, err := service.EnrollTwoFactor(userID)
+	if err != nil {
+		t.Fatalf("Failed to enroll: %v", err)
+	}
+	if uri == "" {
+		t.Error("Expected an otpauth URI")
+	}
+
+	step := auth.TOTPStep(time.Now())
+	code, _ := auth.TOTPCode(secret, step)
+	codes, err := service.ConfirmTwoFactor(userID, "", code)
+	if err != nil {
+		t.Fatalf("Failed to confirm enrollment: %v", err)
+	}
+	if len(codes) != RecoveryCodeCount {
+		t.Fatalf("Expected %d recovery codes, got %d", RecoveryCodeCount, len(codes))
+	}
+	return secret, step, codes
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	service, _ := setupTestService(t)
+	user, _ := service.repo.GetByUsername("reader")
+	secret, step, recoveryCodes := enrollTestUser(t, service, user.ID)
+
+	if _, _, err := service.EnrollTwoFactor(user.ID); err != ErrTwoFactorEnabled {
+		t.Errorf("Expected ErrTwoFactorEnabled, got %v", err)
+	}
+
+	login := &models.LoginRequest{Username: "reader", Password: "old-password"}
+	result, err := service.Login(login, auth.Device{})
+	if err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+	if result.Tokens != nil || result.ChallengeToken == "" {
+		t.Fatalf("Expected a challenge instead of tokens, got %+v", result)
+	}
+
+	// The code used to enroll can't be replayed
+	usedCode, _ := auth.TOTPCode(secret, step)
+	_, err = service.CompleteLogin(&models.TwoFactorLoginRequest{ChallengeToken: result.ChallengeToken, Code: usedCode}, auth.Device{})
+	if err != ErrInvalidCode {
+		t.Errorf("Expected a replayed code to be rejected, got %v", err)
+	}
+
+	nextCode, _ := auth.TOTPCode(secret, step+1)
+	completed, err := service.CompleteLogin(&models.TwoFactorLoginRequest{ChallengeToken: result.ChallengeToken, Code: nextCode}, auth.Device{})
+	if err != nil {
+		t.Fatalf("Failed to complete login: %v", err)
+	}
+	if completed.Tokens == nil || completed.User.ID != user.ID {
+		t.Errorf("Expected tokens for the user, got %+v", completed)
+	}
+
+	// A completed challenge can't be used again
+	_, err = service.CompleteLogin(&models.TwoFactorLoginRequest{ChallengeToken: result.ChallengeToken, Code: recoveryCodes[0]}, auth.Device{})
+	if err != ErrInvalidChallenge {
+		t.Errorf("Expected ErrInvalidChallenge, got %v", err)
+	}
+
+	// Recovery codes work once each, whatever their case
+	result, _ = service.Login(login, auth.Device{})
+	if _, err := service.CompleteLogin(&models.TwoFactorLoginRequest{ChallengeToken: result.ChallengeToken, Code: " " + recoveryCodes[1] + " "}, auth.Device{}); err != nil {
+		t.Errorf("Expected recovery code to work, got %v", err)
+	}
+	result, _ = service.Login(login, auth.Device{})
+	if _, err := service.CompleteLogin(&models.TwoFactorLoginRequest{ChallengeToken: result.ChallengeToken, Code: recoveryCodes[1]}, auth.Device{}); err != ErrInvalidCode {
+		t.Errorf("Expected a used recovery code to be rejected, got %v", err)
+	}
+}
+
+func TestTwoFactorChallengeAttempts(t *testing.T) {
+	service, _ := setupTestService(t)
+	user, _ := service.repo.GetByUsername("reader")
+	secret, step, _ := enrollTestUser(t, service, user.ID)
+
+	result, _ := service.Login(&models.LoginRequest{Username: "reader", Password: "old-password"}, auth.Device{})
+	for i := 0; i < MaxChallengeAttempts; i++ {
+		service.CompleteLogin(&models.TwoFactorLoginRequest{ChallengeToken: result.ChallengeToken, Code: "000000"}, auth.Device{})
+	}
+
+	nextCode, _ := auth.TOTPCode(secret, step+1)
+	_, err := service.CompleteLogin(&models.TwoFactorLoginRequest{ChallengeToken: result.ChallengeToken, Code: nextCode}, auth.Device{})
+	if err != ErrInvalidChallenge {
+		t.Errorf("Expected the challenge to be used up, got %v", err)
+	}
+}
+
+func TestTwoFactorLockout(t *testing.T) {
+	service, _ := setupTestService(t)
+	user, _ := service.repo.GetByUsername("reader")
+	secret, step, _ := enrollTestUser(t, service, user.ID)
+	login := &models.LoginRequest{Username: "reader", Password: "old-password"}
+
+	// Each login starts a fresh challenge, but wrong codes add up across them
+	for i := 0; i < LockoutThreshold; i++ {
+		result, err := service.Login(login, auth.Device{})
+		if err != nil {
+			t.Fatalf("Expected a challenge on attempt %d, got %v", i+1, err)
+		}
+		_, err = service.CompleteLogin(&models.TwoFactorLoginRequest{ChallengeToken: result.ChallengeToken, Code: "000000"}, auth.Device{})
+		if err != ErrInvalidCode {
+			t.Fatalf("Expected ErrInvalidCode on attempt %d, got %v", i+1, err)
+		}
+	}
+	if _, err := service.Login(login, auth.Device{}); err != ErrAccountLocked {
+		t.Fatalf("Expected the password step to be locked, got %v", err)
+	}
+
+	// A challenge from before the lock can't be used to keep guessing
+	service.lockout.Success("reader")
+	result, _ := service.Login(login, auth.Device{})
+	for i := 0; i < LockoutThreshold; i++ {
+		service.lockout.Failure("reader")
+	}
+	nextCode, _ := auth.TOTPCode(secret, step+1)
+	_, err := service.CompleteLogin(&models.TwoFactorLoginRequest{ChallengeToken: result.ChallengeToken, Code: nextCode}, auth.Device{})
+	if err != ErrAccountLocked {
+		t.Errorf("Expected ErrAccountLocked, got %v", err)
+	}
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	service, _ := setupTestService(t)
+	user, _ := service.repo.GetByUsername("reader")
+	_, _, recoveryCodes := enrollTestUser(t, service, user.ID)
+
+	if err := service.repo.SetAdmins([]string{"reader"}); err != nil {
+		t.Fatalf("Failed to set admins: %v", err)
+	}
+	if err := service.DisableTwoFactor(user.ID, recoveryCodes[0]); err != ErrTwoFactorRequired {
+		t.Errorf("Expected admins to be unable to disable two-factor, got %v", err)
+	}
+
+	service.repo.SetAdmins(nil)
+	if err := service.DisableTwoFactor(user.ID, "wrong-code"); err != ErrInvalidCode {
+		t.Errorf("Expected ErrInvalidCode, got %v", err)
+	}
+	if err := service.DisableTwoFactor(user.ID, recoveryCodes[0]); err != nil {
+		t.Fatalf("Failed to disable two-factor: %v", err)
+	}
+
+	result, err := service.Login(&models.LoginRequest{Username: "reader", Password: "old-password"}, auth.Device{})
+	if err != nil || result.Tokens == nil {
+		t.Errorf("Expected a plain login after disabling two-factor, got %+v, %v", result, err)
+	}
+}
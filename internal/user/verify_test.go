@@ -0,0 +1,56 @@
+package user
+
+import (
+	"regexp"
+	"testing"
+
+	"mangahub/pkg/models"
+)
+
+var verifyTokenPattern = regexp.MustCompile(`verify\?token=(\S+)`)
+
+func TestEmailVerification(t *testing.T) {
+	repo := setupTestDB(t)
+	mailer := &recordingMailer{}
+	service := NewService(repo, nil, mailer, "http://localhost:8080/")
+
+	user, err := service.Register(&models.RegisterRequest{
+		Username: "reader",
+		Email:    "reader@example.com",
+		Password: "password123",
+	})
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+
+	if len(mailer.sent) != 1 || mailer.sent[0].To != "reader@example.com" {
+		t.Fatalf("Expected a verification email, got %+v", mailer.sent)
+	}
+	match := verifyTokenPattern.FindStringSubmatch(mailer.sent[0].Body)
+	if match == nil {
+		t.Fatalf("Expected a verification link in the email: %s", mailer.sent[0].Body)
+	}
+
+	if verified, _ := service.IsEmailVerified(user.ID); verified {
+		t.Error("Expected a new account to be unverified")
+	}
+
+	// Asking again straight away is rate limited
+	if err := service.ResendVerification(user.ID); err != ErrTooManyEmails {
+		t.Errorf("Expected ErrTooManyEmails, got %v", err)
+	}
+
+	if err := service.VerifyEmail(match[1]); err != nil {
+		t.Fatalf("Failed to verify email: %v", err)
+	}
+	if verified, _ := service.IsEmailVerified(user.ID); !verified {
+		t.Error("Expected the account to be verified")
+	}
+
+	if err := service.VerifyEmail(match[1]); err != ErrInvalidVerifyToken {
+		t.Errorf("Expected a used token to be rejected, got %v", err)
+	}
+	if err := service.ResendVerification(user.ID); err != ErrAlreadyVerified {
+		t.Errorf("Expected ErrAlreadyVerified, got %v", err)
+	}
+}
@@ -1,12 +1,14 @@
 package websocket
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"sync"
 	"time"
 
+	"mangahub/internal/ratelimit"
+	"mangahub/pkg/protocol"
+
 	"github.com/gorilla/websocket"
 )
 
@@ -29,14 +31,21 @@ type Message struct {
 	Time     string `json:"time"`     // Timestamp HH:MM:SS
 }
 
+// wire is the message as sent to clients
+func (m Message) wire() protocol.ChatMessage {
+	return protocol.ChatMessage{Kind: m.Type, Room: m.Room, Username: m.Username, Text: m.Text, Time: m.Time}
+}
+
 // Client represents a websocket client
 type Client struct {
-	ID       string
-	Username string
-	Conn     *websocket.Conn
-	Room     string
-	Send     chan []byte
-	hub      *Hub
+	ID        string
+	Username  string
+	SessionID string // login the connection was authenticated with
+	Conn      *websocket.Conn
+	Room      string
+	Send      chan []byte
+	hub       *Hub
+	encoding  protocol.Encoding // chosen with the WebSocket subprotocol
 }
 
 // Room represents a chat room with multiple clients
@@ -49,10 +58,11 @@ type Room struct {
 
 // Hub maintains active clients and broadcasts messages
 type Hub struct {
-	rooms      map[string]*Room
-	register   chan *Client
-	unregister chan *Client
-	mu         sync.RWMutex
+	rooms        map[string]*Room
+	register     chan *Client
+	unregister   chan *Client
+	messageLimit *ratelimit.Limiter // chat messages per user; nil means unlimited
+	mu           sync.RWMutex
 }
 
 // NewHub creates a new Hub
@@ -64,6 +74,11 @@ func NewHub() *Hub {
 	}
 }
 
+// LimitMessages limits how fast each user can send chat messages
+func (h *Hub) LimitMessages(l *ratelimit.Limiter) {
+	h.messageLimit = l
+}
+
 // Run starts the hub
 func (h *Hub) Run() {
 	for {
@@ -177,18 +192,25 @@ func (h *Hub) broadcastToRoom(roomName string, msg Message) {
 	}
 	room.mu.Unlock()
 
-	// Marshal message to JSON
-	data, err := json.Marshal(msg)
-	if err != nil {
-		log.Printf("Failed to marshal message: %v", err)
-		return
-	}
+	// Encoded once for each encoding in use
+	wire := msg.wire()
+	encoded := make(map[protocol.Encoding][]byte, 1)
 
 	// Send to all clients in this room
 	room.mu.RLock()
 	defer room.mu.RUnlock()
 
 	for client := range room.Clients {
+		data, ok := encoded[client.encoding]
+		if !ok {
+			var err error
+			if data, err = protocol.Marshal(&wire, client.encoding); err != nil {
+				log.Printf("Failed to marshal message: %v", err)
+				return
+			}
+			encoded[client.encoding] = data
+		}
+
 		select {
 		case client.Send <- data:
 			// Message sent successfully
@@ -211,11 +233,11 @@ func (h *Hub) sendHistoryToClient(client *Client, room *Room) {
 		return
 	}
 
-	historyMsg := map[string]interface{}{
-		"type":     "history",
-		"messages": history,
+	historyMsg := &protocol.History{Messages: make([]protocol.ChatMessage, 0, len(history))}
+	for _, msg := range history {
+		historyMsg.Messages = append(historyMsg.Messages, msg.wire())
 	}
-	data, err := json.Marshal(historyMsg)
+	data, err := protocol.Marshal(historyMsg, client.encoding)
 	if err != nil {
 		return
 	}
@@ -226,6 +248,74 @@ func (h *Hub) sendHistoryToClient(client *Client, room *Room) {
 	}
 }
 
+// DisconnectSession closes every connection opened with a revoked login
+func (h *Hub) DisconnectSession(sessionID string) {
+	if sessionID == "" {
+		return
+	}
+	h.disconnect(func(client *Client) bool { return client.SessionID == sessionID }, "session revoked")
+}
+
+// DisconnectUser closes every connection of a user, such as one deleting their account
+func (h *Hub) DisconnectUser(username string) {
+	h.disconnect(func(client *Client) bool { return client.Username == username }, "account deleted")
+}
+
+func (h *Hub) disconnect(match func(client *Client) bool, reason string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, room := range h.rooms {
+		room.mu.RLock()
+		for client := range room.Clients {
+			if match(client) {
+				// readPump fails once the connection is closed and unregisters the client
+				closeMsg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason)
+				client.Conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(writeWait))
+				client.Conn.Close()
+				log.Printf("Disconnected %s from room %s: %s", client.Username, room.Name, reason)
+			}
+		}
+		room.mu.RUnlock()
+	}
+}
+
+// MessagesBy returns the chat messages a user sent that are still in room history
+func (h *Hub) MessagesBy(username string) []Message {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	messages := []Message{}
+	for _, room := range h.rooms {
+		room.mu.RLock()
+		for _, msg := range room.History {
+			if msg.Type == "chat" && msg.Username == username {
+				messages = append(messages, msg)
+			}
+		}
+		room.mu.RUnlock()
+	}
+	return messages
+}
+
+// ForgetUser removes a user's messages from room history
+func (h *Hub) ForgetUser(username string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, room := range h.rooms {
+		room.mu.Lock()
+		kept := room.History[:0]
+		for _, msg := range room.History {
+			if msg.Username != username {
+				kept = append(kept, msg)
+			}
+		}
+		room.History = kept
+		room.mu.Unlock()
+	}
+}
+
 // GetStats returns hub statistics
 func (h *Hub) GetStats() map[string]interface{} {
 	h.mu.RLock()
@@ -274,12 +364,19 @@ func (c *Client) readPump() {
 			break
 		}
 
-		// Parse incoming JSON message
-		var msg Message
-		if err := json.Unmarshal(data, &msg); err != nil {
+		// Parse incoming message; only its text is used
+		var chat protocol.ChatMessage
+		if err := protocol.Decode(data, c.encoding, &chat); err != nil {
 			log.Printf("Failed to unmarshal message: %v", err)
 			continue
 		}
+		msg := Message{Text: chat.Text}
+
+		// Messages over the limit are dropped and only the sender is told
+		if ok, wait := c.hub.messageLimit.Allow(c.Username); !ok {
+			c.sendSystem(fmt.Sprintf("You're sending messages too fast, wait %d seconds", ratelimit.RetryAfterSeconds(wait)))
+			continue
+		}
 
 		// Set message metadata (server-side, not trusted from client)
 		msg.Username = c.Username
@@ -292,6 +389,26 @@ func (c *Client) readPump() {
 	}
 }
 
+// sendSystem sends a system message to this client only
+func (c *Client) sendSystem(text string) {
+	wire := Message{
+		Type:     "system",
+		Room:     c.Room,
+		Username: c.Username,
+		Text:     text,
+		Time:     time.Now().Format("15:04:05"),
+	}.wire()
+	data, err := protocol.Marshal(&wire, c.encoding)
+	if err != nil {
+		return
+	}
+
+	select {
+	case c.Send <- data:
+	default:
+	}
+}
+
 // writePump pumps messages from the hub to the websocket connection
 func (c *Client) writePump() {
 	ticker := time.NewTicker(pingPeriod)
@@ -309,7 +426,11 @@ func (c *Client) writePump() {
 				return
 			}
 
-			if err := c.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			messageType := websocket.TextMessage
+			if c.encoding == protocol.Protobuf {
+				messageType = websocket.BinaryMessage
+			}
+			if err := c.Conn.WriteMessage(messageType, message); err != nil {
 				return
 			}
 
@@ -322,15 +443,23 @@ func (c *Client) writePump() {
 	}
 }
 
-// ServeWs handles websocket requests from clients
-func ServeWs(hub *Hub, conn *websocket.Conn, username, room string) {
+// ServeWs handles websocket requests from clients. The subprotocol the
+// connection was upgraded with picks the encoding; without one it is JSON.
+func ServeWs(hub *Hub, conn *websocket.Conn, username, room, sessionID string) {
+	_, encoding, err := protocol.ParseSubprotocol(conn.Subprotocol())
+	if err != nil {
+		encoding = protocol.JSON
+	}
+
 	client := &Client{
-		ID:       fmt.Sprintf("%s-%d", username, time.Now().Unix()),
-		Username: username,
-		Room:     room,
-		Conn:     conn,
-		Send:     make(chan []byte, 256),
-		hub:      hub,
+		ID:        fmt.Sprintf("%s-%d", username, time.Now().Unix()),
+		Username:  username,
+		SessionID: sessionID,
+		Room:      room,
+		Conn:      conn,
+		Send:      make(chan []byte, 256),
+		hub:       hub,
+		encoding:  encoding,
 	}
 
 	client.hub.register <- client
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -27,6 +28,11 @@ func InitDB(dbPath string) (*sql.DB, error) {
 		return nil, fmt.Errorf("failed to create tables: %w", err)
 	}
 
+	// Bring databases created by older versions up to date
+	if err := migrateTables(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate tables: %w", err)
+	}
+
 	log.Println("Database initialized successfully")
 	return db, nil
 }
@@ -38,6 +44,16 @@ func createTables(db *sql.DB) error {
 		username TEXT UNIQUE NOT NULL,
 		email TEXT UNIQUE NOT NULL,
 		password_hash TEXT NOT NULL,
+		timezone TEXT NOT NULL DEFAULT 'UTC',
+		email_verified_at TIMESTAMP,
+		is_admin INTEGER NOT NULL DEFAULT 0,
+		deletion_scheduled_at TIMESTAMP,
+		display_name TEXT NOT NULL DEFAULT '',
+		bio TEXT NOT NULL DEFAULT '',
+		avatar_url TEXT NOT NULL DEFAULT '',
+		title_language TEXT NOT NULL DEFAULT 'romaji',
+		privacy TEXT NOT NULL DEFAULT 'public',
+		notification_prefs TEXT NOT NULL DEFAULT '{}',
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
 
@@ -58,19 +74,203 @@ func createTables(db *sql.DB) error {
 		user_id TEXT NOT NULL,
 		manga_id TEXT NOT NULL,
 		current_chapter INTEGER DEFAULT 0,
+		read_chapters TEXT NOT NULL DEFAULT '',
 		status TEXT NOT NULL,
 		rating INTEGER DEFAULT 0,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		completed_at TIMESTAMP,
+		PRIMARY KEY (user_id, manga_id),
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+		FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS reading_positions (
+		user_id TEXT NOT NULL,
+		manga_id TEXT NOT NULL,
+		chapter INTEGER NOT NULL,
+		page INTEGER DEFAULT 0,
+		scroll_percent REAL DEFAULT 0,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		PRIMARY KEY (user_id, manga_id),
 		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
 		FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE
 	);
 
+	CREATE TABLE IF NOT EXISTS reading_sessions (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		manga_id TEXT NOT NULL DEFAULT '',
+		source TEXT NOT NULL,
+		started_at TIMESTAMP NOT NULL,
+		last_activity_at TIMESTAMP NOT NULL,
+		ended_at TIMESTAMP,
+		duration_seconds INTEGER DEFAULT 0,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS session_chapters (
+		session_id TEXT NOT NULL,
+		manga_id TEXT NOT NULL,
+		chapter INTEGER NOT NULL,
+		read_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (session_id, manga_id, chapter),
+		FOREIGN KEY (session_id) REFERENCES reading_sessions(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS reading_goals (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		target INTEGER NOT NULL,
+		period TEXT NOT NULL,
+		notified_period TEXT NOT NULL DEFAULT '',
+		notified_percent INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS user_achievements (
+		user_id TEXT NOT NULL,
+		badge TEXT NOT NULL,
+		unlocked_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (user_id, badge),
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS refresh_tokens (
+		token_hash TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		username TEXT NOT NULL,
+		family_id TEXT NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		used_at TIMESTAMP,
+		revoked_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS login_sessions (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		device_name TEXT NOT NULL DEFAULT '',
+		user_agent TEXT NOT NULL DEFAULT '',
+		ip TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		last_seen_at TIMESTAMP NOT NULL,
+		revoked_at TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS password_reset_tokens (
+		token_hash TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		used_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS email_verifications (
+		token_hash TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		used_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS user_totp (
+		user_id TEXT PRIMARY KEY,
+		secret TEXT NOT NULL,
+		enabled_at TIMESTAMP,
+		last_step INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS recovery_codes (
+		code_hash TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		used_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS login_challenges (
+		token_hash TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		used_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS signing_keys (
+		kid TEXT PRIMARY KEY,
+		algorithm TEXT NOT NULL,
+		private_key TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		retired_at TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS revoked_tokens (
+		jti TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		expires_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS personal_access_tokens (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		token_hash TEXT UNIQUE NOT NULL,
+		scopes TEXT NOT NULL,
+		expires_at TIMESTAMP,
+		last_used_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS user_identities (
+		issuer TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		email TEXT NOT NULL DEFAULT '',
+		last_login_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (issuer, subject),
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS sync_sequences (
+		user_id TEXT PRIMARY KEY,
+		last_seq INTEGER NOT NULL DEFAULT 0,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS sync_events (
+		user_id TEXT NOT NULL,
+		seq INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, seq),
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
 	CREATE INDEX IF NOT EXISTS idx_manga_title ON manga(title);
 	CREATE INDEX IF NOT EXISTS idx_manga_author ON manga(author);
 	CREATE INDEX IF NOT EXISTS idx_user_progress_user ON user_progress(user_id);
 	CREATE INDEX IF NOT EXISTS idx_user_progress_manga ON user_progress(manga_id);
+	CREATE INDEX IF NOT EXISTS idx_reading_sessions_user ON reading_sessions(user_id, started_at);
+	CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens(family_id);
+	CREATE INDEX IF NOT EXISTS idx_login_sessions_user ON login_sessions(user_id, last_seen_at);
+	CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user ON personal_access_tokens(user_id);
+	CREATE INDEX IF NOT EXISTS idx_email_verifications_user ON email_verifications(user_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_recovery_codes_user ON recovery_codes(user_id);
+	CREATE INDEX IF NOT EXISTS idx_user_identities_user ON user_identities(user_id);
 	`
 
 	_, err := db.Exec(schema)
@@ -81,6 +281,74 @@ func createTables(db *sql.DB) error {
 	return nil
 }
 
+// migration adds a column to databases created before it existed. The
+// backfill statements fill it in for existing rows and only run in the same
+// transaction as a successful ALTER, never on later startups.
+type migration struct {
+	alter    string
+	backfill []string
+}
+
+// migrations holds schema changes for databases created before a column existed
+var migrations = []migration{
+	{`ALTER TABLE user_progress ADD COLUMN read_chapters TEXT NOT NULL DEFAULT ''`, []string{
+		`UPDATE user_progress SET read_chapters = '1-' || current_chapter
+			WHERE read_chapters = '' AND current_chapter > 1`,
+		`UPDATE user_progress SET read_chapters = '1'
+			WHERE read_chapters = '' AND current_chapter = 1`,
+	}},
+	{`ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC'`, nil},
+	{`ALTER TABLE user_progress ADD COLUMN completed_at TIMESTAMP`, []string{
+		`UPDATE user_progress SET completed_at = updated_at
+			WHERE status = 'completed' AND completed_at IS NULL`,
+	}},
+	{`ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP`, []string{
+		// Accounts from before verification existed were never sent a token; treat them as verified
+		`UPDATE users SET email_verified_at = created_at
+			WHERE email_verified_at IS NULL
+			AND id NOT IN (SELECT user_id FROM email_verifications)`,
+	}},
+	{`ALTER TABLE users ADD COLUMN is_admin INTEGER NOT NULL DEFAULT 0`, nil},
+	{`ALTER TABLE users ADD COLUMN deletion_scheduled_at TIMESTAMP`, nil},
+	{`ALTER TABLE users ADD COLUMN display_name TEXT NOT NULL DEFAULT ''`, nil},
+	{`ALTER TABLE users ADD COLUMN bio TEXT NOT NULL DEFAULT ''`, nil},
+	{`ALTER TABLE users ADD COLUMN avatar_url TEXT NOT NULL DEFAULT ''`, nil},
+	{`ALTER TABLE users ADD COLUMN title_language TEXT NOT NULL DEFAULT 'romaji'`, nil},
+	{`ALTER TABLE users ADD COLUMN privacy TEXT NOT NULL DEFAULT 'public'`, nil},
+	{`ALTER TABLE users ADD COLUMN notification_prefs TEXT NOT NULL DEFAULT '{}'`, nil},
+}
+
+func migrateTables(db *sql.DB) error {
+	for _, m := range migrations {
+		if err := m.apply(db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply adds the column and backfills it, or does nothing if it already exists
+func (m migration) apply(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start migration: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.alter); err != nil {
+		if strings.Contains(err.Error(), "duplicate column name") {
+			return nil
+		}
+		return fmt.Errorf("failed to execute migration: %w", err)
+	}
+	for _, stmt := range m.backfill {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to backfill migration: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
 // SeedData seeds initial manga data
 func SeedData(db *sql.DB) error {
 	// Check if data already exists
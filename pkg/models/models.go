@@ -4,11 +4,61 @@ import "time"
 
 // User represents a registered user
 type User struct {
-	ID           string    `json:"id" db:"id"`
-	Username     string    `json:"username" db:"username"`
-	Email        string    `json:"email" db:"email"`
-	PasswordHash string    `json:"-" db:"password_hash"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	ID               string     `json:"id" db:"id"`
+	Username         string     `json:"username" db:"username"`
+	Email            string     `json:"email" db:"email"`
+	PasswordHash     string     `json:"-" db:"password_hash"`
+	Timezone         string     `json:"timezone" db:"timezone"` // IANA name used for streaks and goal periods
+	EmailVerifiedAt  *time.Time `json:"email_verified_at,omitempty" db:"email_verified_at"`
+	IsAdmin          bool       `json:"is_admin" db:"is_admin"` // can broadcast notifications; must use two-factor authentication
+	TwoFactorEnabled bool       `json:"two_factor_enabled" db:"-"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+}
+
+// UserProfile is a user's account details with the preferences they can edit
+type UserProfile struct {
+	UserID                  string          `json:"user_id"`
+	Username                string          `json:"username"`
+	Email                   string          `json:"email"`
+	EmailVerified           bool            `json:"email_verified"`
+	EmailVerifiedAt         *time.Time      `json:"email_verified_at,omitempty"`
+	IsAdmin                 bool            `json:"is_admin"`
+	TwoFactorEnabled        bool            `json:"two_factor_enabled"`
+	DisplayName             string          `json:"display_name"`
+	Bio                     string          `json:"bio"`
+	AvatarURL               string          `json:"avatar_url"`
+	Timezone                string          `json:"timezone"`
+	TitleLanguage           string          `json:"title_language"` // english, romaji or native
+	Privacy                 string          `json:"privacy"`        // public, members or private
+	NotificationPreferences map[string]bool `json:"notification_preferences"`
+	CreatedAt               time.Time       `json:"created_at"`
+}
+
+// NotificationTypes lists the notifications a user can turn on or off
+var NotificationTypes = []string{"chapter_releases", "system_updates"}
+
+// PersonalAccessToken represents a named, scoped token for scripts and bots.
+// The token itself is only shown once, when it is created.
+type PersonalAccessToken struct {
+	ID         string     `json:"id" db:"id"`
+	UserID     string     `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// LoginSession represents a device the user is logged in on
+type LoginSession struct {
+	ID         string    `json:"id" db:"id"`
+	UserID     string    `json:"user_id" db:"user_id"`
+	DeviceName string    `json:"device_name" db:"device_name"`
+	UserAgent  string    `json:"user_agent" db:"user_agent"` // browser user agent or CLI version
+	IP         string    `json:"ip" db:"ip"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at" db:"last_seen_at"`
+	Current    bool      `json:"current"` // the session making the request
 }
 
 // Manga represents a manga series
@@ -27,21 +77,130 @@ type Manga struct {
 
 // UserProgress represents user's reading progress
 type UserProgress struct {
-	UserID         string    `json:"user_id" db:"user_id"`
-	MangaID        string    `json:"manga_id" db:"manga_id"`
-	CurrentChapter int       `json:"current_chapter" db:"current_chapter"`
-	Status         string    `json:"status" db:"status"` // reading, completed, plan-to-read, on-hold, dropped
-	Rating         int       `json:"rating" db:"rating"` // 1-10, 0 means unrated
-	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
-	StartedAt      time.Time `json:"started_at" db:"started_at"`
+	UserID         string     `json:"user_id" db:"user_id"`
+	MangaID        string     `json:"manga_id" db:"manga_id"`
+	CurrentChapter int        `json:"current_chapter" db:"current_chapter"` // highest continuous chapter read
+	ReadChapters   string     `json:"read_chapters" db:"read_chapters"`     // compact ranges, e.g. "1-20,25"
+	Status         string     `json:"status" db:"status"`                   // reading, completed, plan-to-read, on-hold, dropped
+	Rating         int        `json:"rating" db:"rating"`                   // 1-10, 0 means unrated
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+	StartedAt      time.Time  `json:"started_at" db:"started_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// ReadingPosition represents where a user stopped inside a chapter
+type ReadingPosition struct {
+	UserID        string    `json:"user_id" db:"user_id"`
+	MangaID       string    `json:"manga_id" db:"manga_id"`
+	Chapter       int       `json:"chapter" db:"chapter"`
+	Page          int       `json:"page" db:"page"`                     // 0 means unknown
+	ScrollPercent float64   `json:"scroll_percent" db:"scroll_percent"` // 0-100 within the page or chapter
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ReadingSession represents a period of reading, started explicitly or inferred from progress events
+type ReadingSession struct {
+	ID              string           `json:"id" db:"id"`
+	UserID          string           `json:"user_id" db:"user_id"`
+	MangaID         string           `json:"manga_id,omitempty" db:"manga_id"`
+	Source          string           `json:"source" db:"source"` // manual, inferred
+	StartedAt       time.Time        `json:"started_at" db:"started_at"`
+	LastActivityAt  time.Time        `json:"last_activity_at" db:"last_activity_at"`
+	EndedAt         *time.Time       `json:"ended_at,omitempty" db:"ended_at"`
+	DurationSeconds int64            `json:"duration_seconds" db:"duration_seconds"`
+	Chapters        []SessionChapter `json:"chapters,omitempty"`
+}
+
+// SessionChapter represents a chapter covered during a reading session
+type SessionChapter struct {
+	MangaID string    `json:"manga_id" db:"manga_id"`
+	Chapter int       `json:"chapter" db:"chapter"`
+	ReadAt  time.Time `json:"read_at" db:"read_at"`
+}
+
+// ReadingSummary represents time spent reading, aggregated per day, manga and genre
+type ReadingSummary struct {
+	From         time.Time      `json:"from"`
+	To           time.Time      `json:"to"`
+	TotalSeconds int64          `json:"total_seconds"`
+	Sessions     int            `json:"sessions"`
+	PerDay       []ReadingTotal `json:"per_day"`
+	PerManga     []ReadingTotal `json:"per_manga"`
+	PerGenre     []ReadingTotal `json:"per_genre"`
+}
+
+// ReadingTotal represents the time spent on one day, manga or genre
+type ReadingTotal struct {
+	Key      string `json:"key"` // date (YYYY-MM-DD), manga ID or genre
+	Label    string `json:"label,omitempty"`
+	Seconds  int64  `json:"seconds"`
+	Chapters int    `json:"chapters"`
+}
+
+// UserStats represents a user's reading statistics for a period
+type UserStats struct {
+	Period            string          `json:"period"` // week, month, year, all
+	From              *time.Time      `json:"from,omitempty"`
+	To                time.Time       `json:"to"`
+	ChaptersRead      int             `json:"chapters_read"`
+	ChaptersPerBucket []StatCount     `json:"chapters_per_bucket"` // per day, or per month/year for longer periods
+	LibraryTotal      int             `json:"library_total"`
+	StatusCounts      map[string]int  `json:"status_counts"`
+	CompletionRate    float64         `json:"completion_rate"` // completed / started, 0-1
+	AverageRating     float64         `json:"average_rating"`
+	RatedCount        int             `json:"rated_count"`
+	Genres            []StatCount     `json:"genres"`
+	LongestFinished   *FinishedSeries `json:"longest_finished,omitempty"`
+	CurrentStreak     int             `json:"current_streak"` // consecutive days with reading, ending today or yesterday
+	LongestStreak     int             `json:"longest_streak"`
+	ActiveHours       [24]int         `json:"active_hours"`     // chapters read per hour of day
+	MostActiveHour    int             `json:"most_active_hour"` // -1 when there's no activity
+}
+
+// StatCount represents a labelled count in a statistics breakdown
+type StatCount struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// FinishedSeries represents a completed manga in a user's statistics
+type FinishedSeries struct {
+	MangaID       string `json:"manga_id"`
+	Title         string `json:"title"`
+	TotalChapters int    `json:"total_chapters"`
+}
+
+// Goal represents a reading goal, e.g. 50 chapters per month
+type Goal struct {
+	ID          string    `json:"id" db:"id"`
+	UserID      string    `json:"user_id" db:"user_id"`
+	Kind        string    `json:"kind" db:"kind"`     // chapters, series
+	Target      int       `json:"target" db:"target"` // chapters read or series finished per period
+	Period      string    `json:"period" db:"period"` // day, week, month, year
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	Progress    int       `json:"progress"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Completed   bool      `json:"completed"`
+}
+
+// Achievement represents a badge and whether the user has unlocked it
+type Achievement struct {
+	Badge       string     `json:"badge"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	UnlockedAt  *time.Time `json:"unlocked_at,omitempty"`
 }
 
 // ProgressUpdate represents a progress update event
 type ProgressUpdate struct {
-	UserID    string `json:"user_id"`
-	MangaID   string `json:"manga_id"`
-	Chapter   int    `json:"chapter"`
-	Timestamp int64  `json:"timestamp"`
+	UserID        string  `json:"user_id"`
+	MangaID       string  `json:"manga_id"`
+	Chapter       int     `json:"chapter"`
+	Page          int     `json:"page,omitempty"`
+	ScrollPercent float64 `json:"scroll_percent,omitempty"`
+	SessionID     string  `json:"session_id,omitempty"`
+	Timestamp     int64   `json:"timestamp"`
 }
 
 // ChatMessage represents a chat message
@@ -62,8 +221,21 @@ type Notification struct {
 
 // LoginRequest represents login credentials
 type LoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username   string `json:"username" binding:"required"`
+	Password   string `json:"password" binding:"required"`
+	DeviceName string `json:"device_name"` // shown in the session list, e.g. the host name
+}
+
+// RefreshRequest represents a request to rotate a refresh token
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// CreateTokenRequest represents a request to create a personal access token
+type CreateTokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=64"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"min=0"` // 0 = never expires
 }
 
 // RegisterRequest represents registration data
@@ -92,8 +264,98 @@ type AddToLibraryRequest struct {
 
 // UpdateProgressRequest represents progress update request
 type UpdateProgressRequest struct {
-	MangaID string `json:"manga_id" binding:"required"`
-	Chapter int    `json:"chapter" binding:"required,min=1"`
+	MangaID       string   `json:"manga_id" binding:"required"`
+	Chapter       int      `json:"chapter" binding:"required,min=1"`
+	Page          *int     `json:"page,omitempty" binding:"omitempty,min=1"`
+	ScrollPercent *float64 `json:"scroll_percent,omitempty" binding:"omitempty,min=0,max=100"`
+	SessionID     string   `json:"session_id,omitempty"` // reading session to attribute the chapter to
+}
+
+// StartSessionRequest represents a request to start a reading session
+type StartSessionRequest struct {
+	MangaID string `json:"manga_id"`
+}
+
+// UpdateSessionRequest represents a request to update (stop) a reading session
+type UpdateSessionRequest struct {
+	Ended bool `json:"ended"`
+}
+
+// CreateGoalRequest represents a request to create a reading goal
+type CreateGoalRequest struct {
+	Kind   string `json:"kind" binding:"required,oneof=chapters series"`
+	Target int    `json:"target" binding:"required,min=1"`
+	Period string `json:"period" binding:"required,oneof=day week month year"`
+}
+
+// ChangePasswordRequest represents a request to change the logged-in user's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8"`
+}
+
+// ForgotPasswordRequest represents a request to email a password reset token
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest represents a request to set a new password with a reset token
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8"`
+}
+
+// TwoFactorLoginRequest represents the second step of a login with two-factor authentication
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"` // authenticator code or recovery code
+	DeviceName     string `json:"device_name"`
+}
+
+// SSOStartRequest represents the start of a single sign-on login
+type SSOStartRequest struct {
+	RedirectURI string `json:"redirect_uri" binding:"required"` // loopback URL the provider sends the browser back to
+}
+
+// SSOCallbackRequest represents the code the identity provider redirected back with
+type SSOCallbackRequest struct {
+	State      string `json:"state" binding:"required"`
+	Code       string `json:"code" binding:"required"`
+	DeviceName string `json:"device_name"`
+}
+
+// TwoFactorCodeRequest represents a request confirmed with an authenticator or recovery code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DeleteAccountRequest represents a request to delete the logged-in user's account
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code"` // required when two-factor authentication is enabled
+}
+
+// UpdateProfileRequest represents a partial profile update; omitted fields are left unchanged
+type UpdateProfileRequest struct {
+	DisplayName             *string         `json:"display_name"`
+	Bio                     *string         `json:"bio"`
+	AvatarURL               *string         `json:"avatar_url"`
+	Timezone                *string         `json:"timezone"`
+	TitleLanguage           *string         `json:"title_language"`
+	Privacy                 *string         `json:"privacy"`
+	NotificationPreferences map[string]bool `json:"notification_preferences"` // merged into the current ones
+	Email                   *string         `json:"email"`
+	CurrentPassword         string          `json:"current_password"` // required to change the email
+}
+
+// UpdateTimezoneRequest represents a request to change the user's time zone
+type UpdateTimezoneRequest struct {
+	Timezone string `json:"timezone" binding:"required"` // IANA name, e.g. Europe/Berlin
+}
+
+// MarkChaptersRequest represents a request to mark or unmark chapters as read
+type MarkChaptersRequest struct {
+	Chapters string `json:"chapters" binding:"required"` // e.g. "1-20,25"
 }
 
 // Response represents standard API response
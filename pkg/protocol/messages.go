@@ -0,0 +1,320 @@
+package protocol
+
+import (
+	"time"
+
+	"mangahub/pkg/models"
+)
+
+// ----- TCP sync -----
+
+// Hello is the first frame a client sends on a sync connection. It is always
+// a JSON line, whatever encoding the client asks for.
+type Hello struct {
+	Token     string   `json:"token"`
+	LastSeq   *int64   `json:"last_seq,omitempty"`  // resume after this event
+	Versions  []int    `json:"versions,omitempty"`  // protocol versions the client speaks; none means 1
+	Encodings []string `json:"encodings,omitempty"` // encodings the client accepts, preferred first
+	Snapshot  bool     `json:"snapshot,omitempty"`  // start with the whole library instead of a replay
+
+	DeviceName string `json:"device_name,omitempty"` // shown to the user's other devices, e.g. "Kindle"
+	DeviceType string `json:"device_type,omitempty"` // such as phone, tablet, desktop or cli
+}
+
+// Welcome accepts a sync connection and settles the version and encoding
+// used from the next frame on
+type Welcome struct {
+	Status    string     `json:"status"`
+	Message   string     `json:"message"`
+	ClientID  string     `json:"client_id"`
+	UserID    string     `json:"user_id"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // when the token expires
+	Seq       int64      `json:"seq,omitempty"`        // latest event of the user
+	Version   int        `json:"version"`
+	Encoding  Encoding   `json:"encoding"`
+}
+
+// AuthError tells a client why it couldn't authenticate
+type AuthError struct {
+	Status  string `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewAuthError creates an auth_error frame
+func NewAuthError(code, message string) *AuthError {
+	return &AuthError{Status: "error", Code: code, Message: message}
+}
+
+// Auth replaces a connection's token before it expires
+type Auth struct {
+	Token string `json:"token"`
+}
+
+// AuthOK accepts a replacement token
+type AuthOK struct {
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ReauthRequired asks a client for a new token before its current one expires
+type ReauthRequired struct {
+	Message   string    `json:"message"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Heartbeat keeps a sync connection alive
+type Heartbeat struct{}
+
+// HeartbeatAck answers a heartbeat
+type HeartbeatAck struct {
+	Timestamp int64 `json:"timestamp"`
+}
+
+// RequestSnapshot asks for the user's whole library
+type RequestSnapshot struct {
+	ID string `json:"id,omitempty"`
+}
+
+// Snapshot is the user's whole library as of event Seq
+type Snapshot struct {
+	ID        string                 `json:"id,omitempty"`
+	Seq       int64                  `json:"seq"`
+	Library   []*models.UserProgress `json:"library"`
+	Timestamp int64                  `json:"timestamp"`
+}
+
+// ProgressUpdate is a chapter read on one of the user's devices. Clients push
+// it with an ID to be acknowledged; the server passes it on with a Seq.
+type ProgressUpdate struct {
+	ID            string   `json:"id,omitempty"`
+	UserID        string   `json:"user_id,omitempty"`
+	MangaID       string   `json:"manga_id"`
+	Chapter       int      `json:"chapter"`
+	Page          *int     `json:"page,omitempty"`
+	ScrollPercent *float64 `json:"scroll_percent,omitempty"`
+	SessionID     string   `json:"session_id,omitempty"`
+	Timestamp     int64    `json:"timestamp,omitempty"`
+	Seq           int64    `json:"seq,omitempty"`
+}
+
+// LibraryUpdate adds, changes or removes a library entry. Fields left out
+// keep their value.
+type LibraryUpdate struct {
+	ID             string `json:"id,omitempty"`
+	MangaID        string `json:"manga_id"`
+	Status         string `json:"status,omitempty"`
+	Rating         *int   `json:"rating,omitempty"`
+	CurrentChapter *int   `json:"current_chapter,omitempty"`
+	ReadChapters   string `json:"read_chapters,omitempty"`
+	Removed        bool   `json:"removed,omitempty"`
+	Timestamp      int64  `json:"timestamp,omitempty"`
+	Seq            int64  `json:"seq,omitempty"`
+}
+
+// Ack answers a frame sent with an ID. Status is "ok", or "error" with a
+// Code and Message.
+type Ack struct {
+	ID      string `json:"id"`
+	Status  string `json:"status"`
+	Seq     int64  `json:"seq,omitempty"` // event the change was published as
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// ResyncRequired means the events a client missed are no longer kept
+type ResyncRequired struct {
+	Seq     int64  `json:"seq"`
+	Message string `json:"message"`
+}
+
+// ReplayComplete follows the events replayed to a resuming client
+type ReplayComplete struct {
+	Seq   int64 `json:"seq"`
+	Count int   `json:"count"`
+}
+
+// SessionRevoked is sent before closing connections of a logged out session
+type SessionRevoked struct {
+	Message   string `json:"message"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+}
+
+// AccountDeleted is sent before closing connections of a deleted account
+type AccountDeleted struct {
+	Message   string `json:"message"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+}
+
+// NowReading tells the user's other devices what this one is reading. An
+// empty MangaID means nothing.
+type NowReading struct {
+	ID      string `json:"id,omitempty"`
+	MangaID string `json:"manga_id,omitempty"`
+	Chapter int    `json:"chapter,omitempty"`
+	Page    int    `json:"page,omitempty"`
+}
+
+// Presence is one of the user's connected devices and what it is reading.
+// Status is "online", or "offline" once it disconnects or stops sending
+// heartbeats.
+type Presence struct {
+	ClientID    string `json:"client_id"`
+	SessionID   string `json:"session_id,omitempty"`
+	DeviceName  string `json:"device_name,omitempty"`
+	DeviceType  string `json:"device_type,omitempty"`
+	Status      string `json:"status"`
+	MangaID     string `json:"manga_id,omitempty"`
+	MangaTitle  string `json:"manga_title,omitempty"`
+	Chapter     int    `json:"chapter,omitempty"`
+	Page        int    `json:"page,omitempty"`
+	ConnectedAt int64  `json:"connected_at"`
+	LastSeen    int64  `json:"last_seen"`
+}
+
+// ----- UDP notifications -----
+
+// Register subscribes the sending address to a user's notifications.
+// Without preferences the user's saved ones apply.
+type Register struct {
+	UserID      string          `json:"user_id,omitempty"`
+	Token       string          `json:"token,omitempty"`
+	Preferences map[string]bool `json:"preferences,omitempty"`
+	Versions    []int           `json:"versions,omitempty"`
+}
+
+// Registered confirms a subscription
+type Registered struct {
+	Status      string          `json:"status"`
+	Message     string          `json:"message"`
+	Preferences map[string]bool `json:"preferences"`
+	Version     int             `json:"version"`
+	Timestamp   int64           `json:"timestamp"`
+}
+
+// Unregister ends the sending address's subscription
+type Unregister struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+// Unregistered confirms a subscription ended
+type Unregistered struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// Ping keeps a subscription alive
+type Ping struct{}
+
+// Pong answers a ping
+type Pong struct {
+	Timestamp int64 `json:"timestamp"`
+}
+
+// Error rejects a request
+type Error struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// NewError creates an error message
+func NewError(message string) *Error {
+	return &Error{Status: "error", Message: message}
+}
+
+// Notification is pushed to subscribers. Kind is what it is about, such as
+// chapter_release or goal_completed.
+type Notification struct {
+	Kind       string `json:"kind"`
+	Title      string `json:"title,omitempty"`
+	Message    string `json:"message"`
+	MangaID    string `json:"manga_id,omitempty"`
+	MangaTitle string `json:"manga_title,omitempty"`
+	Chapter    int    `json:"chapter,omitempty"`
+	Timestamp  int64  `json:"timestamp"`
+}
+
+// ----- WebSocket chat -----
+
+// ChatMessage is a line in a chat room. Kind is "chat" for what users say
+// and "system" for the server's announcements.
+type ChatMessage struct {
+	Kind     string `json:"kind,omitempty"`
+	Room     string `json:"room,omitempty"`
+	Username string `json:"username,omitempty"`
+	Text     string `json:"text"`
+	Time     string `json:"time,omitempty"` // HH:MM:SS
+}
+
+// History is a room's recent messages, sent on joining it
+type History struct {
+	Messages []ChatMessage `json:"messages"`
+}
+
+func (*Hello) MessageType() string           { return "hello" }
+func (*Welcome) MessageType() string         { return "welcome" }
+func (*AuthError) MessageType() string       { return "auth_error" }
+func (*Auth) MessageType() string            { return "auth" }
+func (*AuthOK) MessageType() string          { return "auth_ok" }
+func (*ReauthRequired) MessageType() string  { return "reauth_required" }
+func (*Heartbeat) MessageType() string       { return "heartbeat" }
+func (*HeartbeatAck) MessageType() string    { return "heartbeat_ack" }
+func (*RequestSnapshot) MessageType() string { return "request_snapshot" }
+func (*Snapshot) MessageType() string        { return "snapshot" }
+func (*ProgressUpdate) MessageType() string  { return "progress_update" }
+func (*LibraryUpdate) MessageType() string   { return "library_update" }
+func (*Ack) MessageType() string             { return "ack" }
+func (*ResyncRequired) MessageType() string  { return "resync_required" }
+func (*ReplayComplete) MessageType() string  { return "replay_complete" }
+func (*SessionRevoked) MessageType() string  { return "session_revoked" }
+func (*AccountDeleted) MessageType() string  { return "account_deleted" }
+func (*NowReading) MessageType() string      { return "now_reading" }
+func (*Presence) MessageType() string        { return "presence" }
+func (*Register) MessageType() string        { return "register" }
+func (*Registered) MessageType() string      { return "registered" }
+func (*Unregister) MessageType() string      { return "unregister" }
+func (*Unregistered) MessageType() string    { return "unregistered" }
+func (*Ping) MessageType() string            { return "ping" }
+func (*Pong) MessageType() string            { return "pong" }
+func (*Error) MessageType() string           { return "error" }
+func (*Notification) MessageType() string    { return "notification" }
+func (*ChatMessage) MessageType() string     { return "chat" }
+func (*History) MessageType() string         { return "history" }
+
+// SetSeq numbers an update in its user's event sequence
+func (m *ProgressUpdate) SetSeq(seq int64) { m.Seq = seq }
+
+// SetSeq numbers an update in its user's event sequence
+func (m *LibraryUpdate) SetSeq(seq int64) { m.Seq = seq }
+
+// registry creates an empty message of each type
+var registry = map[string]func() Message{
+	"hello":            func() Message { return &Hello{} },
+	"welcome":          func() Message { return &Welcome{} },
+	"auth_error":       func() Message { return &AuthError{} },
+	"auth":             func() Message { return &Auth{} },
+	"auth_ok":          func() Message { return &AuthOK{} },
+	"reauth_required":  func() Message { return &ReauthRequired{} },
+	"heartbeat":        func() Message { return &Heartbeat{} },
+	"heartbeat_ack":    func() Message { return &HeartbeatAck{} },
+	"request_snapshot": func() Message { return &RequestSnapshot{} },
+	"snapshot":         func() Message { return &Snapshot{} },
+	"progress_update":  func() Message { return &ProgressUpdate{} },
+	"library_update":   func() Message { return &LibraryUpdate{} },
+	"ack":              func() Message { return &Ack{} },
+	"resync_required":  func() Message { return &ResyncRequired{} },
+	"replay_complete":  func() Message { return &ReplayComplete{} },
+	"session_revoked":  func() Message { return &SessionRevoked{} },
+	"account_deleted":  func() Message { return &AccountDeleted{} },
+	"now_reading":      func() Message { return &NowReading{} },
+	"presence":         func() Message { return &Presence{} },
+	"register":         func() Message { return &Register{} },
+	"registered":       func() Message { return &Registered{} },
+	"unregister":       func() Message { return &Unregister{} },
+	"unregistered":     func() Message { return &Unregistered{} },
+	"ping":             func() Message { return &Ping{} },
+	"pong":             func() Message { return &Pong{} },
+	"error":            func() Message { return &Error{} },
+	"notification":     func() Message { return &Notification{} },
+	"chat":             func() Message { return &ChatMessage{} },
+	"history":          func() Message { return &History{} },
+}
@@ -0,0 +1,462 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"mangahub/pkg/models"
+	pb "mangahub/proto/proto"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var errEmptyFrame = errors.New("frame has no message")
+
+func marshalProto(msg Message) ([]byte, error) {
+	frame, err := toFrame(msg)
+	if err != nil {
+		return nil, err
+	}
+	// Deterministic so equal messages encode to equal bytes
+	return proto.MarshalOptions{Deterministic: true}.Marshal(frame)
+}
+
+func unmarshalProto(data []byte) (Message, error) {
+	var frame pb.Frame
+	if err := proto.Unmarshal(data, &frame); err != nil {
+		return nil, fmt.Errorf("invalid protobuf message: %w", err)
+	}
+	return fromFrame(&frame)
+}
+
+// toFrame converts a message to its protobuf Frame
+func toFrame(msg Message) (*pb.Frame, error) {
+	frame := &pb.Frame{}
+	switch m := msg.(type) {
+	case *Hello:
+		frame.Body = &pb.Frame_Hello{Hello: &pb.Hello{
+			Token:     m.Token,
+			LastSeq:   m.LastSeq,
+			Versions:  toInt32s(m.Versions),
+			Encodings: m.Encodings,
+			Snapshot:  m.Snapshot,
+
+			DeviceName: m.DeviceName,
+			DeviceType: m.DeviceType,
+		}}
+	case *Welcome:
+		frame.Body = &pb.Frame_Welcome{Welcome: &pb.Welcome{
+			Status:    m.Status,
+			Message:   m.Message,
+			ClientId:  m.ClientID,
+			UserId:    m.UserID,
+			ExpiresAt: toOptionalTimestamp(m.ExpiresAt),
+			Seq:       m.Seq,
+			Version:   int32(m.Version),
+			Encoding:  string(m.Encoding),
+		}}
+	case *AuthError:
+		frame.Body = &pb.Frame_AuthError{AuthError: &pb.AuthError{
+			Status:  m.Status,
+			Code:    m.Code,
+			Message: m.Message,
+		}}
+	case *Auth:
+		frame.Body = &pb.Frame_Auth{Auth: &pb.Auth{Token: m.Token}}
+	case *AuthOK:
+		frame.Body = &pb.Frame_AuthOk{AuthOk: &pb.AuthOK{ExpiresAt: toOptionalTimestamp(m.ExpiresAt)}}
+	case *ReauthRequired:
+		frame.Body = &pb.Frame_ReauthRequired{ReauthRequired: &pb.ReauthRequired{
+			Message:   m.Message,
+			ExpiresAt: toTimestamp(m.ExpiresAt),
+		}}
+	case *Heartbeat:
+		frame.Body = &pb.Frame_Heartbeat{Heartbeat: &pb.Empty{}}
+	case *HeartbeatAck:
+		frame.Body = &pb.Frame_HeartbeatAck{HeartbeatAck: &pb.HeartbeatAck{Timestamp: m.Timestamp}}
+	case *RequestSnapshot:
+		frame.Body = &pb.Frame_RequestSnapshot{RequestSnapshot: &pb.RequestSnapshot{Id: m.ID}}
+	case *Snapshot:
+		library := make([]*pb.LibraryEntry, 0, len(m.Library))
+		for _, entry := range m.Library {
+			if entry != nil {
+				library = append(library, toLibraryEntry(entry))
+			}
+		}
+		frame.Body = &pb.Frame_Snapshot{Snapshot: &pb.Snapshot{
+			Id:        m.ID,
+			Seq:       m.Seq,
+			Library:   library,
+			Timestamp: m.Timestamp,
+		}}
+	case *ProgressUpdate:
+		frame.Body = &pb.Frame_ProgressUpdate{ProgressUpdate: &pb.ProgressUpdate{
+			Id:            m.ID,
+			UserId:        m.UserID,
+			MangaId:       m.MangaID,
+			Chapter:       int32(m.Chapter),
+			Page:          toOptionalInt32(m.Page),
+			ScrollPercent: m.ScrollPercent,
+			SessionId:     m.SessionID,
+			Timestamp:     m.Timestamp,
+			Seq:           m.Seq,
+		}}
+	case *LibraryUpdate:
+		frame.Body = &pb.Frame_LibraryUpdate{LibraryUpdate: &pb.LibraryUpdate{
+			Id:             m.ID,
+			MangaId:        m.MangaID,
+			Status:         m.Status,
+			Rating:         toOptionalInt32(m.Rating),
+			CurrentChapter: toOptionalInt32(m.CurrentChapter),
+			ReadChapters:   m.ReadChapters,
+			Removed:        m.Removed,
+			Timestamp:      m.Timestamp,
+			Seq:            m.Seq,
+		}}
+	case *Ack:
+		frame.Body = &pb.Frame_Ack{Ack: &pb.Ack{
+			Id:      m.ID,
+			Status:  m.Status,
+			Seq:     m.Seq,
+			Code:    m.Code,
+			Message: m.Message,
+		}}
+	case *ResyncRequired:
+		frame.Body = &pb.Frame_ResyncRequired{ResyncRequired: &pb.ResyncRequired{Seq: m.Seq, Message: m.Message}}
+	case *ReplayComplete:
+		frame.Body = &pb.Frame_ReplayComplete{ReplayComplete: &pb.ReplayComplete{Seq: m.Seq, Count: int32(m.Count)}}
+	case *SessionRevoked:
+		frame.Body = &pb.Frame_SessionRevoked{SessionRevoked: &pb.Goodbye{Message: m.Message, Timestamp: m.Timestamp}}
+	case *AccountDeleted:
+		frame.Body = &pb.Frame_AccountDeleted{AccountDeleted: &pb.Goodbye{Message: m.Message, Timestamp: m.Timestamp}}
+	case *NowReading:
+		frame.Body = &pb.Frame_NowReading{NowReading: &pb.NowReading{
+			Id:      m.ID,
+			MangaId: m.MangaID,
+			Chapter: int32(m.Chapter),
+			Page:    int32(m.Page),
+		}}
+	case *Presence:
+		frame.Body = &pb.Frame_Presence{Presence: &pb.Presence{
+			ClientId:    m.ClientID,
+			SessionId:   m.SessionID,
+			DeviceName:  m.DeviceName,
+			DeviceType:  m.DeviceType,
+			Status:      m.Status,
+			MangaId:     m.MangaID,
+			MangaTitle:  m.MangaTitle,
+			Chapter:     int32(m.Chapter),
+			Page:        int32(m.Page),
+			ConnectedAt: m.ConnectedAt,
+			LastSeen:    m.LastSeen,
+		}}
+	case *Register:
+		frame.Body = &pb.Frame_Register{Register: &pb.Register{
+			UserId:      m.UserID,
+			Token:       m.Token,
+			Preferences: m.Preferences,
+			Versions:    toInt32s(m.Versions),
+		}}
+	case *Registered:
+		frame.Body = &pb.Frame_Registered{Registered: &pb.Registered{
+			Status:      m.Status,
+			Message:     m.Message,
+			Preferences: m.Preferences,
+			Version:     int32(m.Version),
+			Timestamp:   m.Timestamp,
+		}}
+	case *Unregister:
+		frame.Body = &pb.Frame_Unregister{Unregister: &pb.Unregister{UserId: m.UserID}}
+	case *Unregistered:
+		frame.Body = &pb.Frame_Unregistered{Unregistered: &pb.StatusMessage{Status: m.Status, Message: m.Message}}
+	case *Ping:
+		frame.Body = &pb.Frame_Ping{Ping: &pb.Empty{}}
+	case *Pong:
+		frame.Body = &pb.Frame_Pong{Pong: &pb.Pong{Timestamp: m.Timestamp}}
+	case *Error:
+		frame.Body = &pb.Frame_Error{Error: &pb.StatusMessage{Status: m.Status, Message: m.Message}}
+	case *Notification:
+		frame.Body = &pb.Frame_Notification{Notification: &pb.Notification{
+			Kind:       m.Kind,
+			Title:      m.Title,
+			Message:    m.Message,
+			MangaId:    m.MangaID,
+			MangaTitle: m.MangaTitle,
+			Chapter:    int32(m.Chapter),
+			Timestamp:  m.Timestamp,
+		}}
+	case *ChatMessage:
+		frame.Body = &pb.Frame_Chat{Chat: toChatMessage(m)}
+	case *History:
+		messages := make([]*pb.ChatMessage, 0, len(m.Messages))
+		for i := range m.Messages {
+			messages = append(messages, toChatMessage(&m.Messages[i]))
+		}
+		frame.Body = &pb.Frame_History{History: &pb.History{Messages: messages}}
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownType, msg.MessageType())
+	}
+	return frame, nil
+}
+
+// fromFrame converts a protobuf Frame to its message
+func fromFrame(frame *pb.Frame) (Message, error) {
+	switch body := frame.Body.(type) {
+	case *pb.Frame_Hello:
+		m := body.Hello
+		return &Hello{
+			Token:     m.Token,
+			LastSeq:   m.LastSeq,
+			Versions:  fromInt32s(m.Versions),
+			Encodings: m.Encodings,
+			Snapshot:  m.Snapshot,
+
+			DeviceName: m.DeviceName,
+			DeviceType: m.DeviceType,
+		}, nil
+	case *pb.Frame_Welcome:
+		m := body.Welcome
+		return &Welcome{
+			Status:    m.Status,
+			Message:   m.Message,
+			ClientID:  m.ClientId,
+			UserID:    m.UserId,
+			ExpiresAt: fromOptionalTimestamp(m.ExpiresAt),
+			Seq:       m.Seq,
+			Version:   int(m.Version),
+			Encoding:  Encoding(m.Encoding),
+		}, nil
+	case *pb.Frame_AuthError:
+		m := body.AuthError
+		return &AuthError{Status: m.Status, Code: m.Code, Message: m.Message}, nil
+	case *pb.Frame_Auth:
+		return &Auth{Token: body.Auth.Token}, nil
+	case *pb.Frame_AuthOk:
+		return &AuthOK{ExpiresAt: fromOptionalTimestamp(body.AuthOk.ExpiresAt)}, nil
+	case *pb.Frame_ReauthRequired:
+		m := body.ReauthRequired
+		return &ReauthRequired{Message: m.Message, ExpiresAt: fromTimestamp(m.ExpiresAt)}, nil
+	case *pb.Frame_Heartbeat:
+		return &Heartbeat{}, nil
+	case *pb.Frame_HeartbeatAck:
+		return &HeartbeatAck{Timestamp: body.HeartbeatAck.Timestamp}, nil
+	case *pb.Frame_RequestSnapshot:
+		return &RequestSnapshot{ID: body.RequestSnapshot.Id}, nil
+	case *pb.Frame_Snapshot:
+		m := body.Snapshot
+		library := make([]*models.UserProgress, 0, len(m.Library))
+		for _, entry := range m.Library {
+			library = append(library, fromLibraryEntry(entry))
+		}
+		return &Snapshot{ID: m.Id, Seq: m.Seq, Library: library, Timestamp: m.Timestamp}, nil
+	case *pb.Frame_ProgressUpdate:
+		m := body.ProgressUpdate
+		return &ProgressUpdate{
+			ID:            m.Id,
+			UserID:        m.UserId,
+			MangaID:       m.MangaId,
+			Chapter:       int(m.Chapter),
+			Page:          fromOptionalInt32(m.Page),
+			ScrollPercent: m.ScrollPercent,
+			SessionID:     m.SessionId,
+			Timestamp:     m.Timestamp,
+			Seq:           m.Seq,
+		}, nil
+	case *pb.Frame_LibraryUpdate:
+		m := body.LibraryUpdate
+		return &LibraryUpdate{
+			ID:             m.Id,
+			MangaID:        m.MangaId,
+			Status:         m.Status,
+			Rating:         fromOptionalInt32(m.Rating),
+			CurrentChapter: fromOptionalInt32(m.CurrentChapter),
+			ReadChapters:   m.ReadChapters,
+			Removed:        m.Removed,
+			Timestamp:      m.Timestamp,
+			Seq:            m.Seq,
+		}, nil
+	case *pb.Frame_Ack:
+		m := body.Ack
+		return &Ack{ID: m.Id, Status: m.Status, Seq: m.Seq, Code: m.Code, Message: m.Message}, nil
+	case *pb.Frame_ResyncRequired:
+		return &ResyncRequired{Seq: body.ResyncRequired.Seq, Message: body.ResyncRequired.Message}, nil
+	case *pb.Frame_ReplayComplete:
+		return &ReplayComplete{Seq: body.ReplayComplete.Seq, Count: int(body.ReplayComplete.Count)}, nil
+	case *pb.Frame_SessionRevoked:
+		return &SessionRevoked{Message: body.SessionRevoked.Message, Timestamp: body.SessionRevoked.Timestamp}, nil
+	case *pb.Frame_AccountDeleted:
+		return &AccountDeleted{Message: body.AccountDeleted.Message, Timestamp: body.AccountDeleted.Timestamp}, nil
+	case *pb.Frame_NowReading:
+		m := body.NowReading
+		return &NowReading{ID: m.Id, MangaID: m.MangaId, Chapter: int(m.Chapter), Page: int(m.Page)}, nil
+	case *pb.Frame_Presence:
+		m := body.Presence
+		return &Presence{
+			ClientID:    m.ClientId,
+			SessionID:   m.SessionId,
+			DeviceName:  m.DeviceName,
+			DeviceType:  m.DeviceType,
+			Status:      m.Status,
+			MangaID:     m.MangaId,
+			MangaTitle:  m.MangaTitle,
+			Chapter:     int(m.Chapter),
+			Page:        int(m.Page),
+			ConnectedAt: m.ConnectedAt,
+			LastSeen:    m.LastSeen,
+		}, nil
+	case *pb.Frame_Register:
+		m := body.Register
+		return &Register{
+			UserID:      m.UserId,
+			Token:       m.Token,
+			Preferences: m.Preferences,
+			Versions:    fromInt32s(m.Versions),
+		}, nil
+	case *pb.Frame_Registered:
+		m := body.Registered
+		return &Registered{
+			Status:      m.Status,
+			Message:     m.Message,
+			Preferences: m.Preferences,
+			Version:     int(m.Version),
+			Timestamp:   m.Timestamp,
+		}, nil
+	case *pb.Frame_Unregister:
+		return &Unregister{UserID: body.Unregister.UserId}, nil
+	case *pb.Frame_Unregistered:
+		return &Unregistered{Status: body.Unregistered.Status, Message: body.Unregistered.Message}, nil
+	case *pb.Frame_Ping:
+		return &Ping{}, nil
+	case *pb.Frame_Pong:
+		return &Pong{Timestamp: body.Pong.Timestamp}, nil
+	case *pb.Frame_Error:
+		return &Error{Status: body.Error.Status, Message: body.Error.Message}, nil
+	case *pb.Frame_Notification:
+		m := body.Notification
+		return &Notification{
+			Kind:       m.Kind,
+			Title:      m.Title,
+			Message:    m.Message,
+			MangaID:    m.MangaId,
+			MangaTitle: m.MangaTitle,
+			Chapter:    int(m.Chapter),
+			Timestamp:  m.Timestamp,
+		}, nil
+	case *pb.Frame_Chat:
+		m := fromChatMessage(body.Chat)
+		return &m, nil
+	case *pb.Frame_History:
+		messages := make([]ChatMessage, 0, len(body.History.Messages))
+		for _, m := range body.History.Messages {
+			messages = append(messages, fromChatMessage(m))
+		}
+		return &History{Messages: messages}, nil
+	}
+	return nil, errEmptyFrame
+}
+
+func toLibraryEntry(p *models.UserProgress) *pb.LibraryEntry {
+	return &pb.LibraryEntry{
+		UserId:         p.UserID,
+		MangaId:        p.MangaID,
+		CurrentChapter: int32(p.CurrentChapter),
+		ReadChapters:   p.ReadChapters,
+		Status:         p.Status,
+		Rating:         int32(p.Rating),
+		UpdatedAt:      toTimestamp(p.UpdatedAt),
+		StartedAt:      toTimestamp(p.StartedAt),
+		CompletedAt:    toOptionalTimestamp(p.CompletedAt),
+	}
+}
+
+func fromLibraryEntry(e *pb.LibraryEntry) *models.UserProgress {
+	return &models.UserProgress{
+		UserID:         e.UserId,
+		MangaID:        e.MangaId,
+		CurrentChapter: int(e.CurrentChapter),
+		ReadChapters:   e.ReadChapters,
+		Status:         e.Status,
+		Rating:         int(e.Rating),
+		UpdatedAt:      fromTimestamp(e.UpdatedAt),
+		StartedAt:      fromTimestamp(e.StartedAt),
+		CompletedAt:    fromOptionalTimestamp(e.CompletedAt),
+	}
+}
+
+func toChatMessage(m *ChatMessage) *pb.ChatMessage {
+	return &pb.ChatMessage{Kind: m.Kind, Room: m.Room, Username: m.Username, Text: m.Text, Time: m.Time}
+}
+
+func fromChatMessage(m *pb.ChatMessage) ChatMessage {
+	return ChatMessage{Kind: m.Kind, Room: m.Room, Username: m.Username, Text: m.Text, Time: m.Time}
+}
+
+// toTimestamp leaves zero times out
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func toOptionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func fromOptionalTimestamp(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func toOptionalInt32(n *int) *int32 {
+	if n == nil {
+		return nil
+	}
+	v := int32(*n)
+	return &v
+}
+
+func fromOptionalInt32(n *int32) *int {
+	if n == nil {
+		return nil
+	}
+	v := int(*n)
+	return &v
+}
+
+func toInt32s(ns []int) []int32 {
+	if ns == nil {
+		return nil
+	}
+	out := make([]int32, len(ns))
+	for i, n := range ns {
+		out[i] = int32(n)
+	}
+	return out
+}
+
+func fromInt32s(ns []int32) []int {
+	if ns == nil {
+		return nil
+	}
+	out := make([]int, len(ns))
+	for i, n := range ns {
+		out[i] = int(n)
+	}
+	return out
+}
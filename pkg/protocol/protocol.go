@@ -0,0 +1,239 @@
+// Package protocol defines the messages exchanged with the TCP sync, UDP
+// notification and WebSocket chat servers, and how they are encoded.
+//
+// Every message is a typed struct. In JSON it is an object whose "type"
+// field names the message, such as {"type":"ping"}. The compact protobuf
+// encoding wraps it in a Frame (proto/protocol.proto). A client and server
+// agree on the protocol version and encoding when they connect.
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Version is the newest protocol version this package speaks
+	Version = 1
+
+	// MinVersion is the oldest protocol version still supported
+	MinVersion = 1
+)
+
+// Encoding is how messages are written on the wire
+type Encoding string
+
+const (
+	// JSON writes messages as JSON objects, one per line on streams
+	JSON Encoding = "json"
+	// Protobuf writes messages as protobuf Frames, prefixed with their length
+	// on streams
+	Protobuf Encoding = "protobuf"
+)
+
+var (
+	ErrUnknownType        = errors.New("unknown message type")
+	ErrUnexpectedType     = errors.New("unexpected message type")
+	ErrUnsupportedVersion = errors.New("no supported protocol version")
+	ErrUnknownEncoding    = errors.New("unknown encoding, use json or protobuf")
+	ErrFrameTooLarge      = errors.New("frame too large")
+)
+
+// Message is a typed protocol message
+type Message interface {
+	// MessageType is the name of the message on the wire
+	MessageType() string
+}
+
+// Sequenced is a message numbered in its user's event sequence
+type Sequenced interface {
+	Message
+	SetSeq(seq int64)
+}
+
+// New creates an empty message of the named type
+func New(msgType string) (Message, error) {
+	create, ok := registry[msgType]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownType, msgType)
+	}
+	return create(), nil
+}
+
+// ParseEncoding parses an encoding name. An empty name means JSON.
+func ParseEncoding(name string) (Encoding, error) {
+	switch Encoding(name) {
+	case "", JSON:
+		return JSON, nil
+	case Protobuf:
+		return Protobuf, nil
+	}
+	return JSON, ErrUnknownEncoding
+}
+
+// Negotiate picks the newest version offered by a peer that this package
+// supports. Peers that offer none predate negotiation and speak version 1.
+func Negotiate(versions []int) (int, error) {
+	if len(versions) == 0 {
+		return 1, nil
+	}
+	best := 0
+	for _, v := range versions {
+		if v >= MinVersion && v <= Version && v > best {
+			best = v
+		}
+	}
+	if best == 0 {
+		return 0, ErrUnsupportedVersion
+	}
+	return best, nil
+}
+
+// NegotiateEncoding picks the first encoding offered by a peer that this
+// package supports, or JSON
+func NegotiateEncoding(names []string) Encoding {
+	for _, name := range names {
+		if enc, err := ParseEncoding(name); err == nil && name != "" {
+			return enc
+		}
+	}
+	return JSON
+}
+
+// Detect tells the encoding of a message that arrived on its own, such as a
+// UDP datagram. JSON messages start with '{', which no Frame can.
+func Detect(data []byte) Encoding {
+	if len(data) > 0 && data[0] == '{' {
+		return JSON
+	}
+	return Protobuf
+}
+
+// Subprotocols are the WebSocket subprotocols a server accepts, each naming a
+// version and encoding
+func Subprotocols() []string {
+	var names []string
+	for v := Version; v >= MinVersion; v-- {
+		names = append(names, Subprotocol(v, JSON), Subprotocol(v, Protobuf))
+	}
+	return names
+}
+
+// Subprotocol names a WebSocket subprotocol, such as mangahub.v1.json
+func Subprotocol(version int, enc Encoding) string {
+	return fmt.Sprintf("mangahub.v%d.%s", version, enc)
+}
+
+// ParseSubprotocol returns the version and encoding of a WebSocket
+// subprotocol. Connections without one speak version 1 in JSON.
+func ParseSubprotocol(name string) (int, Encoding, error) {
+	if name == "" {
+		return 1, JSON, nil
+	}
+	parts := strings.Split(name, ".")
+	if len(parts) != 3 || parts[0] != "mangahub" || !strings.HasPrefix(parts[1], "v") {
+		return 0, "", fmt.Errorf("unknown subprotocol %q", name)
+	}
+	version, err := strconv.Atoi(parts[1][1:])
+	if err != nil {
+		return 0, "", fmt.Errorf("unknown subprotocol %q", name)
+	}
+	if _, err := Negotiate([]int{version}); err != nil {
+		return 0, "", err
+	}
+	enc, err := ParseEncoding(parts[2])
+	if err != nil {
+		return 0, "", err
+	}
+	return version, enc, nil
+}
+
+// Marshal encodes a message on its own, without stream framing
+func Marshal(msg Message, enc Encoding) ([]byte, error) {
+	switch enc {
+	case JSON:
+		return marshalJSON(msg)
+	case Protobuf:
+		return marshalProto(msg)
+	}
+	return nil, ErrUnknownEncoding
+}
+
+// Unmarshal decodes a message of any type
+func Unmarshal(data []byte, enc Encoding) (Message, error) {
+	switch enc {
+	case JSON:
+		return unmarshalJSON(data)
+	case Protobuf:
+		return unmarshalProto(data)
+	}
+	return nil, ErrUnknownEncoding
+}
+
+// Decode decodes a message that must be of msg's type. JSON messages without
+// a type are accepted, so older clients can leave it out.
+func Decode(data []byte, enc Encoding, msg Message) error {
+	if enc == JSON {
+		var envelope struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return fmt.Errorf("invalid JSON message: %w", err)
+		}
+		if envelope.Type != "" && envelope.Type != msg.MessageType() {
+			return fmt.Errorf("%w %q, want %q", ErrUnexpectedType, envelope.Type, msg.MessageType())
+		}
+		if err := json.Unmarshal(data, msg); err != nil {
+			return fmt.Errorf("invalid %s message: %w", msg.MessageType(), err)
+		}
+		return nil
+	}
+
+	decoded, err := Unmarshal(data, enc)
+	if err != nil {
+		return err
+	}
+	if decoded.MessageType() != msg.MessageType() {
+		return fmt.Errorf("%w %q, want %q", ErrUnexpectedType, decoded.MessageType(), msg.MessageType())
+	}
+	reflect.ValueOf(msg).Elem().Set(reflect.ValueOf(decoded).Elem())
+	return nil
+}
+
+// marshalJSON writes the message's fields after its type
+func marshalJSON(msg Message) ([]byte, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	msgType, _ := json.Marshal(msg.MessageType())
+
+	data := make([]byte, 0, len(body)+len(msgType)+9)
+	data = append(data, `{"type":`...)
+	data = append(data, msgType...)
+	if len(body) > 2 {
+		data = append(data, ',')
+	}
+	return append(data, body[1:]...), nil
+}
+
+func unmarshalJSON(data []byte) (Message, error) {
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid JSON message: %w", err)
+	}
+	msg, err := New(envelope.Type)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("invalid %s message: %w", envelope.Type, err)
+	}
+	return msg, nil
+}
@@ -0,0 +1,254 @@
+package protocol
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"mangahub/pkg/models"
+)
+
+var encodings = []Encoding{JSON, Protobuf}
+
+// samples returns a message of every type with its fields set
+func samples() []Message {
+	at := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	completed := at.Add(time.Hour)
+	lastSeq := int64(41)
+	page := 12
+	scroll := 37.5
+	rating := 8
+	chapter := 0
+
+	return []Message{
+		&Hello{Token: "jwt", LastSeq: &lastSeq, Versions: []int{1, 2}, Encodings: []string{"protobuf", "json"}, Snapshot: true, DeviceName: "Kindle", DeviceType: "tablet"},
+		&Welcome{Status: "connected", Message: "welcome", ClientID: "u1_1", UserID: "u1", ExpiresAt: &at, Seq: 42, Version: 1, Encoding: Protobuf},
+		NewAuthError("token_expired", "token has expired"),
+		&Auth{Token: "fresh"},
+		&AuthOK{ExpiresAt: &at},
+		&ReauthRequired{Message: "token expires soon", ExpiresAt: at},
+		&Heartbeat{},
+		&HeartbeatAck{Timestamp: at.Unix()},
+		&RequestSnapshot{ID: "resync"},
+		&Snapshot{ID: "resync", Seq: 42, Timestamp: at.Unix(), Library: []*models.UserProgress{
+			{UserID: "u1", MangaID: "one-piece", CurrentChapter: 100, ReadChapters: "1-100,105", Status: "reading", Rating: 9, UpdatedAt: at, StartedAt: at},
+			{UserID: "u1", MangaID: "naruto", CurrentChapter: 700, Status: "completed", UpdatedAt: at, StartedAt: at, CompletedAt: &completed},
+		}},
+		&ProgressUpdate{ID: "push-1", UserID: "u1", MangaID: "one-piece", Chapter: 101, Page: &page, ScrollPercent: &scroll, SessionID: "s1", Timestamp: at.Unix(), Seq: 43},
+		&LibraryUpdate{ID: "push-2", MangaID: "one-piece", Status: "on-hold", Rating: &rating, CurrentChapter: &chapter, ReadChapters: "1-3", Timestamp: at.Unix(), Seq: 44},
+		&Ack{ID: "push-3", Status: "error", Code: "manga_not_found", Message: "manga not found"},
+		&ResyncRequired{Seq: 44, Message: "resync"},
+		&ReplayComplete{Seq: 44, Count: 3},
+		&SessionRevoked{Message: "logged out", Timestamp: at.Unix()},
+		&AccountDeleted{Message: "deleted", Timestamp: at.Unix()},
+		&NowReading{ID: "r1", MangaID: "one-piece", Chapter: 101, Page: 12},
+		&Presence{ClientID: "u1_2", SessionID: "s1", DeviceName: "Kindle", DeviceType: "tablet", Status: "online", MangaID: "one-piece", MangaTitle: "One Piece", Chapter: 101, Page: 12, ConnectedAt: at.Unix(), LastSeen: at.Unix() + 30},
+		&Register{UserID: "u1", Token: "jwt", Preferences: map[string]bool{"chapter_releases": false, "system_updates": true}, Versions: []int{1}},
+		&Registered{Status: "registered", Message: "subscribed", Preferences: map[string]bool{"chapter_releases": true}, Version: 1, Timestamp: at.Unix()},
+		&Unregister{UserID: "u1"},
+		&Unregistered{Status: "unregistered", Message: "unsubscribed"},
+		&Ping{},
+		&Pong{Timestamp: at.Unix()},
+		NewError("invalid or expired token"),
+		&Notification{Kind: "chapter_release", Title: "New Chapter Released!", Message: "One Piece - Chapter 1100", MangaID: "one-piece", MangaTitle: "One Piece", Chapter: 1100, Timestamp: at.Unix()},
+		&ChatMessage{Kind: "chat", Room: "general", Username: "luffy", Text: "hi 👋", Time: "15:09:26"},
+		&History{Messages: []ChatMessage{
+			{Kind: "system", Room: "general", Username: "zoro", Text: "zoro joined the room", Time: "15:09:00"},
+			{Kind: "chat", Room: "general", Username: "zoro", Text: "yo", Time: "15:09:10"},
+		}},
+	}
+}
+
+func TestSamplesCoverEveryType(t *testing.T) {
+	seen := map[string]bool{}
+	for _, msg := range samples() {
+		seen[msg.MessageType()] = true
+	}
+	for msgType := range registry {
+		if !seen[msgType] {
+			t.Errorf("no sample of %s", msgType)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, enc := range encodings {
+		for _, msg := range samples() {
+			data, err := Marshal(msg, enc)
+			if err != nil {
+				t.Fatalf("%s: marshal %s: %v", enc, msg.MessageType(), err)
+			}
+			got, err := Unmarshal(data, enc)
+			if err != nil {
+				t.Fatalf("%s: unmarshal %s: %v", enc, msg.MessageType(), err)
+			}
+			if !reflect.DeepEqual(got, msg) {
+				t.Errorf("%s: %s round trip\n got %+v\nwant %+v", enc, msg.MessageType(), got, msg)
+			}
+			if Detect(data) != enc {
+				t.Errorf("%s: %s detected as %s", enc, msg.MessageType(), Detect(data))
+			}
+		}
+	}
+}
+
+func TestJSONHasType(t *testing.T) {
+	data, _ := Marshal(&Ping{}, JSON)
+	if string(data) != `{"type":"ping"}` {
+		t.Errorf("ping = %s", data)
+	}
+	data, _ = Marshal(&Auth{Token: "t"}, JSON)
+	if string(data) != `{"type":"auth","token":"t"}` {
+		t.Errorf("auth = %s", data)
+	}
+}
+
+func TestStream(t *testing.T) {
+	for _, enc := range encodings {
+		var buf bytes.Buffer
+		for _, msg := range samples() {
+			if err := WriteFrame(&buf, msg, enc); err != nil {
+				t.Fatalf("%s: write %s: %v", enc, msg.MessageType(), err)
+			}
+		}
+
+		r := NewReader(&buf)
+		r.SetEncoding(enc)
+		for _, want := range samples() {
+			got, err := r.ReadMessage()
+			if err != nil {
+				t.Fatalf("%s: read %s: %v", enc, want.MessageType(), err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: read %+v, want %+v", enc, got, want)
+			}
+		}
+		if _, err := r.ReadFrame(); err == nil {
+			t.Errorf("%s: read past the last frame", enc)
+		}
+	}
+}
+
+func TestFrameTooLarge(t *testing.T) {
+	r := NewReader(bytes.NewReader(append(bytes.Repeat([]byte("a"), MaxFrameSize+1), '\n')))
+	if _, err := r.ReadFrame(); err != ErrFrameTooLarge {
+		t.Errorf("long line: got %v, want ErrFrameTooLarge", err)
+	}
+
+	r = NewReader(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0x7f}))
+	r.SetEncoding(Protobuf)
+	if _, err := r.ReadFrame(); err != ErrFrameTooLarge {
+		t.Errorf("large prefix: got %v, want ErrFrameTooLarge", err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	// Clients that predate the protocol package send the handshake without a type
+	var hello Hello
+	if err := Decode([]byte(`{"token":"jwt","last_seq":7}`), JSON, &hello); err != nil {
+		t.Fatalf("untyped hello: %v", err)
+	}
+	if hello.Token != "jwt" || hello.LastSeq == nil || *hello.LastSeq != 7 {
+		t.Errorf("hello = %+v", hello)
+	}
+
+	if err := Decode([]byte(`{"type":"ping"}`), JSON, &hello); err == nil {
+		t.Error("decoded a ping as a hello")
+	}
+
+	data, _ := Marshal(&Auth{Token: "fresh"}, Protobuf)
+	var auth Auth
+	if err := Decode(data, Protobuf, &auth); err != nil || auth.Token != "fresh" {
+		t.Errorf("protobuf auth = %+v, %v", auth, err)
+	}
+	if err := Decode(data, Protobuf, &hello); err == nil {
+		t.Error("decoded a protobuf auth as a hello")
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		versions []int
+		want     int
+		err      error
+	}{
+		{nil, 1, nil},
+		{[]int{1}, 1, nil},
+		{[]int{Version + 1, Version}, Version, nil},
+		{[]int{Version + 1}, 0, ErrUnsupportedVersion},
+		{[]int{0}, 0, ErrUnsupportedVersion},
+	}
+	for _, tt := range tests {
+		got, err := Negotiate(tt.versions)
+		if got != tt.want || err != tt.err {
+			t.Errorf("Negotiate(%v) = %d, %v, want %d, %v", tt.versions, got, err, tt.want, tt.err)
+		}
+	}
+
+	if enc := NegotiateEncoding([]string{"msgpack", "protobuf", "json"}); enc != Protobuf {
+		t.Errorf("encoding = %s, want protobuf", enc)
+	}
+	if enc := NegotiateEncoding(nil); enc != JSON {
+		t.Errorf("default encoding = %s, want json", enc)
+	}
+}
+
+func TestSubprotocol(t *testing.T) {
+	for _, name := range Subprotocols() {
+		if _, _, err := ParseSubprotocol(name); err != nil {
+			t.Errorf("ParseSubprotocol(%q): %v", name, err)
+		}
+	}
+
+	version, enc, err := ParseSubprotocol(Subprotocol(1, Protobuf))
+	if version != 1 || enc != Protobuf || err != nil {
+		t.Errorf("got %d, %s, %v", version, enc, err)
+	}
+	if version, enc, _ := ParseSubprotocol(""); version != 1 || enc != JSON {
+		t.Errorf("no subprotocol = %d, %s, want 1, json", version, enc)
+	}
+	for _, name := range []string{"chat", "mangahub.v99.json", "mangahub.v1.xml"} {
+		if _, _, err := ParseSubprotocol(name); err == nil {
+			t.Errorf("accepted %q", name)
+		}
+	}
+}
+
+// FuzzUnmarshal checks that decoding arbitrary input doesn't panic, and that
+// whatever decodes encodes back to a message that decodes the same way
+func FuzzUnmarshal(f *testing.F) {
+	for _, enc := range encodings {
+		for _, msg := range samples() {
+			data, _ := Marshal(msg, enc)
+			f.Add(data)
+		}
+	}
+	f.Add([]byte(`{"token":"jwt"}`))
+	f.Add([]byte(`{"type":"snapshot","library":[null]}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, enc := range encodings {
+			msg, err := Unmarshal(data, enc)
+			if err != nil {
+				continue
+			}
+			encoded, err := Marshal(msg, enc)
+			if err != nil {
+				// Such as a JSON time outside years 0-9999
+				continue
+			}
+			again, err := Unmarshal(encoded, enc)
+			if err != nil {
+				t.Fatalf("%s: re-encoded %s doesn't decode: %v\n%q", enc, msg.MessageType(), err, encoded)
+			}
+			reencoded, err := Marshal(again, enc)
+			if err != nil {
+				t.Fatalf("%s: re-decoded %s doesn't encode: %v", enc, msg.MessageType(), err)
+			}
+			if !bytes.Equal(encoded, reencoded) {
+				t.Fatalf("%s: %s changed on a round trip\n%q\n%q", enc, msg.MessageType(), encoded, reencoded)
+			}
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// MaxFrameSize bounds a frame read from a stream
+const MaxFrameSize = 1 << 20
+
+// MarshalFrame encodes a message for a stream: a JSON line, or a protobuf
+// Frame prefixed with its length as a uvarint
+func MarshalFrame(msg Message, enc Encoding) ([]byte, error) {
+	data, err := Marshal(msg, enc)
+	if err != nil {
+		return nil, err
+	}
+	if enc == JSON {
+		return append(data, '\n'), nil
+	}
+	frame := binary.AppendUvarint(make([]byte, 0, len(data)+binary.MaxVarintLen32), uint64(len(data)))
+	return append(frame, data...), nil
+}
+
+// WriteFrame writes a message to a stream
+func WriteFrame(w io.Writer, msg Message, enc Encoding) error {
+	frame, err := MarshalFrame(msg, enc)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(frame)
+	return err
+}
+
+// Reader reads frames from a stream. It starts out reading JSON lines, and
+// switches encoding once the handshake settles it.
+type Reader struct {
+	r   *bufio.Reader
+	enc Encoding
+}
+
+// NewReader creates a Reader reading JSON lines from r
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r), enc: JSON}
+}
+
+// SetEncoding sets the encoding of the frames read from now on
+func (r *Reader) SetEncoding(enc Encoding) {
+	r.enc = enc
+}
+
+// Encoding returns the encoding of the frames being read
+func (r *Reader) Encoding() Encoding {
+	return r.enc
+}
+
+// ReadFrame returns the next encoded message, without its framing. An error
+// means the stream can't be read any further.
+func (r *Reader) ReadFrame() ([]byte, error) {
+	if r.enc == Protobuf {
+		size, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			return nil, err
+		}
+		if size > MaxFrameSize {
+			return nil, ErrFrameTooLarge
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r.r, data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+
+	var line []byte
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		if len(line)+len(chunk) > MaxFrameSize {
+			return nil, ErrFrameTooLarge
+		}
+		line = append(line, chunk...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return bytes.TrimRight(line, "\r\n"), nil
+	}
+}
+
+// ReadMessage reads and decodes the next message. Use ReadFrame to tell
+// messages that can't be decoded from a broken stream.
+func (r *Reader) ReadMessage() (Message, error) {
+	data, err := r.ReadFrame()
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data, r.enc)
+}
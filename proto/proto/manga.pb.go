@@ -429,6 +429,426 @@ func (x *UpdateProgressResponse) GetUpdatedAt() int64 {
 	return 0
 }
 
+type MarkChaptersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	MangaId       string                 `protobuf:"bytes,2,opt,name=manga_id,json=mangaId,proto3" json:"manga_id,omitempty"`
+	Chapters      string                 `protobuf:"bytes,3,opt,name=chapters,proto3" json:"chapters,omitempty"` // e.g. "1-20,25"
+	Unread        bool                   `protobuf:"varint,4,opt,name=unread,proto3" json:"unread,omitempty"`    // unmark instead of mark
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MarkChaptersRequest) Reset() {
+	*x = MarkChaptersRequest{}
+	mi := &file_manga_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MarkChaptersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarkChaptersRequest) ProtoMessage() {}
+
+func (x *MarkChaptersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_manga_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarkChaptersRequest.ProtoReflect.Descriptor instead.
+func (*MarkChaptersRequest) Descriptor() ([]byte, []int) {
+	return file_manga_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *MarkChaptersRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *MarkChaptersRequest) GetMangaId() string {
+	if x != nil {
+		return x.MangaId
+	}
+	return ""
+}
+
+func (x *MarkChaptersRequest) GetChapters() string {
+	if x != nil {
+		return x.Chapters
+	}
+	return ""
+}
+
+func (x *MarkChaptersRequest) GetUnread() bool {
+	if x != nil {
+		return x.Unread
+	}
+	return false
+}
+
+type MarkChaptersResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Success        bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message        string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	CurrentChapter int32                  `protobuf:"varint,3,opt,name=current_chapter,json=currentChapter,proto3" json:"current_chapter,omitempty"`
+	ReadChapters   string                 `protobuf:"bytes,4,opt,name=read_chapters,json=readChapters,proto3" json:"read_chapters,omitempty"`
+	ReadCount      int32                  `protobuf:"varint,5,opt,name=read_count,json=readCount,proto3" json:"read_count,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MarkChaptersResponse) Reset() {
+	*x = MarkChaptersResponse{}
+	mi := &file_manga_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MarkChaptersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarkChaptersResponse) ProtoMessage() {}
+
+func (x *MarkChaptersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_manga_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarkChaptersResponse.ProtoReflect.Descriptor instead.
+func (*MarkChaptersResponse) Descriptor() ([]byte, []int) {
+	return file_manga_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *MarkChaptersResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *MarkChaptersResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *MarkChaptersResponse) GetCurrentChapter() int32 {
+	if x != nil {
+		return x.CurrentChapter
+	}
+	return 0
+}
+
+func (x *MarkChaptersResponse) GetReadChapters() string {
+	if x != nil {
+		return x.ReadChapters
+	}
+	return ""
+}
+
+func (x *MarkChaptersResponse) GetReadCount() int32 {
+	if x != nil {
+		return x.ReadCount
+	}
+	return 0
+}
+
+type UserStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Period        string                 `protobuf:"bytes,2,opt,name=period,proto3" json:"period,omitempty"`     // week, month, year, all
+	Timezone      string                 `protobuf:"bytes,3,opt,name=timezone,proto3" json:"timezone,omitempty"` // IANA name, defaults to UTC
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserStatsRequest) Reset() {
+	*x = UserStatsRequest{}
+	mi := &file_manga_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserStatsRequest) ProtoMessage() {}
+
+func (x *UserStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_manga_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserStatsRequest.ProtoReflect.Descriptor instead.
+func (*UserStatsRequest) Descriptor() ([]byte, []int) {
+	return file_manga_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UserStatsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UserStatsRequest) GetPeriod() string {
+	if x != nil {
+		return x.Period
+	}
+	return ""
+}
+
+func (x *UserStatsRequest) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
+}
+
+type StatCount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Label         string                 `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatCount) Reset() {
+	*x = StatCount{}
+	mi := &file_manga_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatCount) ProtoMessage() {}
+
+func (x *StatCount) ProtoReflect() protoreflect.Message {
+	mi := &file_manga_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatCount.ProtoReflect.Descriptor instead.
+func (*StatCount) Descriptor() ([]byte, []int) {
+	return file_manga_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *StatCount) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *StatCount) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type UserStatsResponse struct {
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	Period                  string                 `protobuf:"bytes,1,opt,name=period,proto3" json:"period,omitempty"`
+	ChaptersRead            int32                  `protobuf:"varint,2,opt,name=chapters_read,json=chaptersRead,proto3" json:"chapters_read,omitempty"`
+	ChaptersPerBucket       []*StatCount           `protobuf:"bytes,3,rep,name=chapters_per_bucket,json=chaptersPerBucket,proto3" json:"chapters_per_bucket,omitempty"`
+	LibraryTotal            int32                  `protobuf:"varint,4,opt,name=library_total,json=libraryTotal,proto3" json:"library_total,omitempty"`
+	StatusCounts            map[string]int32       `protobuf:"bytes,5,rep,name=status_counts,json=statusCounts,proto3" json:"status_counts,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	CompletionRate          float64                `protobuf:"fixed64,6,opt,name=completion_rate,json=completionRate,proto3" json:"completion_rate,omitempty"`
+	AverageRating           float64                `protobuf:"fixed64,7,opt,name=average_rating,json=averageRating,proto3" json:"average_rating,omitempty"`
+	RatedCount              int32                  `protobuf:"varint,8,opt,name=rated_count,json=ratedCount,proto3" json:"rated_count,omitempty"`
+	Genres                  []*StatCount           `protobuf:"bytes,9,rep,name=genres,proto3" json:"genres,omitempty"`
+	LongestFinishedId       string                 `protobuf:"bytes,10,opt,name=longest_finished_id,json=longestFinishedId,proto3" json:"longest_finished_id,omitempty"`
+	LongestFinishedTitle    string                 `protobuf:"bytes,11,opt,name=longest_finished_title,json=longestFinishedTitle,proto3" json:"longest_finished_title,omitempty"`
+	LongestFinishedChapters int32                  `protobuf:"varint,12,opt,name=longest_finished_chapters,json=longestFinishedChapters,proto3" json:"longest_finished_chapters,omitempty"`
+	CurrentStreak           int32                  `protobuf:"varint,13,opt,name=current_streak,json=currentStreak,proto3" json:"current_streak,omitempty"`
+	LongestStreak           int32                  `protobuf:"varint,14,opt,name=longest_streak,json=longestStreak,proto3" json:"longest_streak,omitempty"`
+	ActiveHours             []int32                `protobuf:"varint,15,rep,packed,name=active_hours,json=activeHours,proto3" json:"active_hours,omitempty"`
+	MostActiveHour          int32                  `protobuf:"varint,16,opt,name=most_active_hour,json=mostActiveHour,proto3" json:"most_active_hour,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *UserStatsResponse) Reset() {
+	*x = UserStatsResponse{}
+	mi := &file_manga_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserStatsResponse) ProtoMessage() {}
+
+func (x *UserStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_manga_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserStatsResponse.ProtoReflect.Descriptor instead.
+func (*UserStatsResponse) Descriptor() ([]byte, []int) {
+	return file_manga_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *UserStatsResponse) GetPeriod() string {
+	if x != nil {
+		return x.Period
+	}
+	return ""
+}
+
+func (x *UserStatsResponse) GetChaptersRead() int32 {
+	if x != nil {
+		return x.ChaptersRead
+	}
+	return 0
+}
+
+func (x *UserStatsResponse) GetChaptersPerBucket() []*StatCount {
+	if x != nil {
+		return x.ChaptersPerBucket
+	}
+	return nil
+}
+
+func (x *UserStatsResponse) GetLibraryTotal() int32 {
+	if x != nil {
+		return x.LibraryTotal
+	}
+	return 0
+}
+
+func (x *UserStatsResponse) GetStatusCounts() map[string]int32 {
+	if x != nil {
+		return x.StatusCounts
+	}
+	return nil
+}
+
+func (x *UserStatsResponse) GetCompletionRate() float64 {
+	if x != nil {
+		return x.CompletionRate
+	}
+	return 0
+}
+
+func (x *UserStatsResponse) GetAverageRating() float64 {
+	if x != nil {
+		return x.AverageRating
+	}
+	return 0
+}
+
+func (x *UserStatsResponse) GetRatedCount() int32 {
+	if x != nil {
+		return x.RatedCount
+	}
+	return 0
+}
+
+func (x *UserStatsResponse) GetGenres() []*StatCount {
+	if x != nil {
+		return x.Genres
+	}
+	return nil
+}
+
+func (x *UserStatsResponse) GetLongestFinishedId() string {
+	if x != nil {
+		return x.LongestFinishedId
+	}
+	return ""
+}
+
+func (x *UserStatsResponse) GetLongestFinishedTitle() string {
+	if x != nil {
+		return x.LongestFinishedTitle
+	}
+	return ""
+}
+
+func (x *UserStatsResponse) GetLongestFinishedChapters() int32 {
+	if x != nil {
+		return x.LongestFinishedChapters
+	}
+	return 0
+}
+
+func (x *UserStatsResponse) GetCurrentStreak() int32 {
+	if x != nil {
+		return x.CurrentStreak
+	}
+	return 0
+}
+
+func (x *UserStatsResponse) GetLongestStreak() int32 {
+	if x != nil {
+		return x.LongestStreak
+	}
+	return 0
+}
+
+func (x *UserStatsResponse) GetActiveHours() []int32 {
+	if x != nil {
+		return x.ActiveHours
+	}
+	return nil
+}
+
+func (x *UserStatsResponse) GetMostActiveHour() int32 {
+	if x != nil {
+		return x.MostActiveHour
+	}
+	return 0
+}
+
 var File_manga_proto protoreflect.FileDescriptor
 
 const file_manga_proto_rawDesc = "" +
@@ -465,11 +885,54 @@ const file_manga_proto_rawDesc = "" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12'\n" +
 	"\x0fcurrent_chapter\x18\x03 \x01(\x05R\x0ecurrentChapter\x12\x1d\n" +
 	"\n" +
-	"updated_at\x18\x04 \x01(\x03R\tupdatedAt2\xd3\x01\n" +
+	"updated_at\x18\x04 \x01(\x03R\tupdatedAt\"}\n" +
+	"\x13MarkChaptersRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bmanga_id\x18\x02 \x01(\tR\amangaId\x12\x1a\n" +
+	"\bchapters\x18\x03 \x01(\tR\bchapters\x12\x16\n" +
+	"\x06unread\x18\x04 \x01(\bR\x06unread\"\xb7\x01\n" +
+	"\x14MarkChaptersResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12'\n" +
+	"\x0fcurrent_chapter\x18\x03 \x01(\x05R\x0ecurrentChapter\x12#\n" +
+	"\rread_chapters\x18\x04 \x01(\tR\freadChapters\x12\x1d\n" +
+	"\n" +
+	"read_count\x18\x05 \x01(\x05R\treadCount\"_\n" +
+	"\x10UserStatsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06period\x18\x02 \x01(\tR\x06period\x12\x1a\n" +
+	"\btimezone\x18\x03 \x01(\tR\btimezone\"7\n" +
+	"\tStatCount\x12\x14\n" +
+	"\x05label\x18\x01 \x01(\tR\x05label\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\"\xa1\x06\n" +
+	"\x11UserStatsResponse\x12\x16\n" +
+	"\x06period\x18\x01 \x01(\tR\x06period\x12#\n" +
+	"\rchapters_read\x18\x02 \x01(\x05R\fchaptersRead\x12@\n" +
+	"\x13chapters_per_bucket\x18\x03 \x03(\v2\x10.manga.StatCountR\x11chaptersPerBucket\x12#\n" +
+	"\rlibrary_total\x18\x04 \x01(\x05R\flibraryTotal\x12O\n" +
+	"\rstatus_counts\x18\x05 \x03(\v2*.manga.UserStatsResponse.StatusCountsEntryR\fstatusCounts\x12'\n" +
+	"\x0fcompletion_rate\x18\x06 \x01(\x01R\x0ecompletionRate\x12%\n" +
+	"\x0eaverage_rating\x18\a \x01(\x01R\raverageRating\x12\x1f\n" +
+	"\vrated_count\x18\b \x01(\x05R\n" +
+	"ratedCount\x12(\n" +
+	"\x06genres\x18\t \x03(\v2\x10.manga.StatCountR\x06genres\x12.\n" +
+	"\x13longest_finished_id\x18\n" +
+	" \x01(\tR\x11longestFinishedId\x124\n" +
+	"\x16longest_finished_title\x18\v \x01(\tR\x14longestFinishedTitle\x12:\n" +
+	"\x19longest_finished_chapters\x18\f \x01(\x05R\x17longestFinishedChapters\x12%\n" +
+	"\x0ecurrent_streak\x18\r \x01(\x05R\rcurrentStreak\x12%\n" +
+	"\x0elongest_streak\x18\x0e \x01(\x05R\rlongestStreak\x12!\n" +
+	"\factive_hours\x18\x0f \x03(\x05R\vactiveHours\x12(\n" +
+	"\x10most_active_hour\x18\x10 \x01(\x05R\x0emostActiveHour\x1a?\n" +
+	"\x11StatusCountsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x012\xe3\x02\n" +
 	"\fMangaService\x128\n" +
 	"\bGetManga\x12\x16.manga.GetMangaRequest\x1a\x14.manga.MangaResponse\x12:\n" +
 	"\vSearchManga\x12\x14.manga.SearchRequest\x1a\x15.manga.SearchResponse\x12M\n" +
-	"\x0eUpdateProgress\x12\x1c.manga.UpdateProgressRequest\x1a\x1d.manga.UpdateProgressResponseB\tZ\a./protob\x06proto3"
+	"\x0eUpdateProgress\x12\x1c.manga.UpdateProgressRequest\x1a\x1d.manga.UpdateProgressResponse\x12K\n" +
+	"\x10MarkChaptersRead\x12\x1a.manga.MarkChaptersRequest\x1a\x1b.manga.MarkChaptersResponse\x12A\n" +
+	"\fGetUserStats\x12\x17.manga.UserStatsRequest\x1a\x18.manga.UserStatsResponseB\tZ\a./protob\x06proto3"
 
 var (
 	file_manga_proto_rawDescOnce sync.Once
@@ -483,7 +946,7 @@ func file_manga_proto_rawDescGZIP() []byte {
 	return file_manga_proto_rawDescData
 }
 
-var file_manga_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_manga_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
 var file_manga_proto_goTypes = []any{
 	(*GetMangaRequest)(nil),        // 0: manga.GetMangaRequest
 	(*MangaResponse)(nil),          // 1: manga.MangaResponse
@@ -491,20 +954,33 @@ var file_manga_proto_goTypes = []any{
 	(*SearchResponse)(nil),         // 3: manga.SearchResponse
 	(*UpdateProgressRequest)(nil),  // 4: manga.UpdateProgressRequest
 	(*UpdateProgressResponse)(nil), // 5: manga.UpdateProgressResponse
+	(*MarkChaptersRequest)(nil),    // 6: manga.MarkChaptersRequest
+	(*MarkChaptersResponse)(nil),   // 7: manga.MarkChaptersResponse
+	(*UserStatsRequest)(nil),       // 8: manga.UserStatsRequest
+	(*StatCount)(nil),              // 9: manga.StatCount
+	(*UserStatsResponse)(nil),      // 10: manga.UserStatsResponse
+	nil,                            // 11: manga.UserStatsResponse.StatusCountsEntry
 }
 var file_manga_proto_depIdxs = []int32{
-	1, // 0: manga.SearchResponse.mangas:type_name -> manga.MangaResponse
-	0, // 1: manga.MangaService.GetManga:input_type -> manga.GetMangaRequest
-	2, // 2: manga.MangaService.SearchManga:input_type -> manga.SearchRequest
-	4, // 3: manga.MangaService.UpdateProgress:input_type -> manga.UpdateProgressRequest
-	1, // 4: manga.MangaService.GetManga:output_type -> manga.MangaResponse
-	3, // 5: manga.MangaService.SearchManga:output_type -> manga.SearchResponse
-	5, // 6: manga.MangaService.UpdateProgress:output_type -> manga.UpdateProgressResponse
-	4, // [4:7] is the sub-list for method output_type
-	1, // [1:4] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	1,  // 0: manga.SearchResponse.mangas:type_name -> manga.MangaResponse
+	9,  // 1: manga.UserStatsResponse.chapters_per_bucket:type_name -> manga.StatCount
+	11, // 2: manga.UserStatsResponse.status_counts:type_name -> manga.UserStatsResponse.StatusCountsEntry
+	9,  // 3: manga.UserStatsResponse.genres:type_name -> manga.StatCount
+	0,  // 4: manga.MangaService.GetManga:input_type -> manga.GetMangaRequest
+	2,  // 5: manga.MangaService.SearchManga:input_type -> manga.SearchRequest
+	4,  // 6: manga.MangaService.UpdateProgress:input_type -> manga.UpdateProgressRequest
+	6,  // 7: manga.MangaService.MarkChaptersRead:input_type -> manga.MarkChaptersRequest
+	8,  // 8: manga.MangaService.GetUserStats:input_type -> manga.UserStatsRequest
+	1,  // 9: manga.MangaService.GetManga:output_type -> manga.MangaResponse
+	3,  // 10: manga.MangaService.SearchManga:output_type -> manga.SearchResponse
+	5,  // 11: manga.MangaService.UpdateProgress:output_type -> manga.UpdateProgressResponse
+	7,  // 12: manga.MangaService.MarkChaptersRead:output_type -> manga.MarkChaptersResponse
+	10, // 13: manga.MangaService.GetUserStats:output_type -> manga.UserStatsResponse
+	9,  // [9:14] is the sub-list for method output_type
+	4,  // [4:9] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_manga_proto_init() }
@@ -518,7 +994,7 @@ func file_manga_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_manga_proto_rawDesc), len(file_manga_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   12,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
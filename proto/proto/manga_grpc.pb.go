@@ -19,9 +19,11 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	MangaService_GetManga_FullMethodName       = "/manga.MangaService/GetManga"
-	MangaService_SearchManga_FullMethodName    = "/manga.MangaService/SearchManga"
-	MangaService_UpdateProgress_FullMethodName = "/manga.MangaService/UpdateProgress"
+	MangaService_GetManga_FullMethodName         = "/manga.MangaService/GetManga"
+	MangaService_SearchManga_FullMethodName      = "/manga.MangaService/SearchManga"
+	MangaService_UpdateProgress_FullMethodName   = "/manga.MangaService/UpdateProgress"
+	MangaService_MarkChaptersRead_FullMethodName = "/manga.MangaService/MarkChaptersRead"
+	MangaService_GetUserStats_FullMethodName     = "/manga.MangaService/GetUserStats"
 )
 
 // MangaServiceClient is the client API for MangaService service.
@@ -33,6 +35,8 @@ type MangaServiceClient interface {
 	GetManga(ctx context.Context, in *GetMangaRequest, opts ...grpc.CallOption) (*MangaResponse, error)
 	SearchManga(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
 	UpdateProgress(ctx context.Context, in *UpdateProgressRequest, opts ...grpc.CallOption) (*UpdateProgressResponse, error)
+	MarkChaptersRead(ctx context.Context, in *MarkChaptersRequest, opts ...grpc.CallOption) (*MarkChaptersResponse, error)
+	GetUserStats(ctx context.Context, in *UserStatsRequest, opts ...grpc.CallOption) (*UserStatsResponse, error)
 }
 
 type mangaServiceClient struct {
@@ -73,6 +77,26 @@ func (c *mangaServiceClient) UpdateProgress(ctx context.Context, in *UpdateProgr
 	return out, nil
 }
 
+func (c *mangaServiceClient) MarkChaptersRead(ctx context.Context, in *MarkChaptersRequest, opts ...grpc.CallOption) (*MarkChaptersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MarkChaptersResponse)
+	err := c.cc.Invoke(ctx, MangaService_MarkChaptersRead_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mangaServiceClient) GetUserStats(ctx context.Context, in *UserStatsRequest, opts ...grpc.CallOption) (*UserStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UserStatsResponse)
+	err := c.cc.Invoke(ctx, MangaService_GetUserStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MangaServiceServer is the server API for MangaService service.
 // All implementations must embed UnimplementedMangaServiceServer
 // for forward compatibility.
@@ -82,6 +106,8 @@ type MangaServiceServer interface {
 	GetManga(context.Context, *GetMangaRequest) (*MangaResponse, error)
 	SearchManga(context.Context, *SearchRequest) (*SearchResponse, error)
 	UpdateProgress(context.Context, *UpdateProgressRequest) (*UpdateProgressResponse, error)
+	MarkChaptersRead(context.Context, *MarkChaptersRequest) (*MarkChaptersResponse, error)
+	GetUserStats(context.Context, *UserStatsRequest) (*UserStatsResponse, error)
 	mustEmbedUnimplementedMangaServiceServer()
 }
 
@@ -101,6 +127,12 @@ func (UnimplementedMangaServiceServer) SearchManga(context.Context, *SearchReque
 func (UnimplementedMangaServiceServer) UpdateProgress(context.Context, *UpdateProgressRequest) (*UpdateProgressResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateProgress not implemented")
 }
+func (UnimplementedMangaServiceServer) MarkChaptersRead(context.Context, *MarkChaptersRequest) (*MarkChaptersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MarkChaptersRead not implemented")
+}
+func (UnimplementedMangaServiceServer) GetUserStats(context.Context, *UserStatsRequest) (*UserStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserStats not implemented")
+}
 func (UnimplementedMangaServiceServer) mustEmbedUnimplementedMangaServiceServer() {}
 func (UnimplementedMangaServiceServer) testEmbeddedByValue()                      {}
 
@@ -176,6 +208,42 @@ func _MangaService_UpdateProgress_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MangaService_MarkChaptersRead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MarkChaptersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MangaServiceServer).MarkChaptersRead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MangaService_MarkChaptersRead_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MangaServiceServer).MarkChaptersRead(ctx, req.(*MarkChaptersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MangaService_GetUserStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MangaServiceServer).GetUserStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MangaService_GetUserStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MangaServiceServer).GetUserStats(ctx, req.(*UserStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // MangaService_ServiceDesc is the grpc.ServiceDesc for MangaService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -195,6 +263,14 @@ var MangaService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateProgress",
 			Handler:    _MangaService_UpdateProgress_Handler,
 		},
+		{
+			MethodName: "MarkChaptersRead",
+			Handler:    _MangaService_MarkChaptersRead_Handler,
+		},
+		{
+			MethodName: "GetUserStats",
+			Handler:    _MangaService_GetUserStats_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "manga.proto",
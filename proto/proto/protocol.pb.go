@@ -0,0 +1,2728 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.33.1
+// source: protocol.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Frame is one message of the compact binary encoding of the MangaHub wire
+// protocol (pkg/protocol). The JSON encoding uses the same field names. No
+// field tag encodes as '{', so the two encodings can't be mistaken.
+type Frame struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Body:
+	//
+	//	*Frame_Hello
+	//	*Frame_Welcome
+	//	*Frame_AuthError
+	//	*Frame_Auth
+	//	*Frame_AuthOk
+	//	*Frame_ReauthRequired
+	//	*Frame_Heartbeat
+	//	*Frame_HeartbeatAck
+	//	*Frame_RequestSnapshot
+	//	*Frame_Snapshot
+	//	*Frame_ProgressUpdate
+	//	*Frame_LibraryUpdate
+	//	*Frame_Ack
+	//	*Frame_ResyncRequired
+	//	*Frame_ReplayComplete
+	//	*Frame_SessionRevoked
+	//	*Frame_AccountDeleted
+	//	*Frame_NowReading
+	//	*Frame_Presence
+	//	*Frame_Register
+	//	*Frame_Registered
+	//	*Frame_Unregister
+	//	*Frame_Unregistered
+	//	*Frame_Ping
+	//	*Frame_Pong
+	//	*Frame_Error
+	//	*Frame_Notification
+	//	*Frame_Chat
+	//	*Frame_History
+	Body          isFrame_Body `protobuf_oneof:"body"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Frame) Reset() {
+	*x = Frame{}
+	mi := &file_protocol_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Frame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Frame) ProtoMessage() {}
+
+func (x *Frame) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Frame.ProtoReflect.Descriptor instead.
+func (*Frame) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Frame) GetBody() isFrame_Body {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *Frame) GetHello() *Hello {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Hello); ok {
+			return x.Hello
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetWelcome() *Welcome {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Welcome); ok {
+			return x.Welcome
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetAuthError() *AuthError {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_AuthError); ok {
+			return x.AuthError
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetAuth() *Auth {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Auth); ok {
+			return x.Auth
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetAuthOk() *AuthOK {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_AuthOk); ok {
+			return x.AuthOk
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetReauthRequired() *ReauthRequired {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_ReauthRequired); ok {
+			return x.ReauthRequired
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetHeartbeat() *Empty {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Heartbeat); ok {
+			return x.Heartbeat
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetHeartbeatAck() *HeartbeatAck {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_HeartbeatAck); ok {
+			return x.HeartbeatAck
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetRequestSnapshot() *RequestSnapshot {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_RequestSnapshot); ok {
+			return x.RequestSnapshot
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetSnapshot() *Snapshot {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Snapshot); ok {
+			return x.Snapshot
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetProgressUpdate() *ProgressUpdate {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_ProgressUpdate); ok {
+			return x.ProgressUpdate
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetLibraryUpdate() *LibraryUpdate {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_LibraryUpdate); ok {
+			return x.LibraryUpdate
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetAck() *Ack {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Ack); ok {
+			return x.Ack
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetResyncRequired() *ResyncRequired {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_ResyncRequired); ok {
+			return x.ResyncRequired
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetReplayComplete() *ReplayComplete {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_ReplayComplete); ok {
+			return x.ReplayComplete
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetSessionRevoked() *Goodbye {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_SessionRevoked); ok {
+			return x.SessionRevoked
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetAccountDeleted() *Goodbye {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_AccountDeleted); ok {
+			return x.AccountDeleted
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetNowReading() *NowReading {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_NowReading); ok {
+			return x.NowReading
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetPresence() *Presence {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Presence); ok {
+			return x.Presence
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetRegister() *Register {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Register); ok {
+			return x.Register
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetRegistered() *Registered {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Registered); ok {
+			return x.Registered
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetUnregister() *Unregister {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Unregister); ok {
+			return x.Unregister
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetUnregistered() *StatusMessage {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Unregistered); ok {
+			return x.Unregistered
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetPing() *Empty {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Ping); ok {
+			return x.Ping
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetPong() *Pong {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Pong); ok {
+			return x.Pong
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetError() *StatusMessage {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetNotification() *Notification {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Notification); ok {
+			return x.Notification
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetChat() *ChatMessage {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_Chat); ok {
+			return x.Chat
+		}
+	}
+	return nil
+}
+
+func (x *Frame) GetHistory() *History {
+	if x != nil {
+		if x, ok := x.Body.(*Frame_History); ok {
+			return x.History
+		}
+	}
+	return nil
+}
+
+type isFrame_Body interface {
+	isFrame_Body()
+}
+
+type Frame_Hello struct {
+	// TCP sync
+	Hello *Hello `protobuf:"bytes,1,opt,name=hello,proto3,oneof"`
+}
+
+type Frame_Welcome struct {
+	Welcome *Welcome `protobuf:"bytes,2,opt,name=welcome,proto3,oneof"`
+}
+
+type Frame_AuthError struct {
+	AuthError *AuthError `protobuf:"bytes,3,opt,name=auth_error,json=authError,proto3,oneof"`
+}
+
+type Frame_Auth struct {
+	Auth *Auth `protobuf:"bytes,4,opt,name=auth,proto3,oneof"`
+}
+
+type Frame_AuthOk struct {
+	AuthOk *AuthOK `protobuf:"bytes,5,opt,name=auth_ok,json=authOk,proto3,oneof"`
+}
+
+type Frame_ReauthRequired struct {
+	ReauthRequired *ReauthRequired `protobuf:"bytes,6,opt,name=reauth_required,json=reauthRequired,proto3,oneof"`
+}
+
+type Frame_Heartbeat struct {
+	Heartbeat *Empty `protobuf:"bytes,7,opt,name=heartbeat,proto3,oneof"`
+}
+
+type Frame_HeartbeatAck struct {
+	HeartbeatAck *HeartbeatAck `protobuf:"bytes,8,opt,name=heartbeat_ack,json=heartbeatAck,proto3,oneof"`
+}
+
+type Frame_RequestSnapshot struct {
+	RequestSnapshot *RequestSnapshot `protobuf:"bytes,9,opt,name=request_snapshot,json=requestSnapshot,proto3,oneof"`
+}
+
+type Frame_Snapshot struct {
+	Snapshot *Snapshot `protobuf:"bytes,10,opt,name=snapshot,proto3,oneof"`
+}
+
+type Frame_ProgressUpdate struct {
+	ProgressUpdate *ProgressUpdate `protobuf:"bytes,11,opt,name=progress_update,json=progressUpdate,proto3,oneof"`
+}
+
+type Frame_LibraryUpdate struct {
+	LibraryUpdate *LibraryUpdate `protobuf:"bytes,12,opt,name=library_update,json=libraryUpdate,proto3,oneof"`
+}
+
+type Frame_Ack struct {
+	Ack *Ack `protobuf:"bytes,13,opt,name=ack,proto3,oneof"`
+}
+
+type Frame_ResyncRequired struct {
+	ResyncRequired *ResyncRequired `protobuf:"bytes,14,opt,name=resync_required,json=resyncRequired,proto3,oneof"`
+}
+
+type Frame_ReplayComplete struct {
+	ReplayComplete *ReplayComplete `protobuf:"bytes,15,opt,name=replay_complete,json=replayComplete,proto3,oneof"`
+}
+
+type Frame_SessionRevoked struct {
+	SessionRevoked *Goodbye `protobuf:"bytes,16,opt,name=session_revoked,json=sessionRevoked,proto3,oneof"`
+}
+
+type Frame_AccountDeleted struct {
+	AccountDeleted *Goodbye `protobuf:"bytes,17,opt,name=account_deleted,json=accountDeleted,proto3,oneof"`
+}
+
+type Frame_NowReading struct {
+	NowReading *NowReading `protobuf:"bytes,28,opt,name=now_reading,json=nowReading,proto3,oneof"`
+}
+
+type Frame_Presence struct {
+	Presence *Presence `protobuf:"bytes,29,opt,name=presence,proto3,oneof"`
+}
+
+type Frame_Register struct {
+	// UDP notifications
+	Register *Register `protobuf:"bytes,18,opt,name=register,proto3,oneof"`
+}
+
+type Frame_Registered struct {
+	Registered *Registered `protobuf:"bytes,19,opt,name=registered,proto3,oneof"`
+}
+
+type Frame_Unregister struct {
+	Unregister *Unregister `protobuf:"bytes,20,opt,name=unregister,proto3,oneof"`
+}
+
+type Frame_Unregistered struct {
+	Unregistered *StatusMessage `protobuf:"bytes,21,opt,name=unregistered,proto3,oneof"`
+}
+
+type Frame_Ping struct {
+	Ping *Empty `protobuf:"bytes,22,opt,name=ping,proto3,oneof"`
+}
+
+type Frame_Pong struct {
+	Pong *Pong `protobuf:"bytes,23,opt,name=pong,proto3,oneof"`
+}
+
+type Frame_Error struct {
+	Error *StatusMessage `protobuf:"bytes,24,opt,name=error,proto3,oneof"`
+}
+
+type Frame_Notification struct {
+	Notification *Notification `protobuf:"bytes,25,opt,name=notification,proto3,oneof"`
+}
+
+type Frame_Chat struct {
+	// WebSocket chat
+	Chat *ChatMessage `protobuf:"bytes,26,opt,name=chat,proto3,oneof"`
+}
+
+type Frame_History struct {
+	History *History `protobuf:"bytes,27,opt,name=history,proto3,oneof"`
+}
+
+func (*Frame_Hello) isFrame_Body() {}
+
+func (*Frame_Welcome) isFrame_Body() {}
+
+func (*Frame_AuthError) isFrame_Body() {}
+
+func (*Frame_Auth) isFrame_Body() {}
+
+func (*Frame_AuthOk) isFrame_Body() {}
+
+func (*Frame_ReauthRequired) isFrame_Body() {}
+
+func (*Frame_Heartbeat) isFrame_Body() {}
+
+func (*Frame_HeartbeatAck) isFrame_Body() {}
+
+func (*Frame_RequestSnapshot) isFrame_Body() {}
+
+func (*Frame_Snapshot) isFrame_Body() {}
+
+func (*Frame_ProgressUpdate) isFrame_Body() {}
+
+func (*Frame_LibraryUpdate) isFrame_Body() {}
+
+func (*Frame_Ack) isFrame_Body() {}
+
+func (*Frame_ResyncRequired) isFrame_Body() {}
+
+func (*Frame_ReplayComplete) isFrame_Body() {}
+
+func (*Frame_SessionRevoked) isFrame_Body() {}
+
+func (*Frame_AccountDeleted) isFrame_Body() {}
+
+func (*Frame_NowReading) isFrame_Body() {}
+
+func (*Frame_Presence) isFrame_Body() {}
+
+func (*Frame_Register) isFrame_Body() {}
+
+func (*Frame_Registered) isFrame_Body() {}
+
+func (*Frame_Unregister) isFrame_Body() {}
+
+func (*Frame_Unregistered) isFrame_Body() {}
+
+func (*Frame_Ping) isFrame_Body() {}
+
+func (*Frame_Pong) isFrame_Body() {}
+
+func (*Frame_Error) isFrame_Body() {}
+
+func (*Frame_Notification) isFrame_Body() {}
+
+func (*Frame_Chat) isFrame_Body() {}
+
+func (*Frame_History) isFrame_Body() {}
+
+type Empty struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Empty) Reset() {
+	*x = Empty{}
+	mi := &file_protocol_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Empty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Empty) ProtoMessage() {}
+
+func (x *Empty) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Empty.ProtoReflect.Descriptor instead.
+func (*Empty) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{1}
+}
+
+type Hello struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	LastSeq       *int64                 `protobuf:"varint,2,opt,name=last_seq,json=lastSeq,proto3,oneof" json:"last_seq,omitempty"`
+	Versions      []int32                `protobuf:"varint,3,rep,packed,name=versions,proto3" json:"versions,omitempty"`
+	Encodings     []string               `protobuf:"bytes,4,rep,name=encodings,proto3" json:"encodings,omitempty"`
+	Snapshot      bool                   `protobuf:"varint,5,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	DeviceName    string                 `protobuf:"bytes,6,opt,name=device_name,json=deviceName,proto3" json:"device_name,omitempty"`
+	DeviceType    string                 `protobuf:"bytes,7,opt,name=device_type,json=deviceType,proto3" json:"device_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Hello) Reset() {
+	*x = Hello{}
+	mi := &file_protocol_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hello) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hello) ProtoMessage() {}
+
+func (x *Hello) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hello.ProtoReflect.Descriptor instead.
+func (*Hello) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Hello) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *Hello) GetLastSeq() int64 {
+	if x != nil && x.LastSeq != nil {
+		return *x.LastSeq
+	}
+	return 0
+}
+
+func (x *Hello) GetVersions() []int32 {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+func (x *Hello) GetEncodings() []string {
+	if x != nil {
+		return x.Encodings
+	}
+	return nil
+}
+
+func (x *Hello) GetSnapshot() bool {
+	if x != nil {
+		return x.Snapshot
+	}
+	return false
+}
+
+func (x *Hello) GetDeviceName() string {
+	if x != nil {
+		return x.DeviceName
+	}
+	return ""
+}
+
+func (x *Hello) GetDeviceType() string {
+	if x != nil {
+		return x.DeviceType
+	}
+	return ""
+}
+
+type Welcome struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	ClientId      string                 `protobuf:"bytes,3,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	Seq           int64                  `protobuf:"varint,6,opt,name=seq,proto3" json:"seq,omitempty"`
+	Version       int32                  `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	Encoding      string                 `protobuf:"bytes,8,opt,name=encoding,proto3" json:"encoding,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Welcome) Reset() {
+	*x = Welcome{}
+	mi := &file_protocol_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Welcome) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Welcome) ProtoMessage() {}
+
+func (x *Welcome) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Welcome.ProtoReflect.Descriptor instead.
+func (*Welcome) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Welcome) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Welcome) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Welcome) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *Welcome) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Welcome) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *Welcome) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Welcome) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Welcome) GetEncoding() string {
+	if x != nil {
+		return x.Encoding
+	}
+	return ""
+}
+
+type AuthError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Code          string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthError) Reset() {
+	*x = AuthError{}
+	mi := &file_protocol_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthError) ProtoMessage() {}
+
+func (x *AuthError) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthError.ProtoReflect.Descriptor instead.
+func (*AuthError) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AuthError) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *AuthError) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *AuthError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type Auth struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Auth) Reset() {
+	*x = Auth{}
+	mi := &file_protocol_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Auth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Auth) ProtoMessage() {}
+
+func (x *Auth) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Auth.ProtoReflect.Descriptor instead.
+func (*Auth) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Auth) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type AuthOK struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthOK) Reset() {
+	*x = AuthOK{}
+	mi := &file_protocol_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthOK) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthOK) ProtoMessage() {}
+
+func (x *AuthOK) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthOK.ProtoReflect.Descriptor instead.
+func (*AuthOK) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AuthOK) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type ReauthRequired struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReauthRequired) Reset() {
+	*x = ReauthRequired{}
+	mi := &file_protocol_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReauthRequired) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReauthRequired) ProtoMessage() {}
+
+func (x *ReauthRequired) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReauthRequired.ProtoReflect.Descriptor instead.
+func (*ReauthRequired) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ReauthRequired) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ReauthRequired) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type HeartbeatAck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartbeatAck) Reset() {
+	*x = HeartbeatAck{}
+	mi := &file_protocol_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatAck) ProtoMessage() {}
+
+func (x *HeartbeatAck) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatAck.ProtoReflect.Descriptor instead.
+func (*HeartbeatAck) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *HeartbeatAck) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type RequestSnapshot struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestSnapshot) Reset() {
+	*x = RequestSnapshot{}
+	mi := &file_protocol_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestSnapshot) ProtoMessage() {}
+
+func (x *RequestSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestSnapshot.ProtoReflect.Descriptor instead.
+func (*RequestSnapshot) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RequestSnapshot) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type LibraryEntry struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	MangaId        string                 `protobuf:"bytes,2,opt,name=manga_id,json=mangaId,proto3" json:"manga_id,omitempty"`
+	CurrentChapter int32                  `protobuf:"varint,3,opt,name=current_chapter,json=currentChapter,proto3" json:"current_chapter,omitempty"`
+	ReadChapters   string                 `protobuf:"bytes,4,opt,name=read_chapters,json=readChapters,proto3" json:"read_chapters,omitempty"`
+	Status         string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	Rating         int32                  `protobuf:"varint,6,opt,name=rating,proto3" json:"rating,omitempty"`
+	UpdatedAt      *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	StartedAt      *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	CompletedAt    *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *LibraryEntry) Reset() {
+	*x = LibraryEntry{}
+	mi := &file_protocol_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LibraryEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LibraryEntry) ProtoMessage() {}
+
+func (x *LibraryEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LibraryEntry.ProtoReflect.Descriptor instead.
+func (*LibraryEntry) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *LibraryEntry) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *LibraryEntry) GetMangaId() string {
+	if x != nil {
+		return x.MangaId
+	}
+	return ""
+}
+
+func (x *LibraryEntry) GetCurrentChapter() int32 {
+	if x != nil {
+		return x.CurrentChapter
+	}
+	return 0
+}
+
+func (x *LibraryEntry) GetReadChapters() string {
+	if x != nil {
+		return x.ReadChapters
+	}
+	return ""
+}
+
+func (x *LibraryEntry) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *LibraryEntry) GetRating() int32 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+func (x *LibraryEntry) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *LibraryEntry) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *LibraryEntry) GetCompletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompletedAt
+	}
+	return nil
+}
+
+type Snapshot struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Seq           int64                  `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
+	Library       []*LibraryEntry        `protobuf:"bytes,3,rep,name=library,proto3" json:"library,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Snapshot) Reset() {
+	*x = Snapshot{}
+	mi := &file_protocol_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Snapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Snapshot) ProtoMessage() {}
+
+func (x *Snapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Snapshot.ProtoReflect.Descriptor instead.
+func (*Snapshot) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Snapshot) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Snapshot) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Snapshot) GetLibrary() []*LibraryEntry {
+	if x != nil {
+		return x.Library
+	}
+	return nil
+}
+
+func (x *Snapshot) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type ProgressUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	MangaId       string                 `protobuf:"bytes,3,opt,name=manga_id,json=mangaId,proto3" json:"manga_id,omitempty"`
+	Chapter       int32                  `protobuf:"varint,4,opt,name=chapter,proto3" json:"chapter,omitempty"`
+	Page          *int32                 `protobuf:"varint,5,opt,name=page,proto3,oneof" json:"page,omitempty"`
+	ScrollPercent *float64               `protobuf:"fixed64,6,opt,name=scroll_percent,json=scrollPercent,proto3,oneof" json:"scroll_percent,omitempty"`
+	SessionId     string                 `protobuf:"bytes,7,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Seq           int64                  `protobuf:"varint,9,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProgressUpdate) Reset() {
+	*x = ProgressUpdate{}
+	mi := &file_protocol_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProgressUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProgressUpdate) ProtoMessage() {}
+
+func (x *ProgressUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProgressUpdate.ProtoReflect.Descriptor instead.
+func (*ProgressUpdate) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ProgressUpdate) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ProgressUpdate) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ProgressUpdate) GetMangaId() string {
+	if x != nil {
+		return x.MangaId
+	}
+	return ""
+}
+
+func (x *ProgressUpdate) GetChapter() int32 {
+	if x != nil {
+		return x.Chapter
+	}
+	return 0
+}
+
+func (x *ProgressUpdate) GetPage() int32 {
+	if x != nil && x.Page != nil {
+		return *x.Page
+	}
+	return 0
+}
+
+func (x *ProgressUpdate) GetScrollPercent() float64 {
+	if x != nil && x.ScrollPercent != nil {
+		return *x.ScrollPercent
+	}
+	return 0
+}
+
+func (x *ProgressUpdate) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ProgressUpdate) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *ProgressUpdate) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type LibraryUpdate struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	MangaId        string                 `protobuf:"bytes,2,opt,name=manga_id,json=mangaId,proto3" json:"manga_id,omitempty"`
+	Status         string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Rating         *int32                 `protobuf:"varint,4,opt,name=rating,proto3,oneof" json:"rating,omitempty"`
+	CurrentChapter *int32                 `protobuf:"varint,5,opt,name=current_chapter,json=currentChapter,proto3,oneof" json:"current_chapter,omitempty"`
+	ReadChapters   string                 `protobuf:"bytes,6,opt,name=read_chapters,json=readChapters,proto3" json:"read_chapters,omitempty"`
+	Removed        bool                   `protobuf:"varint,7,opt,name=removed,proto3" json:"removed,omitempty"`
+	Timestamp      int64                  `protobuf:"varint,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Seq            int64                  `protobuf:"varint,9,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *LibraryUpdate) Reset() {
+	*x = LibraryUpdate{}
+	mi := &file_protocol_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LibraryUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LibraryUpdate) ProtoMessage() {}
+
+func (x *LibraryUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LibraryUpdate.ProtoReflect.Descriptor instead.
+func (*LibraryUpdate) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *LibraryUpdate) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *LibraryUpdate) GetMangaId() string {
+	if x != nil {
+		return x.MangaId
+	}
+	return ""
+}
+
+func (x *LibraryUpdate) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *LibraryUpdate) GetRating() int32 {
+	if x != nil && x.Rating != nil {
+		return *x.Rating
+	}
+	return 0
+}
+
+func (x *LibraryUpdate) GetCurrentChapter() int32 {
+	if x != nil && x.CurrentChapter != nil {
+		return *x.CurrentChapter
+	}
+	return 0
+}
+
+func (x *LibraryUpdate) GetReadChapters() string {
+	if x != nil {
+		return x.ReadChapters
+	}
+	return ""
+}
+
+func (x *LibraryUpdate) GetRemoved() bool {
+	if x != nil {
+		return x.Removed
+	}
+	return false
+}
+
+func (x *LibraryUpdate) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *LibraryUpdate) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type Ack struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Seq           int64                  `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
+	Code          string                 `protobuf:"bytes,4,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string                 `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ack) Reset() {
+	*x = Ack{}
+	mi := &file_protocol_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ack) ProtoMessage() {}
+
+func (x *Ack) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ack.ProtoReflect.Descriptor instead.
+func (*Ack) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Ack) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Ack) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Ack) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Ack) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Ack) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ResyncRequired struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           int64                  `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResyncRequired) Reset() {
+	*x = ResyncRequired{}
+	mi := &file_protocol_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResyncRequired) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResyncRequired) ProtoMessage() {}
+
+func (x *ResyncRequired) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResyncRequired.ProtoReflect.Descriptor instead.
+func (*ResyncRequired) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ResyncRequired) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *ResyncRequired) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ReplayComplete struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           int64                  `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplayComplete) Reset() {
+	*x = ReplayComplete{}
+	mi := &file_protocol_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplayComplete) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplayComplete) ProtoMessage() {}
+
+func (x *ReplayComplete) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplayComplete.ProtoReflect.Descriptor instead.
+func (*ReplayComplete) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ReplayComplete) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *ReplayComplete) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type Goodbye struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Goodbye) Reset() {
+	*x = Goodbye{}
+	mi := &file_protocol_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Goodbye) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Goodbye) ProtoMessage() {}
+
+func (x *Goodbye) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Goodbye.ProtoReflect.Descriptor instead.
+func (*Goodbye) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Goodbye) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Goodbye) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type NowReading struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	MangaId       string                 `protobuf:"bytes,2,opt,name=manga_id,json=mangaId,proto3" json:"manga_id,omitempty"`
+	Chapter       int32                  `protobuf:"varint,3,opt,name=chapter,proto3" json:"chapter,omitempty"`
+	Page          int32                  `protobuf:"varint,4,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NowReading) Reset() {
+	*x = NowReading{}
+	mi := &file_protocol_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NowReading) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NowReading) ProtoMessage() {}
+
+func (x *NowReading) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NowReading.ProtoReflect.Descriptor instead.
+func (*NowReading) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *NowReading) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *NowReading) GetMangaId() string {
+	if x != nil {
+		return x.MangaId
+	}
+	return ""
+}
+
+func (x *NowReading) GetChapter() int32 {
+	if x != nil {
+		return x.Chapter
+	}
+	return 0
+}
+
+func (x *NowReading) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+type Presence struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClientId      string                 `protobuf:"bytes,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	SessionId     string                 `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	DeviceName    string                 `protobuf:"bytes,3,opt,name=device_name,json=deviceName,proto3" json:"device_name,omitempty"`
+	DeviceType    string                 `protobuf:"bytes,4,opt,name=device_type,json=deviceType,proto3" json:"device_type,omitempty"`
+	Status        string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	MangaId       string                 `protobuf:"bytes,6,opt,name=manga_id,json=mangaId,proto3" json:"manga_id,omitempty"`
+	MangaTitle    string                 `protobuf:"bytes,7,opt,name=manga_title,json=mangaTitle,proto3" json:"manga_title,omitempty"`
+	Chapter       int32                  `protobuf:"varint,8,opt,name=chapter,proto3" json:"chapter,omitempty"`
+	Page          int32                  `protobuf:"varint,9,opt,name=page,proto3" json:"page,omitempty"`
+	ConnectedAt   int64                  `protobuf:"varint,10,opt,name=connected_at,json=connectedAt,proto3" json:"connected_at,omitempty"`
+	LastSeen      int64                  `protobuf:"varint,11,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Presence) Reset() {
+	*x = Presence{}
+	mi := &file_protocol_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Presence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Presence) ProtoMessage() {}
+
+func (x *Presence) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Presence.ProtoReflect.Descriptor instead.
+func (*Presence) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *Presence) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *Presence) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *Presence) GetDeviceName() string {
+	if x != nil {
+		return x.DeviceName
+	}
+	return ""
+}
+
+func (x *Presence) GetDeviceType() string {
+	if x != nil {
+		return x.DeviceType
+	}
+	return ""
+}
+
+func (x *Presence) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Presence) GetMangaId() string {
+	if x != nil {
+		return x.MangaId
+	}
+	return ""
+}
+
+func (x *Presence) GetMangaTitle() string {
+	if x != nil {
+		return x.MangaTitle
+	}
+	return ""
+}
+
+func (x *Presence) GetChapter() int32 {
+	if x != nil {
+		return x.Chapter
+	}
+	return 0
+}
+
+func (x *Presence) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *Presence) GetConnectedAt() int64 {
+	if x != nil {
+		return x.ConnectedAt
+	}
+	return 0
+}
+
+func (x *Presence) GetLastSeen() int64 {
+	if x != nil {
+		return x.LastSeen
+	}
+	return 0
+}
+
+type Register struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Token         string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	Preferences   map[string]bool        `protobuf:"bytes,3,rep,name=preferences,proto3" json:"preferences,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Versions      []int32                `protobuf:"varint,4,rep,packed,name=versions,proto3" json:"versions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Register) Reset() {
+	*x = Register{}
+	mi := &file_protocol_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Register) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Register) ProtoMessage() {}
+
+func (x *Register) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Register.ProtoReflect.Descriptor instead.
+func (*Register) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Register) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Register) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *Register) GetPreferences() map[string]bool {
+	if x != nil {
+		return x.Preferences
+	}
+	return nil
+}
+
+func (x *Register) GetVersions() []int32 {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+type Registered struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Preferences   map[string]bool        `protobuf:"bytes,3,rep,name=preferences,proto3" json:"preferences,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Version       int32                  `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Registered) Reset() {
+	*x = Registered{}
+	mi := &file_protocol_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Registered) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Registered) ProtoMessage() {}
+
+func (x *Registered) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Registered.ProtoReflect.Descriptor instead.
+func (*Registered) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Registered) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Registered) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Registered) GetPreferences() map[string]bool {
+	if x != nil {
+		return x.Preferences
+	}
+	return nil
+}
+
+func (x *Registered) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Registered) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type Unregister struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Unregister) Reset() {
+	*x = Unregister{}
+	mi := &file_protocol_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Unregister) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Unregister) ProtoMessage() {}
+
+func (x *Unregister) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Unregister.ProtoReflect.Descriptor instead.
+func (*Unregister) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *Unregister) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type StatusMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusMessage) Reset() {
+	*x = StatusMessage{}
+	mi := &file_protocol_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusMessage) ProtoMessage() {}
+
+func (x *StatusMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusMessage.ProtoReflect.Descriptor instead.
+func (*StatusMessage) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *StatusMessage) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *StatusMessage) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type Pong struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Pong) Reset() {
+	*x = Pong{}
+	mi := &file_protocol_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Pong) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pong) ProtoMessage() {}
+
+func (x *Pong) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pong.ProtoReflect.Descriptor instead.
+func (*Pong) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *Pong) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type Notification struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	MangaId       string                 `protobuf:"bytes,4,opt,name=manga_id,json=mangaId,proto3" json:"manga_id,omitempty"`
+	MangaTitle    string                 `protobuf:"bytes,5,opt,name=manga_title,json=mangaTitle,proto3" json:"manga_title,omitempty"`
+	Chapter       int32                  `protobuf:"varint,6,opt,name=chapter,proto3" json:"chapter,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Notification) Reset() {
+	*x = Notification{}
+	mi := &file_protocol_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Notification) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Notification) ProtoMessage() {}
+
+func (x *Notification) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Notification.ProtoReflect.Descriptor instead.
+func (*Notification) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *Notification) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Notification) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Notification) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Notification) GetMangaId() string {
+	if x != nil {
+		return x.MangaId
+	}
+	return ""
+}
+
+func (x *Notification) GetMangaTitle() string {
+	if x != nil {
+		return x.MangaTitle
+	}
+	return ""
+}
+
+func (x *Notification) GetChapter() int32 {
+	if x != nil {
+		return x.Chapter
+	}
+	return 0
+}
+
+func (x *Notification) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type ChatMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	Room          string                 `protobuf:"bytes,2,opt,name=room,proto3" json:"room,omitempty"`
+	Username      string                 `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	Text          string                 `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	Time          string                 `protobuf:"bytes,5,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatMessage) Reset() {
+	*x = ChatMessage{}
+	mi := &file_protocol_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatMessage) ProtoMessage() {}
+
+func (x *ChatMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatMessage.ProtoReflect.Descriptor instead.
+func (*ChatMessage) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ChatMessage) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetRoom() string {
+	if x != nil {
+		return x.Room
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+type History struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Messages      []*ChatMessage         `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *History) Reset() {
+	*x = History{}
+	mi := &file_protocol_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *History) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*History) ProtoMessage() {}
+
+func (x *History) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use History.ProtoReflect.Descriptor instead.
+func (*History) Descriptor() ([]byte, []int) {
+	return file_protocol_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *History) GetMessages() []*ChatMessage {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+var File_protocol_proto protoreflect.FileDescriptor
+
+const file_protocol_proto_rawDesc = "" +
+	"\n" +
+	"\x0eprotocol.proto\x12\bprotocol\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb3\f\n" +
+	"\x05Frame\x12'\n" +
+	"\x05hello\x18\x01 \x01(\v2\x0f.protocol.HelloH\x00R\x05hello\x12-\n" +
+	"\awelcome\x18\x02 \x01(\v2\x11.protocol.WelcomeH\x00R\awelcome\x124\n" +
+	"\n" +
+	"auth_error\x18\x03 \x01(\v2\x13.protocol.AuthErrorH\x00R\tauthError\x12$\n" +
+	"\x04auth\x18\x04 \x01(\v2\x0e.protocol.AuthH\x00R\x04auth\x12+\n" +
+	"\aauth_ok\x18\x05 \x01(\v2\x10.protocol.AuthOKH\x00R\x06authOk\x12C\n" +
+	"\x0freauth_required\x18\x06 \x01(\v2\x18.protocol.ReauthRequiredH\x00R\x0ereauthRequired\x12/\n" +
+	"\theartbeat\x18\a \x01(\v2\x0f.protocol.EmptyH\x00R\theartbeat\x12=\n" +
+	"\rheartbeat_ack\x18\b \x01(\v2\x16.protocol.HeartbeatAckH\x00R\fheartbeatAck\x12F\n" +
+	"\x10request_snapshot\x18\t \x01(\v2\x19.protocol.RequestSnapshotH\x00R\x0frequestSnapshot\x120\n" +
+	"\bsnapshot\x18\n" +
+	" \x01(\v2\x12.protocol.SnapshotH\x00R\bsnapshot\x12C\n" +
+	"\x0fprogress_update\x18\v \x01(\v2\x18.protocol.ProgressUpdateH\x00R\x0eprogressUpdate\x12@\n" +
+	"\x0elibrary_update\x18\f \x01(\v2\x17.protocol.LibraryUpdateH\x00R\rlibraryUpdate\x12!\n" +
+	"\x03ack\x18\r \x01(\v2\r.protocol.AckH\x00R\x03ack\x12C\n" +
+	"\x0fresync_required\x18\x0e \x01(\v2\x18.protocol.ResyncRequiredH\x00R\x0eresyncRequired\x12C\n" +
+	"\x0freplay_complete\x18\x0f \x01(\v2\x18.protocol.ReplayCompleteH\x00R\x0ereplayComplete\x12<\n" +
+	"\x0fsession_revoked\x18\x10 \x01(\v2\x11.protocol.GoodbyeH\x00R\x0esessionRevoked\x12<\n" +
+	"\x0faccount_deleted\x18\x11 \x01(\v2\x11.protocol.GoodbyeH\x00R\x0eaccountDeleted\x127\n" +
+	"\vnow_reading\x18\x1c \x01(\v2\x14.protocol.NowReadingH\x00R\n" +
+	"nowReading\x120\n" +
+	"\bpresence\x18\x1d \x01(\v2\x12.protocol.PresenceH\x00R\bpresence\x120\n" +
+	"\bregister\x18\x12 \x01(\v2\x12.protocol.RegisterH\x00R\bregister\x126\n" +
+	"\n" +
+	"registered\x18\x13 \x01(\v2\x14.protocol.RegisteredH\x00R\n" +
+	"registered\x126\n" +
+	"\n" +
+	"unregister\x18\x14 \x01(\v2\x14.protocol.UnregisterH\x00R\n" +
+	"unregister\x12=\n" +
+	"\funregistered\x18\x15 \x01(\v2\x17.protocol.StatusMessageH\x00R\funregistered\x12%\n" +
+	"\x04ping\x18\x16 \x01(\v2\x0f.protocol.EmptyH\x00R\x04ping\x12$\n" +
+	"\x04pong\x18\x17 \x01(\v2\x0e.protocol.PongH\x00R\x04pong\x12/\n" +
+	"\x05error\x18\x18 \x01(\v2\x17.protocol.StatusMessageH\x00R\x05error\x12<\n" +
+	"\fnotification\x18\x19 \x01(\v2\x16.protocol.NotificationH\x00R\fnotification\x12+\n" +
+	"\x04chat\x18\x1a \x01(\v2\x15.protocol.ChatMessageH\x00R\x04chat\x12-\n" +
+	"\ahistory\x18\x1b \x01(\v2\x11.protocol.HistoryH\x00R\ahistoryB\x06\n" +
+	"\x04body\"\a\n" +
+	"\x05Empty\"\xe2\x01\n" +
+	"\x05Hello\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x1e\n" +
+	"\blast_seq\x18\x02 \x01(\x03H\x00R\alastSeq\x88\x01\x01\x12\x1a\n" +
+	"\bversions\x18\x03 \x03(\x05R\bversions\x12\x1c\n" +
+	"\tencodings\x18\x04 \x03(\tR\tencodings\x12\x1a\n" +
+	"\bsnapshot\x18\x05 \x01(\bR\bsnapshot\x12\x1f\n" +
+	"\vdevice_name\x18\x06 \x01(\tR\n" +
+	"deviceName\x12\x1f\n" +
+	"\vdevice_type\x18\a \x01(\tR\n" +
+	"deviceTypeB\v\n" +
+	"\t_last_seq\"\xf4\x01\n" +
+	"\aWelcome\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1b\n" +
+	"\tclient_id\x18\x03 \x01(\tR\bclientId\x12\x17\n" +
+	"\auser_id\x18\x04 \x01(\tR\x06userId\x129\n" +
+	"\n" +
+	"expires_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x10\n" +
+	"\x03seq\x18\x06 \x01(\x03R\x03seq\x12\x18\n" +
+	"\aversion\x18\a \x01(\x05R\aversion\x12\x1a\n" +
+	"\bencoding\x18\b \x01(\tR\bencoding\"Q\n" +
+	"\tAuthError\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"\x1c\n" +
+	"\x04Auth\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\"C\n" +
+	"\x06AuthOK\x129\n" +
+	"\n" +
+	"expires_at\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"e\n" +
+	"\x0eReauthRequired\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x129\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\",\n" +
+	"\fHeartbeatAck\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\"!\n" +
+	"\x0fRequestSnapshot\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xf5\x02\n" +
+	"\fLibraryEntry\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bmanga_id\x18\x02 \x01(\tR\amangaId\x12'\n" +
+	"\x0fcurrent_chapter\x18\x03 \x01(\x05R\x0ecurrentChapter\x12#\n" +
+	"\rread_chapters\x18\x04 \x01(\tR\freadChapters\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12\x16\n" +
+	"\x06rating\x18\x06 \x01(\x05R\x06rating\x129\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x129\n" +
+	"\n" +
+	"started_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12=\n" +
+	"\fcompleted_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\vcompletedAt\"|\n" +
+	"\bSnapshot\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
+	"\x03seq\x18\x02 \x01(\x03R\x03seq\x120\n" +
+	"\alibrary\x18\x03 \x03(\v2\x16.protocol.LibraryEntryR\alibrary\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\"\x9e\x02\n" +
+	"\x0eProgressUpdate\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x19\n" +
+	"\bmanga_id\x18\x03 \x01(\tR\amangaId\x12\x18\n" +
+	"\achapter\x18\x04 \x01(\x05R\achapter\x12\x17\n" +
+	"\x04page\x18\x05 \x01(\x05H\x00R\x04page\x88\x01\x01\x12*\n" +
+	"\x0escroll_percent\x18\x06 \x01(\x01H\x01R\rscrollPercent\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\a \x01(\tR\tsessionId\x12\x1c\n" +
+	"\ttimestamp\x18\b \x01(\x03R\ttimestamp\x12\x10\n" +
+	"\x03seq\x18\t \x01(\x03R\x03seqB\a\n" +
+	"\x05_pageB\x11\n" +
+	"\x0f_scroll_percent\"\xab\x02\n" +
+	"\rLibraryUpdate\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x19\n" +
+	"\bmanga_id\x18\x02 \x01(\tR\amangaId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1b\n" +
+	"\x06rating\x18\x04 \x01(\x05H\x00R\x06rating\x88\x01\x01\x12,\n" +
+	"\x0fcurrent_chapter\x18\x05 \x01(\x05H\x01R\x0ecurrentChapter\x88\x01\x01\x12#\n" +
+	"\rread_chapters\x18\x06 \x01(\tR\freadChapters\x12\x18\n" +
+	"\aremoved\x18\a \x01(\bR\aremoved\x12\x1c\n" +
+	"\ttimestamp\x18\b \x01(\x03R\ttimestamp\x12\x10\n" +
+	"\x03seq\x18\t \x01(\x03R\x03seqB\t\n" +
+	"\a_ratingB\x12\n" +
+	"\x10_current_chapter\"m\n" +
+	"\x03Ack\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x10\n" +
+	"\x03seq\x18\x03 \x01(\x03R\x03seq\x12\x12\n" +
+	"\x04code\x18\x04 \x01(\tR\x04code\x12\x18\n" +
+	"\amessage\x18\x05 \x01(\tR\amessage\"<\n" +
+	"\x0eResyncRequired\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x03R\x03seq\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"8\n" +
+	"\x0eReplayComplete\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x03R\x03seq\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\"A\n" +
+	"\aGoodbye\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\"e\n" +
+	"\n" +
+	"NowReading\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x19\n" +
+	"\bmanga_id\x18\x02 \x01(\tR\amangaId\x12\x18\n" +
+	"\achapter\x18\x03 \x01(\x05R\achapter\x12\x12\n" +
+	"\x04page\x18\x04 \x01(\x05R\x04page\"\xca\x02\n" +
+	"\bPresence\x12\x1b\n" +
+	"\tclient_id\x18\x01 \x01(\tR\bclientId\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionId\x12\x1f\n" +
+	"\vdevice_name\x18\x03 \x01(\tR\n" +
+	"deviceName\x12\x1f\n" +
+	"\vdevice_type\x18\x04 \x01(\tR\n" +
+	"deviceType\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12\x19\n" +
+	"\bmanga_id\x18\x06 \x01(\tR\amangaId\x12\x1f\n" +
+	"\vmanga_title\x18\a \x01(\tR\n" +
+	"mangaTitle\x12\x18\n" +
+	"\achapter\x18\b \x01(\x05R\achapter\x12\x12\n" +
+	"\x04page\x18\t \x01(\x05R\x04page\x12!\n" +
+	"\fconnected_at\x18\n" +
+	" \x01(\x03R\vconnectedAt\x12\x1b\n" +
+	"\tlast_seen\x18\v \x01(\x03R\blastSeen\"\xdc\x01\n" +
+	"\bRegister\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12E\n" +
+	"\vpreferences\x18\x03 \x03(\v2#.protocol.Register.PreferencesEntryR\vpreferences\x12\x1a\n" +
+	"\bversions\x18\x04 \x03(\x05R\bversions\x1a>\n" +
+	"\x10PreferencesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\bR\x05value:\x028\x01\"\xff\x01\n" +
+	"\n" +
+	"Registered\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12G\n" +
+	"\vpreferences\x18\x03 \x03(\v2%.protocol.Registered.PreferencesEntryR\vpreferences\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x05R\aversion\x12\x1c\n" +
+	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp\x1a>\n" +
+	"\x10PreferencesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\bR\x05value:\x028\x01\"%\n" +
+	"\n" +
+	"Unregister\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"A\n" +
+	"\rStatusMessage\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"$\n" +
+	"\x04Pong\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\"\xc6\x01\n" +
+	"\fNotification\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x19\n" +
+	"\bmanga_id\x18\x04 \x01(\tR\amangaId\x12\x1f\n" +
+	"\vmanga_title\x18\x05 \x01(\tR\n" +
+	"mangaTitle\x12\x18\n" +
+	"\achapter\x18\x06 \x01(\x05R\achapter\x12\x1c\n" +
+	"\ttimestamp\x18\a \x01(\x03R\ttimestamp\"y\n" +
+	"\vChatMessage\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x12\n" +
+	"\x04room\x18\x02 \x01(\tR\x04room\x12\x1a\n" +
+	"\busername\x18\x03 \x01(\tR\busername\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\tR\x04text\x12\x12\n" +
+	"\x04time\x18\x05 \x01(\tR\x04time\"<\n" +
+	"\aHistory\x121\n" +
+	"\bmessages\x18\x01 \x03(\v2\x15.protocol.ChatMessageR\bmessagesB\tZ\a./protob\x06proto3"
+
+var (
+	file_protocol_proto_rawDescOnce sync.Once
+	file_protocol_proto_rawDescData []byte
+)
+
+func file_protocol_proto_rawDescGZIP() []byte {
+	file_protocol_proto_rawDescOnce.Do(func() {
+		file_protocol_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_protocol_proto_rawDesc), len(file_protocol_proto_rawDesc)))
+	})
+	return file_protocol_proto_rawDescData
+}
+
+var file_protocol_proto_msgTypes = make([]protoimpl.MessageInfo, 30)
+var file_protocol_proto_goTypes = []any{
+	(*Frame)(nil),                 // 0: protocol.Frame
+	(*Empty)(nil),                 // 1: protocol.Empty
+	(*Hello)(nil),                 // 2: protocol.Hello
+	(*Welcome)(nil),               // 3: protocol.Welcome
+	(*AuthError)(nil),             // 4: protocol.AuthError
+	(*Auth)(nil),                  // 5: protocol.Auth
+	(*AuthOK)(nil),                // 6: protocol.AuthOK
+	(*ReauthRequired)(nil),        // 7: protocol.ReauthRequired
+	(*HeartbeatAck)(nil),          // 8: protocol.HeartbeatAck
+	(*RequestSnapshot)(nil),       // 9: protocol.RequestSnapshot
+	(*LibraryEntry)(nil),          // 10: protocol.LibraryEntry
+	(*Snapshot)(nil),              // 11: protocol.Snapshot
+	(*ProgressUpdate)(nil),        // 12: protocol.ProgressUpdate
+	(*LibraryUpdate)(nil),         // 13: protocol.LibraryUpdate
+	(*Ack)(nil),                   // 14: protocol.Ack
+	(*ResyncRequired)(nil),        // 15: protocol.ResyncRequired
+	(*ReplayComplete)(nil),        // 16: protocol.ReplayComplete
+	(*Goodbye)(nil),               // 17: protocol.Goodbye
+	(*NowReading)(nil),            // 18: protocol.NowReading
+	(*Presence)(nil),              // 19: protocol.Presence
+	(*Register)(nil),              // 20: protocol.Register
+	(*Registered)(nil),            // 21: protocol.Registered
+	(*Unregister)(nil),            // 22: protocol.Unregister
+	(*StatusMessage)(nil),         // 23: protocol.StatusMessage
+	(*Pong)(nil),                  // 24: protocol.Pong
+	(*Notification)(nil),          // 25: protocol.Notification
+	(*ChatMessage)(nil),           // 26: protocol.ChatMessage
+	(*History)(nil),               // 27: protocol.History
+	nil,                           // 28: protocol.Register.PreferencesEntry
+	nil,                           // 29: protocol.Registered.PreferencesEntry
+	(*timestamppb.Timestamp)(nil), // 30: google.protobuf.Timestamp
+}
+var file_protocol_proto_depIdxs = []int32{
+	2,  // 0: protocol.Frame.hello:type_name -> protocol.Hello
+	3,  // 1: protocol.Frame.welcome:type_name -> protocol.Welcome
+	4,  // 2: protocol.Frame.auth_error:type_name -> protocol.AuthError
+	5,  // 3: protocol.Frame.auth:type_name -> protocol.Auth
+	6,  // 4: protocol.Frame.auth_ok:type_name -> protocol.AuthOK
+	7,  // 5: protocol.Frame.reauth_required:type_name -> protocol.ReauthRequired
+	1,  // 6: protocol.Frame.heartbeat:type_name -> protocol.Empty
+	8,  // 7: protocol.Frame.heartbeat_ack:type_name -> protocol.HeartbeatAck
+	9,  // 8: protocol.Frame.request_snapshot:type_name -> protocol.RequestSnapshot
+	11, // 9: protocol.Frame.snapshot:type_name -> protocol.Snapshot
+	12, // 10: protocol.Frame.progress_update:type_name -> protocol.ProgressUpdate
+	13, // 11: protocol.Frame.library_update:type_name -> protocol.LibraryUpdate
+	14, // 12: protocol.Frame.ack:type_name -> protocol.Ack
+	15, // 13: protocol.Frame.resync_required:type_name -> protocol.ResyncRequired
+	16, // 14: protocol.Frame.replay_complete:type_name -> protocol.ReplayComplete
+	17, // 15: protocol.Frame.session_revoked:type_name -> protocol.Goodbye
+	17, // 16: protocol.Frame.account_deleted:type_name -> protocol.Goodbye
+	18, // 17: protocol.Frame.now_reading:type_name -> protocol.NowReading
+	19, // 18: protocol.Frame.presence:type_name -> protocol.Presence
+	20, // 19: protocol.Frame.register:type_name -> protocol.Register
+	21, // 20: protocol.Frame.registered:type_name -> protocol.Registered
+	22, // 21: protocol.Frame.unregister:type_name -> protocol.Unregister
+	23, // 22: protocol.Frame.unregistered:type_name -> protocol.StatusMessage
+	1,  // 23: protocol.Frame.ping:type_name -> protocol.Empty
+	24, // 24: protocol.Frame.pong:type_name -> protocol.Pong
+	23, // 25: protocol.Frame.error:type_name -> protocol.StatusMessage
+	25, // 26: protocol.Frame.notification:type_name -> protocol.Notification
+	26, // 27: protocol.Frame.chat:type_name -> protocol.ChatMessage
+	27, // 28: protocol.Frame.history:type_name -> protocol.History
+	30, // 29: protocol.Welcome.expires_at:type_name -> google.protobuf.Timestamp
+	30, // 30: protocol.AuthOK.expires_at:type_name -> google.protobuf.Timestamp
+	30, // 31: protocol.ReauthRequired.expires_at:type_name -> google.protobuf.Timestamp
+	30, // 32: protocol.LibraryEntry.updated_at:type_name -> google.protobuf.Timestamp
+	30, // 33: protocol.LibraryEntry.started_at:type_name -> google.protobuf.Timestamp
+	30, // 34: protocol.LibraryEntry.completed_at:type_name -> google.protobuf.Timestamp
+	10, // 35: protocol.Snapshot.library:type_name -> protocol.LibraryEntry
+	28, // 36: protocol.Register.preferences:type_name -> protocol.Register.PreferencesEntry
+	29, // 37: protocol.Registered.preferences:type_name -> protocol.Registered.PreferencesEntry
+	26, // 38: protocol.History.messages:type_name -> protocol.ChatMessage
+	39, // [39:39] is the sub-list for method output_type
+	39, // [39:39] is the sub-list for method input_type
+	39, // [39:39] is the sub-list for extension type_name
+	39, // [39:39] is the sub-list for extension extendee
+	0,  // [0:39] is the sub-list for field type_name
+}
+
+func init() { file_protocol_proto_init() }
+func file_protocol_proto_init() {
+	if File_protocol_proto != nil {
+		return
+	}
+	file_protocol_proto_msgTypes[0].OneofWrappers = []any{
+		(*Frame_Hello)(nil),
+		(*Frame_Welcome)(nil),
+		(*Frame_AuthError)(nil),
+		(*Frame_Auth)(nil),
+		(*Frame_AuthOk)(nil),
+		(*Frame_ReauthRequired)(nil),
+		(*Frame_Heartbeat)(nil),
+		(*Frame_HeartbeatAck)(nil),
+		(*Frame_RequestSnapshot)(nil),
+		(*Frame_Snapshot)(nil),
+		(*Frame_ProgressUpdate)(nil),
+		(*Frame_LibraryUpdate)(nil),
+		(*Frame_Ack)(nil),
+		(*Frame_ResyncRequired)(nil),
+		(*Frame_ReplayComplete)(nil),
+		(*Frame_SessionRevoked)(nil),
+		(*Frame_AccountDeleted)(nil),
+		(*Frame_NowReading)(nil),
+		(*Frame_Presence)(nil),
+		(*Frame_Register)(nil),
+		(*Frame_Registered)(nil),
+		(*Frame_Unregister)(nil),
+		(*Frame_Unregistered)(nil),
+		(*Frame_Ping)(nil),
+		(*Frame_Pong)(nil),
+		(*Frame_Error)(nil),
+		(*Frame_Notification)(nil),
+		(*Frame_Chat)(nil),
+		(*Frame_History)(nil),
+	}
+	file_protocol_proto_msgTypes[2].OneofWrappers = []any{}
+	file_protocol_proto_msgTypes[12].OneofWrappers = []any{}
+	file_protocol_proto_msgTypes[13].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_protocol_proto_rawDesc), len(file_protocol_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   30,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_protocol_proto_goTypes,
+		DependencyIndexes: file_protocol_proto_depIdxs,
+		MessageInfos:      file_protocol_proto_msgTypes,
+	}.Build()
+	File_protocol_proto = out.File
+	file_protocol_proto_goTypes = nil
+	file_protocol_proto_depIdxs = nil
+}
@@ -0,0 +1,496 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.33.1
+// source: user.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetProfileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetProfileRequest) Reset() {
+	*x = GetProfileRequest{}
+	mi := &file_user_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetProfileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProfileRequest) ProtoMessage() {}
+
+func (x *GetProfileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProfileRequest.ProtoReflect.Descriptor instead.
+func (*GetProfileRequest) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{0}
+}
+
+type UserProfile struct {
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	UserId                  string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Username                string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	Email                   string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	EmailVerified           bool                   `protobuf:"varint,4,opt,name=email_verified,json=emailVerified,proto3" json:"email_verified,omitempty"`
+	IsAdmin                 bool                   `protobuf:"varint,5,opt,name=is_admin,json=isAdmin,proto3" json:"is_admin,omitempty"`
+	TwoFactorEnabled        bool                   `protobuf:"varint,6,opt,name=two_factor_enabled,json=twoFactorEnabled,proto3" json:"two_factor_enabled,omitempty"`
+	DisplayName             string                 `protobuf:"bytes,7,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Bio                     string                 `protobuf:"bytes,8,opt,name=bio,proto3" json:"bio,omitempty"`
+	AvatarUrl               string                 `protobuf:"bytes,9,opt,name=avatar_url,json=avatarUrl,proto3" json:"avatar_url,omitempty"`
+	Timezone                string                 `protobuf:"bytes,10,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	TitleLanguage           string                 `protobuf:"bytes,11,opt,name=title_language,json=titleLanguage,proto3" json:"title_language,omitempty"`
+	Privacy                 string                 `protobuf:"bytes,12,opt,name=privacy,proto3" json:"privacy,omitempty"`
+	NotificationPreferences map[string]bool        `protobuf:"bytes,13,rep,name=notification_preferences,json=notificationPreferences,proto3" json:"notification_preferences,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	CreatedAt               int64                  `protobuf:"varint,14,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *UserProfile) Reset() {
+	*x = UserProfile{}
+	mi := &file_user_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserProfile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserProfile) ProtoMessage() {}
+
+func (x *UserProfile) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserProfile.ProtoReflect.Descriptor instead.
+func (*UserProfile) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *UserProfile) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UserProfile) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *UserProfile) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *UserProfile) GetEmailVerified() bool {
+	if x != nil {
+		return x.EmailVerified
+	}
+	return false
+}
+
+func (x *UserProfile) GetIsAdmin() bool {
+	if x != nil {
+		return x.IsAdmin
+	}
+	return false
+}
+
+func (x *UserProfile) GetTwoFactorEnabled() bool {
+	if x != nil {
+		return x.TwoFactorEnabled
+	}
+	return false
+}
+
+func (x *UserProfile) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *UserProfile) GetBio() string {
+	if x != nil {
+		return x.Bio
+	}
+	return ""
+}
+
+func (x *UserProfile) GetAvatarUrl() string {
+	if x != nil {
+		return x.AvatarUrl
+	}
+	return ""
+}
+
+func (x *UserProfile) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
+}
+
+func (x *UserProfile) GetTitleLanguage() string {
+	if x != nil {
+		return x.TitleLanguage
+	}
+	return ""
+}
+
+func (x *UserProfile) GetPrivacy() string {
+	if x != nil {
+		return x.Privacy
+	}
+	return ""
+}
+
+func (x *UserProfile) GetNotificationPreferences() map[string]bool {
+	if x != nil {
+		return x.NotificationPreferences
+	}
+	return nil
+}
+
+func (x *UserProfile) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+// UpdateProfileRequest changes only the fields that are set. Changing the
+// email needs current_password.
+type UpdateProfileRequest struct {
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	DisplayName             *string                `protobuf:"bytes,1,opt,name=display_name,json=displayName,proto3,oneof" json:"display_name,omitempty"`
+	Bio                     *string                `protobuf:"bytes,2,opt,name=bio,proto3,oneof" json:"bio,omitempty"`
+	AvatarUrl               *string                `protobuf:"bytes,3,opt,name=avatar_url,json=avatarUrl,proto3,oneof" json:"avatar_url,omitempty"`
+	Timezone                *string                `protobuf:"bytes,4,opt,name=timezone,proto3,oneof" json:"timezone,omitempty"`
+	TitleLanguage           *string                `protobuf:"bytes,5,opt,name=title_language,json=titleLanguage,proto3,oneof" json:"title_language,omitempty"`
+	Privacy                 *string                `protobuf:"bytes,6,opt,name=privacy,proto3,oneof" json:"privacy,omitempty"`
+	Email                   *string                `protobuf:"bytes,7,opt,name=email,proto3,oneof" json:"email,omitempty"`
+	NotificationPreferences map[string]bool        `protobuf:"bytes,8,rep,name=notification_preferences,json=notificationPreferences,proto3" json:"notification_preferences,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	CurrentPassword         string                 `protobuf:"bytes,9,opt,name=current_password,json=currentPassword,proto3" json:"current_password,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *UpdateProfileRequest) Reset() {
+	*x = UpdateProfileRequest{}
+	mi := &file_user_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateProfileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateProfileRequest) ProtoMessage() {}
+
+func (x *UpdateProfileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateProfileRequest.ProtoReflect.Descriptor instead.
+func (*UpdateProfileRequest) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *UpdateProfileRequest) GetDisplayName() string {
+	if x != nil && x.DisplayName != nil {
+		return *x.DisplayName
+	}
+	return ""
+}
+
+func (x *UpdateProfileRequest) GetBio() string {
+	if x != nil && x.Bio != nil {
+		return *x.Bio
+	}
+	return ""
+}
+
+func (x *UpdateProfileRequest) GetAvatarUrl() string {
+	if x != nil && x.AvatarUrl != nil {
+		return *x.AvatarUrl
+	}
+	return ""
+}
+
+func (x *UpdateProfileRequest) GetTimezone() string {
+	if x != nil && x.Timezone != nil {
+		return *x.Timezone
+	}
+	return ""
+}
+
+func (x *UpdateProfileRequest) GetTitleLanguage() string {
+	if x != nil && x.TitleLanguage != nil {
+		return *x.TitleLanguage
+	}
+	return ""
+}
+
+func (x *UpdateProfileRequest) GetPrivacy() string {
+	if x != nil && x.Privacy != nil {
+		return *x.Privacy
+	}
+	return ""
+}
+
+func (x *UpdateProfileRequest) GetEmail() string {
+	if x != nil && x.Email != nil {
+		return *x.Email
+	}
+	return ""
+}
+
+func (x *UpdateProfileRequest) GetNotificationPreferences() map[string]bool {
+	if x != nil {
+		return x.NotificationPreferences
+	}
+	return nil
+}
+
+func (x *UpdateProfileRequest) GetCurrentPassword() string {
+	if x != nil {
+		return x.CurrentPassword
+	}
+	return ""
+}
+
+type UpdateProfileResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Profile          *UserProfile           `protobuf:"bytes,1,opt,name=profile,proto3" json:"profile,omitempty"`
+	EmailChanged     bool                   `protobuf:"varint,2,opt,name=email_changed,json=emailChanged,proto3" json:"email_changed,omitempty"`
+	VerificationSent bool                   `protobuf:"varint,3,opt,name=verification_sent,json=verificationSent,proto3" json:"verification_sent,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *UpdateProfileResponse) Reset() {
+	*x = UpdateProfileResponse{}
+	mi := &file_user_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateProfileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateProfileResponse) ProtoMessage() {}
+
+func (x *UpdateProfileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateProfileResponse.ProtoReflect.Descriptor instead.
+func (*UpdateProfileResponse) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UpdateProfileResponse) GetProfile() *UserProfile {
+	if x != nil {
+		return x.Profile
+	}
+	return nil
+}
+
+func (x *UpdateProfileResponse) GetEmailChanged() bool {
+	if x != nil {
+		return x.EmailChanged
+	}
+	return false
+}
+
+func (x *UpdateProfileResponse) GetVerificationSent() bool {
+	if x != nil {
+		return x.VerificationSent
+	}
+	return false
+}
+
+var File_user_proto protoreflect.FileDescriptor
+
+const file_user_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"user.proto\x12\x05manga\"\x13\n" +
+	"\x11GetProfileRequest\"\xd0\x04\n" +
+	"\vUserProfile\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12%\n" +
+	"\x0eemail_verified\x18\x04 \x01(\bR\remailVerified\x12\x19\n" +
+	"\bis_admin\x18\x05 \x01(\bR\aisAdmin\x12,\n" +
+	"\x12two_factor_enabled\x18\x06 \x01(\bR\x10twoFactorEnabled\x12!\n" +
+	"\fdisplay_name\x18\a \x01(\tR\vdisplayName\x12\x10\n" +
+	"\x03bio\x18\b \x01(\tR\x03bio\x12\x1d\n" +
+	"\n" +
+	"avatar_url\x18\t \x01(\tR\tavatarUrl\x12\x1a\n" +
+	"\btimezone\x18\n" +
+	" \x01(\tR\btimezone\x12%\n" +
+	"\x0etitle_language\x18\v \x01(\tR\rtitleLanguage\x12\x18\n" +
+	"\aprivacy\x18\f \x01(\tR\aprivacy\x12j\n" +
+	"\x18notification_preferences\x18\r \x03(\v2/.manga.UserProfile.NotificationPreferencesEntryR\x17notificationPreferences\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x0e \x01(\x03R\tcreatedAt\x1aJ\n" +
+	"\x1cNotificationPreferencesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\bR\x05value:\x028\x01\"\xca\x04\n" +
+	"\x14UpdateProfileRequest\x12&\n" +
+	"\fdisplay_name\x18\x01 \x01(\tH\x00R\vdisplayName\x88\x01\x01\x12\x15\n" +
+	"\x03bio\x18\x02 \x01(\tH\x01R\x03bio\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"avatar_url\x18\x03 \x01(\tH\x02R\tavatarUrl\x88\x01\x01\x12\x1f\n" +
+	"\btimezone\x18\x04 \x01(\tH\x03R\btimezone\x88\x01\x01\x12*\n" +
+	"\x0etitle_language\x18\x05 \x01(\tH\x04R\rtitleLanguage\x88\x01\x01\x12\x1d\n" +
+	"\aprivacy\x18\x06 \x01(\tH\x05R\aprivacy\x88\x01\x01\x12\x19\n" +
+	"\x05email\x18\a \x01(\tH\x06R\x05email\x88\x01\x01\x12s\n" +
+	"\x18notification_preferences\x18\b \x03(\v28.manga.UpdateProfileRequest.NotificationPreferencesEntryR\x17notificationPreferences\x12)\n" +
+	"\x10current_password\x18\t \x01(\tR\x0fcurrentPassword\x1aJ\n" +
+	"\x1cNotificationPreferencesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\bR\x05value:\x028\x01B\x0f\n" +
+	"\r_display_nameB\x06\n" +
+	"\x04_bioB\r\n" +
+	"\v_avatar_urlB\v\n" +
+	"\t_timezoneB\x11\n" +
+	"\x0f_title_languageB\n" +
+	"\n" +
+	"\b_privacyB\b\n" +
+	"\x06_email\"\x97\x01\n" +
+	"\x15UpdateProfileResponse\x12,\n" +
+	"\aprofile\x18\x01 \x01(\v2\x12.manga.UserProfileR\aprofile\x12#\n" +
+	"\remail_changed\x18\x02 \x01(\bR\femailChanged\x12+\n" +
+	"\x11verification_sent\x18\x03 \x01(\bR\x10verificationSent2\x95\x01\n" +
+	"\vUserService\x12:\n" +
+	"\n" +
+	"GetProfile\x12\x18.manga.GetProfileRequest\x1a\x12.manga.UserProfile\x12J\n" +
+	"\rUpdateProfile\x12\x1b.manga.UpdateProfileRequest\x1a\x1c.manga.UpdateProfileResponseB\tZ\a./protob\x06proto3"
+
+var (
+	file_user_proto_rawDescOnce sync.Once
+	file_user_proto_rawDescData []byte
+)
+
+func file_user_proto_rawDescGZIP() []byte {
+	file_user_proto_rawDescOnce.Do(func() {
+		file_user_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_user_proto_rawDesc), len(file_user_proto_rawDesc)))
+	})
+	return file_user_proto_rawDescData
+}
+
+var file_user_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_user_proto_goTypes = []any{
+	(*GetProfileRequest)(nil),     // 0: manga.GetProfileRequest
+	(*UserProfile)(nil),           // 1: manga.UserProfile
+	(*UpdateProfileRequest)(nil),  // 2: manga.UpdateProfileRequest
+	(*UpdateProfileResponse)(nil), // 3: manga.UpdateProfileResponse
+	nil,                           // 4: manga.UserProfile.NotificationPreferencesEntry
+	nil,                           // 5: manga.UpdateProfileRequest.NotificationPreferencesEntry
+}
+var file_user_proto_depIdxs = []int32{
+	4, // 0: manga.UserProfile.notification_preferences:type_name -> manga.UserProfile.NotificationPreferencesEntry
+	5, // 1: manga.UpdateProfileRequest.notification_preferences:type_name -> manga.UpdateProfileRequest.NotificationPreferencesEntry
+	1, // 2: manga.UpdateProfileResponse.profile:type_name -> manga.UserProfile
+	0, // 3: manga.UserService.GetProfile:input_type -> manga.GetProfileRequest
+	2, // 4: manga.UserService.UpdateProfile:input_type -> manga.UpdateProfileRequest
+	1, // 5: manga.UserService.GetProfile:output_type -> manga.UserProfile
+	3, // 6: manga.UserService.UpdateProfile:output_type -> manga.UpdateProfileResponse
+	5, // [5:7] is the sub-list for method output_type
+	3, // [3:5] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_user_proto_init() }
+func file_user_proto_init() {
+	if File_user_proto != nil {
+		return
+	}
+	file_user_proto_msgTypes[2].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_user_proto_rawDesc), len(file_user_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_user_proto_goTypes,
+		DependencyIndexes: file_user_proto_depIdxs,
+		MessageInfos:      file_user_proto_msgTypes,
+	}.Build()
+	File_user_proto = out.File
+	file_user_proto_goTypes = nil
+	file_user_proto_depIdxs = nil
+}